@@ -0,0 +1,77 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "sync/atomic"
+
+// Atomic is a typed value that is loaded and stored atomically, a type-safe alternative to
+// atomic.Value without interface conversions. Values are boxed behind an atomic.Pointer: Load
+// never allocates, and Store, Swap and CompareAndSwap allocate a single box for the new value.
+//
+// CompareAndSwap compares values with the equalFn given to NewAtomic, which lets it work with
+// non-comparable types such as slices or structs holding maps. Without an equalFn, values are
+// compared with ==, which panics if T's dynamic type is not comparable, as with atomic.Value.
+//
+// The zero value is ready to use, holds the zero value of T and compares with ==. An Atomic must
+// not be copied after first use.
+type Atomic[T any] struct {
+	p     atomic.Pointer[T]
+	equal func(a, b T) bool
+}
+
+// NewAtomic creates a new Atomic holding v. equalFn, if not nil, decides whether two values are
+// equal in CompareAndSwap.
+func NewAtomic[T any](v T, equalFn func(a, b T) bool) *Atomic[T] {
+	a := &Atomic[T]{equal: equalFn}
+	a.p.Store(&v)
+	return a
+}
+
+// Load returns the current value.
+func (a *Atomic[T]) Load() T {
+	if p := a.p.Load(); p != nil {
+		return *p
+	}
+	var zero T
+	return zero
+}
+
+// Store sets the value to v.
+func (a *Atomic[T]) Store(v T) {
+	a.p.Store(&v)
+}
+
+// Swap sets the value to v and returns the previous value.
+func (a *Atomic[T]) Swap(v T) (old T) {
+	if p := a.p.Swap(&v); p != nil {
+		return *p
+	}
+	return old
+}
+
+// CompareAndSwap sets the value to val if the current value equals old, and reports whether it
+// did.
+func (a *Atomic[T]) CompareAndSwap(old, val T) (swapped bool) {
+	box := &val
+	for {
+		p := a.p.Load()
+		var cur T
+		if p != nil {
+			cur = *p
+		}
+		if !a.equals(cur, old) {
+			return false
+		}
+		if a.p.CompareAndSwap(p, box) {
+			return true
+		}
+	}
+}
+
+// Internal helpers
+
+func (a *Atomic[T]) equals(x, y T) bool {
+	if a.equal != nil {
+		return a.equal(x, y)
+	}
+	return any(x) == any(y)
+}
@@ -0,0 +1,47 @@
+package threadsafe
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomic(t *testing.T) {
+	var a Atomic[int]
+	assert.Equal(t, 0, a.Load())
+	assert.True(t, a.CompareAndSwap(0, 1))
+	assert.False(t, a.CompareAndSwap(0, 2))
+	assert.Equal(t, 1, a.Swap(3))
+	a.Store(4)
+	assert.Equal(t, 4, a.Load())
+
+	// Concurrent CAS increments are never lost
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				for {
+					v := a.Load()
+					if a.CompareAndSwap(v, v+1) {
+						break
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 804, a.Load())
+}
+
+func TestAtomicEqualFn(t *testing.T) {
+	a := NewAtomic([]int{1, 2}, slices.Equal[[]int])
+	assert.False(t, a.CompareAndSwap([]int{1}, []int{9}))
+	assert.True(t, a.CompareAndSwap([]int{1, 2}, []int{3}))
+	assert.Equal(t, []int{3}, a.Load())
+
+	// Without an equalFn, non-comparable values panic like atomic.Value
+	var b Atomic[[]int]
+	assert.Panics(t, func() { b.CompareAndSwap([]int{}, nil) })
+}
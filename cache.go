@@ -0,0 +1,410 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"time"
+)
+
+// ErrLoaderPanicked is returned by Cache.GetOrLoad to callers waiting on a loader that panicked.
+var ErrLoaderPanicked = errors.New("threadsafe: cache loader panicked")
+
+// EvictionReason tells why an entry was removed from a Cache.
+type EvictionReason int
+
+const (
+	// EvictedCapacity means the entry was evicted by the policy to make room for another one.
+	EvictedCapacity EvictionReason = iota
+	// EvictedExpired means the entry outlived its TTL.
+	EvictedExpired
+	// EvictedDeleted means the entry was removed through Delete or Clear.
+	EvictedDeleted
+)
+
+// CacheStats is a point-in-time snapshot of cache activity.
+type CacheStats struct {
+	Hits        uint64 // lookups that found a live entry
+	Misses      uint64 // lookups that found no entry or an expired one
+	Loads       uint64 // loader calls made by GetOrLoad
+	LoadErrors  uint64 // loader calls that returned an error or panicked
+	Evictions   uint64 // entries evicted to respect the maximum size
+	Expirations uint64 // entries removed after outliving their TTL
+	Len         int    // current number of entries, including expired ones not yet removed
+}
+
+// HitRatio returns the fraction of lookups that were hits, or 0 if there were none.
+func (s CacheStats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+// cacheEntry is an entry of a Cache, linked into the structures of its eviction policy.
+type cacheEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // zero if the entry never expires
+
+	prev, next *cacheEntry[K, V] // list position, for list-based policies
+	protected  bool              // segment, for CacheSLRU
+	freq       uint64            // access count, for CacheLFU
+	tick       uint64            // last access, for CacheLFU
+	index      int               // heap position, for CacheLFU
+}
+
+// expired reports whether the entry has outlived its TTL at time now.
+func (e *cacheEntry[K, V]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// cacheCall is a loader call in progress, shared by concurrent GetOrLoad callers of one key.
+type cacheCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// Cache is a thread-safe key-value cache with an optional maximum size enforced by an eviction
+// policy, optional per-entry expiry, a loader with duplicate suppression, hit/miss statistics and
+// eviction callbacks. It is protected by a sync.Mutex, since every hit updates the policy.
+//
+// Expired entries are never returned, and are removed lazily when looked up, when evicted by the
+// policy, or by DeleteExpired; until then they count towards Len and the maximum size.
+//
+// GetOrLoad calls the loader at most once at a time per key: concurrent callers for the same key
+// wait for the call in progress and share its result. Errors are returned to those callers and
+// not cached.
+//
+// The zero value is an unbounded LRU cache without expiry, ready to use.
+type Cache[K comparable, V any] struct {
+	// OnEvict, if set, is called with every entry removed from the cache other than by being
+	// overwritten, and the reason for it. It is called after the lock is released, so it may call
+	// back into the cache. It must be set before the cache is used.
+	OnEvict func(key K, value V, reason EvictionReason)
+
+	mu      sync.Mutex
+	entries map[K]*cacheEntry[K, V]
+	policy  cachePolicy[K, V]
+	calls   map[K]*cacheCall[V]
+	maxSize int
+	ttl     time.Duration
+	stats   CacheStats
+}
+
+// NewCache creates a new Cache holding at most maxSize entries, evicting per policy when full,
+// with entries expiring after ttl by default. A maxSize or ttl of 0 or less means no limit.
+func NewCache[K comparable, V any](
+	maxSize int,
+	ttl time.Duration,
+	policy CachePolicy,
+) *Cache[K, V] {
+	return &Cache[K, V]{
+		entries: make(map[K]*cacheEntry[K, V]),
+		policy:  newCachePolicy[K, V](policy, max(maxSize, 0)),
+		calls:   make(map[K]*cacheCall[V]),
+		maxSize: max(maxSize, 0),
+		ttl:     max(ttl, 0),
+	}
+}
+
+// Get returns the value stored under key, if present and not expired, and records the access
+// with the eviction policy.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	return c.getAt(time.Now(), key)
+}
+
+// Peek returns the value stored under key, if present and not expired, without recording the
+// access or updating the statistics.
+func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, found := c.entries[key]; found && !e.expired(time.Now()) {
+		return e.value, true
+	}
+	return value, false
+}
+
+// Set stores value under key with the default TTL, evicting an entry if the cache is full.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.setAt(time.Now(), key, value, c.ttl)
+}
+
+// SetWithTTL stores value under key, expiring after ttl instead of the default TTL, or never if
+// ttl is 0 or less.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.setAt(time.Now(), key, value, max(ttl, 0))
+}
+
+// GetOrLoad returns the value stored under key, or calls load to produce, store and return it. If
+// a load for key is already in progress, it waits for that load and returns its result instead.
+// load receives the ctx of the caller that started it, which gets its result directly. A caller
+// waiting on another caller's load returns ctx.Err() if ctx is done before the result is
+// available, in which case the load still completes for other callers. If the load fails with a
+// context error while the waiting caller's ctx is still live, that caller loads again itself.
+func (c *Cache[K, V]) GetOrLoad(
+	ctx context.Context,
+	key K,
+	load func(ctx context.Context, key K) (V, error),
+) (V, error) {
+	for {
+		if value, ok := c.Get(key); ok {
+			return value, nil
+		}
+
+		c.mu.Lock()
+		c.ensureInitialized()
+		call, inFlight := c.calls[key]
+		if !inFlight {
+			call = &cacheCall[V]{done: make(chan struct{})}
+			c.calls[key] = call
+			c.stats.Loads++
+		}
+		c.mu.Unlock()
+
+		if !inFlight {
+			c.load(ctx, key, call, load)
+			return call.value, call.err
+		}
+		select {
+		case <-call.done:
+			// The load was likely canceled by the ctx of the caller that started it, which must
+			// not fail this caller
+			if ctx.Err() == nil && isContextError(call.err) {
+				continue
+			}
+			return call.value, call.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// Delete removes the entry stored under key, and reports whether it was present.
+func (c *Cache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if ok {
+		c.removeLocked(e)
+	}
+	c.mu.Unlock()
+	if ok {
+		c.notify([]*cacheEntry[K, V]{e}, EvictedDeleted)
+	}
+	return ok
+}
+
+// DeleteExpired removes all expired entries, and returns how many were removed.
+func (c *Cache[K, V]) DeleteExpired() int {
+	now := time.Now()
+	c.mu.Lock()
+	var expired []*cacheEntry[K, V]
+	for _, e := range c.entries {
+		if e.expired(now) {
+			c.removeLocked(e)
+			expired = append(expired, e)
+		}
+	}
+	c.stats.Expirations += uint64(len(expired))
+	c.mu.Unlock()
+	c.notify(expired, EvictedExpired)
+	return len(expired)
+}
+
+// Len returns the number of entries, including expired entries not yet removed.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Clear removes all entries. Statistics are kept.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	removed := make([]*cacheEntry[K, V], 0, len(c.entries))
+	for _, e := range c.entries {
+		c.removeLocked(e)
+		removed = append(removed, e)
+	}
+	c.mu.Unlock()
+	c.notify(removed, EvictedDeleted)
+}
+
+// Stats returns a snapshot of the cache statistics.
+func (c *Cache[K, V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Len = len(c.entries)
+	return stats
+}
+
+// All returns an iterator over a snapshot of the live entries, in no particular order. Accesses
+// through the iterator are not recorded with the eviction policy.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		now := time.Now()
+		c.mu.Lock()
+		keys := make([]K, 0, len(c.entries))
+		values := make([]V, 0, len(c.entries))
+		for _, e := range c.entries {
+			if !e.expired(now) {
+				keys = append(keys, e.key)
+				values = append(values, e.value)
+			}
+		}
+		c.mu.Unlock()
+
+		for i, key := range keys {
+			if !yield(key, values[i]) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first entries of the cache, for logging and debugging.
+func (c *Cache[K, V]) String() string {
+	return formatEntries(c, c.Len(), c.All())
+}
+
+// GoString returns the Go type, length and first entries of the cache, for the %#v verb.
+func (c *Cache[K, V]) GoString() string {
+	return goFormatEntries(c, c.Len(), c.All())
+}
+
+// Internal helpers
+
+// getAt implements Get at time now.
+func (c *Cache[K, V]) getAt(now time.Time, key K) (value V, ok bool) {
+	c.mu.Lock()
+	e, found := c.entries[key]
+	switch {
+	case !found:
+		c.stats.Misses++
+		c.mu.Unlock()
+		return value, false
+	case e.expired(now):
+		c.removeLocked(e)
+		c.stats.Misses++
+		c.stats.Expirations++
+		c.mu.Unlock()
+		c.notify([]*cacheEntry[K, V]{e}, EvictedExpired)
+		return value, false
+	}
+	c.stats.Hits++
+	c.policy.hit(e)
+	value = e.value
+	c.mu.Unlock()
+	return value, true
+}
+
+// setAt implements SetWithTTL at time now.
+func (c *Cache[K, V]) setAt(now time.Time, key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	evicted, expired := c.setLocked(now, key, value, ttl)
+	c.mu.Unlock()
+	c.notify(expired, EvictedExpired)
+	c.notify(evicted, EvictedCapacity)
+}
+
+// setLocked stores value under key, and returns the entries evicted to make room for it, split
+// by whether they had expired. Callers must hold the lock, and pass the entries to notify once
+// they released it.
+func (c *Cache[K, V]) setLocked(
+	now time.Time,
+	key K,
+	value V,
+	ttl time.Duration,
+) (evicted, expired []*cacheEntry[K, V]) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+	c.ensureInitialized()
+	if e, ok := c.entries[key]; ok {
+		e.value, e.expires = value, expires
+		c.policy.hit(e)
+		return nil, nil
+	}
+	for c.maxSize > 0 && len(c.entries) >= c.maxSize {
+		victim := c.policy.victim()
+		c.removeLocked(victim)
+		if victim.expired(now) {
+			c.stats.Expirations++
+			expired = append(expired, victim)
+		} else {
+			c.stats.Evictions++
+			evicted = append(evicted, victim)
+		}
+	}
+	e := &cacheEntry[K, V]{key: key, value: value, expires: expires}
+	c.entries[key] = e
+	c.policy.add(e)
+	return evicted, expired
+}
+
+// load runs load for a call registered in c.calls, stores a successful result, and releases the
+// callers waiting on it. If load panics, the waiters get ErrLoaderPanicked and the panic goes on.
+func (c *Cache[K, V]) load(
+	ctx context.Context,
+	key K,
+	call *cacheCall[V],
+	load func(ctx context.Context, key K) (V, error),
+) {
+	completed := false
+	defer func() {
+		if !completed {
+			call.err = ErrLoaderPanicked
+		}
+		var evicted, expired []*cacheEntry[K, V]
+		c.mu.Lock()
+		delete(c.calls, key)
+		if call.err == nil {
+			evicted, expired = c.setLocked(time.Now(), key, call.value, c.ttl)
+		} else {
+			c.stats.LoadErrors++
+		}
+		c.mu.Unlock()
+		close(call.done)
+		c.notify(expired, EvictedExpired)
+		c.notify(evicted, EvictedCapacity)
+	}()
+	call.value, call.err = load(ctx, key)
+	completed = true
+}
+
+// isContextError reports whether err is the error of a canceled or expired context.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// ensureInitialized lazily allocates the entries, policy and calls for zero-value usage, as an
+// unbounded LRU cache. Callers must hold the lock.
+func (c *Cache[K, V]) ensureInitialized() {
+	if c.entries == nil {
+		c.entries = make(map[K]*cacheEntry[K, V])
+		c.policy = newCachePolicy[K, V](CacheLRU, 0)
+		c.calls = make(map[K]*cacheCall[V])
+	}
+}
+
+// removeLocked removes entry e, which must be present. Callers must hold the lock.
+func (c *Cache[K, V]) removeLocked(e *cacheEntry[K, V]) {
+	delete(c.entries, e.key)
+	c.policy.remove(e)
+}
+
+// notify passes the removed entries to OnEvict, if set. Callers must not hold the lock.
+func (c *Cache[K, V]) notify(removed []*cacheEntry[K, V], reason EvictionReason) {
+	if c.OnEvict == nil {
+		return
+	}
+	for _, e := range removed {
+		c.OnEvict(e.key, e.value, reason)
+	}
+}
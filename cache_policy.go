@@ -0,0 +1,217 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "container/heap"
+
+// CachePolicy selects how a Cache picks the entry to evict when it is full.
+type CachePolicy int
+
+const (
+	// CacheLRU evicts the least recently used entry.
+	CacheLRU CachePolicy = iota
+	// CacheLFU evicts the least frequently used entry, breaking ties by least recent use. It
+	// suits workloads with a stable set of popular keys.
+	CacheLFU
+	// CacheSLRU is a segmented LRU: new entries go to a probationary segment, and only entries
+	// hit again are promoted to a protected segment holding 80% of the entries. Victims come
+	// from the probationary segment first, so a scan of one-off keys cannot flush the entries
+	// in repeated use.
+	CacheSLRU
+)
+
+// slruProtectedShare is the share of a CacheSLRU cache reserved for the protected segment.
+const slruProtectedShare = 0.8
+
+// cachePolicy tracks the entries of a Cache to pick eviction victims. Its methods are called
+// under the cache lock.
+type cachePolicy[K comparable, V any] interface {
+	// add starts tracking a new entry.
+	add(e *cacheEntry[K, V])
+	// hit records an access to a tracked entry.
+	hit(e *cacheEntry[K, V])
+	// remove stops tracking an entry.
+	remove(e *cacheEntry[K, V])
+	// victim returns the entry to evict next. It is only called while entries are tracked.
+	victim() *cacheEntry[K, V]
+}
+
+// newCachePolicy returns the implementation of policy for a cache holding at most maxSize
+// entries, or any number if maxSize is 0. Unknown policies fall back to CacheLRU.
+func newCachePolicy[K comparable, V any](policy CachePolicy, maxSize int) cachePolicy[K, V] {
+	switch policy {
+	case CacheLFU:
+		return &lfuPolicy[K, V]{}
+	case CacheSLRU:
+		return newSLRUPolicy[K, V](maxSize)
+	default:
+		return newLRUPolicy[K, V]()
+	}
+}
+
+// cacheList is an intrusive circular doubly linked list of cache entries, most recent first.
+type cacheList[K comparable, V any] struct {
+	root cacheEntry[K, V] // sentinel
+	len  int
+}
+
+func (l *cacheList[K, V]) init() {
+	l.root.next, l.root.prev = &l.root, &l.root
+}
+
+// pushFront inserts e at the front of the list.
+func (l *cacheList[K, V]) pushFront(e *cacheEntry[K, V]) {
+	e.prev, e.next = &l.root, l.root.next
+	l.root.next.prev = e
+	l.root.next = e
+	l.len++
+}
+
+// unlink removes e from the list.
+func (l *cacheList[K, V]) unlink(e *cacheEntry[K, V]) {
+	e.prev.next, e.next.prev = e.next, e.prev
+	e.prev, e.next = nil, nil
+	l.len--
+}
+
+// back returns the last entry of the list, or nil if it is empty.
+func (l *cacheList[K, V]) back() *cacheEntry[K, V] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// lruPolicy evicts the least recently used entry.
+type lruPolicy[K comparable, V any] struct {
+	list cacheList[K, V]
+}
+
+func newLRUPolicy[K comparable, V any]() *lruPolicy[K, V] {
+	p := &lruPolicy[K, V]{}
+	p.list.init()
+	return p
+}
+
+func (p *lruPolicy[K, V]) add(e *cacheEntry[K, V]) { p.list.pushFront(e) }
+
+func (p *lruPolicy[K, V]) hit(e *cacheEntry[K, V]) {
+	p.list.unlink(e)
+	p.list.pushFront(e)
+}
+
+func (p *lruPolicy[K, V]) remove(e *cacheEntry[K, V]) { p.list.unlink(e) }
+
+func (p *lruPolicy[K, V]) victim() *cacheEntry[K, V] { return p.list.back() }
+
+// slruPolicy evicts from a probationary LRU segment first, protecting the entries that were hit
+// after insertion in a second LRU segment of bounded size.
+type slruPolicy[K comparable, V any] struct {
+	probation    cacheList[K, V]
+	protected    cacheList[K, V]
+	maxProtected int // 0 for no limit
+}
+
+func newSLRUPolicy[K comparable, V any](maxSize int) *slruPolicy[K, V] {
+	p := &slruPolicy[K, V]{}
+	if maxSize > 0 {
+		p.maxProtected = max(int(float64(maxSize)*slruProtectedShare), 1)
+	}
+	p.probation.init()
+	p.protected.init()
+	return p
+}
+
+func (p *slruPolicy[K, V]) add(e *cacheEntry[K, V]) {
+	e.protected = false
+	p.probation.pushFront(e)
+}
+
+func (p *slruPolicy[K, V]) hit(e *cacheEntry[K, V]) {
+	if e.protected {
+		p.protected.unlink(e)
+		p.protected.pushFront(e)
+		return
+	}
+	p.probation.unlink(e)
+	e.protected = true
+	p.protected.pushFront(e)
+	if p.maxProtected > 0 && p.protected.len > p.maxProtected {
+		demoted := p.protected.back()
+		p.protected.unlink(demoted)
+		demoted.protected = false
+		p.probation.pushFront(demoted)
+	}
+}
+
+func (p *slruPolicy[K, V]) remove(e *cacheEntry[K, V]) {
+	if e.protected {
+		p.protected.unlink(e)
+	} else {
+		p.probation.unlink(e)
+	}
+}
+
+func (p *slruPolicy[K, V]) victim() *cacheEntry[K, V] {
+	if e := p.probation.back(); e != nil {
+		return e
+	}
+	return p.protected.back()
+}
+
+// lfuPolicy evicts the least frequently used entry, and among those the least recently used,
+// through a min-heap ordered by access count and last access.
+type lfuPolicy[K comparable, V any] struct {
+	entries []*cacheEntry[K, V]
+	ticks   uint64
+}
+
+func (p *lfuPolicy[K, V]) add(e *cacheEntry[K, V]) {
+	p.ticks++
+	e.freq, e.tick = 1, p.ticks
+	heap.Push(p, e)
+}
+
+func (p *lfuPolicy[K, V]) hit(e *cacheEntry[K, V]) {
+	p.ticks++
+	e.freq++
+	e.tick = p.ticks
+	heap.Fix(p, e.index)
+}
+
+func (p *lfuPolicy[K, V]) remove(e *cacheEntry[K, V]) { heap.Remove(p, e.index) }
+
+func (p *lfuPolicy[K, V]) victim() *cacheEntry[K, V] { return p.entries[0] }
+
+// Len implements heap.Interface.
+func (p *lfuPolicy[K, V]) Len() int { return len(p.entries) }
+
+// Less implements heap.Interface.
+func (p *lfuPolicy[K, V]) Less(i, j int) bool {
+	a, b := p.entries[i], p.entries[j]
+	if a.freq != b.freq {
+		return a.freq < b.freq
+	}
+	return a.tick < b.tick
+}
+
+// Swap implements heap.Interface.
+func (p *lfuPolicy[K, V]) Swap(i, j int) {
+	p.entries[i], p.entries[j] = p.entries[j], p.entries[i]
+	p.entries[i].index, p.entries[j].index = i, j
+}
+
+// Push implements heap.Interface.
+func (p *lfuPolicy[K, V]) Push(x any) {
+	e := x.(*cacheEntry[K, V])
+	e.index = len(p.entries)
+	p.entries = append(p.entries, e)
+}
+
+// Pop implements heap.Interface.
+func (p *lfuPolicy[K, V]) Pop() any {
+	last := len(p.entries) - 1
+	e := p.entries[last]
+	p.entries[last] = nil
+	p.entries = p.entries[:last]
+	return e
+}
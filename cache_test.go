@@ -0,0 +1,298 @@
+package threadsafe
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheEviction struct {
+	key    string
+	reason EvictionReason
+}
+
+func TestCacheLRU(t *testing.T) {
+	c := NewCache[string, int](2, 0, CacheLRU)
+	var evictions []cacheEviction
+	c.OnEvict = func(key string, _ int, reason EvictionReason) {
+		evictions = append(evictions, cacheEviction{key, reason})
+	}
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	_, ok := c.Get("a") // b is now the least recently used
+	assert.True(t, ok)
+	c.Set("c", 3)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, []cacheEviction{{"b", EvictedCapacity}}, evictions)
+
+	c.Set("a", 10) // overwriting is not an eviction
+	v, ok := c.Peek("a")
+	assert.True(t, ok)
+	assert.Equal(t, 10, v)
+	assert.Len(t, evictions, 1)
+
+	assert.True(t, c.Delete("a"))
+	assert.False(t, c.Delete("a"))
+	c.Clear()
+	assert.Equal(t, []cacheEviction{
+		{"b", EvictedCapacity}, {"a", EvictedDeleted}, {"c", EvictedDeleted},
+	}, evictions)
+
+	stats := c.Stats()
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Evictions: 1}, stats)
+	assert.InDelta(t, 0.5, stats.HitRatio(), 1e-9)
+}
+
+func TestCacheLFU(t *testing.T) {
+	c := NewCache[string, int](3, 0, CacheLFU)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	for range 3 {
+		c.Get("a")
+		c.Get("c")
+	}
+	c.Get("b")
+	c.Get("a")
+	c.Set("d", 4) // b has the fewest accesses
+	_, ok := c.Peek("b")
+	assert.False(t, ok)
+
+	c.Set("e", 5) // d and e tie on accesses; d is older
+	_, ok = c.Peek("d")
+	assert.False(t, ok)
+	for _, key := range []string{"a", "c", "e"} {
+		_, ok = c.Peek(key)
+		assert.True(t, ok, key)
+	}
+}
+
+func TestCacheSLRU(t *testing.T) {
+	c := NewCache[int, int](10, 0, CacheSLRU)
+	for key := range 5 {
+		c.Set(key, key)
+		c.Get(key) // promoted to the protected segment
+	}
+	for key := 100; key < 200; key++ {
+		c.Set(key, key) // a scan of one-off keys
+	}
+	for key := range 5 {
+		_, ok := c.Peek(key)
+		assert.True(t, ok, key)
+	}
+	assert.Equal(t, 10, c.Len())
+
+	// The protected segment holds 8 entries; the least recent ones are demoted on overflow
+	for key := 10; key < 20; key++ {
+		c.Set(key, key)
+		c.Get(key)
+	}
+	assert.Equal(t, 10, c.Len())
+	for key := 12; key < 20; key++ {
+		_, ok := c.Peek(key)
+		assert.True(t, ok, key)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := NewCache[string, int](2, time.Minute, CacheLRU)
+	var evictions []cacheEviction
+	c.OnEvict = func(key string, _ int, reason EvictionReason) {
+		evictions = append(evictions, cacheEviction{key, reason})
+	}
+	now := time.Now()
+	c.setAt(now, "a", 1, c.ttl)
+	c.setAt(now, "b", 2, 0)
+
+	_, ok := c.getAt(now.Add(59*time.Second), "a")
+	assert.True(t, ok)
+	_, ok = c.getAt(now.Add(time.Minute), "a")
+	assert.False(t, ok)
+	_, ok = c.getAt(now.Add(time.Hour), "b")
+	assert.True(t, ok)
+	assert.Equal(t, []cacheEviction{{"a", EvictedExpired}}, evictions)
+
+	c.SetWithTTL("c", 3, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, 1, c.DeleteExpired())
+	assert.Equal(t, 1, c.Len())
+
+	count := 0
+	for key, value := range c.All() {
+		assert.Equal(t, "b", key)
+		assert.Equal(t, 2, value)
+		count++
+	}
+	assert.Equal(t, 1, count)
+	assert.Equal(t, uint64(2), c.Stats().Expirations)
+}
+
+func TestCacheGetOrLoad(t *testing.T) {
+	c := NewCache[string, int](0, 0, CacheLRU)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(_ context.Context, key string) (int, error) {
+		calls.Add(1)
+		<-release
+		return len(key), nil
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			v, err := c.GetOrLoad(context.Background(), "abc", load)
+			assert.NoError(t, err)
+			assert.Equal(t, 3, v)
+		})
+	}
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	v, err := c.GetOrLoad(context.Background(), "abc", load)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, int32(1), calls.Load())
+
+	errLoad := errors.New("load failed")
+	_, err = c.GetOrLoad(context.Background(), "x", func(context.Context, string) (int, error) {
+		return 0, errLoad
+	})
+	assert.ErrorIs(t, err, errLoad)
+	_, ok := c.Peek("x")
+	assert.False(t, ok)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(2), stats.Loads)
+	assert.Equal(t, uint64(1), stats.LoadErrors)
+}
+
+func TestCacheGetOrLoadCanceled(t *testing.T) {
+	c := NewCache[string, int](0, 0, CacheLRU)
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_, _ = c.GetOrLoad(context.Background(), "k", func(context.Context, string) (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	cancel()
+	_, err := c.GetOrLoad(ctx, "k", func(context.Context, string) (int, error) {
+		t.Error("second loader called")
+		return 0, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, ok := c.Peek("k")
+		return ok
+	}, time.Second, time.Millisecond)
+
+	// The caller that started a load gets its result, even if ctx is done by then
+	ctx, cancel = context.WithCancel(context.Background())
+	v, err := c.GetOrLoad(ctx, "l", func(context.Context, string) (int, error) {
+		cancel()
+		return 2, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, v)
+
+	// A waiter whose ctx is live loads again when the starting caller's ctx cancels the load
+	ctx, cancel = context.WithCancel(context.Background())
+	started = make(chan struct{})
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "m", func(ctx context.Context, _ string) (int, error) {
+			close(started)
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+		firstErr <- err
+	}()
+	<-started
+	waiter := make(chan int, 1)
+	go func() {
+		v, err := c.GetOrLoad(context.Background(), "m", func(context.Context, string) (int, error) {
+			return 3, nil
+		})
+		assert.NoError(t, err)
+		waiter <- v
+	}()
+	time.Sleep(20 * time.Millisecond) // let the waiter join the load in progress
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	assert.Equal(t, 3, <-waiter)
+}
+
+func TestCacheGetOrLoadPanic(t *testing.T) {
+	c := NewCache[string, int](0, 0, CacheLRU)
+	assert.Panics(t, func() {
+		_, _ = c.GetOrLoad(context.Background(), "k", func(context.Context, string) (int, error) {
+			panic("boom")
+		})
+	})
+	v, err := c.GetOrLoad(context.Background(), "k", func(context.Context, string) (int, error) {
+		return 7, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, v)
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	for _, policy := range []CachePolicy{CacheLRU, CacheLFU, CacheSLRU} {
+		c := NewCache[int, int](64, time.Minute, policy)
+		var wg sync.WaitGroup
+		for w := range 8 {
+			wg.Go(func() {
+				for i := range 500 {
+					key := (w*31 + i) % 100
+					if _, ok := c.Get(key); !ok {
+						c.Set(key, i)
+					}
+					if i%50 == 0 {
+						c.Delete(key)
+					}
+				}
+			})
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, c.Len(), 64)
+	}
+}
+
+func TestCacheZeroValue(t *testing.T) {
+	var c Cache[string, int]
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.False(t, c.Delete("a"))
+
+	for i := range 100 {
+		c.Set(strconv.Itoa(i), i)
+	}
+	assert.Equal(t, 100, c.Len(), "the zero value is unbounded")
+	v, ok := c.Get("42")
+	assert.True(t, ok)
+	assert.Equal(t, 42, v)
+
+	var loading Cache[string, int]
+	v, err := loading.GetOrLoad(context.Background(), "x", func(context.Context, string) (int, error) {
+		return 7, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, v)
+}
@@ -0,0 +1,101 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "context"
+
+// The helpers below bridge the collections of this package and channels. They block on channel
+// operations until ctx is done, in which case they return ctx.Err(), and never close the channels
+// they are given.
+
+// SliceToChan sends a snapshot of the items of s on ch, in order, without modifying s. It returns
+// nil once all items are sent.
+func SliceToChan[T any](ctx context.Context, s Slice[T], ch chan<- T) error {
+	for item := range s.All() {
+		if err := send(ctx, ch, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChanToSlice appends the items received from ch to s until ch is closed, returning nil, or ctx
+// is done.
+func ChanToSlice[T any](ctx context.Context, ch <-chan T, s Slice[T]) error {
+	for {
+		select {
+		case item, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			s.Append(item)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// SetToChan sends a snapshot of the items of s on ch, in no particular order, without modifying
+// s. It returns nil once all items are sent.
+func SetToChan[T comparable](ctx context.Context, s Set[T], ch chan<- T) error {
+	for item := range s.All() {
+		if err := send(ctx, ch, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueFromChan pushes the items received from ch to q until ch is closed, returning nil, or ctx
+// is done. If q implements PushWaiter, as bounded queues do, items are pushed with PushWait so
+// that a full queue applies backpressure to the channel instead of dropping items; an item
+// received while waiting for room is lost if ctx is done first.
+func QueueFromChan[T any](ctx context.Context, ch <-chan T, q Queue[T]) error {
+	waiter, _ := q.(PushWaiter[T])
+	for {
+		select {
+		case item, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if waiter == nil {
+				q.Push(item)
+			} else if err := waiter.PushWait(ctx, item); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// PQDrainToChan pops the items of pq in priority order and sends them on ch until pq is empty,
+// returning nil, or ctx is done. An item popped while ch was not ready is pushed back when ctx is
+// done, so no item is lost. To keep sending items as they are pushed, use the DrainTo method of
+// the blocking priority queues instead.
+func PQDrainToChan[T any](ctx context.Context, pq PriorityQueue[T], ch chan<- T) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item, ok := pq.Pop()
+		if !ok {
+			return nil
+		}
+		if err := send(ctx, ch, item); err != nil {
+			pq.Push(item)
+			return err
+		}
+	}
+}
+
+// Internal helpers
+
+// send sends item on ch, or returns ctx.Err() if ctx is done first.
+func send[T any](ctx context.Context, ch chan<- T, item T) error {
+	select {
+	case ch <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,88 @@
+package threadsafe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSliceToChan(t *testing.T) {
+	s := NewMutexSlice[int](0)
+	s.Append(1, 2, 3)
+	ch := make(chan int, 3)
+	require.NoError(t, SliceToChan(context.Background(), s, ch))
+	assert.Equal(t, []int{1, 2, 3}, []int{<-ch, <-ch, <-ch})
+	assert.Equal(t, 3, s.Len())
+
+	// Nobody receives on an unbuffered channel
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, SliceToChan(ctx, s, make(chan int)), context.DeadlineExceeded)
+}
+
+func TestChanToSlice(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	close(ch)
+	s := NewRWMutexSlice[int](0)
+	require.NoError(t, ChanToSlice(context.Background(), ch, s))
+	assert.Equal(t, []int{1, 2}, s.Peek())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, ChanToSlice(ctx, make(chan int), s), context.Canceled)
+}
+
+func TestSetToChan(t *testing.T) {
+	s := NewRWMutexSet[string]()
+	s.Add("a")
+	s.Add("b")
+	ch := make(chan string, 2)
+	require.NoError(t, SetToChan(context.Background(), s, ch))
+	assert.ElementsMatch(t, []string{"a", "b"}, []string{<-ch, <-ch})
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestQueueFromChan(t *testing.T) {
+	ch := make(chan int)
+	q := NewRWMutexQueue[int]()
+	done := make(chan error)
+	go func() { done <- QueueFromChan(context.Background(), ch, q) }()
+	ch <- 1
+	ch <- 2
+	close(ch)
+	require.NoError(t, <-done)
+	assert.Equal(t, []int{1, 2}, q.Slice())
+
+	// A full bounded queue applies backpressure until ctx is done
+	bq := NewBlockingQueue[int](1)
+	ch = make(chan int, 2)
+	ch <- 1
+	ch <- 2
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, QueueFromChan(ctx, ch, bq), context.DeadlineExceeded)
+	assert.Equal(t, []int{1}, bq.Slice())
+}
+
+func TestPQDrainToChan(t *testing.T) {
+	pq := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	pq.Push(3, 1, 2)
+	ch := make(chan int, 3)
+	require.NoError(t, PQDrainToChan(context.Background(), pq, ch))
+	assert.Equal(t, []int{1, 2, 3}, []int{<-ch, <-ch, <-ch})
+	assert.Equal(t, 0, pq.Len())
+
+	// An item that could not be sent is pushed back
+	pq.Push(5, 4)
+	ch = make(chan int, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, PQDrainToChan(ctx, pq, ch), context.DeadlineExceeded)
+	assert.Equal(t, 4, <-ch)
+	assert.Equal(t, []int{5}, pq.SliceSorted())
+}
@@ -0,0 +1,34 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+// Internal helpers
+//
+// The Clone methods of the collections copy every item with the copyFn they are given while
+// holding the collection's lock, so the clone is a consistent snapshot whose items share no
+// mutable state with the original, e.g. when items are pointers or slices. A nil copyFn copies
+// items shallowly.
+
+// cloneSlice returns a copy of items, with each item copied by copyFn if not nil.
+func cloneSlice[T any](items []T, copyFn func(T) T) []T {
+	cloned := make([]T, len(items))
+	if copyFn == nil {
+		copy(cloned, items)
+		return cloned
+	}
+	for i, item := range items {
+		cloned[i] = copyFn(item)
+	}
+	return cloned
+}
+
+// cloneMap returns a copy of values, with each value copied by copyFn if not nil.
+func cloneMap[K comparable, V any](values map[K]V, copyFn func(V) V) map[K]V {
+	cloned := make(map[K]V, len(values))
+	for k, v := range values {
+		if copyFn != nil {
+			v = copyFn(v)
+		}
+		cloned[k] = v
+	}
+	return cloned
+}
@@ -0,0 +1,128 @@
+package threadsafe
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneMaps(t *testing.T) {
+	m := NewRWMutexMap[string, []int](nil)
+	m.Set("a", []int{1, 2})
+
+	clone := m.Clone(slices.Clone[[]int])
+	value, _ := m.Get("a")
+	value[0] = 100 // mutating the original's value must not affect the clone
+	cloned, ok := clone.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, []int{1, 2}, cloned)
+
+	// A nil copyFn copies shallowly
+	shallow := m.Clone(nil)
+	value[1] = 200
+	cloned, _ = shallow.Get("a")
+	assert.Equal(t, []int{100, 200}, cloned)
+
+	mm := NewMutexMap[string, *int](nil)
+	one := 1
+	mm.Set("a", &one)
+	mclone := mm.Clone(func(p *int) *int { v := *p; return &v })
+	one = 2
+	p, _ := mclone.Get("a")
+	assert.Equal(t, 1, *p)
+
+	sm := NewSyncMap[string, []int](nil)
+	sm.Set("b", []int{3})
+	sclone := sm.Clone(slices.Clone[[]int])
+	sm.Set("c", nil)
+	assert.Equal(t, map[string][]int{"b": {3}}, sclone.GetAll())
+}
+
+func TestCloneSetsAndSlices(t *testing.T) {
+	set := NewRWMutexSet[int]()
+	set.Add(1)
+	set.Add(2)
+	doubled := set.Clone(func(x int) int { return x * 2 })
+	assert.ElementsMatch(t, []int{2, 4}, doubled.Slice())
+	assert.Equal(t, 2, doubled.Len())
+	merged := set.Clone(func(int) int { return 0 })
+	assert.Equal(t, 1, merged.Len())
+
+	syncSet := NewSyncMapSet[int]()
+	syncSet.Add(3)
+	assert.Equal(t, []int{3}, syncSet.Clone(nil).Slice())
+
+	s := NewMutexSlice[[]int](0)
+	s.Append([]int{1})
+	sclone := s.Clone(slices.Clone[[]int])
+	s.Peek()[0][0] = 9
+	s.Append([]int{2})
+	assert.Equal(t, [][]int{{1}}, sclone.Peek())
+
+	rs := NewRWMutexSlice[int](0)
+	rs.Append(1, 2)
+	assert.Equal(t, []int{1, 2}, rs.Clone(nil).Flush())
+	assert.Equal(t, 2, rs.Len())
+}
+
+func TestCloneQueues(t *testing.T) {
+	double := func(x int) int { return x * 2 }
+
+	q := NewRWMutexQueue[int]()
+	q.Push(0, 1, 2)
+	q.Pop()
+	assert.Equal(t, []int{2, 4}, q.Clone(double).Slice())
+
+	ring := NewRingQueue[int](4)
+	ring.Push(1, 2, 3, 4)
+	ring.Pop()
+	ring.Push(5) // wraps around
+	assert.Equal(t, []int{4, 6, 8, 10}, ring.Clone(double).Slice())
+
+	circular := NewCircularQueue[int](2)
+	circular.Push(1, 2, 3)
+	cclone := circular.Clone(nil)
+	assert.Equal(t, []int{2, 3}, cclone.Slice())
+	assert.Equal(t, []int{2}, cclone.PushEvict(4))
+
+	segmented := NewSegmentedQueue[int]()
+	for i := range 300 {
+		segmented.Push(i)
+	}
+	assert.Equal(t, segmented.Slice(), segmented.Clone(nil).Slice())
+
+	bounded := NewBoundedQueue[int](2, OverflowDropOldest)
+	bounded.Push(1, 2)
+	bclone := bounded.Clone(double)
+	bclone.Push(6)
+	assert.Equal(t, []int{4, 6}, bclone.Slice())
+	assert.Equal(t, []int{1, 2}, bounded.Slice())
+
+	blocking := NewBlockingQueue[int](0)
+	blocking.Push(7)
+	assert.Equal(t, []int{14}, blocking.Clone(double).Slice())
+}
+
+func TestClonePriorityQueues(t *testing.T) {
+	negate := func(x int) int { return -x }
+
+	h := NewMinHeap[int]()
+	h.Push(3, 1, 2)
+	// The clone is re-heapified, so copies need not keep the ordering of the originals
+	assert.Equal(t, []int{-3, -2, -1}, h.Clone(negate).SliceSorted())
+	assert.Equal(t, []int{1, 2, 3}, h.SliceSorted())
+
+	pq := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	pq.Push(3, 1, 2)
+	pclone := pq.Clone(negate)
+	assert.Equal(t, []int{-3, -2, -1}, pclone.SliceSorted())
+	assert.Equal(t, 3, pclone.Stats().Len)
+
+	var ordered OrderedPriorityQueue[string]
+	ordered.Push("b", "a")
+	oclone := ordered.Clone(nil)
+	ordered.Push("c")
+	assert.Equal(t, []string{"a", "b"}, oclone.SliceSorted())
+}
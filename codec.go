@@ -0,0 +1,121 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+)
+
+// Codec encodes and decodes individual items of type T to and from bytes. It is used by the
+// persistent and serializable structures in this package, so callers can pick an encoding that
+// suits their item type.
+type Codec[T any] interface {
+	// Encode returns the binary representation of item.
+	Encode(item T) ([]byte, error)
+	// Decode parses data produced by Encode back into an item.
+	Decode(data []byte) (T, error)
+}
+
+// JSONCodec is a Codec using encoding/json.
+type JSONCodec[T any] struct{}
+
+// Encode returns the JSON encoding of item.
+func (JSONCodec[T]) Encode(item T) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+// Decode parses the JSON-encoded data into an item.
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var item T
+	err := json.Unmarshal(data, &item)
+	return item, err
+}
+
+// GobCodec is a Codec using encoding/gob. Each item is encoded independently, including its type
+// information, so items can be decoded in isolation.
+type GobCodec[T any] struct{}
+
+// Encode returns the gob encoding of item.
+func (GobCodec[T]) Encode(item T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(item); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode parses the gob-encoded data into an item.
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var item T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&item)
+	return item, err
+}
+
+// writeSnapshot writes items to w as a count followed by length-prefixed records encoded with
+// codec.
+func writeSnapshot[T any](w io.Writer, codec Codec[T], items []T) error {
+	bw := bufio.NewWriter(w)
+	buf := binary.AppendUvarint(nil, uint64(len(items)))
+	for _, item := range items {
+		data, err := codec.Encode(item)
+		if err != nil {
+			return err
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+		buf = append(buf, data...)
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// readSnapshot reads items written by writeSnapshot from r.
+func readSnapshot[T any](r io.Reader, codec Codec[T]) ([]T, error) {
+	br := bufio.NewReader(r)
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]T, 0, min(count, 1<<16))
+	var data bytes.Buffer
+	for range count {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		// Grow the buffer with the data actually read rather than trusting the size prefix, so
+		// a corrupted prefix cannot trigger a huge allocation
+		if size > math.MaxInt64 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		data.Reset()
+		if _, err := io.CopyN(&data, br, int64(size)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		item, err := codec.Decode(data.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// unexpectedEOF reports a truncated snapshot as io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,295 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// The convergent replicated data types below (CRDTs) let nodes that exchange their state
+// reconcile without coordination: each node updates its own replica, periodically sends State to
+// its peers, and Merges the states it receives. Merging is commutative, associative and
+// idempotent, so replicas converge whatever the order, duplication or delay of the exchanges.
+//
+// Every replica needs a node ID unique across the cluster and stable across restarts, or its
+// updates may be mistaken for another replica's. State values are plain maps and slices that
+// encode with encoding/json and encoding/gob.
+
+// GCounterState is the replicated state of a GCounter: the count added by each node.
+type GCounterState map[string]uint64
+
+// GCounter is a thread-safe grow-only counter CRDT. Each node only increments its own count, and
+// the value is the sum of the counts of all nodes.
+//
+// The zero value is not ready to use; construct via NewGCounter with the node ID of the replica.
+type GCounter struct {
+	mu     sync.RWMutex
+	node   string
+	counts GCounterState
+}
+
+// NewGCounter creates a new GCounter replica for the given node.
+func NewGCounter(node string) *GCounter {
+	return &GCounter{node: node, counts: make(GCounterState)}
+}
+
+// Add increments the counter by n.
+func (c *GCounter) Add(n uint64) {
+	c.mu.Lock()
+	c.counts[c.node] += n
+	c.mu.Unlock()
+}
+
+// Value returns the sum of the counts of all nodes known to the replica.
+func (c *GCounter) Value() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var total uint64
+	for _, n := range c.counts {
+		total += n
+	}
+	return total
+}
+
+// State returns a copy of the replica state, to send to other replicas.
+func (c *GCounter) State() GCounterState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return maps.Clone(c.counts)
+}
+
+// Merge merges the state of another replica into this one, keeping the highest count of each
+// node.
+func (c *GCounter) Merge(other GCounterState) {
+	c.mu.Lock()
+	mergeCounts(c.counts, other)
+	c.mu.Unlock()
+}
+
+// PNCounterState is the replicated state of a PNCounter: the increments and decrements of each
+// node.
+type PNCounterState struct {
+	Inc GCounterState
+	Dec GCounterState
+}
+
+// PNCounter is a thread-safe counter CRDT that can be both incremented and decremented. It pairs
+// a grow-only count of increments with one of decrements, and its value is their difference.
+//
+// The zero value is not ready to use; construct via NewPNCounter with the node ID of the replica.
+type PNCounter struct {
+	mu   sync.RWMutex
+	node string
+	inc  GCounterState
+	dec  GCounterState
+}
+
+// NewPNCounter creates a new PNCounter replica for the given node.
+func NewPNCounter(node string) *PNCounter {
+	return &PNCounter{node: node, inc: make(GCounterState), dec: make(GCounterState)}
+}
+
+// Add adds delta to the counter, which decrements it if delta is negative.
+func (c *PNCounter) Add(delta int64) {
+	c.mu.Lock()
+	if delta >= 0 {
+		c.inc[c.node] += uint64(delta)
+	} else {
+		c.dec[c.node] += uint64(-delta)
+	}
+	c.mu.Unlock()
+}
+
+// Value returns the increments minus the decrements of all nodes known to the replica.
+func (c *PNCounter) Value() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var total int64
+	for _, n := range c.inc {
+		total += int64(n)
+	}
+	for _, n := range c.dec {
+		total -= int64(n)
+	}
+	return total
+}
+
+// State returns a copy of the replica state, to send to other replicas.
+func (c *PNCounter) State() PNCounterState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return PNCounterState{Inc: maps.Clone(c.inc), Dec: maps.Clone(c.dec)}
+}
+
+// Merge merges the state of another replica into this one, keeping the highest increments and
+// decrements of each node.
+func (c *PNCounter) Merge(other PNCounterState) {
+	c.mu.Lock()
+	mergeCounts(c.inc, other.Inc)
+	mergeCounts(c.dec, other.Dec)
+	c.mu.Unlock()
+}
+
+// ORTag identifies a single addition to an ORSet: the node that made it, and its sequence number
+// on that node.
+type ORTag struct {
+	Node string
+	Seq  uint64
+}
+
+// ORSetState is the replicated state of an ORSet: the tags of the additions of each item that
+// are not known to be removed, and the tags of all removed additions.
+type ORSetState[T comparable] struct {
+	Added   map[T][]ORTag
+	Removed []ORTag
+}
+
+// ORSet is a thread-safe observed-remove set CRDT. Each Add is tagged uniquely, and Remove only
+// removes the additions the replica has observed, so an item added on one node concurrently with
+// its removal on another is kept: additions win.
+//
+// Removed tags are kept as tombstones so that merging a state that still holds them does not
+// resurrect the item; they grow with the number of removals.
+//
+// The zero value is not ready to use; construct via NewORSet with the node ID of the replica.
+type ORSet[T comparable] struct {
+	mu      sync.RWMutex
+	node    string
+	seq     uint64
+	added   map[T]map[ORTag]struct{}
+	removed map[ORTag]struct{}
+}
+
+// NewORSet creates a new, empty ORSet replica for the given node.
+func NewORSet[T comparable](node string) *ORSet[T] {
+	return &ORSet[T]{
+		node:    node,
+		added:   make(map[T]map[ORTag]struct{}),
+		removed: make(map[ORTag]struct{}),
+	}
+}
+
+// Add adds item to the set.
+func (s *ORSet[T]) Add(item T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	s.addTagLocked(item, ORTag{Node: s.node, Seq: s.seq})
+}
+
+// Remove removes item from the set, and reports whether it was present. Additions of item by
+// other replicas that this replica has not observed yet are not removed.
+func (s *ORSet[T]) Remove(item T) (removed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tags, ok := s.added[item]
+	if !ok {
+		return false
+	}
+	for tag := range tags {
+		s.removed[tag] = struct{}{}
+	}
+	delete(s.added, item)
+	return true
+}
+
+// Has reports whether item is in the set.
+func (s *ORSet[T]) Has(item T) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.added[item]
+	return ok
+}
+
+// Len returns the number of items in the set.
+func (s *ORSet[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.added)
+}
+
+// Slice returns the items of the set, in no particular order.
+func (s *ORSet[T]) Slice() []T {
+	return slices.Collect(s.All())
+}
+
+// All returns an iterator over a snapshot of the items of the set, in no particular order.
+func (s *ORSet[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.mu.RLock()
+		items := slices.Collect(maps.Keys(s.added))
+		s.mu.RUnlock()
+		for _, item := range items {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// State returns a copy of the replica state, to send to other replicas.
+func (s *ORSet[T]) State() ORSetState[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state := ORSetState[T]{
+		Added:   make(map[T][]ORTag, len(s.added)),
+		Removed: slices.Collect(maps.Keys(s.removed)),
+	}
+	for item, tags := range s.added {
+		state.Added[item] = slices.Collect(maps.Keys(tags))
+	}
+	return state
+}
+
+// Merge merges the state of another replica into this one: an item is in the merged set if
+// either replica holds an addition of it that neither has removed.
+func (s *ORSet[T]) Merge(other ORSetState[T]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range other.Removed {
+		s.removed[tag] = struct{}{}
+	}
+	for item, tags := range other.Added {
+		for _, tag := range tags {
+			if _, gone := s.removed[tag]; !gone {
+				s.addTagLocked(item, tag)
+			}
+		}
+	}
+	for item, tags := range s.added {
+		for tag := range tags {
+			if _, gone := s.removed[tag]; gone {
+				delete(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			delete(s.added, item)
+		}
+	}
+}
+
+// Internal helpers
+
+// addTagLocked records the addition of item tagged tag. It keeps the sequence number of the
+// replica past its own tags, e.g. those restored from a peer after a restart. Callers must hold
+// the lock.
+func (s *ORSet[T]) addTagLocked(item T, tag ORTag) {
+	if tag.Node == s.node {
+		s.seq = max(s.seq, tag.Seq)
+	}
+	tags, ok := s.added[item]
+	if !ok {
+		tags = make(map[ORTag]struct{})
+		s.added[item] = tags
+	}
+	tags[tag] = struct{}{}
+}
+
+// mergeCounts merges the counts of other into counts, keeping the highest count of each node.
+func mergeCounts(counts, other GCounterState) {
+	for node, n := range other {
+		counts[node] = max(counts[node], n)
+	}
+}
@@ -0,0 +1,118 @@
+package threadsafe
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCounter(t *testing.T) {
+	a, b := NewGCounter("a"), NewGCounter("b")
+	a.Add(2)
+	b.Add(3)
+	b.Add(1)
+
+	a.Merge(b.State())
+	a.Merge(b.State()) // merging is idempotent
+	b.Merge(a.State())
+	assert.Equal(t, uint64(6), a.Value())
+	assert.Equal(t, uint64(6), b.Value())
+	assert.Equal(t, GCounterState{"a": 2, "b": 4}, a.State())
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				a.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(806), a.Value())
+}
+
+func TestPNCounter(t *testing.T) {
+	a, b := NewPNCounter("a"), NewPNCounter("b")
+	a.Add(5)
+	a.Add(-2)
+	b.Add(-4)
+
+	a.Merge(b.State())
+	b.Merge(a.State())
+	assert.Equal(t, int64(-1), a.Value())
+	assert.Equal(t, int64(-1), b.Value())
+
+	// States survive an encoding round trip
+	data, err := json.Marshal(a.State())
+	require.NoError(t, err)
+	var decoded PNCounterState
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	c := NewPNCounter("c")
+	c.Merge(decoded)
+	assert.Equal(t, int64(-1), c.Value())
+}
+
+func TestORSet(t *testing.T) {
+	a, b := NewORSet[string]("a"), NewORSet[string]("b")
+	a.Add("x")
+	a.Add("y")
+	b.Merge(a.State())
+	assert.ElementsMatch(t, []string{"x", "y"}, b.Slice())
+
+	// A concurrent add wins over a remove that did not observe it
+	assert.True(t, b.Remove("x"))
+	assert.False(t, b.Remove("x"))
+	a.Add("x")
+	a.Merge(b.State())
+	b.Merge(a.State())
+	assert.True(t, a.Has("x"))
+	assert.True(t, b.Has("x"))
+
+	// An observed remove wins over stale states that still hold the addition
+	stale := a.State()
+	assert.True(t, a.Remove("y"))
+	b.Merge(a.State())
+	b.Merge(stale)
+	assert.False(t, b.Has("y"))
+	assert.Equal(t, 1, b.Len())
+
+	// A restarted replica restoring its state from a peer doesn't reuse its tags
+	restarted := NewORSet[string]("a")
+	restarted.Merge(b.State())
+	restarted.Add("z")
+	b.Remove("z")
+	b.Merge(restarted.State())
+	assert.True(t, b.Has("z"))
+}
+
+func TestORSetConvergence(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	replicas := []*ORSet[int]{NewORSet[int]("a"), NewORSet[int]("b"), NewORSet[int]("c")}
+	for range 500 {
+		replica := replicas[r.IntN(len(replicas))]
+		switch r.IntN(3) {
+		case 0:
+			replica.Add(r.IntN(10))
+		case 1:
+			replica.Remove(r.IntN(10))
+		default:
+			replica.Merge(replicas[r.IntN(len(replicas))].State())
+		}
+	}
+	// Two full exchange rounds make every replica observe every other
+	for range 2 {
+		for _, to := range replicas {
+			for _, from := range replicas {
+				to.Merge(from.State())
+			}
+		}
+	}
+	want := replicas[0].Slice()
+	for _, replica := range replicas[1:] {
+		assert.ElementsMatch(t, want, replica.Slice())
+	}
+}
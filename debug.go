@@ -0,0 +1,221 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// DefaultDebugLimit is the number of items per collection a DebugHandler renders, unless the
+// request sets another limit.
+const DefaultDebugLimit = 100
+
+// DebugHandler is an http.Handler exposing the collections registered with it, the way expvar
+// exposes variables, so operators can inspect the shared in-memory state of a running service
+// without attaching a debugger.
+//
+// A request renders a JSON object with one member per collection, holding its Go type and, for
+// the methods the collection has, its Len, its Stats and its first items as yielded by All:
+//
+//	{"jobs": {"type": "*threadsafe.RWMutexQueue[int]", "len": 3, "items": [1, 2], "truncated": true}}
+//
+// Items of an iter.Seq2 are rendered as {"key": k, "value": v} objects. The query parameter name
+// restricts the response to one collection, and limit sets the number of items rendered per
+// collection, 0 to leave them out. A collection whose methods panic, or whose items do not encode
+// to JSON, is rendered with an "error" member instead.
+//
+// Rendering calls the methods of the collections like any other caller, so it takes their locks;
+// keep the limit low on hot collections. The handler is not registered anywhere; mount it on a
+// mux of your choice, preferably one that is not publicly reachable.
+//
+// The zero value is ready to use.
+type DebugHandler struct {
+	mu          sync.RWMutex
+	collections map[string]any
+}
+
+// Ensure DebugHandler implements http.Handler.
+var _ http.Handler = (*DebugHandler)(nil)
+
+// NewDebugHandler creates a new DebugHandler without registered collections.
+func NewDebugHandler() *DebugHandler {
+	return &DebugHandler{collections: make(map[string]any)}
+}
+
+// Register exposes collection under name, replacing any collection registered under that name.
+// collection may be any value; the methods it is rendered with are looked up when serving.
+func (h *DebugHandler) Register(name string, collection any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.collections == nil {
+		h.collections = make(map[string]any)
+	}
+	h.collections[name] = collection
+}
+
+// Unregister stops exposing the collection registered under name, and reports whether there was
+// one.
+func (h *DebugHandler) Unregister(name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.collections[name]
+	delete(h.collections, name)
+	return ok
+}
+
+// Names returns the names of the registered collections, sorted.
+func (h *DebugHandler) Names() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	names := make([]string, 0, len(h.collections))
+	for name := range h.collections {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ServeHTTP renders the registered collections as JSON. It responds with 400 Bad Request if the
+// limit is not a non-negative integer, and 404 Not Found if no collection is registered under the
+// requested name.
+func (h *DebugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := DefaultDebugLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			http.Error(w, "threadsafe: invalid limit "+strconv.Quote(s), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	h.mu.RLock()
+	collections := make(map[string]any, len(h.collections))
+	if name := query.Get("name"); name != "" {
+		if c, ok := h.collections[name]; ok {
+			collections[name] = c
+		}
+	} else {
+		for name, c := range h.collections {
+			collections[name] = c
+		}
+	}
+	h.mu.RUnlock()
+	if name := query.Get("name"); name != "" && len(collections) == 0 {
+		http.Error(w, "threadsafe: no collection "+strconv.Quote(name), http.StatusNotFound)
+		return
+	}
+
+	rendered := make(map[string]json.RawMessage, len(collections))
+	for name, c := range collections {
+		rendered[name] = renderDebugCollection(c, limit)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(rendered)
+}
+
+// Internal helpers
+
+// debugCollection is the rendering of a collection registered with a DebugHandler.
+type debugCollection struct {
+	Type      string `json:"type"`
+	Len       *int   `json:"len,omitempty"`
+	Stats     any    `json:"stats,omitempty"`
+	Items     any    `json:"items,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// debugEntry is the rendering of an item of an iter.Seq2.
+type debugEntry struct {
+	Key   any `json:"key"`
+	Value any `json:"value"`
+}
+
+// renderDebugCollection renders c with up to limit items, falling back to its type and the error
+// if its methods panic or its rendering does not encode.
+func renderDebugCollection(c any, limit int) json.RawMessage {
+	out := debugCollection{Type: fmt.Sprintf("%T", c)}
+	data, err := func() (data []byte, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		describeDebugCollection(&out, reflect.ValueOf(c), limit)
+		return json.Marshal(out)
+	}()
+	if err != nil {
+		data, _ = json.Marshal(debugCollection{Type: out.Type, Error: err.Error()})
+	}
+	return data
+}
+
+// describeDebugCollection fills out from the Len, Stats and All methods of c, if it has them.
+func describeDebugCollection(out *debugCollection, c reflect.Value, limit int) {
+	if !c.IsValid() {
+		return
+	}
+	if m, ok := debugMethod(c, "Len"); ok && m.Type().Out(0).Kind() == reflect.Int {
+		n := int(m.Call(nil)[0].Int())
+		out.Len = &n
+	}
+	if m, ok := debugMethod(c, "Stats"); ok {
+		out.Stats = m.Call(nil)[0].Interface()
+	}
+	if m, ok := debugMethod(c, "All"); ok && limit > 0 {
+		out.Items, out.Truncated = debugItems(m, limit)
+	}
+}
+
+// debugMethod returns the method of c with the given name, if it takes no arguments and returns
+// a single value.
+func debugMethod(c reflect.Value, name string) (reflect.Value, bool) {
+	m := c.MethodByName(name)
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return reflect.Value{}, false
+	}
+	return m, true
+}
+
+// debugItems collects up to limit items from the iter.Seq or iter.Seq2 returned by all, and
+// reports whether items were left out. It returns nil items if all returns no iterator.
+func debugItems(all reflect.Value, limit int) (items any, truncated bool) {
+	seq := all.Call(nil)[0]
+	seqType := seq.Type()
+	if seqType.Kind() != reflect.Func || seqType.NumIn() != 1 || seqType.NumOut() != 0 ||
+		seq.IsNil() {
+		return nil, false
+	}
+	yieldType := seqType.In(0)
+	if yieldType.Kind() != reflect.Func || yieldType.NumIn() < 1 || yieldType.NumIn() > 2 ||
+		yieldType.NumOut() != 1 || yieldType.Out(0).Kind() != reflect.Bool {
+		return nil, false
+	}
+
+	collected := []any{}
+	stop := reflect.ValueOf(false).Convert(yieldType.Out(0))
+	next := reflect.ValueOf(true).Convert(yieldType.Out(0))
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		if len(collected) == limit {
+			truncated = true
+			return []reflect.Value{stop}
+		}
+		if len(args) == 1 {
+			collected = append(collected, args[0].Interface())
+		} else {
+			collected = append(collected, debugEntry{Key: args[0].Interface(), Value: args[1].Interface()})
+		}
+		return []reflect.Value{next}
+	})
+	seq.Call([]reflect.Value{yield})
+	return collected, truncated
+}
@@ -0,0 +1,93 @@
+package threadsafe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// debugGet serves a request for the given query and decodes the response.
+func debugGet(t *testing.T, h *DebugHandler, query string) map[string]debugCollection {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/collections"+query, nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	var out map[string]debugCollection
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	return out
+}
+
+func TestDebugHandler(t *testing.T) {
+	var h DebugHandler
+	queue := NewRWMutexQueue[int]()
+	queue.Push(1, 2, 3)
+	m := NewRWMutexMap[string, int](nil)
+	m.Set("a", 1)
+	cache := NewCache[string, int](10, 0, CacheLRU)
+	cache.Set("k", 1)
+	cache.Get("k")
+
+	h.Register("queue", queue)
+	h.Register("map", m)
+	h.Register("cache", cache)
+	h.Register("opaque", 42)
+	assert.Equal(t, []string{"cache", "map", "opaque", "queue"}, h.Names())
+
+	out := debugGet(t, &h, "")
+	require.Len(t, out, 4)
+	assert.Equal(t, "*threadsafe.RWMutexQueue[int]", out["queue"].Type)
+	require.NotNil(t, out["queue"].Len)
+	assert.Equal(t, 3, *out["queue"].Len)
+	assert.Equal(t, []any{1.0, 2.0, 3.0}, out["queue"].Items)
+	assert.False(t, out["queue"].Truncated)
+	assert.Equal(t, []any{map[string]any{"key": "a", "value": 1.0}}, out["map"].Items)
+	assert.Equal(t, map[string]any{
+		"Hits": 1.0, "Misses": 0.0, "Loads": 0.0, "LoadErrors": 0.0,
+		"Evictions": 0.0, "Expirations": 0.0, "Len": 1.0,
+	}, out["cache"].Stats)
+	assert.Equal(t, debugCollection{Type: "int"}, out["opaque"])
+
+	// The limit truncates the items, or leaves them out
+	out = debugGet(t, &h, "?name=queue&limit=2")
+	require.Len(t, out, 1)
+	assert.Equal(t, []any{1.0, 2.0}, out["queue"].Items)
+	assert.True(t, out["queue"].Truncated)
+	out = debugGet(t, &h, "?name=queue&limit=0")
+	assert.Nil(t, out["queue"].Items)
+	assert.Equal(t, 3, *out["queue"].Len)
+
+	// Empty collections render an empty list of items
+	queue.Clear()
+	assert.Equal(t, []any{}, debugGet(t, &h, "?name=queue")["queue"].Items)
+
+	assert.True(t, h.Unregister("queue"))
+	assert.False(t, h.Unregister("queue"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?name=queue", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?limit=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDebugHandlerErrors(t *testing.T) {
+	h := NewDebugHandler()
+	// The zero value of a Cache is ready to use, but a nil one panics
+	var cache *Cache[string, int]
+	h.Register("nil", cache)
+	unencodable := NewRWMutexSlice[func()](0)
+	unencodable.Append(func() {})
+	h.Register("funcs", unencodable)
+	h.Register("ok", NewRWMutexSlice[int](0))
+
+	out := debugGet(t, h, "")
+	assert.Equal(t, "*threadsafe.Cache[string,int]", out["nil"].Type)
+	assert.Contains(t, out["nil"].Error, "panic")
+	assert.NotEmpty(t, out["funcs"].Error)
+	assert.Empty(t, out["ok"].Error)
+}
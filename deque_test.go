@@ -0,0 +1,103 @@
+package threadsafe
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkStealingDequeBasicOperations(t *testing.T) {
+	var d WorkStealingDeque[int] // zero value is ready to use
+	_, ok := d.Pop()
+	assert.False(t, ok)
+	_, ok = d.Steal()
+	assert.False(t, ok)
+
+	d.Push(1, 2, 3)
+	assert.Equal(t, 3, d.Len())
+
+	// Owner pops LIFO, thieves steal FIFO
+	item, ok := d.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 3, item)
+	item, ok = d.Steal()
+	assert.True(t, ok)
+	assert.Equal(t, 1, item)
+	item, ok = d.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 2, item)
+	assert.Equal(t, 0, d.Len())
+
+	// Growing past the initial buffer keeps all items
+	for i := range 1000 {
+		d.Push(i)
+	}
+	for i := range 1000 {
+		item, ok := d.Steal()
+		assert.True(t, ok)
+		assert.Equal(t, i, item)
+	}
+}
+
+func TestWorkStealingDequeConcurrentSteal(t *testing.T) {
+	const total = 10000
+	const thieves = 4
+	d := NewWorkStealingDeque[int]()
+
+	seen := make([]atomic.Int32, total)
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	for range thieves {
+		wg.Go(func() {
+			for {
+				if item, ok := d.Steal(); ok {
+					seen[item].Add(1)
+					continue
+				}
+				if done.Load() && d.Len() == 0 {
+					return
+				}
+			}
+		})
+	}
+
+	// Owner interleaves pushes and pops at the bottom
+	for i := range total {
+		d.Push(i)
+		if i%3 == 0 {
+			if item, ok := d.Pop(); ok {
+				seen[item].Add(1)
+			}
+		}
+	}
+	for {
+		item, ok := d.Pop()
+		if !ok {
+			break
+		}
+		seen[item].Add(1)
+	}
+	done.Store(true)
+	wg.Wait()
+
+	// Every item was taken exactly once
+	for i := range seen {
+		assert.Equal(t, int32(1), seen[i].Load(), "item %d", i)
+	}
+}
+
+func TestWorkStealingDequeReleasesItems(t *testing.T) {
+	var d WorkStealingDeque[*int]
+	d.Push(new(int), new(int), new(int))
+	slots := d.buf.Load().slots
+
+	d.Pop()
+	assert.Nil(t, slots[2].Load(), "popped slots are cleared")
+	d.Steal()
+	assert.Nil(t, slots[0].Load(), "stolen slots are cleared")
+	d.Pop()
+	assert.Nil(t, slots[1].Load(), "the last slot is cleared")
+	assert.Zero(t, d.Len())
+}
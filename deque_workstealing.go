@@ -0,0 +1,129 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "sync/atomic"
+
+const workStealingInitialSize = 32 // must be a power of two
+
+// wsBuffer is the circular array backing a WorkStealingDeque. Slots hold pointers so they can be
+// read and written atomically regardless of T.
+type wsBuffer[T any] struct {
+	slots []atomic.Pointer[T]
+	mask  int64
+}
+
+func newWSBuffer[T any](size int64) *wsBuffer[T] {
+	return &wsBuffer[T]{slots: make([]atomic.Pointer[T], size), mask: size - 1}
+}
+
+func (b *wsBuffer[T]) size() int64          { return int64(len(b.slots)) }
+func (b *wsBuffer[T]) get(i int64) *T       { return b.slots[i&b.mask].Load() }
+func (b *wsBuffer[T]) put(i int64, item *T) { b.slots[i&b.mask].Store(item) }
+func (b *wsBuffer[T]) grow(top, bottom int64) *wsBuffer[T] {
+	grown := newWSBuffer[T](b.size() * 2)
+	for i := top; i < bottom; i++ {
+		grown.put(i, b.get(i))
+	}
+	return grown
+}
+
+// WorkStealingDeque is a lock-free double-ended queue for building task schedulers, following the
+// Chase-Lev design. A single owner goroutine pushes and pops items at the bottom end in LIFO
+// order, while any number of other goroutines steal items from the top end in FIFO order.
+//
+// Push and Pop must only be called by the owner goroutine; Steal and Len are safe for use by any
+// goroutine. Owner operations are uncontended except when the deque holds a single item.
+//
+// The zero value is ready to use.
+type WorkStealingDeque[T any] struct {
+	top    atomic.Int64
+	bottom atomic.Int64
+	buf    atomic.Pointer[wsBuffer[T]]
+}
+
+// NewWorkStealingDeque creates a new instance of WorkStealingDeque.
+func NewWorkStealingDeque[T any]() *WorkStealingDeque[T] {
+	return &WorkStealingDeque[T]{}
+}
+
+// Push adds one or more items to the bottom of the deque. Only the owner may call Push.
+func (d *WorkStealingDeque[T]) Push(items ...T) {
+	for _, item := range items {
+		b := d.bottom.Load()
+		t := d.top.Load()
+		buf := d.buf.Load()
+		if buf == nil {
+			buf = newWSBuffer[T](workStealingInitialSize)
+			d.buf.Store(buf)
+		} else if b-t >= buf.size()-1 {
+			buf = buf.grow(t, b)
+			d.buf.Store(buf)
+		}
+		buf.put(b, &item)
+		d.bottom.Store(b + 1)
+	}
+}
+
+// Pop removes and returns the item most recently pushed to the bottom of the deque. Only the
+// owner may call Pop. If the deque is empty, it returns ok == false and the zero value of T.
+func (d *WorkStealingDeque[T]) Pop() (item T, ok bool) {
+	b := d.bottom.Load() - 1
+	d.bottom.Store(b)
+	t := d.top.Load()
+	if t > b {
+		// Empty deque, restore bottom.
+		d.bottom.Store(b + 1)
+		return item, false
+	}
+
+	buf := d.buf.Load()
+	p := buf.get(b)
+	if t == b {
+		// Last item: race against thieves for it.
+		won := d.top.CompareAndSwap(t, t+1)
+		d.bottom.Store(b + 1)
+		if !won {
+			return item, false
+		}
+	}
+	// No thief can reach the slot anymore; release the item for garbage collection.
+	buf.put(b, nil)
+	return *p, true
+}
+
+// Steal removes and returns the oldest item at the top of the deque. It is safe to call from any
+// goroutine. If the deque is empty, it returns ok == false and the zero value of T.
+func (d *WorkStealingDeque[T]) Steal() (item T, ok bool) {
+	for {
+		t := d.top.Load()
+		b := d.bottom.Load()
+		if t >= b {
+			return item, false
+		}
+		buf := d.buf.Load()
+		p := buf.get(t)
+		if d.top.CompareAndSwap(t, t+1) {
+			// Release the item for garbage collection, unless the owner already reused the slot.
+			buf.slots[t&buf.mask].CompareAndSwap(p, nil)
+			return *p, true
+		}
+		// Lost the race against the owner or another thief; retry.
+	}
+}
+
+// Len returns the number of items in the deque. The result is approximate while the deque is
+// being modified concurrently.
+func (d *WorkStealingDeque[T]) Len() int {
+	return int(max(d.bottom.Load()-d.top.Load(), 0))
+}
+
+// String returns the length of the deque, for logging and debugging. Items are not shown, as they
+// cannot be inspected without removing them.
+func (d *WorkStealingDeque[T]) String() string {
+	return formatLen(d, d.Len())
+}
+
+// GoString returns the Go type and length of the deque, for the %#v verb.
+func (d *WorkStealingDeque[T]) GoString() string {
+	return goFormatLen(d, d.Len())
+}
@@ -0,0 +1,81 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// Internal helpers
+//
+// The EstimateBytes methods approximate the memory held by a collection, for capacity planning
+// and per-tenant memory accounting: the container itself, the inline size of every allocated slot
+// as reported by unsafe.Sizeof, and the overhead of the underlying map or list. An optional sizeFn
+// reports the bytes an item references beyond its inline size, such as the data of a string or a
+// slice, and is called under the collection's lock. Allocator rounding and runtime bookkeeping are
+// ignored, so estimates are best compared with each other rather than with heap profiles.
+
+const (
+	// mapSlotsPerEntry over mapEntriesPerSlots approximates the load factor of built-in maps,
+	// which keep up to 7 of every 8 slots filled.
+	mapSlotsPerEntry   = 8
+	mapEntriesPerSlots = 7
+
+	// syncMapEntryOverhead approximates the per-entry cost of a sync.Map beyond the key and value
+	// themselves: the trie node and the interface headers boxing the key and value.
+	syncMapEntryOverhead = 48
+)
+
+// sizeOf returns the inline size of a value of type T.
+func sizeOf[T any]() int {
+	var zero T
+	return int(unsafe.Sizeof(zero))
+}
+
+// sliceBytes estimates the bytes of a backing array with capacity slots that holds items, adding
+// sizeFn(item) per item if sizeFn is not nil.
+func sliceBytes[T any](items []T, capacity int, sizeFn func(T) int) int {
+	n := capacity * sizeOf[T]()
+	if sizeFn != nil {
+		for _, item := range items {
+			n += sizeFn(item)
+		}
+	}
+	return n
+}
+
+// mapBytes estimates the bytes of a built-in map, counting one control byte per slot, adding
+// sizeFn(key, value) per entry if sizeFn is not nil.
+func mapBytes[K comparable, V any](values map[K]V, sizeFn func(K, V) int) int {
+	slot := sizeOf[K]() + sizeOf[V]() + 1
+	n := len(values) * slot * mapSlotsPerEntry / mapEntriesPerSlots
+	if sizeFn != nil {
+		for k, v := range values {
+			n += sizeFn(k, v)
+		}
+	}
+	return n
+}
+
+// syncMapBytes estimates the bytes of the entries of m, which must map K to V, adding
+// sizeFn(key, value) per entry if sizeFn is not nil.
+func syncMapBytes[K comparable, V any](m *sync.Map, sizeFn func(K, V) int) int {
+	entry := sizeOf[K]() + sizeOf[V]() + syncMapEntryOverhead
+	n := 0
+	m.Range(func(k, v any) bool {
+		n += entry
+		if sizeFn != nil {
+			n += sizeFn(k.(K), v.(V)) //nolint:revive
+		}
+		return true
+	})
+	return n
+}
+
+// keySizer adapts a set's item sizer to the key-value sizer of its backing map.
+func keySizer[T any](sizeFn func(T) int) func(T, struct{}) int {
+	if sizeFn == nil {
+		return nil
+	}
+	return func(item T, _ struct{}) int { return sizeFn(item) }
+}
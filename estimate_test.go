@@ -0,0 +1,99 @@
+package threadsafe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateBytes(t *testing.T) {
+	strLen := func(s string) int { return len(s) }
+
+	t.Run("Slices", func(t *testing.T) {
+		s := NewRWMutexSlice[int64](10)
+		empty := s.EstimateBytes(nil)
+		assert.GreaterOrEqual(t, empty, 10*8)
+		s.Append(1, 2, 3)
+		assert.Equal(t, empty, s.EstimateBytes(nil), "spare capacity is already counted")
+
+		words := NewMutexSlice[string](0)
+		words.Append("hello", "world!")
+		assert.Equal(t, words.EstimateBytes(nil)+11, words.EstimateBytes(strLen))
+	})
+
+	t.Run("Maps", func(t *testing.T) {
+		m := NewRWMutexMap[string, int](nil)
+		base := m.EstimateBytes(nil)
+		m.Set("key", 1)
+		grown := m.EstimateBytes(nil)
+		assert.Greater(t, grown, base)
+		keyLen := func(k string, _ int) int { return len(k) }
+		assert.Equal(t, grown+3, m.EstimateBytes(keyLen))
+
+		mm := NewMutexMap[string, int](nil)
+		mm.Set("key", 1)
+		assert.Equal(t, mm.EstimateBytes(nil)+3, mm.EstimateBytes(keyLen))
+
+		sm := NewSyncMap[string, int](nil)
+		base = sm.EstimateBytes(nil)
+		sm.Set("a", 1)
+		sm.Set("bc", 2)
+		assert.Greater(t, sm.EstimateBytes(nil), base)
+		assert.Equal(t, sm.EstimateBytes(nil)+3, sm.EstimateBytes(keyLen))
+	})
+
+	t.Run("Sets", func(t *testing.T) {
+		s := NewRWMutexSet[string]()
+		s.Add("abc")
+		assert.Equal(t, s.EstimateBytes(nil)+3, s.EstimateBytes(strLen))
+
+		ss := NewSyncMapSet[string]()
+		base := ss.EstimateBytes(nil)
+		ss.Add("abcd")
+		assert.Equal(t, base+sizeOf[string]()+syncMapEntryOverhead+4, ss.EstimateBytes(strLen))
+	})
+
+	t.Run("Queues", func(t *testing.T) {
+		ring := NewRingQueue[int64](4)
+		ring.Push(1, 2, 3, 4)
+		ring.Pop()
+		ring.Push(5)
+		assert.Equal(t, sizeOf[RingQueue[int64]]()+4*8, ring.EstimateBytes(nil))
+		counted := 0
+		ring.EstimateBytes(func(int64) int { counted++; return 0 })
+		assert.Equal(t, 4, counted)
+
+		circular := NewCircularQueue[int64](3)
+		assert.Equal(t, sizeOf[CircularQueue[int64]]()+3*8, circular.EstimateBytes(nil))
+
+		segmented := NewSegmentedQueue[int64]()
+		segmented.Push(make([]int64, queueSegmentSize+1)...)
+		assert.Equal(t, sizeOf[SegmentedQueue[int64]]()+2*sizeOf[queueSegment[int64]](),
+			segmented.EstimateBytes(nil))
+
+		queues := []interface {
+			Push(...string)
+			EstimateBytes(func(string) int) int
+		}{
+			NewRWMutexQueue[string](),
+			NewBlockingQueue[string](0),
+			NewBoundedQueue[string](4, OverflowReject),
+		}
+		for _, q := range queues {
+			q.Push("ab", "cde")
+			assert.Equal(t, q.EstimateBytes(nil)+5, q.EstimateBytes(strLen))
+		}
+	})
+
+	t.Run("Heaps", func(t *testing.T) {
+		h := NewMinHeap[string]()
+		h.Push("a", "bb")
+		assert.Equal(t, h.EstimateBytes(nil)+3, h.EstimateBytes(strLen))
+
+		var pq OrderedPriorityQueue[int64]
+		pq.Push(3, 1, 2)
+		base := pq.EstimateBytes(nil)
+		pq.EnableWaitTracking()
+		assert.Greater(t, pq.EstimateBytes(nil), base, "wait tracking timestamps are counted")
+	})
+}
@@ -0,0 +1,120 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// eventHandler is a handler registered on an EventBus. It is compared by pointer on unsubscribe.
+type eventHandler[T any] struct {
+	fn func(ctx context.Context, event T)
+}
+
+// EventBus dispatches events to registered handlers, either synchronously with Publish or
+// asynchronously with PublishAsync, through a bounded queue drained by a WorkerPool.
+//
+// Each handler call is isolated: a panic in one handler is recovered, reported to OnPanic if set,
+// and does not prevent the other handlers from receiving the event. Handlers receive events in
+// registration order; with several workers, async events may be handled concurrently and out of
+// publication order.
+//
+// The zero value is not ready to use; construct via NewEventBus.
+type EventBus[T any] struct {
+	// OnPanic, if set, is called with the event and the recovered value when a handler panics.
+	// It must be set before the first Publish or Start.
+	OnPanic func(event T, recovered any)
+
+	mu       sync.RWMutex
+	handlers []*eventHandler[T] // copy-on-write, so dispatch can use a snapshot without locking
+
+	queue *BoundedQueue[T]
+	pool  *WorkerPool[T]
+}
+
+// NewEventBus creates a new EventBus whose async events are queued in a BoundedQueue of the given
+// capacity and policy, and dispatched by the given number of workers once Start is called.
+// Capacity and workers are coerced to at least 1.
+func NewEventBus[T any](workers, capacity int, policy OverflowPolicy) *EventBus[T] {
+	b := &EventBus[T]{queue: NewBoundedQueue[T](capacity, policy)}
+	b.pool = NewWorkerPool[T](b.queue, workers, b.dispatch)
+	return b
+}
+
+// Subscribe registers handler for all events published from now on, and returns a function
+// unregistering it. unsubscribe may be called more than once.
+func (b *EventBus[T]) Subscribe(handler func(ctx context.Context, event T)) (unsubscribe func()) {
+	h := &eventHandler[T]{fn: handler}
+	b.mu.Lock()
+	b.handlers = append(slices.Clip(b.handlers), h)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		if i := slices.Index(b.handlers, h); i >= 0 {
+			b.handlers = slices.Delete(slices.Clone(b.handlers), i, i+1)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// Handlers returns the number of registered handlers.
+func (b *EventBus[T]) Handlers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.handlers)
+}
+
+// Publish dispatches event to all handlers in the calling goroutine, returning once they have all
+// run.
+func (b *EventBus[T]) Publish(ctx context.Context, event T) {
+	b.dispatch(ctx, event)
+}
+
+// PublishAsync queues event for dispatch by the workers, applying the queue's overflow policy if
+// it is full: with OverflowReject it returns ErrQueueFull, and with OverflowBlock it waits for room
+// until ctx is done, returning ctx.Err(). Handlers receive the context given to Start.
+func (b *EventBus[T]) PublishAsync(ctx context.Context, event T) error {
+	return b.queue.PushWait(ctx, event)
+}
+
+// Pending returns the number of async events waiting for a worker.
+func (b *EventBus[T]) Pending() int {
+	return b.queue.Len()
+}
+
+// Start launches the workers dispatching async events. Handlers receive ctx, and no further
+// events are dispatched once ctx is done. Calling Start more than once has no effect.
+func (b *EventBus[T]) Start(ctx context.Context) {
+	b.pool.Start(ctx)
+}
+
+// Shutdown gracefully stops the workers: no further async events are dispatched and Shutdown
+// waits for the dispatches in progress to complete, or for ctx to be done, in which case
+// ctx.Err() is returned. Events still queued are left undispatched.
+func (b *EventBus[T]) Shutdown(ctx context.Context) error {
+	return b.pool.Shutdown(ctx)
+}
+
+// Internal helpers
+
+// dispatch runs all handlers for event.
+func (b *EventBus[T]) dispatch(ctx context.Context, event T) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		b.call(ctx, h, event)
+	}
+}
+
+// call runs a single handler, recovering from panics.
+func (b *EventBus[T]) call(ctx context.Context, h *eventHandler[T], event T) {
+	defer func() {
+		if r := recover(); r != nil && b.OnPanic != nil {
+			b.OnPanic(event, r)
+		}
+	}()
+	h.fn(ctx, event)
+}
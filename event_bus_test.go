@@ -0,0 +1,64 @@
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus[string](2, 8, OverflowReject)
+	var panics atomic.Int32
+	bus.OnPanic = func(event string, recovered any) {
+		assert.Equal(t, "e1", event)
+		assert.Equal(t, "boom", recovered)
+		panics.Add(1)
+	}
+
+	var mu sync.Mutex
+	var got []string
+	record := func(prefix string) func(context.Context, string) {
+		return func(_ context.Context, event string) {
+			mu.Lock()
+			got = append(got, prefix+event)
+			mu.Unlock()
+		}
+	}
+	bus.Subscribe(record("a:"))
+	unsubscribe := bus.Subscribe(func(context.Context, string) { panic("boom") })
+	bus.Subscribe(record("b:"))
+	assert.Equal(t, 3, bus.Handlers())
+
+	// A panicking handler does not keep the others from running
+	bus.Publish(context.Background(), "e1")
+	assert.Equal(t, []string{"a:e1", "b:e1"}, got)
+	assert.Equal(t, int32(1), panics.Load())
+
+	unsubscribe()
+	unsubscribe()
+	assert.Equal(t, 2, bus.Handlers())
+	bus.Publish(context.Background(), "e2")
+	assert.Equal(t, int32(1), panics.Load())
+}
+
+func TestEventBusAsync(t *testing.T) {
+	bus := NewEventBus[int](4, 2, OverflowReject)
+	var sum atomic.Int64
+	bus.Subscribe(func(_ context.Context, event int) { sum.Add(int64(event)) })
+
+	// Before Start, events wait in the bounded queue
+	assert.NoError(t, bus.PublishAsync(context.Background(), 1))
+	assert.NoError(t, bus.PublishAsync(context.Background(), 2))
+	assert.ErrorIs(t, bus.PublishAsync(context.Background(), 3), ErrQueueFull)
+	assert.Equal(t, 2, bus.Pending())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus.Start(ctx)
+	assert.Eventually(t, func() bool { return sum.Load() == 3 }, time.Second, time.Millisecond)
+	assert.NoError(t, bus.Shutdown(context.Background()))
+}
@@ -0,0 +1,155 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+)
+
+// stringMaxItems is the maximum number of items shown by the String and GoString methods of the
+// collections, so that logging a large collection stays cheap and readable.
+const stringMaxItems = 16
+
+// Internal helpers
+//
+// The String and GoString methods read the length and the items through the collection's own
+// thread-safe methods, so under concurrent modification the two may reflect different instants.
+// No lock is held while items are formatted.
+
+// formatItems formats c for String as its type name, its length n and up to stringMaxItems items
+// of seq in the style of %v for slices, e.g. RWMutexQueue(len=3)[1 2 3].
+func formatItems[T any](c any, n int, seq iter.Seq[T]) string {
+	items, more := headItems(seq)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s(len=%d)[", typeName(c), n)
+	for i, item := range items {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprint(&b, item)
+	}
+	if more {
+		b.WriteString(" ...")
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// goFormatItems formats c for GoString as its Go type, its length n and up to stringMaxItems
+// items of seq in the style of %#v, e.g. &threadsafe.RWMutexQueue[int]{len: 3, items: []int{1,
+// 2, 3}}.
+func goFormatItems[T any](c any, n int, seq iter.Seq[T]) string {
+	items, more := headItems(seq)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s{len: %d, items: []%s{", goTypeName(c), n, reflect.TypeFor[T]())
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%#v", item)
+	}
+	if more {
+		b.WriteString(", ...")
+	}
+	b.WriteString("}}")
+	return b.String()
+}
+
+// formatEntries is formatItems for key-value collections, in the style of %v for maps, e.g.
+// RWMutexMap(len=2)map[a:1 b:2].
+func formatEntries[K, V any](c any, n int, seq iter.Seq2[K, V]) string {
+	entries, more := headEntries(seq)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s(len=%d)map[", typeName(c), n)
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%v:%v", e.key, e.value)
+	}
+	if more {
+		b.WriteString(" ...")
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// goFormatEntries is goFormatItems for key-value collections, e.g.
+// &threadsafe.RWMutexMap[string,int]{len: 2, items: map[string]int{"a":1, "b":2}}.
+func goFormatEntries[K, V any](c any, n int, seq iter.Seq2[K, V]) string {
+	entries, more := headEntries(seq)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s{len: %d, items: map[%s]%s{",
+		goTypeName(c), n, reflect.TypeFor[K](), reflect.TypeFor[V]())
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%#v:%#v", e.key, e.value)
+	}
+	if more {
+		b.WriteString(", ...")
+	}
+	b.WriteString("}}")
+	return b.String()
+}
+
+// formatLen formats c for String as its type name and length, for collections whose items
+// cannot be inspected without removing them, e.g. DelayQueue(len=3).
+func formatLen(c any, n int) string {
+	return fmt.Sprintf("%s(len=%d)", typeName(c), n)
+}
+
+// goFormatLen is formatLen for GoString, e.g. &threadsafe.DelayQueue[int]{len: 3}.
+func goFormatLen(c any, n int) string {
+	return fmt.Sprintf("%s{len: %d}", goTypeName(c), n)
+}
+
+type formatEntry[K, V any] struct {
+	key   K
+	value V
+}
+
+// headItems returns up to stringMaxItems items of seq, and whether seq has more.
+func headItems[T any](seq iter.Seq[T]) (items []T, more bool) {
+	for item := range seq {
+		if len(items) == stringMaxItems {
+			return items, true
+		}
+		items = append(items, item)
+	}
+	return items, false
+}
+
+// headEntries returns up to stringMaxItems entries of seq, and whether seq has more.
+func headEntries[K, V any](seq iter.Seq2[K, V]) (entries []formatEntry[K, V], more bool) {
+	for k, v := range seq {
+		if len(entries) == stringMaxItems {
+			return entries, true
+		}
+		entries = append(entries, formatEntry[K, V]{k, v})
+	}
+	return entries, false
+}
+
+// typeName returns the name of the type of c without package or type arguments.
+func typeName(c any) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", c), "*")
+	name = name[strings.IndexByte(name, '.')+1:]
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// goTypeName returns the Go syntax of the type of c, as a composite literal address for
+// pointers.
+func goTypeName(c any) string {
+	name := fmt.Sprintf("%T", c)
+	if rest, ok := strings.CutPrefix(name, "*"); ok {
+		return "&" + rest
+	}
+	return name
+}
@@ -0,0 +1,66 @@
+package threadsafe
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectionString(t *testing.T) {
+	q := NewRWMutexQueue[int]()
+	assert.Equal(t, "RWMutexQueue(len=0)[]", q.String())
+	q.Push(1, 2, 3)
+	assert.Equal(t, "RWMutexQueue(len=3)[1 2 3]", fmt.Sprint(q))
+	assert.Equal(t, "&threadsafe.RWMutexQueue[int]{len: 3, items: []int{1, 2, 3}}",
+		fmt.Sprintf("%#v", q))
+
+	m := NewRWMutexMap[time.Duration, string](nil)
+	m.Set(time.Second, "a")
+	assert.Equal(t, "RWMutexMap(len=1)map[1s:a]", m.String())
+	assert.Equal(t,
+		`&threadsafe.RWMutexMap[time.Duration,string]{len: 1, `+
+			`items: map[time.Duration]string{1000000000:"a"}}`,
+		m.GoString())
+
+	d := NewDelayQueue[string]()
+	d.PushAfter("x", time.Hour)
+	assert.Equal(t, "DelayQueue(len=1)", d.String())
+	assert.Equal(t, "&threadsafe.DelayQueue[string]{len: 1}", d.GoString())
+}
+
+func TestCollectionStringBounded(t *testing.T) {
+	s := NewMutexSlice[int](0)
+	for i := range stringMaxItems {
+		s.Append(i)
+	}
+	assert.Equal(t, "MutexSlice(len=16)[0 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15]", s.String())
+
+	s.Append(16, 17)
+	assert.Equal(t, "MutexSlice(len=18)[0 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 ...]", s.String())
+	assert.Contains(t, s.GoString(), "{len: 18, items: []int{0, 1, ")
+	assert.Contains(t, s.GoString(), "14, 15, ...}}")
+
+	k := NewTopK[string](100)
+	for i := range 20 {
+		k.Add(fmt.Sprint(i), int64(i+1))
+	}
+	assert.Contains(t, k.String(), "TopK(len=20)[{19 20 0} {18 19 0} ")
+	assert.Contains(t, k.String(), " ...]")
+}
+
+func TestCollectionStringWhileRanging(t *testing.T) {
+	// String must not deadlock when called from a callback of the collection itself.
+	set := NewRWMutexSet[string]()
+	set.Add("a")
+	set.Range(func(string) bool {
+		assert.Equal(t, "RWMutexSet(len=1)[a]", set.String())
+		return true
+	})
+	h := NewMinHeap[int]()
+	h.Push(2, 1)
+	for range h.All() {
+		assert.Equal(t, "RWMutexHeap(len=2)[1 2]", h.String())
+	}
+}
@@ -0,0 +1,234 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Graph is a thread-safe directed graph with nodes of type N, protected by a sync.RWMutex, for
+// dependency graphs such as task DAGs or topology maps that are modified concurrently. Edges are
+// tracked in both directions, so removing a node and looking up its predecessors are cheap.
+//
+// Traversals (Walk, TopologicalSort) compute their result from one consistent state of the graph
+// under the read lock, then call back into user code after releasing it, so callbacks may modify
+// the graph freely. Nodes and neighbors are returned in no particular order.
+//
+// The zero value is ready to use.
+type Graph[N comparable] struct {
+	mu    sync.RWMutex
+	out   map[N]map[N]struct{}
+	in    map[N]map[N]struct{}
+	edges int
+}
+
+// AddNode adds node n to the graph, and reports whether it was added, i.e. was not already
+// present.
+func (g *Graph[N]) AddNode(n N) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.addNodeLocked(n)
+}
+
+// RemoveNode removes node n and all its edges, and reports whether it was present.
+func (g *Graph[N]) RemoveNode(n N) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	succs, ok := g.out[n]
+	if !ok {
+		return false
+	}
+	g.edges -= len(succs) + len(g.in[n])
+	if _, loop := succs[n]; loop {
+		g.edges++ // a self-loop is both outgoing and incoming
+	}
+	for to := range succs {
+		delete(g.in[to], n)
+	}
+	for from := range g.in[n] {
+		delete(g.out[from], n)
+	}
+	delete(g.out, n)
+	delete(g.in, n)
+	return true
+}
+
+// HasNode reports whether node n is in the graph.
+func (g *Graph[N]) HasNode(n N) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.out[n]
+	return ok
+}
+
+// AddEdge adds a directed edge from one node to another, adding the nodes if needed, and reports
+// whether the edge was added, i.e. was not already present.
+func (g *Graph[N]) AddEdge(from, to N) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.addNodeLocked(from)
+	g.addNodeLocked(to)
+	if _, ok := g.out[from][to]; ok {
+		return false
+	}
+	g.out[from][to] = struct{}{}
+	g.in[to][from] = struct{}{}
+	g.edges++
+	return true
+}
+
+// RemoveEdge removes the directed edge from one node to another, and reports whether it was
+// present. The nodes themselves are kept.
+func (g *Graph[N]) RemoveEdge(from, to N) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.out[from][to]; !ok {
+		return false
+	}
+	delete(g.out[from], to)
+	delete(g.in[to], from)
+	g.edges--
+	return true
+}
+
+// HasEdge reports whether the graph has a directed edge from one node to another.
+func (g *Graph[N]) HasEdge(from, to N) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.out[from][to]
+	return ok
+}
+
+// Neighbors returns a copy of the successors of node n, i.e. the targets of its outgoing edges.
+func (g *Graph[N]) Neighbors(n N) []N {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return slices.Collect(maps.Keys(g.out[n]))
+}
+
+// Predecessors returns a copy of the predecessors of node n, i.e. the sources of its incoming
+// edges.
+func (g *Graph[N]) Predecessors(n N) []N {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return slices.Collect(maps.Keys(g.in[n]))
+}
+
+// Len returns the number of nodes.
+func (g *Graph[N]) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.out)
+}
+
+// EdgeCount returns the number of edges.
+func (g *Graph[N]) EdgeCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.edges
+}
+
+// Nodes returns an iterator over a snapshot of the nodes.
+func (g *Graph[N]) Nodes() iter.Seq[N] {
+	return func(yield func(N) bool) {
+		g.mu.RLock()
+		snapshot := slices.Collect(maps.Keys(g.out))
+		g.mu.RUnlock()
+
+		for _, n := range snapshot {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the number of nodes and the first nodes of the graph, for logging and debugging.
+func (g *Graph[N]) String() string {
+	return formatItems(g, g.Len(), g.Nodes())
+}
+
+// GoString returns the Go type, number of nodes and first nodes of the graph, for the %#v verb.
+func (g *Graph[N]) GoString() string {
+	return goFormatItems(g, g.Len(), g.Nodes())
+}
+
+// Walk traverses the graph breadth-first from start, calling f with each reachable node and its
+// distance from start, until f returns false. The traversal runs over a consistent snapshot of
+// the graph; f is called after the lock is released. Nothing is visited if start is not in the
+// graph.
+func (g *Graph[N]) Walk(start N, f func(n N, depth int) bool) {
+	type visit struct {
+		node  N
+		depth int
+	}
+	g.mu.RLock()
+	var order []visit
+	if _, ok := g.out[start]; ok {
+		seen := map[N]struct{}{start: {}}
+		order = append(order, visit{start, 0})
+		for i := 0; i < len(order); i++ {
+			for next := range g.out[order[i].node] {
+				if _, ok := seen[next]; !ok {
+					seen[next] = struct{}{}
+					order = append(order, visit{next, order[i].depth + 1})
+				}
+			}
+		}
+	}
+	g.mu.RUnlock()
+
+	for _, v := range order {
+		if !f(v.node, v.depth) {
+			return
+		}
+	}
+}
+
+// TopologicalSort returns the nodes ordered so that every edge goes from an earlier node to a
+// later one, computed from a consistent snapshot of the graph. If the graph has a cycle, it
+// returns ok == false and a nil slice.
+func (g *Graph[N]) TopologicalSort() (sorted []N, ok bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	indegree := make(map[N]int, len(g.out))
+	sorted = make([]N, 0, len(g.out))
+	for n := range g.out {
+		indegree[n] = len(g.in[n])
+		if indegree[n] == 0 {
+			sorted = append(sorted, n)
+		}
+	}
+	for i := 0; i < len(sorted); i++ {
+		for next := range g.out[sorted[i]] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				sorted = append(sorted, next)
+			}
+		}
+	}
+	if len(sorted) < len(g.out) {
+		return nil, false
+	}
+	return sorted, true
+}
+
+// Internal helpers
+
+// addNodeLocked adds node n if absent, and reports whether it was added. Callers must hold the
+// write lock.
+func (g *Graph[N]) addNodeLocked(n N) bool {
+	if g.out == nil {
+		g.out = make(map[N]map[N]struct{})
+		g.in = make(map[N]map[N]struct{})
+	}
+	if _, ok := g.out[n]; ok {
+		return false
+	}
+	g.out[n] = make(map[N]struct{})
+	g.in[n] = make(map[N]struct{})
+	return true
+}
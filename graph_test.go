@@ -0,0 +1,112 @@
+package threadsafe
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph(t *testing.T) {
+	var g Graph[string]
+	assert.True(t, g.AddNode("a"))
+	assert.False(t, g.AddNode("a"))
+	assert.True(t, g.AddEdge("a", "b"))
+	assert.False(t, g.AddEdge("a", "b"))
+	assert.True(t, g.AddEdge("a", "c"))
+	assert.True(t, g.AddEdge("c", "c"))
+	assert.True(t, g.AddEdge("b", "c"))
+	assert.Equal(t, 3, g.Len())
+	assert.Equal(t, 4, g.EdgeCount())
+
+	assert.True(t, g.HasEdge("a", "b"))
+	assert.False(t, g.HasEdge("b", "a"))
+	assert.ElementsMatch(t, []string{"b", "c"}, g.Neighbors("a"))
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, g.Predecessors("c"))
+	assert.Empty(t, g.Neighbors("missing"))
+
+	assert.True(t, g.RemoveEdge("a", "b"))
+	assert.False(t, g.RemoveEdge("a", "b"))
+	assert.True(t, g.HasNode("b"))
+	assert.Equal(t, 3, g.EdgeCount())
+
+	assert.True(t, g.RemoveNode("c"))
+	assert.False(t, g.RemoveNode("c"))
+	assert.Equal(t, 0, g.EdgeCount())
+	assert.Empty(t, g.Neighbors("a"))
+	assert.ElementsMatch(t, []string{"a", "b"}, slices.Collect(g.Nodes()))
+}
+
+func TestGraphWalk(t *testing.T) {
+	var g Graph[int]
+	g.AddEdge(1, 2)
+	g.AddEdge(1, 3)
+	g.AddEdge(2, 4)
+	g.AddEdge(3, 4)
+	g.AddEdge(4, 1)
+	g.AddNode(5)
+
+	depths := map[int]int{}
+	g.Walk(1, func(n, depth int) bool {
+		depths[n] = depth
+		g.AddEdge(n, 6) // callbacks may modify the graph
+		return true
+	})
+	assert.Equal(t, map[int]int{1: 0, 2: 1, 3: 1, 4: 2}, depths)
+
+	visited := 0
+	g.Walk(1, func(int, int) bool {
+		visited++
+		return false
+	})
+	assert.Equal(t, 1, visited)
+
+	g.Walk(7, func(int, int) bool {
+		t.Error("Walk visited a node from a missing start")
+		return true
+	})
+}
+
+func TestGraphTopologicalSort(t *testing.T) {
+	var g Graph[string]
+	g.AddEdge("compile", "link")
+	g.AddEdge("fetch", "compile")
+	g.AddEdge("link", "test")
+	g.AddEdge("compile", "test")
+	g.AddNode("lint")
+
+	sorted, ok := g.TopologicalSort()
+	assert.True(t, ok)
+	assert.Len(t, sorted, 5)
+	pos := map[string]int{}
+	for i, n := range sorted {
+		pos[n] = i
+	}
+	assert.Less(t, pos["fetch"], pos["compile"])
+	assert.Less(t, pos["compile"], pos["link"])
+	assert.Less(t, pos["link"], pos["test"])
+
+	g.AddEdge("test", "fetch")
+	sorted, ok = g.TopologicalSort()
+	assert.False(t, ok)
+	assert.Nil(t, sorted)
+}
+
+func TestGraphConcurrent(t *testing.T) {
+	var g Graph[int]
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Go(func() {
+			for i := range 100 {
+				g.AddEdge(w*100+i, w*100+i+1)
+				g.Neighbors(w * 100)
+				g.Walk(w*100, func(int, int) bool { return true })
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 800, g.EdgeCount())
+	_, ok := g.TopologicalSort()
+	assert.True(t, ok)
+}
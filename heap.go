@@ -1,7 +1,10 @@
 // Package threadsafe implements thread-safe operations.
 package threadsafe
 
-import "iter"
+import (
+	"iter"
+	"slices"
+)
 
 // Heap is a generic binary heap interface for any type T.
 // Ordering is defined by the implementation, typically via a provided less function.
@@ -18,6 +21,10 @@ type Heap[T any] interface {
 	// If the heap is empty, it returns ok == false and the zero value of T.
 	Peek() (item T, ok bool)
 
+	// PopIf atomically removes and returns the top-priority item only if pred accepts it.
+	// If the heap is empty or pred returns false, it returns ok == false and the zero value of T.
+	PopIf(pred func(top T) bool) (item T, ok bool)
+
 	// Len returns the current number of items stored in the heap.
 	Len() int
 
@@ -29,6 +36,14 @@ type Heap[T any] interface {
 	// new items are added concurrently.
 	Slice() []T
 
+	// SliceSorted returns a copy of the current heap contents in priority order, as Pop would
+	// return them, without modifying the heap.
+	SliceSorted() []T
+
+	// AllSorted returns an iterator over a snapshot of the heap contents in priority order, as
+	// Pop would return them, without modifying the heap.
+	AllSorted() iter.Seq[T]
+
 	// Range calls f sequentially for each item present in the heap in internal
 	// heap order. If f returns false, Range stops the iteration early.
 	Range(f func(item T) bool)
@@ -43,3 +58,37 @@ type Heap[T any] interface {
 	//	}
 	All() iter.Seq[T]
 }
+
+// HeapAsPriorityQueue returns h as a PriorityQueue. The Heap method set is a superset of the
+// PriorityQueue one, so no wrapping is needed; the function exists to make the conversion explicit
+// at call sites.
+func HeapAsPriorityQueue[T any](h Heap[T]) PriorityQueue[T] {
+	return h
+}
+
+// PriorityQueueAsHeap adapts pq to the Heap interface, so code written against Heap can accept a
+// priority queue. If pq already implements Heap it is returned as is; otherwise Slice is derived
+// from a snapshot of All.
+func PriorityQueueAsHeap[T any](pq PriorityQueue[T]) Heap[T] {
+	if h, ok := pq.(Heap[T]); ok {
+		return h
+	}
+	return priorityQueueHeap[T]{pq}
+}
+
+// priorityQueueHeap adds the Heap-only methods to a PriorityQueue.
+type priorityQueueHeap[T any] struct {
+	PriorityQueue[T]
+}
+
+// Slice returns a copy of the queue contents in internal heap order.
+func (h priorityQueueHeap[T]) Slice() []T {
+	return slices.Collect(h.All())
+}
+
+// heapifyBatch reports whether pushing batch items onto a binary heap of n items is cheaper as a
+// bottom-up rebuild, which costs O(n+batch), than as one sift-up per item, which costs
+// O(batch log(n+batch)). Periodic bulk loads hit the rebuild path.
+func heapifyBatch(n, batch int) bool {
+	return batch > 1 && batch >= n
+}
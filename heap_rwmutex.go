@@ -2,9 +2,11 @@
 package threadsafe
 
 import (
+	"cmp"
+	"encoding/json"
+	"io"
 	"iter"
 	"slices"
-	"sync"
 )
 
 // RWMutexHeap is a thread-safe binary heap implementation protected by a sync.RWMutex.
@@ -14,7 +16,7 @@ import (
 //
 // The zero value is not ready to use; use NewRWMutexHeap to construct with a comparator.
 type RWMutexHeap[T any] struct {
-	mu   sync.RWMutex
+	mu   observedRWMutex
 	data []T
 	less func(a, b T) bool
 }
@@ -27,15 +29,34 @@ func NewRWMutexHeap[T any](less func(a, b T) bool) *RWMutexHeap[T] {
 	}
 }
 
-// Push adds one or more items to the heap.
+// NewMinHeap creates a new RWMutexHeap that pops the smallest item first, per cmp.Less.
+func NewMinHeap[T cmp.Ordered]() *RWMutexHeap[T] {
+	return NewRWMutexHeap(cmp.Less[T])
+}
+
+// NewMaxHeap creates a new RWMutexHeap that pops the largest item first, per cmp.Less.
+func NewMaxHeap[T cmp.Ordered]() *RWMutexHeap[T] {
+	return NewRWMutexHeap(func(a, b T) bool { return cmp.Less(b, a) })
+}
+
+// Push adds one or more items to the heap. Batches at least as large as the heap are added by
+// rebuilding the heap bottom-up rather than sifting up each item.
 func (h *RWMutexHeap[T]) Push(items ...T) {
+	defer h.mu.observe("Push")()
 	if len(items) == 0 {
 		return
 	}
 	h.mu.Lock()
-	for _, x := range items {
-		h.data = append(h.data, x)
-		h.up(len(h.data) - 1)
+	n := len(h.data)
+	h.data = append(h.data, items...)
+	if heapifyBatch(n, len(items)) {
+		for i := len(h.data)/2 - 1; i >= 0; i-- {
+			h.down(i)
+		}
+	} else {
+		for i := n; i < len(h.data); i++ {
+			h.up(i)
+		}
 	}
 	h.mu.Unlock()
 }
@@ -43,25 +64,31 @@ func (h *RWMutexHeap[T]) Push(items ...T) {
 // Pop removes and returns the top-priority item.
 // If the heap is empty it returns ok == false and the zero value of T.
 func (h *RWMutexHeap[T]) Pop() (item T, ok bool) {
+	defer h.mu.observe("Pop")()
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	n := len(h.data)
-	if n == 0 {
+	if len(h.data) == 0 {
 		return item, false
 	}
-	// Swap first and last, pop last, then down from root.
-	item = h.data[0]
-	last := h.data[n-1]
-	h.data = h.data[:n-1]
-	if n-1 > 0 {
-		h.data[0] = last
-		h.down(0)
+	return h.popLocked(), true
+}
+
+// PopIf atomically removes and returns the top-priority item only if pred accepts it, e.g. when
+// its deadline has passed. pred is called under the write lock and must not call back into the
+// heap. If the heap is empty or pred returns false, it returns ok == false.
+func (h *RWMutexHeap[T]) PopIf(pred func(top T) bool) (item T, ok bool) {
+	defer h.mu.observe("PopIf")()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.data) == 0 || !pred(h.data[0]) {
+		return item, false
 	}
-	return item, true
+	return h.popLocked(), true
 }
 
 // Peek returns the top-priority item without removing it.
 func (h *RWMutexHeap[T]) Peek() (item T, ok bool) {
+	defer h.mu.observe("Peek")()
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 	if len(h.data) == 0 {
@@ -72,14 +99,22 @@ func (h *RWMutexHeap[T]) Peek() (item T, ok bool) {
 
 // Len returns the current number of items.
 func (h *RWMutexHeap[T]) Len() int {
+	defer h.mu.observe("Len")()
 	h.mu.RLock()
 	l := len(h.data)
 	h.mu.RUnlock()
 	return l
 }
 
+// Instrument makes the heap report its operations and lock waits to ins, or stop reporting if ins
+// is nil. It must be called before the heap is shared between goroutines.
+func (h *RWMutexHeap[T]) Instrument(ins Instrumentation) {
+	h.mu.ins = ins
+}
+
 // Clear removes all items from the heap.
 func (h *RWMutexHeap[T]) Clear() {
+	defer h.mu.observe("Clear")()
 	h.mu.Lock()
 	h.data = nil
 	h.mu.Unlock()
@@ -90,6 +125,37 @@ func (h *RWMutexHeap[T]) Slice() []T {
 	return slices.Collect(h.All())
 }
 
+// SliceSorted returns a copy of the heap contents in priority order, without modifying the heap.
+func (h *RWMutexHeap[T]) SliceSorted() []T {
+	defer h.mu.observe("SliceSorted")()
+	h.mu.RLock()
+	sorted := make([]T, len(h.data))
+	copy(sorted, h.data)
+	h.mu.RUnlock()
+	slices.SortFunc(sorted, func(a, b T) int {
+		switch {
+		case h.less(a, b):
+			return -1
+		case h.less(b, a):
+			return 1
+		}
+		return 0
+	})
+	return sorted
+}
+
+// AllSorted returns an iterator over a snapshot of the heap contents in priority order, without
+// modifying the heap.
+func (h *RWMutexHeap[T]) AllSorted() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		h.mu.RLock()
+		snapshot := make([]T, len(h.data))
+		copy(snapshot, h.data)
+		h.mu.RUnlock()
+		yieldSorted(snapshot, h.less, yield)
+	}
+}
+
 // Range calls f sequentially for each item in internal heap order. This action does not modify
 // the heap or its items.
 func (h *RWMutexHeap[T]) Range(f func(item T) bool) {
@@ -121,6 +187,190 @@ func (h *RWMutexHeap[T]) All() iter.Seq[T] {
 	}
 }
 
+// String returns the length and the first items of the heap, for logging and debugging.
+func (h *RWMutexHeap[T]) String() string {
+	return formatItems(h, h.Len(), h.All())
+}
+
+// GoString returns the Go type, length and first items of the heap, for the %#v verb.
+func (h *RWMutexHeap[T]) GoString() string {
+	return goFormatItems(h, h.Len(), h.All())
+}
+
+// Clone returns a new heap with the same comparator and a copy of each item made by copyFn, under
+// a single lock so the clone is a consistent snapshot. copyFn must not call back into the heap;
+// if nil, items are copied shallowly.
+func (h *RWMutexHeap[T]) Clone(copyFn func(T) T) *RWMutexHeap[T] {
+	defer h.mu.observe("Clone")()
+	h.mu.RLock()
+	items := cloneSlice(h.data, copyFn)
+	h.mu.RUnlock()
+
+	clone := NewRWMutexHeap(h.less)
+	clone.replaceLocked(items)
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the heap, for capacity planning
+// and memory accounting: the heap itself and its backing array, spare capacity included, plus
+// sizeFn(item) per item if sizeFn is not nil, e.g. to count string data. sizeFn must not call
+// back into the heap.
+func (h *RWMutexHeap[T]) EstimateBytes(sizeFn func(item T) int) int {
+	defer h.mu.observe("EstimateBytes")()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return sizeOf[RWMutexHeap[T]]() + sliceBytes(h.data, cap(h.data), sizeFn)
+}
+
+// Reserve grows the heap's storage, if necessary, to fit n more items without reallocating, so
+// that seeding it with a large known volume avoids repeated slice growth under the write lock.
+func (h *RWMutexHeap[T]) Reserve(n int) {
+	defer h.mu.observe("Reserve")()
+	if n <= 0 {
+		return
+	}
+	h.mu.Lock()
+	h.data = slices.Grow(h.data, n)
+	h.mu.Unlock()
+}
+
+// Find returns the first item in internal heap order for which pred returns true, together with
+// its index for use with Fix, RemoveAt and UpdateAt. If no item matches, it returns ok == false and
+// index -1. pred runs under the read lock and must not call back into the heap. The index is only
+// valid until the heap is next modified.
+func (h *RWMutexHeap[T]) Find(pred func(item T) bool) (item T, index int, ok bool) {
+	defer h.mu.observe("Find")()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for i, x := range h.data {
+		if pred(x) {
+			return x, i, true
+		}
+	}
+	return item, -1, false
+}
+
+// Fix re-establishes heap ordering after the item at index i may have changed. Safe no-op if i is
+// out of range.
+func (h *RWMutexHeap[T]) Fix(i int) {
+	defer h.mu.observe("Fix")()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < 0 || i >= len(h.data) {
+		return
+	}
+	if !h.down(i) {
+		h.up(i)
+	}
+}
+
+// RemoveAt removes and returns the item at index i in internal heap order.
+// If i is out of range, it returns ok == false and the zero value of T.
+func (h *RWMutexHeap[T]) RemoveAt(i int) (item T, ok bool) {
+	defer h.mu.observe("RemoveAt")()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := len(h.data)
+	if i < 0 || i >= n {
+		return item, false
+	}
+	item = h.data[i]
+	h.data[i] = h.data[n-1]
+	var zero T
+	h.data[n-1] = zero
+	h.data = h.data[:n-1]
+	if i < n-1 {
+		if !h.down(i) {
+			h.up(i)
+		}
+	}
+	return item, true
+}
+
+// UpdateAt replaces the item at index i with x and restores heap ordering. If i is out of range,
+// it is a no-op and returns false.
+func (h *RWMutexHeap[T]) UpdateAt(i int, x T) bool {
+	defer h.mu.observe("UpdateAt")()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < 0 || i >= len(h.data) {
+		return false
+	}
+	h.data[i] = x
+	if !h.down(i) {
+		h.up(i)
+	}
+	return true
+}
+
+// SaveTo writes a snapshot of the heap contents to w using codec, so that pending work can be
+// persisted, e.g. across restarts, and restored with LoadFrom.
+func (h *RWMutexHeap[T]) SaveTo(w io.Writer, codec Codec[T]) error {
+	defer h.mu.observe("SaveTo")()
+	h.mu.RLock()
+	snapshot := make([]T, len(h.data))
+	copy(snapshot, h.data)
+	h.mu.RUnlock()
+	return writeSnapshot(w, codec, snapshot)
+}
+
+// LoadFrom replaces the heap contents with a snapshot written by SaveTo, rebuilding the heap in
+// O(n) with the current comparator. On error the heap is left unchanged.
+func (h *RWMutexHeap[T]) LoadFrom(r io.Reader, codec Codec[T]) error {
+	defer h.mu.observe("LoadFrom")()
+	items, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.replaceLocked(items)
+	h.mu.Unlock()
+	return nil
+}
+
+// MarshalJSON encodes the heap contents as a JSON array in priority order.
+func (h *RWMutexHeap[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.SliceSorted())
+}
+
+// UnmarshalJSON replaces the heap contents with the items of a JSON array, rebuilding the heap in
+// O(n) with the current comparator. On error the heap is left unchanged.
+func (h *RWMutexHeap[T]) UnmarshalJSON(data []byte) error {
+	defer h.mu.observe("UnmarshalJSON")()
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.replaceLocked(items)
+	h.mu.Unlock()
+	return nil
+}
+
+// replaceLocked replaces the heap contents with items and heapifies them.
+func (h *RWMutexHeap[T]) replaceLocked(items []T) {
+	h.data = items
+	for i := len(h.data)/2 - 1; i >= 0; i-- {
+		h.down(i)
+	}
+}
+
+// popLocked removes and returns the root item; the heap must not be empty.
+func (h *RWMutexHeap[T]) popLocked() T {
+	// Swap first and last, pop last, then down from root.
+	n := len(h.data)
+	item := h.data[0]
+	last := h.data[n-1]
+	var zero T
+	h.data[n-1] = zero
+	h.data = h.data[:n-1]
+	if n-1 > 0 {
+		h.data[0] = last
+		h.down(0)
+	}
+	return item
+}
+
 // up restores the heap property by sifting up the element at index i.
 func (h *RWMutexHeap[T]) up(i int) {
 	idx := i
@@ -134,10 +384,12 @@ func (h *RWMutexHeap[T]) up(i int) {
 	}
 }
 
-// down restores the heap property by sifting down the element at index i.
-func (h *RWMutexHeap[T]) down(i int) {
+// down restores the heap property by sifting down the element at index i; returns true if it
+// moved.
+func (h *RWMutexHeap[T]) down(i int) bool {
 	idx := i
 	n := len(h.data)
+	moved := false
 	for {
 		left := 2*idx + 1
 		if left >= n {
@@ -153,7 +405,9 @@ func (h *RWMutexHeap[T]) down(i int) {
 		}
 		h.data[idx], h.data[smallest] = h.data[smallest], h.data[idx]
 		idx = smallest
+		moved = true
 	}
+	return moved
 }
 
 // Ensure RWMutexHeap implements Heap.
@@ -1,6 +1,8 @@
 package threadsafe
 
 import (
+	"bytes"
+	"encoding/json"
 	"math/rand"
 	"slices"
 	"sync"
@@ -107,6 +109,55 @@ func (s *heapTestSuite[T]) TestSliceAndRange(t *testing.T) {
 	assert.Equal(t, 1, count)
 }
 
+// TestSliceSorted verifies that SliceSorted returns items in pop order without modifying the heap.
+func (s *heapTestSuite[T]) TestSliceSorted(t *testing.T) {
+	h := s.newHeap()
+	assert.Empty(t, h.SliceSorted())
+
+	h.Push(s.item3, s.item1, s.item2)
+	sorted := h.SliceSorted()
+	assert.Len(t, sorted, 3)
+	assert.Equal(t, 3, h.Len())
+	for _, want := range sorted {
+		got, ok := h.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, want, got)
+	}
+}
+
+// TestAllSorted verifies that AllSorted yields items in pop order and supports early stop.
+func (s *heapTestSuite[T]) TestAllSorted(t *testing.T) {
+	h := s.newHeap()
+	h.Push(s.item2, s.item3, s.item1)
+	assert.Equal(t, h.SliceSorted(), collectSeq(h.AllSorted()))
+
+	var calls int
+	h.AllSorted()(func(_ T) bool {
+		calls++
+		return false
+	})
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 3, h.Len())
+}
+
+// TestPopIf verifies that PopIf only pops the top item when the predicate accepts it.
+func (s *heapTestSuite[T]) TestPopIf(t *testing.T) {
+	h := s.newHeap()
+	_, ok := h.PopIf(func(T) bool { return true })
+	assert.False(t, ok)
+
+	h.Push(s.item1, s.item2, s.item3)
+	top, _ := h.Peek()
+	_, ok = h.PopIf(func(T) bool { return false })
+	assert.False(t, ok)
+	assert.Equal(t, 3, h.Len())
+
+	got, ok := h.PopIf(func(x T) bool { return !s.less(top, x) })
+	assert.True(t, ok)
+	assert.Equal(t, top, got)
+	assert.Equal(t, 2, h.Len())
+}
+
 func (s *heapTestSuite[T]) TestAllIterator(t *testing.T) {
 	h := s.newHeap()
 	h.Push(s.item1, s.item2, s.item3)
@@ -137,6 +188,9 @@ func (s *heapTestSuite[T]) TestAllIterator(t *testing.T) {
 func runHeapTestSuite[T any](t *testing.T, s *heapTestSuite[T]) {
 	t.Run("BasicOperations", s.TestBasicOperations)
 	t.Run("SliceAndRange", s.TestSliceAndRange)
+	t.Run("SliceSorted", s.TestSliceSorted)
+	t.Run("AllSorted", s.TestAllSorted)
+	t.Run("PopIf", s.TestPopIf)
 	t.Run("AllIterator", s.TestAllIterator)
 }
 
@@ -186,6 +240,36 @@ func TestHeapImplementations(t *testing.T) {
 		}
 		runHeapTestSuite(t, suite)
 	})
+
+	// priority queue adapted to the Heap interface
+	t.Run("int_priority_queue_adapter", func(t *testing.T) {
+		less := func(a, b int) bool { return a < b }
+		makeHeap := func() Heap[int] { return PriorityQueueAsHeap(NewCorePriorityQueue(less)) }
+		suite := &heapTestSuite[int]{
+			newHeap: makeHeap,
+			less:    less,
+			item1:   1,
+			item2:   2,
+			item3:   3,
+		}
+		runHeapTestSuite(t, suite)
+	})
+}
+
+func TestHeapPriorityQueueAdapters(t *testing.T) {
+	h := NewMinHeap[int]()
+	pq := HeapAsPriorityQueue[int](h)
+	pq.Push(2, 1)
+	top, _ := h.Peek()
+	assert.Equal(t, 1, top)
+
+	// A heap round-trips without wrapping
+	assert.Same(t, h, PriorityQueueAsHeap(pq).(*RWMutexHeap[int]))
+
+	adapted := PriorityQueueAsHeap[int](NewMaxPriorityQueue[int]())
+	adapted.Push(1, 3, 2)
+	assert.ElementsMatch(t, []int{1, 2, 3}, adapted.Slice())
+	assert.Equal(t, []int{3, 2, 1}, adapted.SliceSorted())
 }
 
 // TestHeapPopOrder verifies that popping all elements yields a sorted sequence (by less).
@@ -253,3 +337,112 @@ func TestHeapConcurrentPush(t *testing.T) {
 
 	assert.Equal(t, 0, h.Len())
 }
+
+func TestOrderedHeapConstructors(t *testing.T) {
+	minH := NewMinHeap[int]()
+	minH.Push(3, 1, 2)
+	assert.Equal(t, []int{1, 2, 3}, minH.SliceSorted())
+
+	maxH := NewMaxHeap[string]()
+	maxH.Push("b", "c", "a")
+	assert.Equal(t, []string{"c", "b", "a"}, maxH.SliceSorted())
+}
+
+func TestRWMutexHeapIndexedOperations(t *testing.T) {
+	h := NewMinHeap[int]()
+	h.Push(5, 3, 8, 1, 9)
+
+	_, idx, ok := h.Find(func(x int) bool { return x == 8 })
+	assert.True(t, ok)
+	assert.True(t, h.UpdateAt(idx, 0))
+	top, _ := h.Peek()
+	assert.Equal(t, 0, top)
+
+	_, idx, _ = h.Find(func(x int) bool { return x == 3 })
+	removed, ok := h.RemoveAt(idx)
+	assert.True(t, ok)
+	assert.Equal(t, 3, removed)
+	assert.Equal(t, []int{0, 1, 5, 9}, h.SliceSorted())
+
+	_, ok = h.RemoveAt(10)
+	assert.False(t, ok)
+	assert.False(t, h.UpdateAt(-1, 1))
+	_, idx, ok = h.Find(func(x int) bool { return x == 42 })
+	assert.False(t, ok)
+	assert.Equal(t, -1, idx)
+	h.Fix(10)
+
+	// Randomized removals keep the heap ordered
+	rnd := rand.New(rand.NewSource(7))
+	h.Clear()
+	for range 200 {
+		h.Push(rnd.Intn(1000))
+	}
+	for range 50 {
+		_, _ = h.RemoveAt(rnd.Intn(h.Len()))
+	}
+	var out []int
+	for h.Len() > 0 {
+		v, _ := h.Pop()
+		out = append(out, v)
+	}
+	assert.Len(t, out, 150)
+	assert.True(t, slices.IsSorted(out))
+}
+
+func TestHeapBulkPush(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	h := NewMinHeap[int]()
+	// Small batches sift up, large batches rebuild the heap
+	for _, size := range []int{1, 3, 10, 100, 5, 1000} {
+		batch := make([]int, size)
+		for i := range batch {
+			batch[i] = rnd.Intn(10000)
+		}
+		h.Push(batch...)
+	}
+	assert.Equal(t, 1119, h.Len())
+	var out []int
+	for h.Len() > 0 {
+		v, _ := h.Pop()
+		out = append(out, v)
+	}
+	assert.True(t, slices.IsSorted(out))
+}
+
+func TestRWMutexHeapSaveLoad(t *testing.T) {
+	src := NewMinHeap[string]()
+	src.Push("c", "a", "b")
+	var buf bytes.Buffer
+	assert.NoError(t, src.SaveTo(&buf, GobCodec[string]{}))
+
+	dst := NewMinHeap[string]()
+	assert.NoError(t, dst.LoadFrom(&buf, GobCodec[string]{}))
+	assert.Equal(t, []string{"a", "b", "c"}, dst.SliceSorted())
+
+	assert.Error(t, dst.LoadFrom(bytes.NewReader(nil), GobCodec[string]{}))
+	assert.Equal(t, 3, dst.Len())
+}
+
+func TestRWMutexHeapJSON(t *testing.T) {
+	h := NewMaxHeap[int]()
+	h.Push(2, 5, 1)
+	data, err := json.Marshal(h)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[5,2,1]`, string(data))
+
+	dst := NewMinHeap[int]()
+	assert.NoError(t, json.Unmarshal(data, dst))
+	assert.Equal(t, []int{1, 2, 5}, dst.SliceSorted())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"x":1}`), dst))
+	assert.Equal(t, 3, dst.Len())
+}
+
+func TestRWMutexHeapPopReleasesItems(t *testing.T) {
+	h := NewRWMutexHeap(func(a, b *int) bool { return *a < *b })
+	one, two := 1, 2
+	h.Push(&one, &two)
+	h.Pop()
+	assert.Nil(t, h.data[:2][1], "the vacated slot is zeroed")
+}
@@ -0,0 +1,110 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sync"
+	"time"
+)
+
+// Instrumentation receives latency and contention measurements from a collection, so shared
+// structures can be observed uniformly, e.g. by exporting them as metrics. Its methods are called
+// synchronously from the collection's operations and must be safe for concurrent use; they
+// should return quickly.
+type Instrumentation interface {
+	// OnOp is called after each operation with its name, such as "Get" or "Push", and its
+	// duration, including any time spent waiting for the lock.
+	OnOp(op string, dur time.Duration)
+	// OnLockWait is called after each lock acquisition with the time spent waiting for the lock.
+	OnLockWait(dur time.Duration)
+}
+
+// Instrumented is implemented by the collections that report to an Instrumentation: MutexMap,
+// RWMutexMap, RWMutexSet, MutexSlice, RWMutexSlice, RWMutexQueue and RWMutexHeap.
+type Instrumented interface {
+	// Instrument makes the collection report to ins, or stop reporting if ins is nil. It must be
+	// called before the collection is shared between goroutines.
+	Instrument(ins Instrumentation)
+}
+
+// Ensure the instrumented collections implement Instrumented.
+var (
+	_ Instrumented = (*MutexMap[int, any])(nil)
+	_ Instrumented = (*RWMutexMap[int, any])(nil)
+	_ Instrumented = (*RWMutexSet[int])(nil)
+	_ Instrumented = (*MutexSlice[any])(nil)
+	_ Instrumented = (*RWMutexSlice[any])(nil)
+	_ Instrumented = (*RWMutexQueue[any])(nil)
+	_ Instrumented = (*RWMutexHeap[any])(nil)
+)
+
+// Internal helpers
+
+// noopObservation is returned by observe when no Instrumentation is set.
+func noopObservation() {}
+
+// observedMutex is a sync.Mutex reporting lock waits and operations to an Instrumentation, if
+// set. The zero value is an unlocked mutex without instrumentation.
+type observedMutex struct {
+	sync.Mutex
+	ins Instrumentation
+}
+
+// Lock locks m, reporting the wait.
+func (m *observedMutex) Lock() {
+	if m.ins == nil {
+		m.Mutex.Lock()
+		return
+	}
+	start := time.Now()
+	m.Mutex.Lock()
+	m.ins.OnLockWait(time.Since(start))
+}
+
+// observe starts timing operation op, and returns the function reporting it once done.
+func (m *observedMutex) observe(op string) func() {
+	return observe(m.ins, op)
+}
+
+// observedRWMutex is a sync.RWMutex reporting lock waits and operations to an Instrumentation, if
+// set. The zero value is an unlocked mutex without instrumentation.
+type observedRWMutex struct {
+	sync.RWMutex
+	ins Instrumentation
+}
+
+// Lock locks m for writing, reporting the wait.
+func (m *observedRWMutex) Lock() {
+	if m.ins == nil {
+		m.RWMutex.Lock()
+		return
+	}
+	start := time.Now()
+	m.RWMutex.Lock()
+	m.ins.OnLockWait(time.Since(start))
+}
+
+// RLock locks m for reading, reporting the wait.
+func (m *observedRWMutex) RLock() {
+	if m.ins == nil {
+		m.RWMutex.RLock()
+		return
+	}
+	start := time.Now()
+	m.RWMutex.RLock()
+	m.ins.OnLockWait(time.Since(start))
+}
+
+// observe starts timing operation op, and returns the function reporting it once done.
+func (m *observedRWMutex) observe(op string) func() {
+	return observe(m.ins, op)
+}
+
+// observe starts timing operation op for ins, and returns the function reporting it once done,
+// typically deferred.
+func observe(ins Instrumentation, op string) func() {
+	if ins == nil {
+		return noopObservation
+	}
+	start := time.Now()
+	return func() { ins.OnOp(op, time.Since(start)) }
+}
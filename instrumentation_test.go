@@ -0,0 +1,77 @@
+package threadsafe
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingInstrumentation counts the operations and lock waits reported to it.
+type recordingInstrumentation struct {
+	mu        sync.Mutex
+	ops       map[string]int
+	lockWaits int
+}
+
+func (r *recordingInstrumentation) OnOp(op string, _ time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ops == nil {
+		r.ops = make(map[string]int)
+	}
+	r.ops[op]++
+}
+
+func (r *recordingInstrumentation) OnLockWait(time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lockWaits++
+}
+
+func TestInstrumentation(t *testing.T) {
+	var rec recordingInstrumentation
+	m := NewRWMutexMap[string, int](nil)
+	m.Instrument(&rec)
+	m.Set("a", 1)
+	m.Get("a")
+	m.Get("b")
+	assert.Len(t, slices.Collect(m.Keys()), 1)
+	assert.Equal(t, map[string]int{"Set": 1, "Get": 2}, rec.ops)
+	assert.Equal(t, 4, rec.lockWaits) // Keys is not an operation, but takes the lock
+
+	m.Instrument(nil)
+	m.Set("b", 2)
+	assert.Equal(t, 1, rec.ops["Set"])
+	assert.Equal(t, 4, rec.lockWaits)
+}
+
+func TestInstrumentationCollections(t *testing.T) {
+	var rec recordingInstrumentation
+	var q RWMutexQueue[int]
+	var s MutexSlice[int]
+	set := NewRWMutexSet[int]()
+	h := NewMinHeap[int]()
+	for _, c := range []Instrumented{&q, &s, set, h} {
+		c.Instrument(&rec)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			q.Push(1)
+			q.Pop()
+			s.Append(1)
+			set.Add(1)
+			h.Push(1)
+			h.Pop()
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, map[string]int{
+		"Push": 8, "Pop": 8, "Append": 4, "Add": 4,
+	}, rec.ops)
+	assert.Equal(t, 24, rec.lockWaits)
+}
@@ -0,0 +1,224 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"cmp"
+	"iter"
+	"math/rand/v2"
+	"sync"
+)
+
+// Interval is a closed interval [Lo, Hi] stored in an IntervalTree, with its value.
+type Interval[K cmp.Ordered, V any] struct {
+	Lo, Hi K
+	Value  V
+}
+
+// intervalNode is a node of the treap backing an IntervalTree, ordered by (lo, hi) and augmented
+// with the maximum hi of its subtree.
+type intervalNode[K cmp.Ordered, V any] struct {
+	Interval[K, V]
+	maxHi       K
+	prio        uint64
+	left, right *intervalNode[K, V]
+}
+
+// IntervalTree is a thread-safe set of closed intervals with values, protected by a
+// sync.RWMutex, answering which intervals contain a point or overlap a range, e.g. for
+// schedulers or IP-range lookups. Queries take the read lock, so they run in parallel.
+//
+// It is a treap (a randomized balanced search tree) ordered by the intervals' bounds and
+// augmented with the maximum upper bound of each subtree, so queries skip subtrees that cannot
+// overlap. Intervals with identical bounds may be stored more than once, and are returned in
+// insertion order.
+//
+// The zero value is ready to use.
+//
+// Complexity: Insert/Remove O(log n) expected, Query/QueryRange O(log n + m) expected for m
+// results.
+type IntervalTree[K cmp.Ordered, V any] struct {
+	mu   sync.RWMutex
+	root *intervalNode[K, V]
+	size int
+}
+
+// Insert adds the closed interval [lo, hi] with value. If lo > hi, the bounds are swapped.
+func (t *IntervalTree[K, V]) Insert(lo, hi K, value V) {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	n := &intervalNode[K, V]{
+		Interval: Interval[K, V]{Lo: lo, Hi: hi, Value: value},
+		maxHi:    hi,
+		prio:     rand.Uint64(),
+	}
+	t.mu.Lock()
+	l, r := splitInterval(t.root, lo, hi, true)
+	t.root = mergeInterval(mergeInterval(l, n), r)
+	t.size++
+	t.mu.Unlock()
+}
+
+// Remove removes all intervals with bounds exactly [lo, hi], and returns how many were removed.
+func (t *IntervalTree[K, V]) Remove(lo, hi K) int {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	l, rest := splitInterval(t.root, lo, hi, false)
+	matched, r := splitInterval(rest, lo, hi, true)
+	removed := 0
+	for range walkIntervals(matched) {
+		removed++
+	}
+	t.root = mergeInterval(l, r)
+	t.size -= removed
+	return removed
+}
+
+// Query returns the intervals containing point, ordered by their bounds.
+func (t *IntervalTree[K, V]) Query(point K) []Interval[K, V] {
+	return t.QueryRange(point, point)
+}
+
+// QueryRange returns the intervals overlapping the closed interval [lo, hi], ordered by their
+// bounds. If lo > hi, the bounds are swapped.
+func (t *IntervalTree[K, V]) QueryRange(lo, hi K) []Interval[K, V] {
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var found []Interval[K, V]
+	var query func(n *intervalNode[K, V])
+	query = func(n *intervalNode[K, V]) {
+		if n == nil || n.maxHi < lo {
+			return
+		}
+		query(n.left)
+		if n.Lo > hi {
+			return // neither this interval nor the ones to its right start in time
+		}
+		if n.Hi >= lo {
+			found = append(found, n.Interval)
+		}
+		query(n.right)
+	}
+	query(t.root)
+	return found
+}
+
+// Len returns the number of intervals.
+func (t *IntervalTree[K, V]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.size
+}
+
+// Clear removes all intervals.
+func (t *IntervalTree[K, V]) Clear() {
+	t.mu.Lock()
+	t.root = nil
+	t.size = 0
+	t.mu.Unlock()
+}
+
+// All returns an iterator over a snapshot of all intervals, ordered by their bounds.
+func (t *IntervalTree[K, V]) All() iter.Seq[Interval[K, V]] {
+	return func(yield func(Interval[K, V]) bool) {
+		t.mu.RLock()
+		snapshot := make([]Interval[K, V], 0, t.size)
+		for n := range walkIntervals(t.root) {
+			snapshot = append(snapshot, n.Interval)
+		}
+		t.mu.RUnlock()
+
+		for _, iv := range snapshot {
+			if !yield(iv) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the tree, for logging and debugging.
+func (t *IntervalTree[K, V]) String() string {
+	return formatItems(t, t.Len(), t.All())
+}
+
+// GoString returns the Go type, length and first items of the tree, for the %#v verb.
+func (t *IntervalTree[K, V]) GoString() string {
+	return goFormatItems(t, t.Len(), t.All())
+}
+
+// Internal helpers
+
+// compareInterval orders intervals by lower, then upper bound.
+func compareInterval[K cmp.Ordered, V any](n *intervalNode[K, V], lo, hi K) int {
+	if c := cmp.Compare(n.Lo, lo); c != 0 {
+		return c
+	}
+	return cmp.Compare(n.Hi, hi)
+}
+
+// splitInterval splits t into the nodes before (lo, hi) and the others. With inclusive, nodes
+// equal to (lo, hi) go to the first part instead.
+func splitInterval[K cmp.Ordered, V any](
+	t *intervalNode[K, V],
+	lo, hi K,
+	inclusive bool,
+) (left, right *intervalNode[K, V]) {
+	if t == nil {
+		return nil, nil
+	}
+	c := compareInterval(t, lo, hi)
+	if c < 0 || (inclusive && c == 0) {
+		t.right, right = splitInterval(t.right, lo, hi, inclusive)
+		t.update()
+		return t, right
+	}
+	left, t.left = splitInterval(t.left, lo, hi, inclusive)
+	t.update()
+	return left, t
+}
+
+// mergeInterval joins two treaps, where all nodes of a come before those of b.
+func mergeInterval[K cmp.Ordered, V any](a, b *intervalNode[K, V]) *intervalNode[K, V] {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.prio > b.prio:
+		a.right = mergeInterval(a.right, b)
+		a.update()
+		return a
+	default:
+		b.left = mergeInterval(a, b.left)
+		b.update()
+		return b
+	}
+}
+
+// walkIntervals returns an iterator over the nodes of t in order.
+func walkIntervals[K cmp.Ordered, V any](t *intervalNode[K, V]) iter.Seq[*intervalNode[K, V]] {
+	return func(yield func(*intervalNode[K, V]) bool) {
+		var walk func(n *intervalNode[K, V]) bool
+		walk = func(n *intervalNode[K, V]) bool {
+			return n == nil || (walk(n.left) && yield(n) && walk(n.right))
+		}
+		walk(t)
+	}
+}
+
+// update recomputes the subtree maximum of n from its children.
+func (n *intervalNode[K, V]) update() {
+	n.maxHi = n.Hi
+	if n.left != nil {
+		n.maxHi = max(n.maxHi, n.left.maxHi)
+	}
+	if n.right != nil {
+		n.maxHi = max(n.maxHi, n.right.maxHi)
+	}
+}
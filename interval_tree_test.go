@@ -0,0 +1,87 @@
+package threadsafe
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntervalTree(t *testing.T) {
+	var tree IntervalTree[int, string]
+	tree.Insert(10, 20, "a")
+	tree.Insert(15, 25, "b")
+	tree.Insert(30, 5, "c") // bounds swapped to [5, 30]
+	tree.Insert(40, 50, "d")
+	tree.Insert(10, 20, "a2")
+	assert.Equal(t, 5, tree.Len())
+
+	assert.Equal(t, []string{"c", "a", "a2", "b"}, intervalValues(tree.Query(18)))
+	assert.Equal(t, []string{"c"}, intervalValues(tree.Query(5)))
+	assert.Empty(t, tree.Query(35))
+	assert.Equal(t, []string{"c", "b", "d"}, intervalValues(tree.QueryRange(45, 21)))
+
+	assert.Equal(t, 2, tree.Remove(20, 10))
+	assert.Equal(t, 0, tree.Remove(10, 20))
+	assert.Equal(t, []string{"c", "b"}, intervalValues(tree.Query(18)))
+
+	var all []Interval[int, string]
+	for iv := range tree.All() {
+		all = append(all, iv)
+	}
+	assert.Equal(t, []Interval[int, string]{{5, 30, "c"}, {15, 25, "b"}, {40, 50, "d"}}, all)
+	tree.Clear()
+	assert.Equal(t, 0, tree.Len())
+}
+
+func TestIntervalTreeRandomized(t *testing.T) {
+	var tree IntervalTree[int, int]
+	var ref []Interval[int, int]
+	r := rand.New(rand.NewSource(1))
+	for i := range 500 {
+		lo := r.Intn(1000)
+		hi := lo + r.Intn(100)
+		tree.Insert(lo, hi, i)
+		ref = append(ref, Interval[int, int]{lo, hi, i})
+	}
+	for range 200 {
+		lo := r.Intn(1100)
+		hi := lo + r.Intn(50)
+		var want []int
+		for _, iv := range ref {
+			if iv.Lo <= hi && iv.Hi >= lo {
+				want = append(want, iv.Value)
+			}
+		}
+		got := make([]int, 0)
+		for _, iv := range tree.QueryRange(lo, hi) {
+			got = append(got, iv.Value)
+		}
+		assert.ElementsMatch(t, want, got)
+	}
+}
+
+func TestIntervalTreeConcurrent(t *testing.T) {
+	var tree IntervalTree[int, int]
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 100 {
+				tree.Insert(g*1000+i, g*1000+i+10, i)
+				tree.Query(g*1000 + i)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 800, tree.Len())
+	assert.Len(t, tree.Query(5005), 6)
+}
+
+func intervalValues(ivs []Interval[int, string]) []string {
+	values := make([]string, len(ivs))
+	for i, iv := range ivs {
+		values[i] = iv.Value
+	}
+	return values
+}
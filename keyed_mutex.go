@@ -0,0 +1,108 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"sync"
+)
+
+// keyedLock is the mutex of one key of a KeyedMutex, with the number of goroutines holding or
+// waiting for it. The key is held while sem holds a token.
+type keyedLock struct {
+	sem  chan struct{}
+	refs int
+}
+
+// KeyedMutex is a set of mutexes identified by key, serializing work per key (per user ID, per
+// file) while different keys proceed in parallel. A key's mutex only exists while it is held or
+// waited for, so the set does not grow with the number of keys ever locked.
+//
+// The zero value is ready to use. A KeyedMutex must not be copied after first use.
+type KeyedMutex[K comparable] struct {
+	mu    sync.Mutex
+	locks map[K]*keyedLock
+}
+
+// Lock locks key, blocking until it is available.
+func (m *KeyedMutex[K]) Lock(key K) {
+	m.mu.Lock()
+	l := m.acquireLocked(key)
+	m.mu.Unlock()
+	l.sem <- struct{}{}
+}
+
+// LockWait locks key, blocking until it is available or ctx is done, in which case the key is not
+// locked and ctx.Err() is returned.
+func (m *KeyedMutex[K]) LockWait(ctx context.Context, key K) error {
+	m.mu.Lock()
+	l := m.acquireLocked(key)
+	m.mu.Unlock()
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		m.releaseLocked(key, l)
+		m.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryLock tries to lock key without blocking and reports whether it succeeded.
+func (m *KeyedMutex[K]) TryLock(key K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l := m.acquireLocked(key)
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	default:
+		m.releaseLocked(key, l)
+		return false
+	}
+}
+
+// Unlock unlocks key. As with sync.Mutex, a locked key is not associated with a goroutine, and it
+// is a run-time error to unlock a key that is not locked.
+func (m *KeyedMutex[K]) Unlock(key K) {
+	m.mu.Lock()
+	l, ok := m.locks[key]
+	if !ok || len(l.sem) == 0 {
+		m.mu.Unlock()
+		panic("threadsafe: unlock of unlocked key")
+	}
+	m.releaseLocked(key, l)
+	m.mu.Unlock()
+	<-l.sem
+}
+
+// Len returns the number of keys currently held or waited for.
+func (m *KeyedMutex[K]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.locks)
+}
+
+// Internal helpers (callers must hold m.mu)
+
+// acquireLocked returns the lock of key, creating it if needed, and takes a reference to it.
+func (m *KeyedMutex[K]) acquireLocked(key K) *keyedLock {
+	if m.locks == nil {
+		m.locks = make(map[K]*keyedLock)
+	}
+	l, ok := m.locks[key]
+	if !ok {
+		l = &keyedLock{sem: make(chan struct{}, 1)}
+		m.locks[key] = l
+	}
+	l.refs++
+	return l
+}
+
+// releaseLocked drops a reference to the lock l of key, deleting it once unreferenced.
+func (m *KeyedMutex[K]) releaseLocked(key K, l *keyedLock) {
+	l.refs--
+	if l.refs == 0 {
+		delete(m.locks, key)
+	}
+}
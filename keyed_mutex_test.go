@@ -0,0 +1,73 @@
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var m KeyedMutex[string]
+	var a, b int
+	counters := map[string]*int{"a": &a, "b": &b} // only read concurrently
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		key := "a"
+		if i%2 == 1 {
+			key = "b"
+		}
+		wg.Go(func() {
+			m.Lock(key)
+			defer m.Unlock(key)
+			*counters[key]++ // the race detector flags this if the key lock fails
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 50, a)
+	assert.Equal(t, 50, b)
+
+	// Unused keys are cleaned up
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestKeyedMutexTryLock(t *testing.T) {
+	var m KeyedMutex[int]
+	assert.True(t, m.TryLock(1))
+	assert.False(t, m.TryLock(1))
+	assert.True(t, m.TryLock(2))
+	assert.Equal(t, 2, m.Len())
+
+	// A waiter keeps the key alive until it acquires and releases it
+	acquired := make(chan struct{})
+	go func() {
+		m.Lock(1)
+		close(acquired)
+		m.Unlock(1)
+	}()
+	m.Unlock(1)
+	<-acquired
+	m.Unlock(2)
+	assert.Eventually(t, func() bool { return m.Len() == 0 }, time.Second, time.Millisecond)
+
+	assert.Panics(t, func() { m.Unlock(3) })
+}
+
+func TestKeyedMutexLockWait(t *testing.T) {
+	var m KeyedMutex[string]
+	assert.NoError(t, m.LockWait(context.Background(), "a"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, m.LockWait(ctx, "a"), context.DeadlineExceeded)
+	assert.Equal(t, 1, m.Len()) // the canceled waiter released its reference
+
+	m.Unlock("a")
+	assert.Equal(t, 0, m.Len())
+	assert.NoError(t, m.LockWait(context.Background(), "a"))
+	m.Unlock("a")
+	assert.Panics(t, func() { m.Unlock("a") })
+}
@@ -0,0 +1,79 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// lazyResult is the outcome of a Lazy initialization.
+type lazyResult[T any] struct {
+	v   T
+	err error
+}
+
+// lazyAttempt is a call of init by Lazy.Get, shared with the callers waiting on it.
+type lazyAttempt[T any] struct {
+	result lazyResult[T]
+	done   bool // init returned, rather than panicked
+}
+
+// Lazy is a value initialized on first use, exactly once across goroutines. Unlike
+// sync.OnceValue, initialization may fail, and the value may be discarded with Reset to be
+// initialized again on the next Get.
+//
+// By default a failed initialization is not cached: the error is returned to the callers waiting
+// on it, and the next Get retries. Set CacheErrors to make failures final until Reset.
+//
+// The zero value is ready to use. A Lazy must not be copied after first use.
+type Lazy[T any] struct {
+	// CacheErrors makes Get keep returning the error of a failed initialization until Reset,
+	// instead of retrying. It must be set before the first Get.
+	CacheErrors bool
+
+	mu      sync.Mutex
+	result  atomic.Pointer[lazyResult[T]]
+	attempt atomic.Pointer[lazyAttempt[T]] // init call in progress, if any
+}
+
+// Get returns the value, calling init to produce it if the Lazy is not initialized yet. Concurrent
+// callers wait for a single init call and share its result. Once initialized, Get is a single
+// atomic load and init is not called.
+func (l *Lazy[T]) Get(init func() (T, error)) (T, error) {
+	if r := l.result.Load(); r != nil {
+		return r.v, r.err
+	}
+	// Remember the init call in progress, if any, to share its result even if it fails
+	inProgress := l.attempt.Load()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r := l.result.Load(); r != nil {
+		return r.v, r.err
+	}
+	if inProgress != nil && inProgress.done {
+		return inProgress.result.v, inProgress.result.err
+	}
+
+	a := &lazyAttempt[T]{}
+	l.attempt.Store(a)
+	defer l.attempt.Store(nil)
+	v, err := init()
+	a.result, a.done = lazyResult[T]{v: v, err: err}, true
+	if err == nil || l.CacheErrors {
+		l.result.Store(&a.result)
+	}
+	return v, err
+}
+
+// Initialized reports whether the Lazy holds a value, or a cached error.
+func (l *Lazy[T]) Initialized() bool {
+	return l.result.Load() != nil
+}
+
+// Reset discards the value, or cached error, so that the next Get initializes it again. It waits
+// for an initialization in progress to complete.
+func (l *Lazy[T]) Reset() {
+	l.mu.Lock()
+	l.result.Store(nil)
+	l.mu.Unlock()
+}
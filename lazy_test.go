@@ -0,0 +1,107 @@
+package threadsafe
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLazy(t *testing.T) {
+	var l Lazy[string]
+	var calls atomic.Int32
+	init := func() (string, error) {
+		calls.Add(1)
+		return "value", nil
+	}
+	assert.False(t, l.Initialized())
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			v, err := l.Get(init)
+			assert.NoError(t, err)
+			assert.Equal(t, "value", v)
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+	assert.True(t, l.Initialized())
+
+	l.Reset()
+	assert.False(t, l.Initialized())
+	_, _ = l.Get(init)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestLazyErrors(t *testing.T) {
+	errInit := errors.New("init failed")
+	fail := func() (int, error) { return 0, errInit }
+	ok := func() (int, error) { return 42, nil }
+
+	// Failures are retried by default
+	var retry Lazy[int]
+	_, err := retry.Get(fail)
+	assert.ErrorIs(t, err, errInit)
+	assert.False(t, retry.Initialized())
+	v, err := retry.Get(ok)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+
+	// Cached failures stick until Reset
+	cached := Lazy[int]{CacheErrors: true}
+	_, err = cached.Get(fail)
+	assert.ErrorIs(t, err, errInit)
+	_, err = cached.Get(ok)
+	assert.ErrorIs(t, err, errInit)
+	cached.Reset()
+	v, err = cached.Get(ok)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+
+	// A panicking init leaves the Lazy uninitialized
+	var panicky Lazy[int]
+	assert.Panics(t, func() { _, _ = panicky.Get(func() (int, error) { panic("boom") }) })
+	v, _ = panicky.Get(ok)
+	assert.Equal(t, 42, v)
+}
+
+func TestLazyConcurrentFailure(t *testing.T) {
+	var l Lazy[int]
+	var calls atomic.Int32
+	errInit := errors.New("init failed")
+	started, release := make(chan struct{}), make(chan struct{})
+	fail := func() (int, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return 0, errInit
+	}
+
+	// Callers arriving while init is in progress share its error instead of retrying
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		_, err := l.Get(fail)
+		assert.ErrorIs(t, err, errInit)
+	})
+	<-started
+	for range 20 {
+		wg.Go(func() {
+			_, err := l.Get(fail)
+			assert.ErrorIs(t, err, errInit)
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// The failure is not cached, so the next Get retries
+	_, _ = l.Get(fail)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.False(t, l.Initialized())
+}
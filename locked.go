@@ -0,0 +1,88 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "sync"
+
+// Locked guards a value of any type with a sync.Mutex, so ad-hoc shared state gets the same
+// treatment as the collections without a hand-rolled mutex and struct pair. The value is only
+// reachable through the accessors, which hold the lock for their duration.
+//
+// The zero value is ready to use and holds the zero value of T. A Locked must not be copied
+// after first use.
+type Locked[T any] struct {
+	mu sync.Mutex
+	v  T
+}
+
+// NewLocked creates a new Locked holding v.
+func NewLocked[T any](v T) *Locked[T] {
+	return &Locked[T]{v: v}
+}
+
+// With calls f with a pointer to the value while holding the lock. f may modify the value, but
+// must not retain the pointer or call back into the Locked.
+func (l *Locked[T]) With(f func(v *T)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f(&l.v)
+}
+
+// Get returns a copy of the value.
+func (l *Locked[T]) Get() T {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.v
+}
+
+// Set replaces the value.
+func (l *Locked[T]) Set(v T) {
+	l.mu.Lock()
+	l.v = v
+	l.mu.Unlock()
+}
+
+// RWLocked guards a value of any type with a sync.RWMutex, allowing concurrent readers through
+// RWith and Get. Prefer it over Locked for read-mostly state.
+//
+// The zero value is ready to use and holds the zero value of T. An RWLocked must not be copied
+// after first use.
+type RWLocked[T any] struct {
+	mu sync.RWMutex
+	v  T
+}
+
+// NewRWLocked creates a new RWLocked holding v.
+func NewRWLocked[T any](v T) *RWLocked[T] {
+	return &RWLocked[T]{v: v}
+}
+
+// With calls f with a pointer to the value while holding the write lock. f may modify the value,
+// but must not retain the pointer or call back into the RWLocked.
+func (l *RWLocked[T]) With(f func(v *T)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f(&l.v)
+}
+
+// RWith calls f with the value while holding the read lock. Since T is passed by value, changes
+// to it are not stored, but memory it references (maps, slices, pointers) is shared and must only
+// be read.
+func (l *RWLocked[T]) RWith(f func(v T)) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	f(l.v)
+}
+
+// Get returns a copy of the value.
+func (l *RWLocked[T]) Get() T {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.v
+}
+
+// Set replaces the value.
+func (l *RWLocked[T]) Set(v T) {
+	l.mu.Lock()
+	l.v = v
+	l.mu.Unlock()
+}
@@ -0,0 +1,55 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lockedTestState struct {
+	count int
+	names map[string]int
+}
+
+func TestLocked(t *testing.T) {
+	var zero Locked[int]
+	assert.Equal(t, 0, zero.Get())
+
+	l := NewLocked(lockedTestState{names: map[string]int{}})
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Go(func() {
+			l.With(func(s *lockedTestState) {
+				s.count++
+				s.names[string(rune('a'+i%4))]++
+			})
+		})
+	}
+	wg.Wait()
+	got := l.Get()
+	assert.Equal(t, 100, got.count)
+	assert.Equal(t, map[string]int{"a": 25, "b": 25, "c": 25, "d": 25}, got.names)
+
+	l.Set(lockedTestState{count: -1})
+	assert.Equal(t, -1, l.Get().count)
+}
+
+func TestRWLocked(t *testing.T) {
+	l := NewRWLocked([]int{1, 2})
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() { l.With(func(s *[]int) { *s = append(*s, 3) }) })
+		wg.Go(func() {
+			l.RWith(func(s []int) {
+				assert.Equal(t, []int{1, 2}, s[:2])
+			})
+		})
+	}
+	wg.Wait()
+	assert.Len(t, l.Get(), 52)
+
+	var zero RWLocked[string]
+	zero.Set("x")
+	assert.Equal(t, "x", zero.Get())
+}
@@ -4,6 +4,7 @@ package threadsafe
 import (
 	"iter"
 	"maps"
+	"sync"
 )
 
 // Map is a generic interface for stores with any type V.
@@ -29,6 +30,12 @@ type Map[K comparable, V any] interface {
 	LoadOrStore(key K, value V) (previous V, loaded bool)
 	// Swap swaps the value for a key and returns the previous value if any.
 	Swap(key K, value V) (previous V, loaded bool)
+	// GetOrCompute returns the existing value for the key if present. Otherwise, it calls compute,
+	// and stores and returns its result. compute runs at most once at a time per key: concurrent
+	// callers for the same key wait for it and share its result. The loaded result is false only
+	// for the caller whose computed value was stored. compute is called without holding the
+	// map's lock, but must not call GetOrCompute for the same key.
+	GetOrCompute(key K, compute func() V) (value V, loaded bool)
 
 	// GetAll returns all key-value pairs in the map.
 	GetAll() map[K]V
@@ -36,6 +43,15 @@ type Map[K comparable, V any] interface {
 	GetMany(keys []K) map[K]V
 	// SetMany sets multiple key-value pairs.
 	SetMany(entries map[K]V)
+	// DeleteMany removes the keys from the map, and returns how many of them were present. The
+	// maps backed by a lock remove them under a single acquisition.
+	DeleteMany(keys []K) (removed int)
+	// UpdateMany calls f for each of keys with its current value, if loaded, and stores the new
+	// value f returns if keep is true, or deletes the key otherwise. A key listed more than once
+	// is updated in turn, f seeing the result of the previous update. The maps backed by a lock
+	// apply all updates under a single acquisition, so other goroutines see none or all of them;
+	// f must not call back into the map.
+	UpdateMany(keys []K, f func(key K, value V, loaded bool) (newValue V, keep bool))
 
 	// Equals reports whether the logical content of this map and the other map is the same.
 	// Requires an equal function since V is not of type comparable.
@@ -120,3 +136,73 @@ func equals[K comparable, V any](
 	}
 	return true
 }
+
+// updateEntries implements UpdateMany on values, which must not be nil.
+func updateEntries[K comparable, V any](
+	values map[K]V,
+	keys []K,
+	f func(key K, value V, loaded bool) (V, bool),
+) {
+	for _, key := range keys {
+		value, loaded := values[key]
+		if newValue, keep := f(key, value, loaded); keep {
+			values[key] = newValue
+		} else if loaded {
+			delete(values, key)
+		}
+	}
+}
+
+// computeCall is a GetOrCompute call in progress, waited on by concurrent callers of one key.
+type computeCall struct {
+	done chan struct{}
+}
+
+// computeCalls tracks the GetOrCompute calls in progress of a map. The zero value is ready to use.
+type computeCalls[K comparable] struct {
+	mu    sync.Mutex
+	calls map[K]*computeCall
+}
+
+// getOrCompute implements GetOrCompute for m on top of Get and LoadOrStore, running compute
+// outside of the lock of m. Callers of a key arriving while another runs compute wait for it,
+// and then look the key up again; if compute panicked, one of them runs compute in turn.
+func getOrCompute[K comparable, V any](
+	m Map[K, V],
+	c *computeCalls[K],
+	key K,
+	compute func() V,
+) (V, bool) {
+	for {
+		if value, ok := m.Get(key); ok {
+			return value, true
+		}
+
+		c.mu.Lock()
+		// The call that computed the key may have finished since the lookup above
+		if value, ok := m.Get(key); ok {
+			c.mu.Unlock()
+			return value, true
+		}
+		call, inFlight := c.calls[key]
+		if !inFlight {
+			if c.calls == nil {
+				c.calls = make(map[K]*computeCall)
+			}
+			call = &computeCall{done: make(chan struct{})}
+			c.calls[key] = call
+		}
+		c.mu.Unlock()
+
+		if !inFlight {
+			defer func() {
+				c.mu.Lock()
+				delete(c.calls, key)
+				c.mu.Unlock()
+				close(call.done)
+			}()
+			return m.LoadOrStore(key, compute())
+		}
+		<-call.done
+	}
+}
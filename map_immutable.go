@@ -0,0 +1,548 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	hamtBits = 5               // hash bits consumed per trie level
+	hamtMask = 1<<hamtBits - 1 // mask of the hash bits of a level
+)
+
+// ImmutableMap is a thread-safe implementation of Map backed by a persistent hash array mapped
+// trie (HAMT). Every version of the map is immutable: readers load the current version with a
+// single atomic pointer load and never lock or wait, and writers build a new version that shares
+// all untouched nodes with the previous one, copying only the O(log n) nodes on the path to the
+// changed key.
+//
+// Writers are serialized by a mutex, and each write allocates, so ImmutableMap is best suited to
+// large, read-dominated maps, where it outperforms RWMutexMap as readers never contend on a lock.
+// Iterators and Snapshot are free of copies: they keep reading the version current when they
+// started, however long they take. SetMany publishes all its entries as a single version.
+//
+// The zero value is ready to use, comparing values with == in CompareAndSwap.
+type ImmutableMap[K comparable, V any] struct {
+	root  atomic.Pointer[hamtRoot[K, V]]
+	mu    sync.Mutex // serializes writers
+	equal func(V, V) bool
+
+	computing computeCalls[K] // GetOrCompute calls in progress
+}
+
+// NewImmutableMap creates a new, empty ImmutableMap. equalFn, if not nil, decides whether two
+// values are equal in CompareAndSwap.
+func NewImmutableMap[K comparable, V any](equalFn func(V, V) bool) *ImmutableMap[K, V] {
+	return &ImmutableMap[K, V]{equal: equalFn}
+}
+
+// Snapshot returns an independent copy of the map in O(1), sharing the current version. Writes to
+// either map do not affect the other.
+func (m *ImmutableMap[K, V]) Snapshot() *ImmutableMap[K, V] {
+	snapshot := &ImmutableMap[K, V]{equal: m.equal}
+	snapshot.root.Store(m.root.Load())
+	return snapshot
+}
+
+// Get retrieves the value for the given key.
+func (m *ImmutableMap[K, V]) Get(key K) (V, bool) {
+	return m.root.Load().get(key)
+}
+
+// Set stores a value for the given key.
+func (m *ImmutableMap[K, V]) Set(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.root.Store(m.writeRootLocked().with(key, value))
+}
+
+// Delete removes the key from the map.
+func (m *ImmutableMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Len returns the number of items in the map.
+func (m *ImmutableMap[K, V]) Len() int {
+	return m.root.Load().len()
+}
+
+// Clear removes all items from the map.
+func (m *ImmutableMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.root.Store(&hamtRoot[K, V]{seed: m.writeRootLocked().seed, node: &hamtNode[K, V]{}})
+}
+
+// CompareAndSwap sets the value of key to newValue if its current value equals oldValue, and
+// reports whether it did. Without an equalFn, values are compared with ==, which panics if V is
+// not comparable.
+func (m *ImmutableMap[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.writeRootLocked()
+	current, ok := r.get(key)
+	if !ok {
+		return false
+	}
+	if m.equal != nil && !m.equal(current, oldValue) ||
+		m.equal == nil && any(current) != any(oldValue) {
+		return false
+	}
+	m.root.Store(r.with(key, newValue))
+	return true
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+func (m *ImmutableMap[K, V]) Swap(key K, value V) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.writeRootLocked()
+	previous, loaded := r.get(key)
+	m.root.Store(r.with(key, value))
+	return previous, loaded
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns
+// the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *ImmutableMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	if existing, ok := m.Get(key); ok {
+		return existing, true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.writeRootLocked()
+	if existing, ok := r.get(key); ok {
+		return existing, true
+	}
+	m.root.Store(r.with(key, value))
+	return value, false
+}
+
+// GetOrCompute returns the value for the key, calling compute to store it if absent. A present
+// key is read without locking.
+func (m *ImmutableMap[K, V]) GetOrCompute(key K, compute func() V) (V, bool) {
+	return getOrCompute(m, &m.computing, key, compute)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+func (m *ImmutableMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, previous, loaded := m.writeRootLocked().without(key)
+	if loaded {
+		m.root.Store(r)
+	}
+	return previous, loaded
+}
+
+// GetAll returns all key-value pairs in the map.
+func (m *ImmutableMap[K, V]) GetAll() map[K]V {
+	r := m.root.Load()
+	all := make(map[K]V, r.len())
+	for key, value := range r.all() {
+		all[key] = value
+	}
+	return all
+}
+
+// GetMany retrieves select key-value pairs, all from the same version.
+func (m *ImmutableMap[K, V]) GetMany(keys []K) map[K]V {
+	r := m.root.Load()
+	result := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if value, ok := r.get(key); ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// SetMany sets multiple key-value pairs as a single version, so that readers observe either none
+// or all of them.
+func (m *ImmutableMap[K, V]) SetMany(entries map[K]V) {
+	if len(entries) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.writeRootLocked()
+	for key, value := range entries {
+		r = r.with(key, value)
+	}
+	m.root.Store(r)
+}
+
+// DeleteMany removes the keys from the map, publishing a single version, and returns how many of
+// them were present.
+func (m *ImmutableMap[K, V]) DeleteMany(keys []K) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.writeRootLocked()
+	removed := 0
+	for _, key := range keys {
+		var loaded bool
+		if r, _, loaded = r.without(key); loaded {
+			removed++
+		}
+	}
+	if removed > 0 {
+		m.root.Store(r)
+	}
+	return removed
+}
+
+// UpdateMany calls f for each of keys with its current value, if loaded, and stores the new value
+// f returns if keep is true, or deletes the key otherwise. The updates are published as a single
+// version. f must not call back into the map.
+func (m *ImmutableMap[K, V]) UpdateMany(keys []K, f func(key K, value V, loaded bool) (V, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.writeRootLocked()
+	for _, key := range keys {
+		value, loaded := r.get(key)
+		if newValue, keep := f(key, value, loaded); keep {
+			r = r.with(key, newValue)
+		} else if loaded {
+			r, _, _ = r.without(key)
+		}
+	}
+	m.root.Store(r)
+}
+
+// Equals reports whether the logical content of this map and the other map is the same.
+func (m *ImmutableMap[K, V]) Equals(other Map[K, V], equalFn func(a, b V) bool) bool {
+	return equals(m, other, equalFn)
+}
+
+// Range calls f sequentially for each key and value present in the map, as of the version current
+// when Range was called. If f returns false, range stops the iteration.
+func (m *ImmutableMap[K, V]) Range(f func(key K, value V) bool) {
+	m.root.Load().all()(f)
+}
+
+// All returns an iterator over key-value pairs in the map, as of the version current when the
+// iteration starts. The iteration order is not guaranteed to be consistent. Unlike the mutex
+// backed maps, it does not copy the map.
+func (m *ImmutableMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.root.Load().all()(yield)
+	}
+}
+
+// Keys returns an iterator over keys in the map, as of the version current when the iteration
+// starts. The iteration order is not guaranteed to be consistent.
+func (m *ImmutableMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range m.root.Load().all() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over values in the map, as of the version current when the
+// iteration starts. The iteration order is not guaranteed to be consistent.
+func (m *ImmutableMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, value := range m.root.Load().all() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first entries of the map, for logging and debugging.
+func (m *ImmutableMap[K, V]) String() string {
+	s := m.Snapshot()
+	return formatEntries(m, s.Len(), s.All())
+}
+
+// GoString returns the Go type, length and first entries of the map, for the %#v verb.
+func (m *ImmutableMap[K, V]) GoString() string {
+	s := m.Snapshot()
+	return goFormatEntries(m, s.Len(), s.All())
+}
+
+// AsReadOnly returns a read-only view of the map, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (m *ImmutableMap[K, V]) AsReadOnly() ReadOnlyMap[K, V] {
+	return readOnlyMap[K, V]{m}
+}
+
+// Ensure ImmutableMap implements Map.
+var _ Map[any, any] = (*ImmutableMap[any, any])(nil)
+
+// Internal helpers
+
+// writeRootLocked returns the current version, creating an empty one with a new hash seed for
+// the zero value. Callers must hold the writer lock.
+func (m *ImmutableMap[K, V]) writeRootLocked() *hamtRoot[K, V] {
+	r := m.root.Load()
+	if r == nil {
+		r = &hamtRoot[K, V]{seed: maphash.MakeSeed(), node: &hamtNode[K, V]{}}
+		m.root.Store(r)
+	}
+	return r
+}
+
+// hamtRoot is a version of an ImmutableMap. A nil *hamtRoot is the empty map of the zero value.
+type hamtRoot[K comparable, V any] struct {
+	seed maphash.Seed
+	node *hamtNode[K, V]
+	size int
+}
+
+func (r *hamtRoot[K, V]) len() int {
+	if r == nil {
+		return 0
+	}
+	return r.size
+}
+
+func (r *hamtRoot[K, V]) get(key K) (value V, ok bool) {
+	if r == nil {
+		return value, false
+	}
+	return r.node.get(maphash.Comparable(r.seed, key), 0, key)
+}
+
+// with returns a new version with key set to value.
+func (r *hamtRoot[K, V]) with(key K, value V) *hamtRoot[K, V] {
+	node, added := r.node.with(maphash.Comparable(r.seed, key), 0, key, value)
+	size := r.size
+	if added {
+		size++
+	}
+	return &hamtRoot[K, V]{seed: r.seed, node: node, size: size}
+}
+
+// without returns a new version without key, or r itself if key is absent.
+func (r *hamtRoot[K, V]) without(key K) (*hamtRoot[K, V], V, bool) {
+	node, previous, ok := r.node.without(maphash.Comparable(r.seed, key), 0, key)
+	if !ok {
+		return r, previous, false
+	}
+	if node == nil {
+		node = &hamtNode[K, V]{}
+	}
+	return &hamtRoot[K, V]{seed: r.seed, node: node, size: r.size - 1}, previous, true
+}
+
+// all returns an iterator over the entries of the version.
+func (r *hamtRoot[K, V]) all() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if r != nil {
+			r.node.each(yield)
+		}
+	}
+}
+
+// hamtNode is an immutable trie node. Its children are indexed by hamtBits bits of the key hashes,
+// and stored compactly: bit i of bitmap is set if there is a child for index i, and children holds
+// the present ones in index order.
+type hamtNode[K comparable, V any] struct {
+	bitmap   uint32
+	children []hamtChild[K, V]
+}
+
+// hamtChild is either a sub-node or a leaf.
+type hamtChild[K comparable, V any] struct {
+	node *hamtNode[K, V]
+	leaf *hamtLeaf[K, V]
+}
+
+// hamtLeaf holds the entries whose keys share a hash, usually a single one.
+type hamtLeaf[K comparable, V any] struct {
+	hash    uint64
+	entries []MapEntry[K, V]
+}
+
+// slot returns the bitmap bit for the index of hash at shift, and the position of its child.
+func (n *hamtNode[K, V]) slot(hash uint64, shift uint) (bit uint32, pos int) {
+	bit = 1 << ((hash >> shift) & hamtMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+func (n *hamtNode[K, V]) get(hash uint64, shift uint, key K) (value V, ok bool) {
+	for {
+		bit, pos := n.slot(hash, shift)
+		if n.bitmap&bit == 0 {
+			return value, false
+		}
+		child := n.children[pos]
+		if child.node != nil {
+			n, shift = child.node, shift+hamtBits
+			continue
+		}
+		if child.leaf.hash != hash {
+			return value, false
+		}
+		for _, e := range child.leaf.entries {
+			if e.Key == key {
+				return e.Value, true
+			}
+		}
+		return value, false
+	}
+}
+
+// with returns a copy of n with key set to value, and reports whether key was added.
+func (n *hamtNode[K, V]) with(hash uint64, shift uint, key K, value V) (*hamtNode[K, V], bool) {
+	bit, pos := n.slot(hash, shift)
+	if n.bitmap&bit == 0 {
+		leaf := &hamtLeaf[K, V]{hash: hash, entries: []MapEntry[K, V]{{Key: key, Value: value}}}
+		children := make([]hamtChild[K, V], len(n.children)+1)
+		copy(children, n.children[:pos])
+		children[pos] = hamtChild[K, V]{leaf: leaf}
+		copy(children[pos+1:], n.children[pos:])
+		return &hamtNode[K, V]{bitmap: n.bitmap | bit, children: children}, true
+	}
+
+	child := n.children[pos]
+	switch {
+	case child.node != nil:
+		node, added := child.node.with(hash, shift+hamtBits, key, value)
+		return n.replaced(pos, hamtChild[K, V]{node: node}), added
+	case child.leaf.hash == hash:
+		leaf, added := child.leaf.with(key, value)
+		return n.replaced(pos, hamtChild[K, V]{leaf: leaf}), added
+	default:
+		leaf := &hamtLeaf[K, V]{hash: hash, entries: []MapEntry[K, V]{{Key: key, Value: value}}}
+		node := newHamtPair(child.leaf, leaf, shift+hamtBits)
+		return n.replaced(pos, hamtChild[K, V]{node: node}), true
+	}
+}
+
+// without returns a copy of n without key, or n itself if key is absent. It returns a nil node if
+// n ends up empty.
+func (n *hamtNode[K, V]) without(hash uint64, shift uint, key K) (*hamtNode[K, V], V, bool) {
+	var previous V
+	bit, pos := n.slot(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, previous, false
+	}
+
+	var replacement hamtChild[K, V]
+	child := n.children[pos]
+	switch {
+	case child.node != nil:
+		node, old, ok := child.node.without(hash, shift+hamtBits, key)
+		if !ok {
+			return n, previous, false
+		}
+		previous = old
+		switch {
+		case node == nil:
+		case len(node.children) == 1 && node.children[0].leaf != nil:
+			// Pull a lone leaf up, so the trie stays as shallow as its hashes allow
+			replacement = node.children[0]
+		default:
+			replacement = hamtChild[K, V]{node: node}
+		}
+	case child.leaf.hash == hash:
+		leaf, old, ok := child.leaf.without(key)
+		if !ok {
+			return n, previous, false
+		}
+		previous = old
+		if leaf != nil {
+			replacement = hamtChild[K, V]{leaf: leaf}
+		}
+	default:
+		return n, previous, false
+	}
+
+	if replacement.node != nil || replacement.leaf != nil {
+		return n.replaced(pos, replacement), previous, true
+	}
+	if len(n.children) == 1 {
+		return nil, previous, true
+	}
+	children := make([]hamtChild[K, V], 0, len(n.children)-1)
+	children = append(children, n.children[:pos]...)
+	children = append(children, n.children[pos+1:]...)
+	return &hamtNode[K, V]{bitmap: n.bitmap &^ bit, children: children}, previous, true
+}
+
+// replaced returns a copy of n with the child at pos replaced.
+func (n *hamtNode[K, V]) replaced(pos int, child hamtChild[K, V]) *hamtNode[K, V] {
+	children := make([]hamtChild[K, V], len(n.children))
+	copy(children, n.children)
+	children[pos] = child
+	return &hamtNode[K, V]{bitmap: n.bitmap, children: children}
+}
+
+// each calls yield for each entry under n, and reports whether the iteration should continue.
+func (n *hamtNode[K, V]) each(yield func(K, V) bool) bool {
+	for _, child := range n.children {
+		if child.node != nil {
+			if !child.node.each(yield) {
+				return false
+			}
+			continue
+		}
+		for _, e := range child.leaf.entries {
+			if !yield(e.Key, e.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// newHamtPair returns a node holding two leaves of different hashes, nested as deep as needed
+// for their hashes to differ at shift.
+func newHamtPair[K comparable, V any](a, b *hamtLeaf[K, V], shift uint) *hamtNode[K, V] {
+	ia, ib := (a.hash>>shift)&hamtMask, (b.hash>>shift)&hamtMask
+	if ia == ib {
+		return &hamtNode[K, V]{
+			bitmap:   1 << ia,
+			children: []hamtChild[K, V]{{node: newHamtPair(a, b, shift+hamtBits)}},
+		}
+	}
+	if ia > ib {
+		a, b, ia, ib = b, a, ib, ia
+	}
+	return &hamtNode[K, V]{
+		bitmap:   1<<ia | 1<<ib,
+		children: []hamtChild[K, V]{{leaf: a}, {leaf: b}},
+	}
+}
+
+// with returns a copy of l with key set to value, and reports whether key was added.
+func (l *hamtLeaf[K, V]) with(key K, value V) (*hamtLeaf[K, V], bool) {
+	entries := make([]MapEntry[K, V], len(l.entries), len(l.entries)+1)
+	copy(entries, l.entries)
+	for i := range entries {
+		if entries[i].Key == key {
+			entries[i].Value = value
+			return &hamtLeaf[K, V]{hash: l.hash, entries: entries}, false
+		}
+	}
+	entries = append(entries, MapEntry[K, V]{Key: key, Value: value})
+	return &hamtLeaf[K, V]{hash: l.hash, entries: entries}, true
+}
+
+// without returns a copy of l without key, or nil if l ends up empty.
+func (l *hamtLeaf[K, V]) without(key K) (*hamtLeaf[K, V], V, bool) {
+	for i, e := range l.entries {
+		if e.Key != key {
+			continue
+		}
+		if len(l.entries) == 1 {
+			return nil, e.Value, true
+		}
+		entries := make([]MapEntry[K, V], 0, len(l.entries)-1)
+		entries = append(entries, l.entries[:i]...)
+		entries = append(entries, l.entries[i+1:]...)
+		return &hamtLeaf[K, V]{hash: l.hash, entries: entries}, e.Value, true
+	}
+	var zero V
+	return l, zero, false
+}
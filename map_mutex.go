@@ -2,21 +2,27 @@
 package threadsafe
 
 import (
+	"io"
 	"iter"
 	"maps"
 	"sync"
 )
 
 // MutexMap is a thread-safe implementation of Map using sync.Mutex.
+//
+// The zero value is ready to use, comparing values with == in CompareAndSwap.
 type MutexMap[K comparable, V any] struct {
-	mu     sync.Mutex
+	mu     observedMutex
 	values map[K]V
 
 	equal func(V, V) bool
+
+	computing computeCalls[K] // GetOrCompute calls in progress
 }
 
 // Get retrieves the value for the given key.
 func (m *MutexMap[K, V]) Get(key K) (V, bool) {
+	defer m.mu.observe("Get")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -26,6 +32,7 @@ func (m *MutexMap[K, V]) Get(key K) (V, bool) {
 
 // Set stores a value for the given key.
 func (m *MutexMap[K, V]) Set(key K, value V) {
+	defer m.mu.observe("Set")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -37,6 +44,7 @@ func (m *MutexMap[K, V]) Set(key K, value V) {
 
 // Delete removes the key from the map.
 func (m *MutexMap[K, V]) Delete(key K) {
+	defer m.mu.observe("Delete")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -48,23 +56,43 @@ func (m *MutexMap[K, V]) Delete(key K) {
 
 // Len returns the number of items in the map.
 func (m *MutexMap[K, V]) Len() int {
+	defer m.mu.observe("Len")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	return len(m.values)
 }
 
+// Instrument makes the map report its operations and lock waits to ins, or stop reporting if ins
+// is nil. It must be called before the map is shared between goroutines.
+func (m *MutexMap[K, V]) Instrument(ins Instrumentation) {
+	m.mu.ins = ins
+}
+
+// InTx returns the view of the map inside tx, which must be a transaction run by RunTx with the
+// map among its participants.
+func (m *MutexMap[K, V]) InTx(tx *Tx) MapTx[K, V] {
+	tx.checkLocks(&m.mu)
+	return MapTx[K, V]{tx: tx, values: &m.values}
+}
+
+func (m *MutexMap[K, V]) txLocker() sync.Locker {
+	return &m.mu
+}
+
 // Clear removes all items from the map.
 func (m *MutexMap[K, V]) Clear() {
+	defer m.mu.observe("Clear")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.values = make(map[K]V)
 }
 
-// CompareAndSwap executes the compare-and-swap operation for a key.
-// The MutexMap must have been initialized with an equal function, lest this function panics.
+// CompareAndSwap executes the compare-and-swap operation for a key. Values are compared with the
+// equal function of the map, or with == if it has none, which panics if V is not comparable.
 func (m *MutexMap[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
+	defer m.mu.observe("CompareAndSwap")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -86,11 +114,17 @@ func (m *MutexMap[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
 		return false
 	}
 
-	panic("called CompareAndSwap without equal function")
+	// Fall back on ==, which will panic if V is not comparable, as sync.Map.CompareAndSwap does
+	if any(current) != any(oldValue) {
+		return false
+	}
+	m.values[key] = newValue
+	return true
 }
 
 // Swap swaps the value for a key and returns the previous value if any.
 func (m *MutexMap[K, V]) Swap(key K, value V) (V, bool) {
+	defer m.mu.observe("Swap")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -110,6 +144,7 @@ func (m *MutexMap[K, V]) Swap(key K, value V) (V, bool) {
 // LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns
 // the given value. The loaded result is true if the value was loaded, false if stored.
 func (m *MutexMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	defer m.mu.observe("LoadOrStore")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -124,8 +159,14 @@ func (m *MutexMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
 	return value, false
 }
 
+// GetOrCompute returns the value for the key, calling compute to store it if absent.
+func (m *MutexMap[K, V]) GetOrCompute(key K, compute func() V) (V, bool) {
+	return getOrCompute(m, &m.computing, key, compute)
+}
+
 // LoadAndDelete deletes the value for a key, returning the previous value if any.
 func (m *MutexMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	defer m.mu.observe("LoadAndDelete")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -140,6 +181,7 @@ func (m *MutexMap[K, V]) LoadAndDelete(key K) (V, bool) {
 
 // GetAll returns a copy of all key-value pairs in the map.
 func (m *MutexMap[K, V]) GetAll() map[K]V {
+	defer m.mu.observe("GetAll")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -150,6 +192,7 @@ func (m *MutexMap[K, V]) GetAll() map[K]V {
 
 // GetMany retrieves multiple keys at once.
 func (m *MutexMap[K, V]) GetMany(keys []K) map[K]V {
+	defer m.mu.observe("GetMany")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -165,6 +208,7 @@ func (m *MutexMap[K, V]) GetMany(keys []K) map[K]V {
 
 // SetMany sets multiple key-value pairs at once.
 func (m *MutexMap[K, V]) SetMany(entries map[K]V) {
+	defer m.mu.observe("SetMany")()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -174,6 +218,37 @@ func (m *MutexMap[K, V]) SetMany(entries map[K]V) {
 	maps.Insert(m.values, maps.All(entries))
 }
 
+// DeleteMany removes the keys from the map under a single lock acquisition, and returns how many
+// of them were present.
+func (m *MutexMap[K, V]) DeleteMany(keys []K) int {
+	defer m.mu.observe("DeleteMany")()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for _, key := range keys {
+		if _, ok := m.values[key]; ok {
+			delete(m.values, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// UpdateMany calls f for each of keys with its current value, if loaded, and stores the new value
+// f returns if keep is true, or deletes the key otherwise, all under a single lock acquisition.
+// f must not call back into the map.
+func (m *MutexMap[K, V]) UpdateMany(keys []K, f func(key K, value V, loaded bool) (V, bool)) {
+	defer m.mu.observe("UpdateMany")()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.values == nil {
+		m.values = make(map[K]V)
+	}
+	updateEntries(m.values, keys, f)
+}
+
 // Equals reports whether the logical content of this map and the other map is the same. Requires
 // equalFn to be provided to decide how two values of type V are compared.
 func (m *MutexMap[K, V]) Equals(other Map[K, V], equalFn func(a, b V) bool) bool {
@@ -209,6 +284,43 @@ func (m *MutexMap[K, V]) All() iter.Seq2[K, V] {
 	}
 }
 
+// String returns the length and the first entries of the map, for logging and debugging.
+func (m *MutexMap[K, V]) String() string {
+	return formatEntries(m, m.Len(), m.All())
+}
+
+// GoString returns the Go type, length and first entries of the map, for the %#v verb.
+func (m *MutexMap[K, V]) GoString() string {
+	return goFormatEntries(m, m.Len(), m.All())
+}
+
+// AsReadOnly returns a read-only view of the map, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (m *MutexMap[K, V]) AsReadOnly() ReadOnlyMap[K, V] {
+	return readOnlyMap[K, V]{m}
+}
+
+// Clone returns a new map with the same equal function and a copy of each value made by copyFn,
+// under a single lock so the clone is a consistent snapshot. copyFn must not call back into the
+// map; if nil, values are copied shallowly. Keys are copied as is.
+func (m *MutexMap[K, V]) Clone(copyFn func(V) V) *MutexMap[K, V] {
+	defer m.mu.observe("Clone")()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &MutexMap[K, V]{values: cloneMap(m.values, copyFn), equal: m.equal}
+}
+
+// EstimateBytes returns an approximation of the memory held by the map, for capacity planning
+// and memory accounting: the map itself and its entries, plus sizeFn(key, value) per entry if
+// sizeFn is not nil, e.g. to count the data of string or slice keys and values. sizeFn must not
+// call back into the map.
+func (m *MutexMap[K, V]) EstimateBytes(sizeFn func(key K, value V) int) int {
+	defer m.mu.observe("EstimateBytes")()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sizeOf[MutexMap[K, V]]() + mapBytes(m.values, sizeFn)
+}
+
 // Keys returns an iterator over keys in the map. The iteration order is not guaranteed to be
 // consistent. Note: since this snapshots before iteration, Range is more performant.
 func (m *MutexMap[K, V]) Keys() iter.Seq[K] {
@@ -254,7 +366,32 @@ func MutexMapFromMap[K comparable, V any](m map[K]V, equalFn func(V, V) bool) *M
 	return newMap
 }
 
-// NewMutexMap creates a new instance of MutexMap.
+// SaveTo writes a snapshot of the map contents to w as MapEntry items encoded with codec.
+func (m *MutexMap[K, V]) SaveTo(w io.Writer, codec Codec[MapEntry[K, V]]) error {
+	defer m.mu.observe("SaveTo")()
+	m.mu.Lock()
+	entries := mapEntries(m.values)
+	m.mu.Unlock()
+	return writeSnapshot(w, codec, entries)
+}
+
+// LoadFrom replaces the map contents with a snapshot written by SaveTo. On error the map is left
+// unchanged.
+func (m *MutexMap[K, V]) LoadFrom(r io.Reader, codec Codec[MapEntry[K, V]]) error {
+	defer m.mu.observe("LoadFrom")()
+	entries, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	values := entriesMap(entries)
+	m.mu.Lock()
+	m.values = values
+	m.mu.Unlock()
+	return nil
+}
+
+// NewMutexMap creates a new instance of MutexMap. The equalFn parameter decides how two values of
+// type V are compared in CompareAndSwap, but can be nil if V is comparable.
 func NewMutexMap[K comparable, V any](equalFn func(V, V) bool) *MutexMap[K, V] {
 	return &MutexMap[K, V]{
 		equal:  equalFn,
@@ -0,0 +1,359 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"sync"
+)
+
+// OrderedMap is a thread-safe implementation of Map that remembers the order in which keys were
+// inserted: Range, All, Keys and Values visit the entries oldest first, and so does String.
+// Setting the value of a key already in the map keeps its position, while deleting a key and
+// setting it again moves it to the back. SetMany inserts its new keys in an unspecified order, as
+// its entries come from a Go map; call Set for each entry to control it.
+//
+// Entries are kept in a hash index plus a doubly linked list, so every single-key operation is
+// O(1). Oldest and MoveToBack make it usable as a FIFO or an LRU-ordered cache.
+//
+// The zero value is ready to use, comparing values with == in CompareAndSwap.
+type OrderedMap[K comparable, V any] struct {
+	mu    sync.RWMutex
+	index map[K]*orderedEntry[K, V]
+	root  orderedEntry[K, V] // sentinel of the circular list, oldest entry first
+	equal func(V, V) bool
+
+	computing computeCalls[K] // GetOrCompute calls in progress
+}
+
+// orderedEntry is an entry of an OrderedMap, linked in insertion order.
+type orderedEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *orderedEntry[K, V]
+}
+
+// NewOrderedMap creates a new, empty OrderedMap. equalFn, if not nil, decides whether two values
+// are equal in CompareAndSwap.
+func NewOrderedMap[K comparable, V any](equalFn func(V, V) bool) *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{equal: equalFn}
+}
+
+// Get retrieves the value for the given key.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, ok := m.index[key]; ok {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Set stores a value for the given key. A new key is added at the back of the order, an existing
+// one keeps its position.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setLocked(key, value)
+}
+
+// Delete removes the key from the map.
+func (m *OrderedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Len returns the number of items in the map.
+func (m *OrderedMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.index)
+}
+
+// Clear removes all items from the map.
+func (m *OrderedMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.index = nil
+	m.root.prev, m.root.next = nil, nil
+}
+
+// CompareAndSwap sets the value of key to newValue if its current value equals oldValue, and
+// reports whether it did. The key keeps its position. Without an equalFn, values are compared
+// with ==, which panics if V is not comparable.
+func (m *OrderedMap[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	if m.equal != nil && !m.equal(e.value, oldValue) ||
+		m.equal == nil && any(e.value) != any(oldValue) {
+		return false
+	}
+	e.value = newValue
+	return true
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+func (m *OrderedMap[K, V]) Swap(key K, value V) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.index[key]; ok {
+		previous := e.value
+		e.value = value
+		return previous, true
+	}
+	m.setLocked(key, value)
+	var zero V
+	return zero, false
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns
+// the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *OrderedMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.index[key]; ok {
+		return e.value, true
+	}
+	m.setLocked(key, value)
+	return value, false
+}
+
+// GetOrCompute returns the value for the key, calling compute to store it at the back of the
+// order if absent.
+func (m *OrderedMap[K, V]) GetOrCompute(key K, compute func() V) (V, bool) {
+	return getOrCompute(m, &m.computing, key, compute)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+func (m *OrderedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.index[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	delete(m.index, key)
+	m.unlinkLocked(e)
+	return e.value, true
+}
+
+// GetAll returns all key-value pairs in the map.
+func (m *OrderedMap[K, V]) GetAll() map[K]V {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	all := make(map[K]V, len(m.index))
+	for key, e := range m.index {
+		all[key] = e.value
+	}
+	return all
+}
+
+// GetMany retrieves select key-value pairs.
+func (m *OrderedMap[K, V]) GetMany(keys []K) map[K]V {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if e, ok := m.index[key]; ok {
+			result[key] = e.value
+		}
+	}
+	return result
+}
+
+// SetMany sets multiple key-value pairs. New keys are added at the back of the order, in an
+// unspecified order among themselves.
+func (m *OrderedMap[K, V]) SetMany(entries map[K]V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range entries {
+		m.setLocked(key, value)
+	}
+}
+
+// DeleteMany removes the keys from the map under a single lock acquisition, and returns how many
+// of them were present.
+func (m *OrderedMap[K, V]) DeleteMany(keys []K) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removed := 0
+	for _, key := range keys {
+		if e, ok := m.index[key]; ok {
+			delete(m.index, key)
+			m.unlinkLocked(e)
+			removed++
+		}
+	}
+	return removed
+}
+
+// UpdateMany calls f for each of keys with its current value, if loaded, and stores the new value
+// f returns if keep is true, or deletes the key otherwise, all under a single lock acquisition.
+// Updated keys keep their position, and new keys are added at the back in the order of keys. f
+// must not call back into the map.
+func (m *OrderedMap[K, V]) UpdateMany(keys []K, f func(key K, value V, loaded bool) (V, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		e, loaded := m.index[key]
+		var value V
+		if loaded {
+			value = e.value
+		}
+		newValue, keep := f(key, value, loaded)
+		switch {
+		case keep:
+			m.setLocked(key, newValue)
+		case loaded:
+			delete(m.index, key)
+			m.unlinkLocked(e)
+		}
+	}
+}
+
+// Equals reports whether the logical content of this map and the other map is the same,
+// regardless of order.
+func (m *OrderedMap[K, V]) Equals(other Map[K, V], equalFn func(a, b V) bool) bool {
+	return equals(m, other, equalFn)
+}
+
+// Oldest returns the entry at the front of the order, the one inserted first among those still in
+// the map. If the map is empty it returns ok == false.
+func (m *OrderedMap[K, V]) Oldest() (key K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.index) == 0 {
+		return key, value, false
+	}
+	e := m.root.next
+	return e.key, e.value, true
+}
+
+// MoveToBack moves the key to the back of the order, as if it had just been inserted, and reports
+// whether it was in the map.
+func (m *OrderedMap[K, V]) MoveToBack(key K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	m.unlinkLocked(e)
+	m.pushBackLocked(e)
+	return true
+}
+
+// Range calls f sequentially for each key and value present in the map, in insertion order,
+// holding the read lock. If f returns false, range stops the iteration.
+func (m *OrderedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for e := m.root.next; e != nil && e != &m.root; e = e.next {
+		if !f(e.key, e.value) {
+			return
+		}
+	}
+}
+
+// All returns an iterator over key-value pairs in the map, in insertion order. Note: since this
+// snapshots before iteration, Range is more performant.
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, entry := range m.entries() {
+			if !yield(entry.Key, entry.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over keys in the map, in insertion order. Note: since this snapshots
+// before iteration, Range is more performant.
+func (m *OrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for _, entry := range m.entries() {
+			if !yield(entry.Key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over values in the map, in insertion order. Note: since this
+// snapshots before iteration, Range is more performant.
+func (m *OrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, entry := range m.entries() {
+			if !yield(entry.Value) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first entries of the map, for logging and debugging.
+func (m *OrderedMap[K, V]) String() string {
+	return formatEntries(m, m.Len(), m.All())
+}
+
+// GoString returns the Go type, length and first entries of the map, for the %#v verb.
+func (m *OrderedMap[K, V]) GoString() string {
+	return goFormatEntries(m, m.Len(), m.All())
+}
+
+// AsReadOnly returns a read-only view of the map, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (m *OrderedMap[K, V]) AsReadOnly() ReadOnlyMap[K, V] {
+	return readOnlyMap[K, V]{m}
+}
+
+// Ensure OrderedMap implements Map.
+var _ Map[any, any] = (*OrderedMap[any, any])(nil)
+
+// Internal helpers
+
+// setLocked stores a value for the key, adding it at the back of the order if it is new.
+// Callers must hold the write lock.
+func (m *OrderedMap[K, V]) setLocked(key K, value V) {
+	if e, ok := m.index[key]; ok {
+		e.value = value
+		return
+	}
+	if m.index == nil {
+		m.index = make(map[K]*orderedEntry[K, V])
+	}
+	e := &orderedEntry[K, V]{key: key, value: value}
+	m.index[key] = e
+	m.pushBackLocked(e)
+}
+
+// pushBackLocked links e at the back of the order. Callers must hold the write lock.
+func (m *OrderedMap[K, V]) pushBackLocked(e *orderedEntry[K, V]) {
+	if m.root.next == nil {
+		m.root.next, m.root.prev = &m.root, &m.root
+	}
+	e.prev, e.next = m.root.prev, &m.root
+	m.root.prev.next = e
+	m.root.prev = e
+}
+
+// unlinkLocked removes e from the order. Callers must hold the write lock.
+func (m *OrderedMap[K, V]) unlinkLocked(e *orderedEntry[K, V]) {
+	e.prev.next, e.next.prev = e.next, e.prev
+	e.prev, e.next = nil, nil
+}
+
+// entries returns a snapshot of the entries in insertion order.
+func (m *OrderedMap[K, V]) entries() []MapEntry[K, V] {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entries := make([]MapEntry[K, V], 0, len(m.index))
+	for e := m.root.next; e != nil && e != &m.root; e = e.next {
+		entries = append(entries, MapEntry[K, V]{Key: e.key, Value: e.value})
+	}
+	return entries
+}
@@ -0,0 +1,394 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"hash/maphash"
+	"iter"
+	"sync"
+)
+
+// ShardedMap is a thread-safe implementation of Map that hashes keys across several independent
+// RWMutexMap shards, so writes to keys in different shards proceed in parallel instead of
+// serializing on a single lock. It suits write-heavy workloads spread over many goroutines.
+//
+// Single-key operations lock one shard. Operations spanning several keys, such as Len, GetAll,
+// SetMany, Clear, Equals and the iterators, visit the shards one at a time: each shard is seen
+// atomically, but the map as a whole is not, as other goroutines may write to shards already
+// visited or not yet visited. All methods also share a read lock on the set of shards, which is
+// write-locked by Resharding alone.
+//
+// The number of shards is set at construction and can be changed with Resharding. The zero
+// value is ready to use, with a shard count based on runtime.GOMAXPROCS, comparing values with ==
+// in CompareAndSwap.
+type ShardedMap[K comparable, V any] struct {
+	once   sync.Once
+	mu     sync.RWMutex // guards the shards slice itself, not the shards' contents
+	shards []mapShard[K, V]
+	seed   maphash.Seed
+	count  int // requested number of shards, resolved on initialization
+	equal  func(V, V) bool
+
+	computing computeCalls[K] // GetOrCompute calls in progress
+}
+
+// cacheLineSize is the size of a CPU cache line on common architectures, used as padding to
+// avoid false sharing.
+const cacheLineSize = 64
+
+// mapShard is a shard of a ShardedMap, padded so that neighboring shards' locks do not share a
+// cache line.
+type mapShard[K comparable, V any] struct {
+	RWMutexMap[K, V]
+	_ [cacheLineSize]byte
+}
+
+// NewShardedMap creates a new, empty ShardedMap with the given number of shards. If shardCount is
+// 0, a power of two based on runtime.GOMAXPROCS is used; if shardCount is negative, it is coerced
+// to 1. equalFn, if not nil, decides whether two values are equal in CompareAndSwap.
+func NewShardedMap[K comparable, V any](shardCount int, equalFn func(V, V) bool) *ShardedMap[K, V] {
+	m := &ShardedMap[K, V]{count: shardCount, equal: equalFn}
+	m.ensureInitialized()
+	return m
+}
+
+// Shards returns the current number of shards.
+func (m *ShardedMap[K, V]) Shards() int {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	return len(m.shards)
+}
+
+// Resharding rebuilds the map with shardCount shards, e.g. after the number of usable cores of a
+// long-lived process changed. shardCount follows the rules of NewShardedMap, so 0 picks a count
+// based on the current runtime.GOMAXPROCS. The entries are rehashed into the new shards while
+// all shards are locked; concurrent operations wait until it is done.
+func (m *ShardedMap[K, V]) Resharding(shardCount int) {
+	m.ensureInitialized()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old := m.shards
+	m.shards = newMapShards[K](shardCount, m.equal)
+	for i := range old {
+		for key, value := range old[i].values {
+			shard := &m.shards[m.shardIndex(key)]
+			if shard.values == nil {
+				shard.values = make(map[K]V)
+			}
+			shard.values[key] = value
+		}
+	}
+}
+
+// Get retrieves the value for the given key.
+func (m *ShardedMap[K, V]) Get(key K) (V, bool) {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	return m.shard(key).Get(key)
+}
+
+// Set stores a value for the given key.
+func (m *ShardedMap[K, V]) Set(key K, value V) {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	m.shard(key).Set(key, value)
+}
+
+// Delete removes the key from the map.
+func (m *ShardedMap[K, V]) Delete(key K) {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	m.shard(key).Delete(key)
+}
+
+// Len returns the number of items in the map, summed over the shards.
+func (m *ShardedMap[K, V]) Len() int {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	total := 0
+	for i := range m.shards {
+		total += m.shards[i].Len()
+	}
+	return total
+}
+
+// Clear removes all items from the map, one shard at a time.
+func (m *ShardedMap[K, V]) Clear() {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	for i := range m.shards {
+		m.shards[i].Clear()
+	}
+}
+
+// CompareAndSwap executes the compare-and-swap operation for a key.
+func (m *ShardedMap[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	return m.shard(key).CompareAndSwap(key, oldValue, newValue)
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+func (m *ShardedMap[K, V]) Swap(key K, value V) (V, bool) {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	return m.shard(key).Swap(key, value)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns
+// the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *ShardedMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	return m.shard(key).LoadOrStore(key, value)
+}
+
+// GetOrCompute returns the value for the key, calling compute to store it if absent. compute
+// runs without holding any lock of the shards.
+func (m *ShardedMap[K, V]) GetOrCompute(key K, compute func() V) (V, bool) {
+	return getOrCompute(m, &m.computing, key, compute)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+func (m *ShardedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	return m.shard(key).LoadAndDelete(key)
+}
+
+// GetAll returns all key-value pairs in the map.
+func (m *ShardedMap[K, V]) GetAll() map[K]V {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	all := make(map[K]V)
+	for i := range m.shards {
+		m.shards[i].Range(func(key K, value V) bool {
+			all[key] = value
+			return true
+		})
+	}
+	return all
+}
+
+// GetMany retrieves select key-value pairs.
+func (m *ShardedMap[K, V]) GetMany(keys []K) map[K]V {
+	result := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if value, ok := m.Get(key); ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// SetMany sets multiple key-value pairs, locking each shard once for the entries it holds.
+func (m *ShardedMap[K, V]) SetMany(entries map[K]V) {
+	if len(entries) == 0 {
+		return
+	}
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	perShard := make([]map[K]V, len(m.shards))
+	for key, value := range entries {
+		i := m.shardIndex(key)
+		if perShard[i] == nil {
+			perShard[i] = make(map[K]V)
+		}
+		perShard[i][key] = value
+	}
+	for i, shardEntries := range perShard {
+		if shardEntries != nil {
+			m.shards[i].SetMany(shardEntries)
+		}
+	}
+}
+
+// DeleteMany removes the keys from the map, locking each shard once for the keys it holds, and
+// returns how many of them were present.
+func (m *ShardedMap[K, V]) DeleteMany(keys []K) int {
+	if len(keys) == 0 {
+		return 0
+	}
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	perShard := m.keysByShard(keys)
+	removed := 0
+	for i, shardKeys := range perShard {
+		if shardKeys != nil {
+			removed += m.shards[i].DeleteMany(shardKeys)
+		}
+	}
+	return removed
+}
+
+// UpdateMany calls f for each of keys with its current value, if loaded, and stores the new value
+// f returns if keep is true, or deletes the key otherwise. The shards holding keys are locked in a
+// fixed order and held until all updates are applied, so the updates are atomic across shards. f
+// is called for the keys of one shard after another, and must not call back into the map.
+func (m *ShardedMap[K, V]) UpdateMany(keys []K, f func(key K, value V, loaded bool) (V, bool)) {
+	if len(keys) == 0 {
+		return
+	}
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	perShard := m.keysByShard(keys)
+
+	// Lock the shards in index order, so concurrent calls cannot deadlock, and hold every lock
+	// until all updates are applied
+	var locked []*mapShard[K, V]
+	defer func() {
+		for _, shard := range locked {
+			shard.mu.Unlock()
+		}
+	}()
+	for i, shardKeys := range perShard {
+		if shardKeys == nil {
+			continue
+		}
+		shard := &m.shards[i]
+		shard.mu.Lock()
+		locked = append(locked, shard)
+		if shard.values == nil {
+			shard.values = make(map[K]V)
+		}
+		updateEntries(shard.values, shardKeys, f)
+	}
+}
+
+// Equals reports whether the logical content of this map and the other map is the same.
+func (m *ShardedMap[K, V]) Equals(other Map[K, V], equalFn func(a, b V) bool) bool {
+	return equals(m, other, equalFn)
+}
+
+// Range calls f sequentially for each key and value present in the map, one shard at a time,
+// holding the shard's read lock. If f returns false, range stops the iteration.
+func (m *ShardedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	next := true
+	for i := range m.shards {
+		m.shards[i].Range(func(key K, value V) bool {
+			next = f(key, value)
+			return next
+		})
+		if !next {
+			return
+		}
+	}
+}
+
+// All returns an iterator over key-value pairs in the map, snapshotting one shard at a time
+// before iterating. The iteration order is not guaranteed to be consistent.
+func (m *ShardedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, entry := range m.entries() {
+			if !yield(entry.Key, entry.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over keys in the map, snapshotting one shard at a time. The iteration
+// order is not guaranteed to be consistent.
+func (m *ShardedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range m.All() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over values in the map, snapshotting one shard at a time. The
+// iteration order is not guaranteed to be consistent.
+func (m *ShardedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, value := range m.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first entries of the map, for logging and debugging.
+func (m *ShardedMap[K, V]) String() string {
+	return formatEntries(m, m.Len(), m.All())
+}
+
+// GoString returns the Go type, length and first entries of the map, for the %#v verb.
+func (m *ShardedMap[K, V]) GoString() string {
+	return goFormatEntries(m, m.Len(), m.All())
+}
+
+// AsReadOnly returns a read-only view of the map, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (m *ShardedMap[K, V]) AsReadOnly() ReadOnlyMap[K, V] {
+	return readOnlyMap[K, V]{m}
+}
+
+// Ensure ShardedMap implements Map.
+var _ Map[any, any] = (*ShardedMap[any, any])(nil)
+
+// Internal helpers
+
+// ensureInitialized creates the shards on first use, so that the zero value is ready to use.
+func (m *ShardedMap[K, V]) ensureInitialized() {
+	m.once.Do(func() {
+		m.seed = maphash.MakeSeed()
+		m.shards = newMapShards[K](m.count, m.equal)
+	})
+}
+
+// rlockShards read-locks the set of shards, initializing it first if needed for zero-value usage.
+func (m *ShardedMap[K, V]) rlockShards() {
+	m.ensureInitialized()
+	m.mu.RLock()
+}
+
+// newMapShards creates the shards of a ShardedMap, see NewShardedMap.
+func newMapShards[K comparable, V any](shardCount int, equalFn func(V, V) bool) []mapShard[K, V] {
+	shards := make([]mapShard[K, V], resolveShardCount(shardCount))
+	for i := range shards {
+		shards[i].equal = equalFn
+	}
+	return shards
+}
+
+// entries returns a snapshot of the entries, taking one shard at a time.
+func (m *ShardedMap[K, V]) entries() []MapEntry[K, V] {
+	m.rlockShards()
+	defer m.mu.RUnlock()
+	var entries []MapEntry[K, V]
+	for i := range m.shards {
+		for key, value := range m.shards[i].All() {
+			entries = append(entries, MapEntry[K, V]{Key: key, Value: value})
+		}
+	}
+	return entries
+}
+
+func (m *ShardedMap[K, V]) shardIndex(key K) int {
+	if len(m.shards) == 1 {
+		return 0
+	}
+	return int(maphash.Comparable(m.seed, key) % uint64(len(m.shards)))
+}
+
+// keysByShard groups keys by the index of their shard, keeping their order. Callers must hold
+// the read lock on the set of shards.
+func (m *ShardedMap[K, V]) keysByShard(keys []K) [][]K {
+	perShard := make([][]K, len(m.shards))
+	for _, key := range keys {
+		i := m.shardIndex(key)
+		perShard[i] = append(perShard[i], key)
+	}
+	return perShard
+}
+
+// shard returns the shard of key. Callers must hold the read lock on the set of shards.
+func (m *ShardedMap[K, V]) shard(key K) *mapShard[K, V] {
+	return &m.shards[m.shardIndex(key)]
+}
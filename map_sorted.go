@@ -0,0 +1,452 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"cmp"
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+)
+
+// SortedMap is a thread-safe implementation of Map that keeps its keys sorted, backed by a skip
+// list under a sync.RWMutex. Range, All, Keys and Values visit the entries in ascending key
+// order, and Min, Max, Ascend and Descend give access to the ends of the map and to key ranges,
+// which unordered maps cannot do without a full scan and a sort.
+//
+// Keys are ordered and compared with cmp.Compare, so a floating-point NaN key is a single key
+// sorting before all others, unlike in a Go map.
+//
+// Complexity: Get, Set and Delete O(log n) expected, Min and Max O(1), Ascend and Descend
+// O(log n + m) for m entries in the range.
+//
+// The zero value is ready to use, comparing values with == in CompareAndSwap.
+type SortedMap[K cmp.Ordered, V any] struct {
+	mu    sync.RWMutex
+	head  *sortedNode[K, V] // sentinel, nil until the first insertion
+	tail  *sortedNode[K, V] // entry with the largest key
+	level int               // number of levels in use
+	size  int
+	equal func(V, V) bool
+
+	computing computeCalls[K] // GetOrCompute calls in progress
+}
+
+// sortedNode is an entry of a SortedMap, linked forwards at each of its levels and backwards at
+// the bottom level.
+type sortedNode[K cmp.Ordered, V any] struct {
+	key   K
+	value V
+	prev  *sortedNode[K, V] // nil for the first entry
+	next  []*sortedNode[K, V]
+}
+
+// NewSortedMap creates a new, empty SortedMap. equalFn, if not nil, decides whether two values
+// are equal in CompareAndSwap.
+func NewSortedMap[K cmp.Ordered, V any](equalFn func(V, V) bool) *SortedMap[K, V] {
+	return &SortedMap[K, V]{equal: equalFn}
+}
+
+// Get retrieves the value for the given key.
+func (m *SortedMap[K, V]) Get(key K) (V, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if n := m.getLocked(key); n != nil {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Set stores a value for the given key.
+func (m *SortedMap[K, V]) Set(key K, value V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setLocked(key, value)
+}
+
+// Delete removes the key from the map.
+func (m *SortedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// Len returns the number of items in the map.
+func (m *SortedMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.size
+}
+
+// Clear removes all items from the map.
+func (m *SortedMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.head, m.tail, m.level, m.size = nil, nil, 0, 0
+}
+
+// CompareAndSwap sets the value of key to newValue if its current value equals oldValue, and
+// reports whether it did. Without an equalFn, values are compared with ==, which panics if V is
+// not comparable.
+func (m *SortedMap[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.getLocked(key)
+	if n == nil {
+		return false
+	}
+	if m.equal != nil && !m.equal(n.value, oldValue) ||
+		m.equal == nil && any(n.value) != any(oldValue) {
+		return false
+	}
+	n.value = newValue
+	return true
+}
+
+// Swap swaps the value for a key and returns the previous value if any.
+func (m *SortedMap[K, V]) Swap(key K, value V) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setLocked(key, value)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns
+// the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *SortedMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n := m.getLocked(key); n != nil {
+		return n.value, true
+	}
+	m.setLocked(key, value)
+	return value, false
+}
+
+// GetOrCompute returns the value for the key, calling compute to store it if absent.
+func (m *SortedMap[K, V]) GetOrCompute(key K, compute func() V) (V, bool) {
+	return getOrCompute(m, &m.computing, key, compute)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+func (m *SortedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.deleteLocked(key)
+}
+
+// GetAll returns all key-value pairs in the map.
+func (m *SortedMap[K, V]) GetAll() map[K]V {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	all := make(map[K]V, m.size)
+	for n := m.firstLocked(); n != nil; n = n.next[0] {
+		all[n.key] = n.value
+	}
+	return all
+}
+
+// GetMany retrieves select key-value pairs.
+func (m *SortedMap[K, V]) GetMany(keys []K) map[K]V {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if n := m.getLocked(key); n != nil {
+			result[key] = n.value
+		}
+	}
+	return result
+}
+
+// SetMany sets multiple key-value pairs.
+func (m *SortedMap[K, V]) SetMany(entries map[K]V) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, value := range entries {
+		m.setLocked(key, value)
+	}
+}
+
+// DeleteMany removes the keys from the map under a single lock acquisition, and returns how many
+// of them were present.
+func (m *SortedMap[K, V]) DeleteMany(keys []K) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removed := 0
+	for _, key := range keys {
+		if _, ok := m.deleteLocked(key); ok {
+			removed++
+		}
+	}
+	return removed
+}
+
+// UpdateMany calls f for each of keys with its current value, if loaded, and stores the new value
+// f returns if keep is true, or deletes the key otherwise, all under a single lock acquisition.
+// f must not call back into the map.
+func (m *SortedMap[K, V]) UpdateMany(keys []K, f func(key K, value V, loaded bool) (V, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		var value V
+		n := m.getLocked(key)
+		if n != nil {
+			value = n.value
+		}
+		newValue, keep := f(key, value, n != nil)
+		switch {
+		case keep:
+			m.setLocked(key, newValue)
+		case n != nil:
+			m.deleteLocked(key)
+		}
+	}
+}
+
+// Equals reports whether the logical content of this map and the other map is the same.
+func (m *SortedMap[K, V]) Equals(other Map[K, V], equalFn func(a, b V) bool) bool {
+	return equals(m, other, equalFn)
+}
+
+// Min returns the entry with the smallest key. If the map is empty it returns ok == false.
+func (m *SortedMap[K, V]) Min() (key K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if n := m.firstLocked(); n != nil {
+		return n.key, n.value, true
+	}
+	return key, value, false
+}
+
+// Max returns the entry with the largest key. If the map is empty it returns ok == false.
+func (m *SortedMap[K, V]) Max() (key K, value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.tail != nil {
+		return m.tail.key, m.tail.value, true
+	}
+	return key, value, false
+}
+
+// Ascend returns an iterator over the entries with from <= key <= to, in ascending key order.
+// It is empty if from is greater than to. Note: this snapshots the range before iteration.
+func (m *SortedMap[K, V]) Ascend(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.mu.RLock()
+		var entries []MapEntry[K, V]
+		for n := m.seekLocked(from, nil); n != nil && cmp.Compare(n.key, to) <= 0; n = n.next[0] {
+			entries = append(entries, MapEntry[K, V]{Key: n.key, Value: n.value})
+		}
+		m.mu.RUnlock()
+		yieldEntries(entries, yield)
+	}
+}
+
+// Descend returns an iterator over the entries with from >= key >= to, in descending key order.
+// It is empty if from is less than to. Note: this snapshots the range before iteration.
+func (m *SortedMap[K, V]) Descend(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.mu.RLock()
+		var entries []MapEntry[K, V]
+		for n := m.lastAtMostLocked(from); n != nil && cmp.Compare(n.key, to) >= 0; n = n.prev {
+			entries = append(entries, MapEntry[K, V]{Key: n.key, Value: n.value})
+		}
+		m.mu.RUnlock()
+		yieldEntries(entries, yield)
+	}
+}
+
+// Range calls f sequentially for each key and value present in the map, in ascending key order,
+// holding the read lock. If f returns false, range stops the iteration.
+func (m *SortedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for n := m.firstLocked(); n != nil; n = n.next[0] {
+		if !f(n.key, n.value) {
+			return
+		}
+	}
+}
+
+// All returns an iterator over key-value pairs in the map, in ascending key order. Note: since
+// this snapshots before iteration, Range is more performant.
+func (m *SortedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		yieldEntries(m.entries(), yield)
+	}
+}
+
+// Keys returns an iterator over keys in the map, in ascending order. Note: since this snapshots
+// before iteration, Range is more performant.
+func (m *SortedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for _, entry := range m.entries() {
+			if !yield(entry.Key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over values in the map, in ascending key order. Note: since this
+// snapshots before iteration, Range is more performant.
+func (m *SortedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, entry := range m.entries() {
+			if !yield(entry.Value) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first entries of the map, for logging and debugging.
+func (m *SortedMap[K, V]) String() string {
+	return formatEntries(m, m.Len(), m.All())
+}
+
+// GoString returns the Go type, length and first entries of the map, for the %#v verb.
+func (m *SortedMap[K, V]) GoString() string {
+	return goFormatEntries(m, m.Len(), m.All())
+}
+
+// AsReadOnly returns a read-only view of the map, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (m *SortedMap[K, V]) AsReadOnly() ReadOnlyMap[K, V] {
+	return readOnlyMap[K, V]{m}
+}
+
+// Ensure SortedMap implements Map.
+var _ Map[int, any] = (*SortedMap[int, any])(nil)
+
+// Internal helpers
+
+// seekLocked returns the first entry with a key not less than key, or nil if there is none. If
+// update is not nil, it is filled with the last node before that entry at each level in use.
+// Callers must hold the lock.
+func (m *SortedMap[K, V]) seekLocked(
+	key K,
+	update *[skipListMaxLevel]*sortedNode[K, V],
+) *sortedNode[K, V] {
+	if m.head == nil {
+		return nil
+	}
+	x := m.head
+	for level := m.level - 1; level >= 0; level-- {
+		for x.next[level] != nil && cmp.Less(x.next[level].key, key) {
+			x = x.next[level]
+		}
+		if update != nil {
+			update[level] = x
+		}
+	}
+	return x.next[0]
+}
+
+// getLocked returns the entry for key, or nil if there is none. Callers must hold the lock.
+func (m *SortedMap[K, V]) getLocked(key K) *sortedNode[K, V] {
+	if n := m.seekLocked(key, nil); n != nil && cmp.Compare(n.key, key) == 0 {
+		return n
+	}
+	return nil
+}
+
+// firstLocked returns the entry with the smallest key, or nil if the map is empty. Callers must
+// hold the lock.
+func (m *SortedMap[K, V]) firstLocked() *sortedNode[K, V] {
+	if m.head == nil {
+		return nil
+	}
+	return m.head.next[0]
+}
+
+// lastAtMostLocked returns the last entry with a key not greater than key, or nil if there is
+// none. Callers must hold the lock.
+func (m *SortedMap[K, V]) lastAtMostLocked(key K) *sortedNode[K, V] {
+	n := m.seekLocked(key, nil)
+	switch {
+	case n == nil:
+		return m.tail
+	case cmp.Compare(n.key, key) == 0:
+		return n
+	}
+	return n.prev
+}
+
+// setLocked stores a value for the key and returns the previous value if any. Callers must hold
+// the write lock.
+func (m *SortedMap[K, V]) setLocked(key K, value V) (V, bool) {
+	if m.head == nil {
+		m.head = &sortedNode[K, V]{next: make([]*sortedNode[K, V], skipListMaxLevel)}
+	}
+	var update [skipListMaxLevel]*sortedNode[K, V]
+	if n := m.seekLocked(key, &update); n != nil && cmp.Compare(n.key, key) == 0 {
+		previous := n.value
+		n.value = value
+		return previous, true
+	}
+
+	top := min(bits.TrailingZeros64(rand.Uint64()), skipListMaxLevel-1)
+	for ; m.level <= top; m.level++ {
+		update[m.level] = m.head
+	}
+	n := &sortedNode[K, V]{key: key, value: value, next: make([]*sortedNode[K, V], top+1)}
+	for level := range n.next {
+		n.next[level] = update[level].next[level]
+		update[level].next[level] = n
+	}
+	if update[0] != m.head {
+		n.prev = update[0]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n
+	} else {
+		m.tail = n
+	}
+	m.size++
+	var zero V
+	return zero, false
+}
+
+// deleteLocked removes the key and returns its value if it was present. Callers must hold the
+// write lock.
+func (m *SortedMap[K, V]) deleteLocked(key K) (V, bool) {
+	var update [skipListMaxLevel]*sortedNode[K, V]
+	n := m.seekLocked(key, &update)
+	if n == nil || cmp.Compare(n.key, key) != 0 {
+		var zero V
+		return zero, false
+	}
+	for level := range n.next {
+		update[level].next[level] = n.next[level]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n.prev
+	} else {
+		m.tail = n.prev
+	}
+	for m.level > 0 && m.head.next[m.level-1] == nil {
+		m.level--
+	}
+	m.size--
+	return n.value, true
+}
+
+// entries returns a snapshot of the entries in ascending key order.
+func (m *SortedMap[K, V]) entries() []MapEntry[K, V] {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entries := make([]MapEntry[K, V], 0, m.size)
+	for n := m.firstLocked(); n != nil; n = n.next[0] {
+		entries = append(entries, MapEntry[K, V]{Key: n.key, Value: n.value})
+	}
+	return entries
+}
+
+// yieldEntries yields entries in order until yield returns false.
+func yieldEntries[K comparable, V any](entries []MapEntry[K, V], yield func(K, V) bool) {
+	for _, entry := range entries {
+		if !yield(entry.Key, entry.Value) {
+			return
+		}
+	}
+}
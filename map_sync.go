@@ -2,6 +2,7 @@
 package threadsafe
 
 import (
+	"io"
 	"iter"
 	"maps"
 	"sync"
@@ -11,14 +12,19 @@ import (
 // Note: the internal implementation of sync.Map requires a comparable type to run the
 // CompareAndSwap operation. To circumvent this, attach an equal function to the map
 // upon creation.
+//
+// The zero value is ready to use, comparing values with == in CompareAndSwap.
 type SyncMap[K comparable, V any] struct {
-	values sync.Map
-	equal  func(V, V) bool
+	values  sync.Map
+	wrapped *sync.Map // set by WrapSyncMap, in place of values
+	equal   func(V, V) bool
+
+	computing computeCalls[K] // GetOrCompute calls in progress
 }
 
 // Get retrieves the value for the given key.
 func (s *SyncMap[K, V]) Get(key K) (V, bool) {
-	value, ok := s.values.Load(key)
+	value, ok := s.syncMap().Load(key)
 	if !ok {
 		var zero V
 		return zero, false
@@ -28,19 +34,19 @@ func (s *SyncMap[K, V]) Get(key K) (V, bool) {
 
 // Set stores a value for the given key.
 func (s *SyncMap[K, V]) Set(key K, value V) {
-	s.values.Store(key, value)
+	s.syncMap().Store(key, value)
 }
 
 // Delete removes the key from the store.
 func (s *SyncMap[K, V]) Delete(key K) {
-	s.values.Delete(key)
+	s.syncMap().Delete(key)
 }
 
 // Len returns the number of items in the store.
 // Note: This is an O(n) operation as sync.Map doesn't track its size.
 func (s *SyncMap[K, V]) Len() int {
 	count := 0
-	s.values.Range(func(_, _ any) bool {
+	s.syncMap().Range(func(_, _ any) bool {
 		count++
 		return true
 	})
@@ -49,7 +55,7 @@ func (s *SyncMap[K, V]) Len() int {
 
 // Clear removes all items from the store.
 func (s *SyncMap[K, V]) Clear() {
-	s.values.Clear()
+	s.syncMap().Clear()
 }
 
 // CompareAndSwap executes the compare-and-swap operation for a key.
@@ -62,19 +68,19 @@ func (s *SyncMap[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
 
 	if s.equal != nil {
 		if s.equal(current, oldValue) {
-			s.values.Store(key, newValue)
+			s.syncMap().Store(key, newValue)
 			return true
 		}
 		return false
 	}
 
 	// Fall back on sync.Map.CompareAndSwap, which will panic if V is not comparable
-	return s.values.CompareAndSwap(key, oldValue, newValue)
+	return s.syncMap().CompareAndSwap(key, oldValue, newValue)
 }
 
 // Swap swaps the value for a key and returns the previous value if any.
 func (s *SyncMap[K, V]) Swap(key K, value V) (V, bool) {
-	old, loaded := s.values.Swap(key, value)
+	old, loaded := s.syncMap().Swap(key, value)
 	if !loaded {
 		var zero V
 		return zero, false
@@ -85,16 +91,22 @@ func (s *SyncMap[K, V]) Swap(key K, value V) (V, bool) {
 // LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns
 // the given value. The loaded result is true if the value was loaded, false if stored.
 func (s *SyncMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
-	v, loaded := s.values.LoadOrStore(key, value)
+	v, loaded := s.syncMap().LoadOrStore(key, value)
 	if !loaded {
 		return value, false
 	}
 	return v.(V), true //nolint:revive
 }
 
+// GetOrCompute returns the value for the key, calling compute to store it with LoadOrStore if
+// absent.
+func (s *SyncMap[K, V]) GetOrCompute(key K, compute func() V) (V, bool) {
+	return getOrCompute(s, &s.computing, key, compute)
+}
+
 // LoadAndDelete deletes the value for a key, returning the previous value if any.
 func (s *SyncMap[K, V]) LoadAndDelete(key K) (V, bool) {
-	v, loaded := s.values.LoadAndDelete(key)
+	v, loaded := s.syncMap().LoadAndDelete(key)
 	if !loaded {
 		var zero V
 		return zero, false
@@ -125,6 +137,33 @@ func (s *SyncMap[K, V]) SetMany(entries map[K]V) {
 	}
 }
 
+// DeleteMany removes the keys from the map one at a time, and returns how many of them were
+// present.
+func (s *SyncMap[K, V]) DeleteMany(keys []K) int {
+	removed := 0
+	for _, key := range keys {
+		if _, loaded := s.syncMap().LoadAndDelete(key); loaded {
+			removed++
+		}
+	}
+	return removed
+}
+
+// UpdateMany calls f for each of keys with its current value, if loaded, and stores the new value
+// f returns if keep is true, or deletes the key otherwise. Unlike the maps backed by a lock, it is
+// not atomic: the keys are updated one at a time, and a concurrent write to a key between its
+// load and its update is overwritten.
+func (s *SyncMap[K, V]) UpdateMany(keys []K, f func(key K, value V, loaded bool) (V, bool)) {
+	for _, key := range keys {
+		value, loaded := s.Get(key)
+		if newValue, keep := f(key, value, loaded); keep {
+			s.Set(key, newValue)
+		} else if loaded {
+			s.Delete(key)
+		}
+	}
+}
+
 // Equals reports whether the logical content of this map and the other map is the same. Requires
 // equalFn to be provided to decide how two values of type V are compared.
 func (s *SyncMap[K, V]) Equals(other Map[K, V], equalFn func(a, b V) bool) bool {
@@ -134,7 +173,7 @@ func (s *SyncMap[K, V]) Equals(other Map[K, V], equalFn func(a, b V) bool) bool
 // Range calls f sequentially for each key and value present in the map.
 // If f returns false, range stops the iteration.
 func (s *SyncMap[K, V]) Range(f func(key K, value V) bool) {
-	s.values.Range(func(k, v any) bool {
+	s.syncMap().Range(func(k, v any) bool {
 		return f(k.(K), v.(V))
 	})
 }
@@ -143,17 +182,48 @@ func (s *SyncMap[K, V]) Range(f func(key K, value V) bool) {
 // The iteration order is not guaranteed to be consistent.
 func (s *SyncMap[K, V]) All() iter.Seq2[K, V] {
 	return func(yield func(K, V) bool) {
-		s.values.Range(func(k, v any) bool {
+		s.syncMap().Range(func(k, v any) bool {
 			return yield(k.(K), v.(V)) //nolint:revive
 		})
 	}
 }
 
+// String returns the length and the first entries of the map, for logging and debugging.
+func (s *SyncMap[K, V]) String() string {
+	return formatEntries(s, s.Len(), s.All())
+}
+
+// GoString returns the Go type, length and first entries of the map, for the %#v verb.
+func (s *SyncMap[K, V]) GoString() string {
+	return goFormatEntries(s, s.Len(), s.All())
+}
+
+// AsReadOnly returns a read-only view of the map, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (s *SyncMap[K, V]) AsReadOnly() ReadOnlyMap[K, V] {
+	return readOnlyMap[K, V]{s}
+}
+
+// Clone returns a new map with the same equal function and a copy of each value made by copyFn;
+// if nil, values are copied shallowly. Keys are copied as is. As with Range, the clone does not
+// necessarily correspond to any consistent state of the map under concurrent modification.
+func (s *SyncMap[K, V]) Clone(copyFn func(V) V) *SyncMap[K, V] {
+	return SyncMapFromMap(cloneMap(s.GetAll(), copyFn), s.equal)
+}
+
+// EstimateBytes returns an approximation of the memory held by the map, for capacity planning
+// and memory accounting: the map itself and its entries, plus sizeFn(key, value) per entry if
+// sizeFn is not nil, e.g. to count the data of string or slice keys and values. Like Range, it
+// does not observe a consistent snapshot under concurrent writes.
+func (s *SyncMap[K, V]) EstimateBytes(sizeFn func(key K, value V) int) int {
+	return sizeOf[SyncMap[K, V]]() + syncMapBytes(s.syncMap(), sizeFn)
+}
+
 // Keys returns an iterator over keys in the map.
 // The iteration order is not guaranteed to be consistent.
 func (s *SyncMap[K, V]) Keys() iter.Seq[K] {
 	return func(yield func(K) bool) {
-		s.values.Range(func(k, _ any) bool {
+		s.syncMap().Range(func(k, _ any) bool {
 			return yield(k.(K)) //nolint:revive
 		})
 	}
@@ -163,12 +233,34 @@ func (s *SyncMap[K, V]) Keys() iter.Seq[K] {
 // The iteration order is not guaranteed to be consistent.
 func (s *SyncMap[K, V]) Values() iter.Seq[V] {
 	return func(yield func(V) bool) {
-		s.values.Range(func(_, v any) bool {
+		s.syncMap().Range(func(_, v any) bool {
 			return yield(v.(V)) //nolint:revive
 		})
 	}
 }
 
+// SaveTo writes a snapshot of the map contents to w as MapEntry items encoded with codec. As with
+// Range, the snapshot does not necessarily correspond to any consistent state of the map under
+// concurrent modification.
+func (s *SyncMap[K, V]) SaveTo(w io.Writer, codec Codec[MapEntry[K, V]]) error {
+	return writeSnapshot(w, codec, mapEntries(s.GetAll()))
+}
+
+// LoadFrom replaces the map contents with a snapshot written by SaveTo. On error the map is left
+// unchanged. The replacement is not atomic: concurrent readers may observe a partially loaded
+// map.
+func (s *SyncMap[K, V]) LoadFrom(r io.Reader, codec Codec[MapEntry[K, V]]) error {
+	entries, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	s.syncMap().Clear()
+	for _, e := range entries {
+		s.syncMap().Store(e.Key, e.Value)
+	}
+	return nil
+}
+
 // NewSyncMap creates a new instance of SyncMap. The equalFn parameter is required to
 // decide how two values of type V are compared, but can be nil if V is comparable.
 func NewSyncMap[K comparable, V any](equalFn func(V, V) bool) *SyncMap[K, V] {
@@ -183,3 +275,19 @@ func SyncMapFromMap[K comparable, V any](m map[K]V, equalFn func(V, V) bool) *Sy
 	newMap.SetMany(m)
 	return newMap
 }
+
+// WrapSyncMap creates a new instance of SyncMap backed by m itself rather than a copy, so that
+// existing state can be migrated without copying it. All keys and values in m must be of types K
+// and V, or the methods reading them panic. Ownership of m is transferred: the caller must not
+// use m directly afterwards, as direct writes would bypass the equalFn-based CompareAndSwap.
+func WrapSyncMap[K comparable, V any](m *sync.Map, equalFn func(V, V) bool) *SyncMap[K, V] {
+	return &SyncMap[K, V]{wrapped: m, equal: equalFn}
+}
+
+// syncMap returns the sync.Map backing the map.
+func (s *SyncMap[K, V]) syncMap() *sync.Map {
+	if s.wrapped != nil {
+		return s.wrapped
+	}
+	return &s.values
+}
@@ -2,10 +2,14 @@ package threadsafe
 
 import (
 	"maps"
+	"math/rand/v2"
+	"runtime"
 	"slices"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -35,6 +39,26 @@ func TestSyncMapImplementsMap(_ *testing.T) {
 	var _ Map[string, int] = &SyncMap[string, int]{}
 }
 
+func TestImmutableMapImplementsMap(_ *testing.T) {
+	var _ Map[string, int] = &ImmutableMap[string, int]{}
+}
+
+func TestShardedMapImplementsMap(_ *testing.T) {
+	var _ Map[string, int] = &ShardedMap[string, int]{}
+}
+
+func TestOrderedMapImplementsMap(_ *testing.T) {
+	var _ Map[string, int] = &OrderedMap[string, int]{}
+}
+
+func TestSortedMapImplementsMap(_ *testing.T) {
+	var _ Map[string, int] = &SortedMap[string, int]{}
+}
+
+func TestTTLMapImplementsMap(_ *testing.T) {
+	var _ Map[string, int] = &TTLMap[string, int]{}
+}
+
 func (s *mapTestSuite[K, V]) TestBasicOperations(t *testing.T) {
 	store := s.newMap()
 	assert.Equal(t, 0, store.Len())
@@ -138,6 +162,45 @@ func (s *mapTestSuite[K, V]) TestSetMany(t *testing.T) {
 	assert.Equal(t, s.val2, val)
 }
 
+func (s *mapTestSuite[K, V]) TestDeleteMany(t *testing.T) {
+	store := s.newMap()
+	assert.Zero(t, store.DeleteMany(nil))
+	store.SetMany(map[K]V{s.key1: s.val1, s.key2: s.val2, s.key3: s.val3})
+
+	// Absent and repeated keys are not counted
+	assert.Equal(t, 2, store.DeleteMany([]K{s.key1, s.key2, s.key1}))
+	assert.Equal(t, map[K]V{s.key3: s.val3}, store.GetAll())
+	assert.Zero(t, store.DeleteMany([]K{s.key1}))
+	assert.Equal(t, 1, store.DeleteMany([]K{s.key3}))
+	assert.Zero(t, store.Len())
+}
+
+func (s *mapTestSuite[K, V]) TestUpdateMany(t *testing.T) {
+	store := s.newMap()
+	store.Set(s.key1, s.val1)
+	store.Set(s.key2, s.val2)
+
+	// Replace key1, delete key2, add key3, and leave an absent key absent
+	var seen []K
+	store.UpdateMany([]K{s.key1, s.key2, s.key3, s.key3}, func(key K, value V, loaded bool) (V, bool) {
+		seen = append(seen, key)
+		switch key {
+		case s.key1:
+			assert.True(t, loaded)
+			assert.Equal(t, s.val1, value)
+			return s.val3, true
+		case s.key2:
+			return value, false
+		}
+		if loaded {
+			return value, false
+		}
+		return s.val1, true
+	})
+	assert.ElementsMatch(t, []K{s.key1, s.key2, s.key3, s.key3}, seen)
+	assert.Equal(t, map[K]V{s.key1: s.val3}, store.GetAll())
+}
+
 func (s *mapTestSuite[K, V]) TestRange(t *testing.T) {
 	store := s.newMap()
 	store.Set(s.key1, s.val1)
@@ -174,6 +237,44 @@ func (s *mapTestSuite[K, V]) TestLoadOrStore(t *testing.T) {
 	assert.Equal(t, 1, store.Len())
 }
 
+func (s *mapTestSuite[K, V]) TestGetOrCompute(t *testing.T) {
+	store := s.newMap()
+	var calls atomic.Int32
+	compute := func() V {
+		calls.Add(1)
+		time.Sleep(time.Millisecond) // let the other callers pile up
+		return s.val1
+	}
+
+	var wg sync.WaitGroup
+	var stored atomic.Int32
+	for range 8 {
+		wg.Go(func() {
+			v, loaded := store.GetOrCompute(s.key1, compute)
+			assert.Equal(t, s.val1, v)
+			if !loaded {
+				stored.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, int32(1), stored.Load())
+
+	// Present keys are not computed, and a panicking compute leaves the key absent
+	v, loaded := store.GetOrCompute(s.key1, func() V { panic("unexpected call") })
+	assert.True(t, loaded)
+	assert.Equal(t, s.val1, v)
+	assert.Panics(t, func() {
+		store.GetOrCompute(s.key2, func() V { panic("boom") })
+	})
+	_, ok := store.Get(s.key2)
+	assert.False(t, ok)
+	v, loaded = store.GetOrCompute(s.key2, func() V { return s.val2 })
+	assert.False(t, loaded)
+	assert.Equal(t, s.val2, v)
+}
+
 func (s *mapTestSuite[K, V]) TestLoadAndDelete(t *testing.T) {
 	store := s.newMap()
 
@@ -299,8 +400,11 @@ func runMapTestSuite[K comparable, V any](t *testing.T, s *mapTestSuite[K, V]) {
 	t.Run("GetAll", s.TestGetAll)
 	t.Run("GetMany", s.TestGetMany)
 	t.Run("SetMany", s.TestSetMany)
+	t.Run("DeleteMany", s.TestDeleteMany)
+	t.Run("UpdateMany", s.TestUpdateMany)
 	t.Run("Range", s.TestRange)
 	t.Run("LoadOrStore", s.TestLoadOrStore)
+	t.Run("GetOrCompute", s.TestGetOrCompute)
 	t.Run("LoadAndDelete", s.TestLoadAndDelete)
 	if s.equal != nil {
 		t.Run("Iterators", s.TestIterators)
@@ -345,6 +449,66 @@ func testStringIntMapImplementations(t *testing.T) {
 		runMapTestSuite(t, suite)
 	})
 
+	t.Run("ImmutableMap", func(t *testing.T) {
+		suite := &mapTestSuite[string, int]{
+			newMap: func() Map[string, int] {
+				return NewImmutableMap[string](func(a, b int) bool { return a == b })
+			},
+			key1: "one", key2: "two", key3: "three",
+			val1: 1, val2: 2, val3: 3,
+			equal: func(a, b int) bool { return a == b },
+		}
+		runMapTestSuite(t, suite)
+	})
+
+	t.Run("ShardedMap", func(t *testing.T) {
+		suite := &mapTestSuite[string, int]{
+			newMap: func() Map[string, int] {
+				return NewShardedMap[string](4, func(a, b int) bool { return a == b })
+			},
+			key1: "one", key2: "two", key3: "three",
+			val1: 1, val2: 2, val3: 3,
+			equal: func(a, b int) bool { return a == b },
+		}
+		runMapTestSuite(t, suite)
+	})
+
+	t.Run("OrderedMap", func(t *testing.T) {
+		suite := &mapTestSuite[string, int]{
+			newMap: func() Map[string, int] {
+				return NewOrderedMap[string](func(a, b int) bool { return a == b })
+			},
+			key1: "one", key2: "two", key3: "three",
+			val1: 1, val2: 2, val3: 3,
+			equal: func(a, b int) bool { return a == b },
+		}
+		runMapTestSuite(t, suite)
+	})
+
+	t.Run("SortedMap", func(t *testing.T) {
+		suite := &mapTestSuite[string, int]{
+			newMap: func() Map[string, int] {
+				return NewSortedMap[string](func(a, b int) bool { return a == b })
+			},
+			key1: "one", key2: "two", key3: "three",
+			val1: 1, val2: 2, val3: 3,
+			equal: func(a, b int) bool { return a == b },
+		}
+		runMapTestSuite(t, suite)
+	})
+
+	t.Run("TTLMap", func(t *testing.T) {
+		suite := &mapTestSuite[string, int]{
+			newMap: func() Map[string, int] {
+				return NewTTLMap[string](time.Hour, 0, func(a, b int) bool { return a == b })
+			},
+			key1: "one", key2: "two", key3: "three",
+			val1: 1, val2: 2, val3: 3,
+			equal: func(a, b int) bool { return a == b },
+		}
+		runMapTestSuite(t, suite)
+	})
+
 	t.Run("SyncMap (nil equalFn for comparable V)", func(t *testing.T) {
 		suite := &mapTestSuite[string, int]{
 			newMap: func() Map[string, int] {
@@ -401,6 +565,66 @@ func testIntStructMapImplementations(t *testing.T) {
 		}
 		runMapTestSuite(t, suite)
 	})
+
+	t.Run("ImmutableMap", func(t *testing.T) {
+		suite := &mapTestSuite[int, testStruct]{
+			newMap: func() Map[int, testStruct] {
+				return NewImmutableMap[int](equalFunc)
+			},
+			key1: 1, key2: 2, key3: 3,
+			val1: testStruct{1, "A"}, val2: testStruct{2, "B"}, val3: testStruct{3, "C"},
+			equal: equalFunc,
+		}
+		runMapTestSuite(t, suite)
+	})
+
+	t.Run("ShardedMap", func(t *testing.T) {
+		suite := &mapTestSuite[int, testStruct]{
+			newMap: func() Map[int, testStruct] {
+				return NewShardedMap[int](4, equalFunc)
+			},
+			key1: 1, key2: 2, key3: 3,
+			val1: testStruct{1, "A"}, val2: testStruct{2, "B"}, val3: testStruct{3, "C"},
+			equal: equalFunc,
+		}
+		runMapTestSuite(t, suite)
+	})
+
+	t.Run("OrderedMap", func(t *testing.T) {
+		suite := &mapTestSuite[int, testStruct]{
+			newMap: func() Map[int, testStruct] {
+				return NewOrderedMap[int](equalFunc)
+			},
+			key1: 1, key2: 2, key3: 3,
+			val1: testStruct{1, "A"}, val2: testStruct{2, "B"}, val3: testStruct{3, "C"},
+			equal: equalFunc,
+		}
+		runMapTestSuite(t, suite)
+	})
+
+	t.Run("SortedMap", func(t *testing.T) {
+		suite := &mapTestSuite[int, testStruct]{
+			newMap: func() Map[int, testStruct] {
+				return NewSortedMap[int](equalFunc)
+			},
+			key1: 1, key2: 2, key3: 3,
+			val1: testStruct{1, "A"}, val2: testStruct{2, "B"}, val3: testStruct{3, "C"},
+			equal: equalFunc,
+		}
+		runMapTestSuite(t, suite)
+	})
+
+	t.Run("TTLMap", func(t *testing.T) {
+		suite := &mapTestSuite[int, testStruct]{
+			newMap: func() Map[int, testStruct] {
+				return NewTTLMap[int](time.Hour, 0, equalFunc)
+			},
+			key1: 1, key2: 2, key3: 3,
+			val1: testStruct{1, "A"}, val2: testStruct{2, "B"}, val3: testStruct{3, "C"},
+			equal: equalFunc,
+		}
+		runMapTestSuite(t, suite)
+	})
 }
 
 // TestMapImplementations is the main test function that sets up and runs the test suites.
@@ -409,6 +633,380 @@ func TestMapImplementations(t *testing.T) {
 	t.Run("int-struct", testIntStructMapImplementations)
 }
 
+func TestWrapMap(t *testing.T) {
+	t.Run("RWMutexMap", func(t *testing.T) {
+		values := map[string]int{"a": 1}
+		m := WrapMap(values, func(a, b int) bool { return a == b })
+		m.Set("b", 2)
+		assert.Equal(t, 2, values["b"], "the wrapped map is used in place")
+		assert.True(t, m.CompareAndSwap("a", 1, 10))
+		assert.Equal(t, map[string]int{"a": 10, "b": 2}, m.GetAll())
+
+		empty := WrapMap[string, int](nil, nil)
+		empty.Set("x", 1)
+		assert.Equal(t, 1, empty.Len())
+	})
+
+	t.Run("SyncMap", func(t *testing.T) {
+		var values sync.Map
+		values.Store("a", 1)
+		m := WrapSyncMap[string, int](&values, nil)
+		m.Set("b", 2)
+		v, ok := values.Load("b")
+		assert.True(t, ok, "the wrapped sync.Map is used in place")
+		assert.Equal(t, 2, v)
+		assert.True(t, m.CompareAndSwap("a", 1, 10))
+		assert.Equal(t, map[string]int{"a": 10, "b": 2}, m.GetAll())
+		m.Clear()
+		assert.Equal(t, 0, m.Len())
+	})
+}
+
+func TestImmutableMap(t *testing.T) {
+	var m ImmutableMap[int, int]
+	_, ok := m.Get(1)
+	assert.False(t, ok)
+	assert.Zero(t, m.Len())
+
+	// Random operations agree with a Go map across enough keys to nest several trie levels
+	r := rand.New(rand.NewPCG(1, 2))
+	want := make(map[int]int)
+	for i := range 20000 {
+		key := r.IntN(5000)
+		if r.IntN(3) == 0 {
+			m.Delete(key)
+			delete(want, key)
+		} else {
+			m.Set(key, i)
+			want[key] = i
+		}
+	}
+	assert.Equal(t, len(want), m.Len())
+	assert.Equal(t, want, m.GetAll())
+
+	// Snapshots are isolated from later writes, in both directions
+	snapshot := m.Snapshot()
+	m.Clear()
+	snapshot.Set(-1, -1)
+	assert.Zero(t, m.Len())
+	assert.Equal(t, len(want)+1, snapshot.Len())
+	_, ok = m.Get(-1)
+	assert.False(t, ok)
+
+	// Iterators keep reading the version current when they started
+	m.SetMany(map[int]int{1: 1, 2: 2, 3: 3})
+	seen := 0
+	for key := range m.All() {
+		m.Delete(key)
+		seen++
+	}
+	assert.Equal(t, 3, seen)
+	assert.Zero(t, m.Len())
+}
+
+func TestImmutableMapHashCollisions(t *testing.T) {
+	// Keys are placed by the hashes given, to exercise full collisions and deep nesting
+	node := &hamtNode[string, int]{}
+	hashes := map[string]uint64{"a": 0, "b": 0, "c": 1 << 62, "d": 1<<62 | 1}
+	keys := []string{"a", "b", "c", "d"}
+	for i, key := range keys {
+		var added bool
+		node, added = node.with(hashes[key], 0, key, i)
+		assert.True(t, added)
+	}
+	node, added := node.with(hashes["b"], 0, "b", 10)
+	assert.False(t, added)
+	for key, want := range map[string]int{"a": 0, "b": 10, "c": 2, "d": 3} {
+		value, ok := node.get(hashes[key], 0, key)
+		assert.True(t, ok, key)
+		assert.Equal(t, want, value, key)
+	}
+
+	before := node
+	for _, key := range keys {
+		var ok bool
+		node, _, ok = node.without(hashes[key], 0, key)
+		assert.True(t, ok, key)
+		if node != nil {
+			_, _, ok = node.without(hashes[key], 0, key)
+			assert.False(t, ok, key)
+		}
+	}
+	assert.Nil(t, node)
+	value, ok := before.get(hashes["d"], 0, "d")
+	assert.True(t, ok, "previous versions are unaffected")
+	assert.Equal(t, 3, value)
+}
+
+func TestImmutableMapConcurrentReaders(t *testing.T) {
+	m := NewImmutableMap[int, int](nil)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		// Every version moves value between the keys, keeping their sum constant
+		for i := range 1000 {
+			m.SetMany(map[int]int{0: 100 - i%100, 1: i % 100})
+		}
+	})
+	for range 4 {
+		wg.Go(func() {
+			for range 1000 {
+				values := m.GetMany([]int{0, 1})
+				if len(values) > 0 {
+					assert.Equal(t, 100, values[0]+values[1])
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestShardedMap(t *testing.T) {
+	t.Run("ShardCount", func(t *testing.T) {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(6))
+		assert.Equal(t, 8, NewShardedMap[int, int](0, nil).Shards())
+		assert.Equal(t, 3, NewShardedMap[int, int](3, nil).Shards())
+		assert.Equal(t, 1, NewShardedMap[int, int](-2, nil).Shards())
+
+		var zero ShardedMap[int, int]
+		assert.Equal(t, 8, zero.Shards())
+	})
+
+	t.Run("SpreadsKeys", func(t *testing.T) {
+		m := NewShardedMap[int, int](4, nil)
+		entries := make(map[int]int)
+		for i := range 100 {
+			entries[i] = i * i
+		}
+		m.SetMany(entries)
+		assert.Equal(t, 100, m.Len())
+		assert.Equal(t, entries, m.GetAll())
+
+		used := 0
+		for i := range m.shards {
+			if m.shards[i].Len() > 0 {
+				used++
+			}
+		}
+		assert.Equal(t, 4, used)
+
+		// Range stops across shard boundaries
+		visited := 0
+		m.Range(func(_, _ int) bool {
+			visited++
+			return visited < 50
+		})
+		assert.Equal(t, 50, visited)
+
+		m.Clear()
+		assert.Zero(t, m.Len())
+	})
+
+	t.Run("Resharding", func(t *testing.T) {
+		m := NewShardedMap[int, int](4, nil)
+		entries := make(map[int]int)
+		for i := range 100 {
+			entries[i] = i * i
+		}
+		m.SetMany(entries)
+		m.Resharding(3)
+		assert.Equal(t, 3, m.Shards())
+		assert.Equal(t, entries, m.GetAll())
+		for i := range 100 {
+			v, ok := m.Get(i)
+			assert.True(t, ok)
+			assert.Equal(t, i*i, v)
+		}
+
+		var zero ShardedMap[int, int]
+		zero.Resharding(2)
+		zero.Set(1, 2)
+		assert.Equal(t, 2, zero.Shards())
+		assert.Equal(t, map[int]int{1: 2}, zero.GetAll())
+	})
+
+	t.Run("ConcurrentResharding", func(t *testing.T) {
+		m := NewShardedMap[int, int](2, nil)
+		var wg sync.WaitGroup
+		for g := range 4 {
+			wg.Go(func() {
+				for i := range 100 {
+					m.Set(g*100+i, i)
+					m.GetOrCompute(-g*100-i-1, func() int { return i })
+				}
+			})
+		}
+		for n := range 8 {
+			m.Resharding(n + 1)
+		}
+		wg.Wait()
+		assert.Equal(t, 800, m.Len())
+	})
+}
+
+func TestOrderedMap(t *testing.T) {
+	var m OrderedMap[string, int]
+	_, _, ok := m.Oldest()
+	assert.False(t, ok)
+	assert.Empty(t, slices.Collect(m.Keys()))
+
+	for i, key := range []string{"c", "a", "d", "b"} {
+		m.Set(key, i)
+	}
+	assert.Equal(t, []string{"c", "a", "d", "b"}, slices.Collect(m.Keys()))
+	assert.Equal(t, []int{0, 1, 2, 3}, slices.Collect(m.Values()))
+
+	// Updates keep the position, re-insertions move to the back
+	m.Set("c", 10)
+	assert.True(t, m.CompareAndSwap("a", 1, 11))
+	m.Delete("d")
+	m.Set("d", 12)
+	var keys []string
+	m.Range(func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Equal(t, []string{"c", "a", "b", "d"}, keys)
+	assert.Equal(t, map[string]int{"a": 11, "b": 3, "c": 10, "d": 12}, m.GetAll())
+
+	key, value, ok := m.Oldest()
+	assert.True(t, ok)
+	assert.Equal(t, "c", key)
+	assert.Equal(t, 10, value)
+	assert.True(t, m.MoveToBack("c"))
+	assert.False(t, m.MoveToBack("x"))
+	assert.Equal(t, []string{"a", "b", "d", "c"}, slices.Collect(m.Keys()))
+	assert.Equal(t, "OrderedMap(len=4)map[a:11 b:3 d:12 c:10]", m.String())
+
+	m.Clear()
+	assert.Zero(t, m.Len())
+	m.Set("z", 1)
+	assert.Equal(t, []string{"z"}, slices.Collect(m.Keys()))
+}
+
+func TestSortedMap(t *testing.T) {
+	var m SortedMap[int, int]
+	_, _, ok := m.Min()
+	assert.False(t, ok)
+	_, _, ok = m.Max()
+	assert.False(t, ok)
+	keys, _ := collectSeq2(m.Ascend(0, 10))
+	assert.Empty(t, keys)
+
+	// Random writes, checked against a Go map
+	reference := make(map[int]int)
+	for i := range 2000 {
+		key := rand.IntN(200)
+		if i%3 == 0 {
+			m.Delete(key)
+			delete(reference, key)
+		} else {
+			m.Set(key, i)
+			reference[key] = i
+		}
+	}
+	sorted := slices.Sorted(maps.Keys(reference))
+	assert.Equal(t, len(reference), m.Len())
+	assert.Equal(t, reference, m.GetAll())
+	assert.Equal(t, sorted, slices.Collect(m.Keys()))
+
+	minKey, minValue, ok := m.Min()
+	assert.True(t, ok)
+	assert.Equal(t, sorted[0], minKey)
+	assert.Equal(t, reference[minKey], minValue)
+	maxKey, _, ok := m.Max()
+	assert.True(t, ok)
+	assert.Equal(t, sorted[len(sorted)-1], maxKey)
+
+	// Ranges are inclusive, whether or not their bounds are keys
+	var want []int
+	for _, key := range sorted {
+		if key >= 50 && key <= 150 {
+			want = append(want, key)
+		}
+	}
+	keys, values := collectSeq2(m.Ascend(50, 150))
+	assert.Equal(t, want, keys)
+	assert.Equal(t, reference[want[0]], values[0])
+	slices.Reverse(want)
+	keys, _ = collectSeq2(m.Descend(150, 50))
+	assert.Equal(t, want, keys)
+	keys, _ = collectSeq2(m.Ascend(minKey, maxKey))
+	assert.Equal(t, sorted, keys)
+	keys, _ = collectSeq2(m.Ascend(150, 50))
+	assert.Empty(t, keys)
+	keys, _ = collectSeq2(m.Descend(50, 150))
+	assert.Empty(t, keys)
+
+	m.Clear()
+	assert.Zero(t, m.Len())
+	m.Set(1, 1)
+	assert.Equal(t, []int{1}, slices.Collect(m.Keys()))
+}
+
+func TestTTLMap(t *testing.T) {
+	m := NewTTLMap[string, int](time.Hour, 0, nil)
+	var evicted []string
+	m.OnEvict = func(key string, _ int) { evicted = append(evicted, key) }
+	m.Set("default", 1)
+	m.SetWithTTL("forever", 2, 0)
+	m.SetWithTTL("short", 3, time.Millisecond)
+	m.SetWithTTL("overwritten", 4, time.Millisecond)
+
+	remaining, ok := m.TTL("default")
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour, remaining, float64(time.Minute))
+	remaining, ok = m.TTL("forever")
+	assert.True(t, ok)
+	assert.Zero(t, remaining)
+
+	// Expired entries are invisible before they are removed
+	time.Sleep(5 * time.Millisecond)
+	_, ok = m.Get("short")
+	assert.False(t, ok)
+	_, ok = m.TTL("short")
+	assert.False(t, ok)
+	assert.Equal(t, 2, m.Len())
+	assert.Equal(t, map[string]int{"default": 1, "forever": 2}, m.GetAll())
+	m.Range(func(key string, _ int) bool {
+		assert.NotEqual(t, "short", key)
+		return true
+	})
+	assert.Empty(t, evicted)
+
+	// Writes to an expired key report it before replacing it
+	_, loaded := m.LoadOrStore("overwritten", 5)
+	assert.False(t, loaded)
+	assert.Equal(t, []string{"overwritten"}, evicted)
+	assert.Equal(t, 1, m.DeleteExpired())
+	assert.Equal(t, []string{"overwritten", "short"}, evicted)
+	assert.Equal(t, 3, m.Len())
+	m.Close()
+}
+
+func TestTTLMapJanitor(t *testing.T) {
+	m := NewTTLMap[int, int](time.Millisecond, time.Millisecond, nil)
+	defer m.Close()
+	expired := make(chan int, 10)
+	m.OnEvict = func(key, _ int) { expired <- key }
+	m.Set(1, 1)
+	m.SetWithTTL(2, 2, time.Hour)
+
+	select {
+	case key := <-expired:
+		assert.Equal(t, 1, key)
+	case <-time.After(time.Second):
+		t.Fatal("janitor did not remove the expired entry")
+	}
+	assert.Equal(t, []int{2}, slices.Collect(m.Keys()))
+
+	m.Close()
+	m.Close()
+	var zero TTLMap[int, int]
+	zero.Set(1, 1)
+	zero.Close()
+	assert.Equal(t, 1, zero.Len())
+}
+
 func TestCalculateMapDiff(t *testing.T) {
 	// Test empty maps
 	diff := CalculateMapDiff(
@@ -511,15 +1109,65 @@ func TestConcurrentAccess(t *testing.T) {
 				return NewSyncMap[string](func(a, b int) bool { return a == b })
 			},
 		},
+		{
+			name: "ImmutableMap",
+			newMap: func() Map[string, int] {
+				return NewImmutableMap[string](func(a, b int) bool { return a == b })
+			},
+		},
+		{
+			name: "ShardedMap",
+			newMap: func() Map[string, int] {
+				return NewShardedMap[string](0, func(a, b int) bool { return a == b })
+			},
+		},
+		{
+			name: "OrderedMap",
+			newMap: func() Map[string, int] {
+				return NewOrderedMap[string](func(a, b int) bool { return a == b })
+			},
+		},
+		{
+			name: "SortedMap",
+			newMap: func() Map[string, int] {
+				return NewSortedMap[string](func(a, b int) bool { return a == b })
+			},
+		},
+		{
+			name: "TTLMap",
+			newMap: func() Map[string, int] {
+				return NewTTLMap[string](time.Hour, 0, func(a, b int) bool { return a == b })
+			},
+		},
 	}
 
 	for _, tt := range implementations {
 		t.Run(tt.name, func(t *testing.T) {
 			testConcurrentMapAccess(t, tt.newMap())
+			if tt.name != "SyncMap" { // SyncMap applies UpdateMany one key at a time
+				testConcurrentUpdateMany(t, tt.newMap())
+			}
 		})
 	}
 }
 
+// testConcurrentUpdateMany checks that concurrent read-modify-write batches lose no updates.
+func testConcurrentUpdateMany(t *testing.T, store Map[string, int]) {
+	keys := []string{"a", "b", "c", "a"}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 200 {
+				store.UpdateMany(keys, func(_ string, value int, _ bool) (int, bool) {
+					return value + 1, true
+				})
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, map[string]int{"a": 3200, "b": 1600, "c": 1600}, store.GetAll())
+}
+
 func TestMapZeroValue(t *testing.T) {
 	t.Run("RWMutexMap", func(t *testing.T) {
 		var m RWMutexMap[string, int]
@@ -599,6 +1247,25 @@ func TestMapZeroValue(t *testing.T) {
 		assert.False(t, ok)
 		assert.Equal(t, 0, m2.Len())
 	})
+
+	t.Run("CompareAndSwap", func(t *testing.T) {
+		// Without an equal function, values are compared with ==
+		var m MutexMap[string, int]
+		m.Set("key", 1)
+		assert.False(t, m.CompareAndSwap("key", 2, 3))
+		assert.True(t, m.CompareAndSwap("key", 1, 3))
+
+		var rw RWMutexMap[string, int]
+		assert.False(t, rw.CompareAndSwap("key", 0, 1))
+		rw.Set("key", 1)
+		assert.True(t, rw.CompareAndSwap("key", 1, 3))
+		val, _ := rw.Get("key")
+		assert.Equal(t, 3, val)
+
+		var slices RWMutexMap[string, []int]
+		slices.Set("key", nil)
+		assert.Panics(t, func() { slices.CompareAndSwap("key", nil, []int{1}) })
+	})
 }
 
 //
@@ -669,6 +1336,36 @@ func BenchmarkMapImplementations(b *testing.B) {
 			return NewSyncMap[string](func(a, b int) bool { return a == b })
 		})
 	})
+
+	b.Run("ImmutableMap", func(b *testing.B) {
+		benchmarkMap(b, func() Map[string, int] {
+			return NewImmutableMap[string](func(a, b int) bool { return a == b })
+		})
+	})
+
+	b.Run("ShardedMap", func(b *testing.B) {
+		benchmarkMap(b, func() Map[string, int] {
+			return NewShardedMap[string](0, func(a, b int) bool { return a == b })
+		})
+	})
+
+	b.Run("OrderedMap", func(b *testing.B) {
+		benchmarkMap(b, func() Map[string, int] {
+			return NewOrderedMap[string](func(a, b int) bool { return a == b })
+		})
+	})
+
+	b.Run("SortedMap", func(b *testing.B) {
+		benchmarkMap(b, func() Map[string, int] {
+			return NewSortedMap[string](func(a, b int) bool { return a == b })
+		})
+	})
+
+	b.Run("TTLMap", func(b *testing.B) {
+		benchmarkMap(b, func() Map[string, int] {
+			return NewTTLMap[string](time.Hour, 0, func(a, b int) bool { return a == b })
+		})
+	})
 }
 
 func BenchmarkMapIterationPatterns(b *testing.B) {
@@ -0,0 +1,439 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"sync"
+	"time"
+)
+
+// ttlEntry is an entry of a TTLMap.
+type ttlEntry[V any] struct {
+	value   V
+	expires time.Time // zero if the entry never expires
+}
+
+// expired reports whether the entry has outlived its TTL at time now.
+func (e ttlEntry[V]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// TTLMap is a thread-safe implementation of Map whose entries expire after a time to live, using
+// sync.RWMutex. Set applies the default TTL of the map, SetWithTTL a TTL of its own.
+//
+// Expired entries are never visible: Get, Len, Range and the other reads skip them as if they had
+// been deleted. They are removed by a background janitor goroutine, started by NewTTLMap with a
+// cleanup interval, by DeleteExpired, or when a write finds them under its key. Until then they
+// hold memory, and Len, which counts the live entries, is O(n).
+//
+// The zero value is ready to use, with no default TTL and no janitor, comparing values with == in
+// CompareAndSwap. A map with a janitor must be closed with Close to stop it.
+type TTLMap[K comparable, V any] struct {
+	// OnEvict, if set, is called with every entry removed from the map because it expired, other
+	// than through Clear. It is called after the lock is released, so it may call back into the
+	// map. It must be set before the map is used.
+	OnEvict func(key K, value V)
+
+	mu      sync.RWMutex
+	entries map[K]ttlEntry[V]
+	ttl     time.Duration
+	equal   func(V, V) bool
+
+	done   chan struct{} // nil without a janitor
+	exited chan struct{}
+	once   sync.Once
+
+	computing computeCalls[K] // GetOrCompute calls in progress
+}
+
+// NewTTLMap creates a new, empty TTLMap whose entries expire after ttl by default, or never if
+// ttl is 0 or less. If cleanupInterval is positive, a janitor goroutine removes expired entries
+// at that interval until Close is called. equalFn, if not nil, decides whether two values are
+// equal in CompareAndSwap.
+func NewTTLMap[K comparable, V any](
+	ttl, cleanupInterval time.Duration,
+	equalFn func(V, V) bool,
+) *TTLMap[K, V] {
+	m := &TTLMap[K, V]{
+		entries: make(map[K]ttlEntry[V]),
+		ttl:     max(ttl, 0),
+		equal:   equalFn,
+	}
+	if cleanupInterval > 0 {
+		m.done = make(chan struct{})
+		m.exited = make(chan struct{})
+		go m.janitor(cleanupInterval)
+	}
+	return m
+}
+
+// Get retrieves the value for the given key, if present and not expired.
+func (m *TTLMap[K, V]) Get(key K) (V, bool) {
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, ok := m.entries[key]; ok && !e.expired(now) {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// TTL returns the time left before the entry for key expires. It returns ok == false if the key
+// is absent or expired, and a remaining duration of 0 if the entry never expires.
+func (m *TTLMap[K, V]) TTL(key K) (remaining time.Duration, ok bool) {
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, found := m.entries[key]
+	switch {
+	case !found || e.expired(now):
+		return 0, false
+	case e.expires.IsZero():
+		return 0, true
+	}
+	return e.expires.Sub(now), true
+}
+
+// Set stores a value for the given key, expiring after the default TTL of the map.
+func (m *TTLMap[K, V]) Set(key K, value V) {
+	m.SetWithTTL(key, value, m.ttl)
+}
+
+// SetWithTTL stores a value for the given key, expiring after ttl instead of the default TTL, or
+// never if ttl is 0 or less.
+func (m *TTLMap[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	now := time.Now()
+	m.mu.Lock()
+	expired := m.dropExpiredLocked(key, now)
+	m.setLocked(key, value, now, ttl)
+	m.mu.Unlock()
+	m.notify(expired)
+}
+
+// Delete removes the key from the map.
+func (m *TTLMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// DeleteExpired removes all expired entries, and returns how many were removed.
+func (m *TTLMap[K, V]) DeleteExpired() int {
+	now := time.Now()
+	m.mu.Lock()
+	var expired []MapEntry[K, V]
+	for key, e := range m.entries {
+		if e.expired(now) {
+			delete(m.entries, key)
+			expired = append(expired, MapEntry[K, V]{Key: key, Value: e.value})
+		}
+	}
+	m.mu.Unlock()
+	m.notify(expired)
+	return len(expired)
+}
+
+// Len returns the number of live items in the map. It is O(n).
+func (m *TTLMap[K, V]) Len() int {
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	n := 0
+	for _, e := range m.entries {
+		if !e.expired(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// Clear removes all items from the map.
+func (m *TTLMap[K, V]) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[K]ttlEntry[V])
+}
+
+// CompareAndSwap sets the value of key to newValue if its current value equals oldValue, and
+// reports whether it did. The entry keeps its expiry time. Without an equalFn, values are
+// compared with ==, which panics if V is not comparable.
+func (m *TTLMap[K, V]) CompareAndSwap(key K, oldValue, newValue V) bool {
+	now := time.Now()
+	m.mu.Lock()
+	expired := m.dropExpiredLocked(key, now)
+	e, ok := m.entries[key]
+	swapped := ok && (m.equal != nil && m.equal(e.value, oldValue) ||
+		m.equal == nil && any(e.value) == any(oldValue))
+	if swapped {
+		e.value = newValue
+		m.entries[key] = e
+	}
+	m.mu.Unlock()
+	m.notify(expired)
+	return swapped
+}
+
+// Swap stores a value for the key with the default TTL, and returns the previous value if any.
+func (m *TTLMap[K, V]) Swap(key K, value V) (V, bool) {
+	now := time.Now()
+	m.mu.Lock()
+	expired := m.dropExpiredLocked(key, now)
+	previous, loaded := m.entries[key]
+	m.setLocked(key, value, now, m.ttl)
+	m.mu.Unlock()
+	m.notify(expired)
+	return previous.value, loaded
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores the given
+// value with the default TTL and returns it. The loaded result is true if the value was loaded,
+// false if stored.
+func (m *TTLMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	now := time.Now()
+	m.mu.Lock()
+	expired := m.dropExpiredLocked(key, now)
+	e, loaded := m.entries[key]
+	if loaded {
+		value = e.value
+	} else {
+		m.setLocked(key, value, now, m.ttl)
+	}
+	m.mu.Unlock()
+	m.notify(expired)
+	return value, loaded
+}
+
+// GetOrCompute returns the value for the key, calling compute to store it with the default TTL
+// if absent.
+func (m *TTLMap[K, V]) GetOrCompute(key K, compute func() V) (V, bool) {
+	return getOrCompute(m, &m.computing, key, compute)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+func (m *TTLMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	now := time.Now()
+	m.mu.Lock()
+	expired := m.dropExpiredLocked(key, now)
+	e, loaded := m.entries[key]
+	delete(m.entries, key)
+	m.mu.Unlock()
+	m.notify(expired)
+	return e.value, loaded
+}
+
+// GetAll returns all live key-value pairs in the map.
+func (m *TTLMap[K, V]) GetAll() map[K]V {
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	all := make(map[K]V, len(m.entries))
+	for key, e := range m.entries {
+		if !e.expired(now) {
+			all[key] = e.value
+		}
+	}
+	return all
+}
+
+// GetMany retrieves select live key-value pairs.
+func (m *TTLMap[K, V]) GetMany(keys []K) map[K]V {
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if e, ok := m.entries[key]; ok && !e.expired(now) {
+			result[key] = e.value
+		}
+	}
+	return result
+}
+
+// SetMany sets multiple key-value pairs, expiring after the default TTL of the map.
+func (m *TTLMap[K, V]) SetMany(entries map[K]V) {
+	now := time.Now()
+	m.mu.Lock()
+	var expired []MapEntry[K, V]
+	for key, value := range entries {
+		expired = append(expired, m.dropExpiredLocked(key, now)...)
+		m.setLocked(key, value, now, m.ttl)
+	}
+	m.mu.Unlock()
+	m.notify(expired)
+}
+
+// DeleteMany removes the keys from the map under a single lock acquisition, and returns how many
+// of them were present and not expired.
+func (m *TTLMap[K, V]) DeleteMany(keys []K) int {
+	now := time.Now()
+	m.mu.Lock()
+	var expired []MapEntry[K, V]
+	removed := 0
+	for _, key := range keys {
+		expired = append(expired, m.dropExpiredLocked(key, now)...)
+		if _, ok := m.entries[key]; ok {
+			delete(m.entries, key)
+			removed++
+		}
+	}
+	m.mu.Unlock()
+	m.notify(expired)
+	return removed
+}
+
+// UpdateMany calls f for each of live keys with its current value, if loaded, and stores the new
+// value f returns with the default TTL if keep is true, or deletes the key otherwise, all under a
+// single lock acquisition. f must not call back into the map.
+func (m *TTLMap[K, V]) UpdateMany(keys []K, f func(key K, value V, loaded bool) (V, bool)) {
+	now := time.Now()
+	m.mu.Lock()
+	var expired []MapEntry[K, V]
+	for _, key := range keys {
+		expired = append(expired, m.dropExpiredLocked(key, now)...)
+		e, loaded := m.entries[key]
+		if newValue, keep := f(key, e.value, loaded); keep {
+			m.setLocked(key, newValue, now, m.ttl)
+		} else if loaded {
+			delete(m.entries, key)
+		}
+	}
+	m.mu.Unlock()
+	m.notify(expired)
+}
+
+// Equals reports whether the logical content of this map and the other map is the same.
+func (m *TTLMap[K, V]) Equals(other Map[K, V], equalFn func(a, b V) bool) bool {
+	return equals(m, other, equalFn)
+}
+
+// Range calls f sequentially for each live key and value present in the map, holding the read
+// lock. If f returns false, range stops the iteration.
+func (m *TTLMap[K, V]) Range(f func(key K, value V) bool) {
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for key, e := range m.entries {
+		if !e.expired(now) && !f(key, e.value) {
+			return
+		}
+	}
+}
+
+// All returns an iterator over the live key-value pairs in the map. The iteration order is not
+// guaranteed to be consistent. Note: since this snapshots before iteration, Range is more
+// performant.
+func (m *TTLMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for key, value := range m.GetAll() {
+			if !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the live keys in the map. The iteration order is not guaranteed
+// to be consistent. Note: since this snapshots before iteration, Range is more performant.
+func (m *TTLMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for key := range m.GetAll() {
+			if !yield(key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the live values in the map. The iteration order is not
+// guaranteed to be consistent. Note: since this snapshots before iteration, Range is more
+// performant.
+func (m *TTLMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, value := range m.GetAll() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first entries of the map, for logging and debugging.
+func (m *TTLMap[K, V]) String() string {
+	return formatEntries(m, m.Len(), m.All())
+}
+
+// GoString returns the Go type, length and first entries of the map, for the %#v verb.
+func (m *TTLMap[K, V]) GoString() string {
+	return goFormatEntries(m, m.Len(), m.All())
+}
+
+// AsReadOnly returns a read-only view of the map, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (m *TTLMap[K, V]) AsReadOnly() ReadOnlyMap[K, V] {
+	return readOnlyMap[K, V]{m}
+}
+
+// Close stops the janitor goroutine, if any, and waits for it to exit. The map remains usable,
+// with expired entries removed only by DeleteExpired and writes. Close is idempotent.
+func (m *TTLMap[K, V]) Close() {
+	if m.done == nil {
+		return
+	}
+	m.once.Do(func() {
+		close(m.done)
+		<-m.exited
+	})
+}
+
+// Ensure TTLMap implements Map.
+var _ Map[any, any] = (*TTLMap[any, any])(nil)
+
+// Internal helpers
+
+// setLocked stores value under key, expiring after ttl from now, or never if ttl is 0 or less.
+// Callers must hold the write lock.
+func (m *TTLMap[K, V]) setLocked(key K, value V, now time.Time, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+	if m.entries == nil {
+		m.entries = make(map[K]ttlEntry[V])
+	}
+	m.entries[key] = ttlEntry[V]{value: value, expires: expires}
+}
+
+// dropExpiredLocked removes the entry for key if it has expired at time now, and returns it for
+// notify. Callers must hold the write lock.
+func (m *TTLMap[K, V]) dropExpiredLocked(key K, now time.Time) []MapEntry[K, V] {
+	e, ok := m.entries[key]
+	if !ok || !e.expired(now) {
+		return nil
+	}
+	delete(m.entries, key)
+	return []MapEntry[K, V]{{Key: key, Value: e.value}}
+}
+
+// notify calls OnEvict with each of the expired entries. Callers must not hold the lock.
+func (m *TTLMap[K, V]) notify(expired []MapEntry[K, V]) {
+	if m.OnEvict == nil {
+		return
+	}
+	for _, entry := range expired {
+		m.OnEvict(entry.Key, entry.Value)
+	}
+}
+
+// janitor removes expired entries every interval until Close is called.
+func (m *TTLMap[K, V]) janitor(interval time.Duration) {
+	defer close(m.exited)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.DeleteExpired()
+		case <-m.done:
+			return
+		}
+	}
+}
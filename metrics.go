@@ -0,0 +1,217 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counter is a monotonically increasing count of events, such as requests served, updated with
+// atomics so Add never locks.
+//
+// Rate returns the average increase per second since the previous call to Rate, so a single
+// reporter, e.g. a metrics scraper calling it at its interval, observes the rate over each of its
+// windows. Concurrent reporters split the windows between them; use a SlidingWindowCounter for
+// rates over a fixed window instead.
+//
+// The zero value is ready to use; its first rate window starts on the first call to Rate. A
+// Counter must not be copied after first use.
+type Counter struct {
+	n    atomic.Uint64
+	rate rateWindow
+}
+
+// NewCounter creates a new Counter at zero, with its first rate window starting now.
+func NewCounter() *Counter {
+	c := &Counter{}
+	c.rate.at = time.Now()
+	return c
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.n.Add(1)
+}
+
+// Add increments the counter by n.
+func (c *Counter) Add(n uint64) {
+	c.n.Add(n)
+}
+
+// Load returns the current count.
+func (c *Counter) Load() uint64 {
+	return c.n.Load()
+}
+
+// Reset sets the counter back to zero and starts a new rate window.
+func (c *Counter) Reset() {
+	c.rate.reset(time.Now(), func() float64 {
+		c.n.Store(0)
+		return 0
+	})
+}
+
+// Rate returns the average increase of the counter per second since the previous call to Rate,
+// or since the counter was created or reset, and starts a new rate window. It returns 0 for the
+// first window of a zero value Counter, whose start is unknown.
+func (c *Counter) Rate() float64 {
+	return c.rate.next(time.Now(), func() float64 { return float64(c.n.Load()) })
+}
+
+// Gauge is a value that goes up and down, such as the number of items in flight, updated with
+// atomics so Add and Set never lock.
+//
+// Rate returns the average change per second since the previous call to Rate, with the same
+// single reporter semantics as Counter.Rate.
+//
+// The zero value is ready to use; its first rate window starts on the first call to Rate. A Gauge
+// must not be copied after first use.
+type Gauge struct {
+	v    atomic.Int64
+	rate rateWindow
+}
+
+// NewGauge creates a new Gauge at zero, with its first rate window starting now.
+func NewGauge() *Gauge {
+	g := &Gauge{}
+	g.rate.at = time.Now()
+	return g
+}
+
+// Add adds delta to the gauge, which decreases it if delta is negative.
+func (g *Gauge) Add(delta int64) {
+	g.v.Add(delta)
+}
+
+// Set sets the gauge to v.
+func (g *Gauge) Set(v int64) {
+	g.v.Store(v)
+}
+
+// Load returns the current value.
+func (g *Gauge) Load() int64 {
+	return g.v.Load()
+}
+
+// Reset sets the gauge back to zero and starts a new rate window.
+func (g *Gauge) Reset() {
+	g.rate.reset(time.Now(), func() float64 {
+		g.v.Store(0)
+		return 0
+	})
+}
+
+// Rate returns the average change of the gauge per second since the previous call to Rate, or
+// since the gauge was created or reset, and starts a new rate window. It returns 0 for the first
+// window of a zero value Gauge, whose start is unknown.
+func (g *Gauge) Rate() float64 {
+	return g.rate.next(time.Now(), func() float64 { return float64(g.v.Load()) })
+}
+
+// LabeledCounter is a set of Counters keyed by a label, such as requests per route, created on
+// first use. Counters are never removed except by Reset, so labels should come from a bounded
+// set.
+//
+// The zero value is ready to use.
+type LabeledCounter struct {
+	counters RWMutexMap[string, *Counter]
+}
+
+// NewLabeledCounter creates a new LabeledCounter without labels.
+func NewLabeledCounter() *LabeledCounter {
+	return &LabeledCounter{}
+}
+
+// Counter returns the Counter of label, creating it if needed. Holding on to it avoids the lookup
+// on hot paths.
+func (c *LabeledCounter) Counter(label string) *Counter {
+	if counter, ok := c.counters.Get(label); ok {
+		return counter
+	}
+	counter, _ := c.counters.LoadOrStore(label, NewCounter())
+	return counter
+}
+
+// Inc increments the counter of label by one.
+func (c *LabeledCounter) Inc(label string) {
+	c.Counter(label).Inc()
+}
+
+// Add increments the counter of label by n.
+func (c *LabeledCounter) Add(label string, n uint64) {
+	c.Counter(label).Add(n)
+}
+
+// Load returns the count of label, which is 0 for labels never counted.
+func (c *LabeledCounter) Load(label string) uint64 {
+	if counter, ok := c.counters.Get(label); ok {
+		return counter.Load()
+	}
+	return 0
+}
+
+// Len returns the number of labels.
+func (c *LabeledCounter) Len() int {
+	return c.counters.Len()
+}
+
+// Reset removes all labels and their counts.
+func (c *LabeledCounter) Reset() {
+	c.counters.Clear()
+}
+
+// All returns an iterator over the labels and their counts, in no particular order.
+func (c *LabeledCounter) All() iter.Seq2[string, uint64] {
+	return func(yield func(string, uint64) bool) {
+		for label, counter := range c.counters.All() {
+			if !yield(label, counter.Load()) {
+				return
+			}
+		}
+	}
+}
+
+// Rates returns the rate of each label as returned by Counter.Rate, starting a new rate window
+// for each.
+func (c *LabeledCounter) Rates() map[string]float64 {
+	rates := make(map[string]float64, c.counters.Len())
+	for label, counter := range c.counters.All() {
+		rates[label] = counter.Rate()
+	}
+	return rates
+}
+
+// Internal helpers
+
+// rateWindow tracks the start of the current rate window of a Counter or Gauge: the value at the
+// previous call to Rate, and when it was made. The zero value has no window started.
+type rateWindow struct {
+	mu    sync.Mutex
+	value float64
+	at    time.Time
+}
+
+// reset stores the value returned by store as the start of a new window, under the lock so that
+// concurrent Rate calls observe the reset and the new window together.
+func (w *rateWindow) reset(now time.Time, store func() float64) {
+	w.mu.Lock()
+	w.value, w.at = store(), now
+	w.mu.Unlock()
+}
+
+// next returns the rate of change per second from the start of the window to the value returned
+// by load, and starts a new window at that value. load is called under the lock so that a
+// concurrent reset falls entirely before or after it.
+func (w *rateWindow) next(now time.Time, load func() float64) float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	prev, at, value := w.value, w.at, load()
+	w.value, w.at = value, now
+	elapsed := now.Sub(at).Seconds()
+	if at.IsZero() || elapsed <= 0 {
+		return 0
+	}
+	return (value - prev) / elapsed
+}
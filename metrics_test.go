@@ -0,0 +1,100 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				c.Inc()
+			}
+			c.Add(10)
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(880), c.Load())
+
+	time.Sleep(10 * time.Millisecond)
+	rate := c.Rate()
+	assert.Greater(t, rate, 0.0)
+	assert.LessOrEqual(t, rate, 880/0.01)
+	assert.Zero(t, c.Rate(), "a new window starts at each call")
+
+	c.Add(5)
+	c.Reset()
+	assert.Zero(t, c.Load())
+	assert.Zero(t, c.Rate(), "a reset starts a new window")
+
+	var zero Counter
+	zero.Inc()
+	assert.Zero(t, zero.Rate(), "the first window of a zero value is unknown")
+}
+
+func TestGauge(t *testing.T) {
+	var g Gauge
+	g.Set(10)
+	g.Add(-4)
+	assert.Equal(t, int64(6), g.Load())
+	assert.Zero(t, g.Rate())
+
+	g.Add(-10)
+	time.Sleep(10 * time.Millisecond)
+	assert.Less(t, g.Rate(), 0.0)
+	g.Reset()
+	assert.Zero(t, g.Load())
+}
+
+func TestRateWindow(t *testing.T) {
+	var w rateWindow
+	start := time.Unix(100, 0)
+	value := 0.0
+	load := func() float64 { return value }
+
+	assert.Zero(t, w.next(start, load))
+	value = 30
+	assert.Equal(t, 15.0, w.next(start.Add(2*time.Second), load))
+	value = 20
+	assert.Equal(t, -10.0, w.next(start.Add(3*time.Second), load))
+	assert.Zero(t, w.next(start.Add(3*time.Second), load), "empty windows have no rate")
+	w.reset(start.Add(4*time.Second), func() float64 { return 0 })
+	value = 5
+	assert.Equal(t, 5.0, w.next(start.Add(5*time.Second), load))
+}
+
+func TestLabeledCounter(t *testing.T) {
+	var c LabeledCounter
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				c.Inc("get")
+			}
+			c.Add("post", 2)
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(400), c.Load("get"))
+	assert.Equal(t, uint64(8), c.Load("post"))
+	assert.Zero(t, c.Load("put"))
+	assert.Equal(t, 2, c.Len())
+	assert.Same(t, c.Counter("get"), c.Counter("get"))
+
+	counts := make(map[string]uint64)
+	for label, n := range c.All() {
+		counts[label] = n
+	}
+	assert.Equal(t, map[string]uint64{"get": 400, "post": 8}, counts)
+	assert.Len(t, c.Rates(), 2)
+
+	c.Reset()
+	assert.Zero(t, c.Len())
+	assert.Zero(t, c.Load("get"))
+}
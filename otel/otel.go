@@ -0,0 +1,154 @@
+// Package otel exports the instrumentation of threadsafe collections as OpenTelemetry metrics.
+//
+// Register is the one-line setup for a collection:
+//
+//	queue := threadsafe.NewRWMutexQueue[Job]()
+//	if _, err := otel.Register(meter, "jobs", queue); err != nil {
+//		return err
+//	}
+//
+// All metrics carry a "collection" attribute holding the name given to Register:
+//
+//   - threadsafe.size: gauge of the number of items, for collections with a Len method
+//   - threadsafe.ops: counter of operations, with an "op" attribute naming the method
+//   - threadsafe.op.duration: histogram of operation durations in seconds, with an "op" attribute
+//   - threadsafe.lock.wait: histogram of lock wait durations in seconds
+package otel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jkbrsn/threadsafe"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+)
+
+// durationBuckets are the default histogram boundaries in seconds, from 1µs to 1s, since
+// operations on in-memory collections are far shorter than the SDK defaults assume.
+var durationBuckets = []float64{
+	1e-6, 2.5e-6, 5e-6, 1e-5, 2.5e-5, 5e-5, 1e-4, 2.5e-4, 5e-4, 1e-3, 1e-2, 0.1, 1,
+}
+
+// Instrumentation is a threadsafe.Instrumentation recording operations and lock waits of one
+// collection as OpenTelemetry metrics.
+type Instrumentation struct {
+	ops        metric.Int64Counter
+	opDuration metric.Float64Histogram
+	lockWait   metric.Float64Histogram
+	name       string
+	attrs      metric.MeasurementOption // collection attribute only
+	opAttrs    sync.Map                 // op name -> metric.MeasurementOption
+}
+
+// NewInstrumentation creates the instruments for the collection called name on meter.
+func NewInstrumentation(meter metric.Meter, name string) (*Instrumentation, error) {
+	ops, err := meter.Int64Counter("threadsafe.ops",
+		metric.WithDescription("Number of operations on a threadsafe collection."),
+		metric.WithUnit("{operation}"))
+	if err != nil {
+		return nil, err
+	}
+	opDuration, err := meter.Float64Histogram("threadsafe.op.duration",
+		metric.WithDescription("Duration of operations on a threadsafe collection."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	if err != nil {
+		return nil, err
+	}
+	lockWait, err := meter.Float64Histogram("threadsafe.lock.wait",
+		metric.WithDescription("Time spent waiting for the lock of a threadsafe collection."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	if err != nil {
+		return nil, err
+	}
+	return &Instrumentation{
+		ops:        ops,
+		opDuration: opDuration,
+		lockWait:   lockWait,
+		name:       name,
+		attrs:      metric.WithAttributeSet(attribute.NewSet(collectionAttr(name))),
+	}, nil
+}
+
+// OnOp records an operation and its duration.
+func (i *Instrumentation) OnOp(op string, dur time.Duration) {
+	attrs := i.opAttributes(op)
+	ctx := context.Background()
+	i.ops.Add(ctx, 1, attrs)
+	i.opDuration.Record(ctx, dur.Seconds(), attrs)
+}
+
+// OnLockWait records a lock wait.
+func (i *Instrumentation) OnLockWait(dur time.Duration) {
+	i.lockWait.Record(context.Background(), dur.Seconds(), i.attrs)
+}
+
+// Register instruments collection c as name on meter, and reports its size through a gauge if it
+// has a Len method. The returned registration unregisters the gauge callback; it does not stop
+// the operation and lock wait measurements, which stop with c.Instrument(nil).
+//
+// Like c.Instrument, Register must be called before c is shared between goroutines.
+func Register(
+	meter metric.Meter,
+	name string,
+	c threadsafe.Instrumented,
+) (metric.Registration, error) {
+	ins, err := NewInstrumentation(meter, name)
+	if err != nil {
+		return nil, err
+	}
+	sized, ok := c.(interface{ Len() int })
+	if !ok {
+		c.Instrument(ins)
+		return noopRegistration{}, nil
+	}
+
+	size, err := meter.Int64ObservableGauge("threadsafe.size",
+		metric.WithDescription("Number of items in a threadsafe collection."),
+		metric.WithUnit("{item}"))
+	if err != nil {
+		return nil, err
+	}
+	reg, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(size, int64(sized.Len()), ins.attrs)
+		return nil
+	}, size)
+	if err != nil {
+		return nil, err
+	}
+	c.Instrument(ins)
+	return reg, nil
+}
+
+// Internal helpers
+
+// opAttributes returns the measurement option for operation op, caching it per op.
+func (i *Instrumentation) opAttributes(op string) metric.MeasurementOption {
+	if attrs, ok := i.opAttrs.Load(op); ok {
+		return attrs.(metric.MeasurementOption)
+	}
+	attrs := metric.WithAttributeSet(attribute.NewSet(
+		collectionAttr(i.name),
+		attribute.String("op", op),
+	))
+	actual, _ := i.opAttrs.LoadOrStore(op, attrs)
+	return actual.(metric.MeasurementOption)
+}
+
+func collectionAttr(name string) attribute.KeyValue {
+	return attribute.String("collection", name)
+}
+
+// noopRegistration is returned by Register for collections without a size gauge.
+type noopRegistration struct {
+	embedded.Registration
+}
+
+func (noopRegistration) Unregister() error { return nil }
+
+// Ensure Instrumentation implements threadsafe.Instrumentation.
+var _ threadsafe.Instrumentation = (*Instrumentation)(nil)
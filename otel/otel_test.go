@@ -0,0 +1,80 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRegister(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	q := threadsafe.NewRWMutexQueue[int]()
+	reg, err := Register(meter, "jobs", q)
+	require.NoError(t, err)
+	q.Push(1, 2)
+	q.Push(3)
+	q.Pop()
+
+	metrics := collect(t, reader)
+	collection := attribute.String("collection", "jobs")
+
+	size := metrics["threadsafe.size"].Data.(metricdata.Gauge[int64])
+	require.Len(t, size.DataPoints, 1)
+	assert.Equal(t, int64(2), size.DataPoints[0].Value)
+	assert.Equal(t, attribute.NewSet(collection), size.DataPoints[0].Attributes)
+
+	ops := map[string]int64{}
+	for _, dp := range metrics["threadsafe.ops"].Data.(metricdata.Sum[int64]).DataPoints {
+		op, _ := dp.Attributes.Value("op")
+		ops[op.AsString()] = dp.Value
+	}
+	// Reading the size gauge calls Len, which is an operation as well
+	assert.Equal(t, map[string]int64{"Push": 2, "Pop": 1, "Len": 1}, ops)
+
+	durations := metrics["threadsafe.op.duration"].Data.(metricdata.Histogram[float64])
+	assert.Len(t, durations.DataPoints, 3)
+	waits := metrics["threadsafe.lock.wait"].Data.(metricdata.Histogram[float64])
+	require.Len(t, waits.DataPoints, 1)
+	assert.Equal(t, uint64(4), waits.DataPoints[0].Count)
+
+	require.NoError(t, reg.Unregister())
+	_, ok := collect(t, reader)["threadsafe.size"]
+	assert.False(t, ok)
+}
+
+func TestRegisterWithoutLen(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	reg, err := Register(meter, "unsized", unsized{})
+	require.NoError(t, err)
+	assert.NoError(t, reg.Unregister())
+	_, ok := collect(t, reader)["threadsafe.size"]
+	assert.False(t, ok)
+}
+
+// unsized is an Instrumented collection without a Len method.
+type unsized struct{}
+
+func (unsized) Instrument(threadsafe.Instrumentation) {}
+
+// collect reads the metrics of reader by name.
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Metrics {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Metrics{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+	return metrics
+}
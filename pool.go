@@ -0,0 +1,66 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "sync"
+
+// Pool is a typed pool of reusable objects, such as buffers, wrapping sync.Pool so callers don't
+// need any conversions. Like sync.Pool, it is meant for pointer-like types: putting a plain value
+// type into the underlying sync.Pool allocates.
+//
+// An optional reset hook prepares items for reuse as they are put back. With a max-idle capacity,
+// idle items are kept in a bounded free list instead of sync.Pool: at most that many are
+// retained, items beyond it are dropped on Put, and retained items survive garbage collections.
+//
+// The zero value is not ready to use; construct via NewPool.
+type Pool[T any] struct {
+	newFn func() T
+	reset func(item T) T
+	pool  sync.Pool
+	idle  chan T // bounded free list, if a max-idle capacity is set
+}
+
+// NewPool creates a new Pool using newFn to create items when none are idle. reset, if not nil,
+// is applied to items on Put and returns the item to keep, e.g. a buffer truncated to zero
+// length. A maxIdle greater than zero bounds the number of idle items retained.
+func NewPool[T any](newFn func() T, reset func(item T) T, maxIdle int) *Pool[T] {
+	p := &Pool[T]{newFn: newFn, reset: reset}
+	if maxIdle > 0 {
+		p.idle = make(chan T, maxIdle)
+	}
+	return p
+}
+
+// Get returns an idle item, or a new one from newFn if there is none. If newFn is nil, the zero
+// value of T is returned instead.
+func (p *Pool[T]) Get() T {
+	if p.idle != nil {
+		select {
+		case item := <-p.idle:
+			return item
+		default:
+		}
+	} else if v := p.pool.Get(); v != nil {
+		return v.(T)
+	}
+	if p.newFn == nil {
+		var zero T
+		return zero
+	}
+	return p.newFn()
+}
+
+// Put resets item with the reset hook, if any, and makes it available to Get. The caller must
+// not use item afterwards.
+func (p *Pool[T]) Put(item T) {
+	if p.reset != nil {
+		item = p.reset(item)
+	}
+	if p.idle == nil {
+		p.pool.Put(item)
+		return
+	}
+	select {
+	case p.idle <- item:
+	default: // at max idle capacity
+	}
+}
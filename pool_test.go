@@ -0,0 +1,59 @@
+package threadsafe
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	var created atomic.Int32
+	p := NewPool(func() *bytes.Buffer {
+		created.Add(1)
+		return new(bytes.Buffer)
+	}, func(b *bytes.Buffer) *bytes.Buffer {
+		b.Reset()
+		return b
+	}, 0)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			b := p.Get()
+			assert.Equal(t, 0, b.Len())
+			b.WriteString("data")
+			p.Put(b)
+		})
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, created.Load(), int32(50))
+
+	// Without newFn, Get returns the zero value
+	assert.Nil(t, NewPool[*bytes.Buffer](nil, nil, 0).Get())
+}
+
+func TestPoolMaxIdle(t *testing.T) {
+	var created int
+	p := NewPool(func() []byte {
+		created++
+		return make([]byte, 0, 64)
+	}, func(b []byte) []byte { return b[:0] }, 2)
+
+	a, b, c := p.Get(), p.Get(), p.Get()
+	assert.Equal(t, 3, created)
+	a = append(a, 'x')
+	p.Put(a)
+	p.Put(b)
+	p.Put(c) // dropped: two items are already idle
+
+	reused := p.Get()
+	assert.Empty(t, reused)
+	assert.Equal(t, 64, cap(reused))
+	p.Get()
+	assert.Equal(t, 3, created)
+	p.Get()
+	assert.Equal(t, 4, created)
+}
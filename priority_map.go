@@ -0,0 +1,135 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "iter"
+
+// priorityMapEntry is a key-value pair of a PriorityMap.
+type priorityMapEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// PriorityMap is a thread-safe map whose entries are also ordered by value, so the entry with the
+// minimum value can be peeked or popped in addition to the usual keyed access, e.g. for deadline
+// tracking or expiring caches keyed by ID and ordered by expiry time.
+//
+// It is backed by a KeyedPriorityQueue, so every operation runs under a single lock and
+// PopMin/PopMinIf are atomic with respect to Set and Delete.
+//
+// The zero value is not ready to use; construct via NewPriorityMap. The less(a,b) comparator must
+// define a strict weak ordering (irreflexive, transitive, consistent).
+//
+// Complexity: Set/Delete/PopMin/UpdatePriority O(log n), Get/PeekMin O(1).
+type PriorityMap[K comparable, V any] struct {
+	pq *KeyedPriorityQueue[K, priorityMapEntry[K, V]]
+}
+
+// Get retrieves the value for the given key.
+func (m *PriorityMap[K, V]) Get(key K) (value V, loaded bool) {
+	e, ok := m.pq.Get(key)
+	return e.value, ok
+}
+
+// Contains reports whether the key is in the map.
+func (m *PriorityMap[K, V]) Contains(key K) bool {
+	return m.pq.Contains(key)
+}
+
+// Set stores a value for the given key, replacing any previous value and moving the entry to its
+// new position in the value order.
+func (m *PriorityMap[K, V]) Set(key K, value V) {
+	m.pq.Push(priorityMapEntry[K, V]{key: key, value: value})
+}
+
+// UpdatePriority replaces the value for the given key and moves the entry to its new position in
+// the value order. It returns false, leaving the map unchanged, if the key is not present.
+func (m *PriorityMap[K, V]) UpdatePriority(key K, value V) bool {
+	return m.pq.UpdatePriority(key, priorityMapEntry[K, V]{key: key, value: value})
+}
+
+// Delete removes the key from the map. If the key doesn't exist, Delete is a no-op.
+func (m *PriorityMap[K, V]) Delete(key K) {
+	m.pq.Remove(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+func (m *PriorityMap[K, V]) LoadAndDelete(key K) (previous V, loaded bool) {
+	e, ok := m.pq.Remove(key)
+	return e.value, ok
+}
+
+// PeekMin returns the entry with the minimum value per the comparator without removing it.
+func (m *PriorityMap[K, V]) PeekMin() (key K, value V, ok bool) {
+	e, ok := m.pq.Peek()
+	return e.key, e.value, ok
+}
+
+// PopMin removes and returns the entry with the minimum value per the comparator.
+func (m *PriorityMap[K, V]) PopMin() (key K, value V, ok bool) {
+	e, ok := m.pq.Pop()
+	return e.key, e.value, ok
+}
+
+// PopMinIf atomically removes and returns the entry with the minimum value only if pred accepts
+// it, e.g. when its deadline has passed. pred is called under the write lock and must not call
+// back into the map. If the map is empty or pred returns false, it returns ok == false.
+func (m *PriorityMap[K, V]) PopMinIf(
+	pred func(key K, value V) bool,
+) (key K, value V, ok bool) {
+	e, ok := m.pq.PopIf(func(min priorityMapEntry[K, V]) bool { return pred(min.key, min.value) })
+	return e.key, e.value, ok
+}
+
+// Len returns the number of entries in the map.
+func (m *PriorityMap[K, V]) Len() int {
+	return m.pq.Len()
+}
+
+// Clear removes all entries from the map.
+func (m *PriorityMap[K, V]) Clear() {
+	m.pq.Clear()
+}
+
+// All returns an iterator over a snapshot of the key-value pairs in the map.
+// The iteration order is not guaranteed to be consistent.
+func (m *PriorityMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for e := range m.pq.All() {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first entries of the map, for logging and debugging.
+func (m *PriorityMap[K, V]) String() string {
+	return formatEntries(m, m.Len(), m.All())
+}
+
+// GoString returns the Go type, length and first entries of the map, for the %#v verb.
+func (m *PriorityMap[K, V]) GoString() string {
+	return goFormatEntries(m, m.Len(), m.All())
+}
+
+// AllSorted returns an iterator over a snapshot of the key-value pairs in value order, without
+// modifying the map.
+func (m *PriorityMap[K, V]) AllSorted() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for e := range m.pq.AllSorted() {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// NewPriorityMap creates a new PriorityMap whose entries are ordered by value using less.
+func NewPriorityMap[K comparable, V any](less func(a, b V) bool) *PriorityMap[K, V] {
+	return &PriorityMap[K, V]{
+		pq: NewKeyedPriorityQueue(
+			func(a, b priorityMapEntry[K, V]) bool { return less(a.value, b.value) },
+			func(e priorityMapEntry[K, V]) K { return e.key },
+		),
+	}
+}
@@ -0,0 +1,81 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityMap(t *testing.T) {
+	m := NewPriorityMap[string](func(a, b int) bool { return a < b })
+	_, _, ok := m.PeekMin()
+	assert.False(t, ok)
+
+	m.Set("a", 30)
+	m.Set("b", 10)
+	m.Set("c", 20)
+	m.Set("a", 5) // replaces and reorders
+	assert.Equal(t, 3, m.Len())
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 5, v)
+
+	key, value, ok := m.PeekMin()
+	assert.True(t, ok)
+	assert.Equal(t, "a", key)
+	assert.Equal(t, 5, value)
+
+	assert.True(t, m.UpdatePriority("a", 40))
+	assert.False(t, m.UpdatePriority("missing", 1))
+	assert.False(t, m.Contains("missing"))
+
+	var keys []string
+	for k := range m.AllSorted() {
+		keys = append(keys, k)
+	}
+	assert.Equal(t, []string{"b", "c", "a"}, keys)
+
+	_, _, ok = m.PopMinIf(func(_ string, v int) bool { return v > 10 })
+	assert.False(t, ok)
+	key, value, ok = m.PopMin()
+	assert.True(t, ok)
+	assert.Equal(t, "b", key)
+	assert.Equal(t, 10, value)
+
+	previous, loaded := m.LoadAndDelete("c")
+	assert.True(t, loaded)
+	assert.Equal(t, 20, previous)
+	m.Delete("missing")
+	assert.Equal(t, 1, m.Len())
+
+	all := map[string]int{}
+	for k, v := range m.All() {
+		all[k] = v
+	}
+	assert.Equal(t, map[string]int{"a": 40}, all)
+	m.Clear()
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestPriorityMapConcurrent(t *testing.T) {
+	m := NewPriorityMap[int](func(a, b int) bool { return a < b })
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Go(func() {
+			for i := range 100 {
+				m.Set(w*100+i, i)
+				m.UpdatePriority(w*100+i, -i)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 800, m.Len())
+
+	prev := -100
+	for m.Len() > 0 {
+		_, v, _ := m.PopMin()
+		assert.GreaterOrEqual(t, v, prev)
+		prev = v
+	}
+}
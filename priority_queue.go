@@ -1,7 +1,10 @@
 // Package threadsafe implements thread-safe operations.
 package threadsafe
 
-import "iter"
+import (
+	"iter"
+	"time"
+)
 
 // PriorityQueue is a generic thread-safe priority queue interface (min-heap) for any type T.
 // Ordering is defined by the implementation at construction time via a comparator. Implementations
@@ -18,6 +21,11 @@ type PriorityQueue[T any] interface {
 	// If empty, returns ok == false and the zero value of T.
 	Peek() (item T, ok bool)
 
+	// PopIf atomically removes and returns the minimum only if pred accepts it, removing the race
+	// between Peek and Pop. If empty or pred returns false, returns ok == false and the zero value
+	// of T.
+	PopIf(pred func(min T) bool) (item T, ok bool)
+
 	// Len returns the number of items in the queue.
 	Len() int
 
@@ -36,6 +44,20 @@ type PriorityQueue[T any] interface {
 	//	    fmt.Println(item)
 	//	}
 	All() iter.Seq[T]
+
+	// AllSorted returns an iterator over a snapshot of the items in priority order, as Pop would
+	// return them, without modifying the queue. Stopping early only pays for the items yielded.
+	//
+	// Example usage:
+	//
+	//	for job := range myPQ.AllSorted() {
+	//	    fmt.Println(job) // next jobs first
+	//	}
+	AllSorted() iter.Seq[T]
+
+	// SliceSorted returns a copy of the items in priority order, as Pop would return them,
+	// without modifying the queue.
+	SliceSorted() []T
 }
 
 // PriorityQueueIndexed exposes index-based mutation helpers intended for advanced use-cases.
@@ -59,4 +81,145 @@ type PriorityQueueIndexed[T any] interface {
 	// UpdateAt replaces the element at index i with x and restores queue invariants.
 	// If i is out of range, it is a no-op and returns false.
 	UpdateAt(i int, x T) bool
+
+	// Find returns the first item in internal heap order for which pred returns true, together
+	// with its index. If no item matches, it returns ok == false and index -1.
+	Find(pred func(item T) bool) (item T, index int, ok bool)
+}
+
+// yieldSorted yields items in priority order per less, taking ownership of items. It heapifies
+// items in O(n) and then pops them one at a time, so stopping early costs O(n + k log n) for k
+// yielded items.
+func yieldSorted[T any](items []T, less func(a, b T) bool, yield func(T) bool) {
+	down := func(i, n int) {
+		for {
+			l := 2*i + 1
+			if l >= n {
+				return
+			}
+			smallest := l
+			if r := l + 1; r < n && less(items[r], items[l]) {
+				smallest = r
+			}
+			if !less(items[smallest], items[i]) {
+				return
+			}
+			items[i], items[smallest] = items[smallest], items[i]
+			i = smallest
+		}
+	}
+	n := len(items)
+	for i := n/2 - 1; i >= 0; i-- {
+		down(i, n)
+	}
+	for n > 0 {
+		if !yield(items[0]) {
+			return
+		}
+		n--
+		items[0] = items[n]
+		down(0, n)
+	}
+}
+
+// Indexed is implemented by priority queue items that track their own position in the heap.
+// IndexedPriorityQueue calls SetHeapIndex whenever an item moves, and with -1 once it leaves the
+// queue, so that callers can look up an item's index without an onSwap callback. Implementations
+// must be comparable, typically pointers, since a copy would not observe the updates.
+type Indexed interface {
+	// SetHeapIndex records the item's current index in the heap, or -1 if it was removed.
+	SetHeapIndex(i int)
+	// HeapIndex returns the index last recorded by SetHeapIndex.
+	HeapIndex() int
+}
+
+// PriorityQueueStats is a point-in-time snapshot of priority queue activity, for exposing queue
+// health without wrapping every operation.
+type PriorityQueueStats struct {
+	Pushes  uint64        // items pushed since construction, not counting restored snapshots
+	Pops    uint64        // items removed since construction, by Pop or RemoveAt
+	Len     int           // current number of items
+	MaxLen  int           // highest number of items held at once
+	AvgWait time.Duration // mean time removed items spent queued; zero unless wait tracking is on
+}
+
+// pqStats accumulates PriorityQueueStats for a binary heap. When wait tracking is enabled,
+// pushedAt holds the push time of each item and must mirror the heap array: every append, swap
+// and truncation of the items is applied to it as well. Callers must hold the write lock.
+type pqStats struct {
+	pushes    uint64
+	pops      uint64
+	maxLen    int
+	trackWait bool
+	pushedAt  []time.Time
+	waited    uint64 // pops timed since wait tracking was enabled
+	waitTotal time.Duration
+}
+
+// enableWait starts tracking wait times; n items already queued are stamped with the current
+// time.
+func (s *pqStats) enableWait(n int) {
+	if s.trackWait {
+		return
+	}
+	s.trackWait = true
+	now := time.Now()
+	s.pushedAt = make([]time.Time, n)
+	for i := range s.pushedAt {
+		s.pushedAt[i] = now
+	}
+}
+
+// push records an item appended to the heap, which now holds n items.
+func (s *pqStats) push(n int) {
+	s.pushes++
+	s.maxLen = max(s.maxLen, n)
+	if s.trackWait {
+		s.pushedAt = append(s.pushedAt, time.Now())
+	}
+}
+
+// replace records the heap contents replaced by n restored items, which are not counted as
+// pushes.
+func (s *pqStats) replace(n int) {
+	s.maxLen = max(s.maxLen, n)
+	if s.trackWait {
+		now := time.Now()
+		s.pushedAt = s.pushedAt[:0]
+		for range n {
+			s.pushedAt = append(s.pushedAt, now)
+		}
+	}
+}
+
+func (s *pqStats) swap(i, j int) {
+	if s.trackWait {
+		s.pushedAt[i], s.pushedAt[j] = s.pushedAt[j], s.pushedAt[i]
+	}
+}
+
+// pop records the removal of the item at the last index of the heap array.
+func (s *pqStats) pop(last int) {
+	s.pops++
+	if s.trackWait {
+		s.waited++
+		s.waitTotal += time.Since(s.pushedAt[last])
+		s.pushedAt = s.pushedAt[:last]
+	}
+}
+
+func (s *pqStats) clear() {
+	if s.trackWait {
+		s.pushedAt = s.pushedAt[:0]
+	}
+}
+
+// snapshot returns the stats of a heap currently holding n items. Callers must hold at least a
+// read lock.
+func (s *pqStats) snapshot(n int) PriorityQueueStats {
+	stats := PriorityQueueStats{Pushes: s.pushes, Pops: s.pops, Len: n, MaxLen: s.maxLen}
+	if s.waited > 0 {
+		stats.AvgWait = s.waitTotal / time.Duration(s.waited)
+	}
+	return stats
 }
@@ -0,0 +1,295 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+	"time"
+)
+
+// agingEntry is an item of an AgingPriorityQueue together with its scoring state.
+type agingEntry[T any] struct {
+	item     T
+	pushedAt time.Time
+	seq      uint64  // push order, used to keep items with equal scores in FIFO order
+	score    float64 // effective priority as of the last reweight
+}
+
+func lessAging[T any](a, b agingEntry[T]) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return a.seq < b.seq
+}
+
+// AgingPriorityQueue is a thread-safe binary min-heap whose effective priorities improve with the
+// time items spend queued, so that low-priority items in long-running schedulers can't starve
+// forever.
+//
+// Priorities are given by a caller-supplied score function of the item and the time it has
+// waited; lower scores pop first, and scores are expected to decrease as the wait grows, e.g.
+//
+//	score := func(j Job, waited time.Duration) float64 {
+//		return float64(j.Priority) - waited.Seconds()/10 // one level per 10s queued
+//	}
+//
+// Since scores change over time, the heap is periodically reweighted: when Pop, PopIf or Peek run
+// more than the reweight interval after the last reweight, all scores are recomputed and the heap
+// is rebuilt in O(n). Between reweights, queued items are ranked by their last computed score and
+// new items by their score at push time. A non-positive interval reweights on every Pop, PopIf
+// and Peek, which is exact but O(n).
+//
+// The zero value is not ready to use; construct via NewAgingPriorityQueue.
+//
+// Complexity: Push O(log n), Pop O(log n) plus O(n) per reweight, Peek O(1) plus O(n) per reweight.
+type AgingPriorityQueue[T any] struct {
+	mu         sync.RWMutex
+	entries    []agingEntry[T]
+	score      func(item T, waited time.Duration) float64
+	interval   time.Duration
+	reweighted time.Time
+	seq        uint64
+}
+
+// Push inserts one or more items into the queue.
+func (q *AgingPriorityQueue[T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	now := time.Now()
+	q.mu.Lock()
+	for _, x := range items {
+		q.seq++
+		q.entries = append(q.entries, agingEntry[T]{
+			item:     x,
+			pushedAt: now,
+			seq:      q.seq,
+			score:    q.score(x, 0),
+		})
+		q.up(len(q.entries) - 1)
+	}
+	q.mu.Unlock()
+}
+
+// Pop removes and returns the item with the lowest effective priority score.
+func (q *AgingPriorityQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.entries) == 0 {
+		return item, false
+	}
+	q.maybeReweightLocked()
+	return q.popLocked(), true
+}
+
+// PopIf atomically removes and returns the item with the lowest effective priority score only if
+// pred accepts it. pred is called under the write lock and must not call back into the queue. If
+// the queue is empty or pred returns false, it returns ok == false.
+func (q *AgingPriorityQueue[T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.entries) == 0 {
+		return item, false
+	}
+	q.maybeReweightLocked()
+	if !pred(q.entries[0].item) {
+		return item, false
+	}
+	return q.popLocked(), true
+}
+
+// Peek returns the item with the lowest effective priority score without removing it. It takes
+// the write lock, since it may reweight the queue.
+func (q *AgingPriorityQueue[T]) Peek() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.entries) == 0 {
+		return item, false
+	}
+	q.maybeReweightLocked()
+	return q.entries[0].item, true
+}
+
+// Reweight recomputes the scores of all items for their current wait and rebuilds the heap.
+func (q *AgingPriorityQueue[T]) Reweight() {
+	q.mu.Lock()
+	q.reweightLocked(time.Now())
+	q.mu.Unlock()
+}
+
+// Len returns the number of items.
+func (q *AgingPriorityQueue[T]) Len() int {
+	q.mu.RLock()
+	l := len(q.entries)
+	q.mu.RUnlock()
+	return l
+}
+
+// Clear removes all items.
+func (q *AgingPriorityQueue[T]) Clear() {
+	q.mu.Lock()
+	q.entries = nil
+	q.mu.Unlock()
+}
+
+// Range iterates over a snapshot of items in arbitrary internal order. Mutations during range
+// does not affect the current iteration.
+func (q *AgingPriorityQueue[T]) Range(f func(item T) bool) {
+	for item := range q.All() {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue in internal heap order (not sorted).
+// The iteration order is implementation-defined and not guaranteed to be priority-sorted.
+func (q *AgingPriorityQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := make([]T, len(q.entries))
+		for i, e := range q.entries {
+			snapshot[i] = e.item
+		}
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *AgingPriorityQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *AgingPriorityQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AllSorted returns an iterator over a snapshot of the items in order of their current effective
+// priority, without modifying the queue.
+func (q *AgingPriorityQueue[T]) AllSorted() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := make([]agingEntry[T], len(q.entries))
+		copy(snapshot, q.entries)
+		q.mu.RUnlock()
+
+		now := time.Now()
+		for i := range snapshot {
+			snapshot[i].score = q.score(snapshot[i].item, now.Sub(snapshot[i].pushedAt))
+		}
+		yieldSorted(snapshot, lessAging[T], func(e agingEntry[T]) bool {
+			return yield(e.item)
+		})
+	}
+}
+
+// SliceSorted returns a copy of the items in order of their current effective priority, without
+// modifying the queue.
+func (q *AgingPriorityQueue[T]) SliceSorted() []T {
+	return slices.Collect(q.AllSorted())
+}
+
+// Internal helpers (callers must hold write lock)
+
+// maybeReweightLocked reweights the queue if the reweight interval has elapsed.
+func (q *AgingPriorityQueue[T]) maybeReweightLocked() {
+	now := time.Now()
+	if q.interval > 0 && now.Sub(q.reweighted) < q.interval {
+		return
+	}
+	q.reweightLocked(now)
+}
+
+func (q *AgingPriorityQueue[T]) reweightLocked(now time.Time) {
+	for i := range q.entries {
+		e := &q.entries[i]
+		e.score = q.score(e.item, now.Sub(e.pushedAt))
+	}
+	for i := len(q.entries)/2 - 1; i >= 0; i-- {
+		q.down(i)
+	}
+	q.reweighted = now
+}
+
+// popLocked removes and returns the top item; the queue must not be empty.
+func (q *AgingPriorityQueue[T]) popLocked() T {
+	last := len(q.entries) - 1
+	q.swap(0, last)
+	item := q.entries[last].item
+	q.entries[last] = agingEntry[T]{}
+	q.entries = q.entries[:last]
+	if len(q.entries) > 0 {
+		q.down(0)
+	}
+	return item
+}
+
+func (q *AgingPriorityQueue[T]) lessIdx(i, j int) bool {
+	return lessAging(q.entries[i], q.entries[j])
+}
+
+func (q *AgingPriorityQueue[T]) swap(i, j int) {
+	if i == j {
+		return
+	}
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+}
+
+func (q *AgingPriorityQueue[T]) up(i int) {
+	idx := i
+	for {
+		p := (idx - 1) / 2
+		if idx == 0 || !q.lessIdx(idx, p) {
+			break
+		}
+		q.swap(idx, p)
+		idx = p
+	}
+}
+
+// down moves item at i down; returns true if moved down.
+func (q *AgingPriorityQueue[T]) down(i int) bool {
+	idx := i
+	n := len(q.entries)
+	moved := false
+	for {
+		l := 2*idx + 1
+		if l >= n {
+			break
+		}
+		smallest := l
+		r := l + 1
+		if r < n && q.lessIdx(r, l) {
+			smallest = r
+		}
+		if !q.lessIdx(smallest, idx) {
+			break
+		}
+		q.swap(idx, smallest)
+		idx = smallest
+		moved = true
+	}
+	return moved
+}
+
+// NewAgingPriorityQueue creates a new aging priority queue ranking items by score, where lower
+// scores pop first and scores should decrease as the time waited grows. The queue is reweighted
+// at most once per reweightInterval; a non-positive interval reweights on every Pop and Peek.
+func NewAgingPriorityQueue[T any](
+	score func(item T, waited time.Duration) float64,
+	reweightInterval time.Duration,
+) *AgingPriorityQueue[T] {
+	return &AgingPriorityQueue[T]{
+		score:      score,
+		interval:   reweightInterval,
+		reweighted: time.Now(),
+	}
+}
@@ -0,0 +1,317 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"iter"
+	"math/bits"
+	"slices"
+	"sync"
+)
+
+// BoundedPriorityQueue is a thread-safe priority queue holding at most a fixed number of items.
+// Pushing into a full queue evicts the lowest-priority item, which may be the pushed item itself,
+// so the queue always retains the best items seen. This suits top-K and best-effort scheduling
+// workloads that must not grow without bound.
+//
+// It is backed by a min-max heap, so both the best and the worst item are reachable in O(1) and
+// removable in O(log n).
+//
+// The zero value is not ready to use; construct via NewBoundedPriorityQueue. The less(a,b)
+// comparator must define a strict weak ordering (irreflexive, transitive, consistent).
+//
+// Complexity: Push/Pop/PopWorst O(log n), Peek/PeekWorst O(1); Range does not mutate the heap.
+type BoundedPriorityQueue[T any] struct {
+	mu       sync.RWMutex
+	items    []T
+	less     func(a, b T) bool
+	capacity int
+	changed  broadcaster // signaled on push, for PopWait
+}
+
+// Push inserts one or more items into the queue, evicting the lowest-priority items if the queue
+// is full.
+func (q *BoundedPriorityQueue[T]) Push(items ...T) {
+	_ = q.PushEvict(items...)
+}
+
+// PushEvict inserts one or more items into the queue and returns the items evicted to respect the
+// capacity. A pushed item ranking no better than the worst item of a full queue is evicted
+// itself. It returns nil if nothing was evicted.
+func (q *BoundedPriorityQueue[T]) PushEvict(items ...T) (evicted []T) {
+	if len(items) == 0 {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, x := range items {
+		if len(q.items) == q.capacity {
+			w := q.worstIdx()
+			if !q.less(x, q.items[w]) {
+				evicted = append(evicted, x)
+				continue
+			}
+			evicted = append(evicted, q.removeAt(w))
+		}
+		q.items = append(q.items, x)
+		q.up(len(q.items) - 1)
+	}
+	q.changed.broadcast()
+	return evicted
+}
+
+// Pop removes and returns the minimum item per the comparator.
+func (q *BoundedPriorityQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return item, false
+	}
+	return q.removeAt(0), true
+}
+
+// PopWait removes and returns the minimum item per the comparator, blocking while the queue is
+// empty until ctx is done, in which case ctx.Err() is returned.
+func (q *BoundedPriorityQueue[T]) PopWait(ctx context.Context) (item T, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 {
+		if err := q.changed.waitLocked(ctx, &q.mu); err != nil {
+			return item, err
+		}
+	}
+	return q.removeAt(0), nil
+}
+
+// PopIf atomically removes and returns the minimum item only if pred accepts it, e.g. when its
+// deadline has passed. pred is called under the write lock and must not call back into the
+// queue. If the queue is empty or pred returns false, it returns ok == false.
+func (q *BoundedPriorityQueue[T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 || !pred(q.items[0]) {
+		return item, false
+	}
+	return q.removeAt(0), true
+}
+
+// Peek returns the minimum item without removing it.
+func (q *BoundedPriorityQueue[T]) Peek() (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if len(q.items) == 0 {
+		return item, false
+	}
+	return q.items[0], true
+}
+
+// PopWorst removes and returns the maximum item per the comparator, which is the next item that
+// would be evicted.
+func (q *BoundedPriorityQueue[T]) PopWorst() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return item, false
+	}
+	return q.removeAt(q.worstIdx()), true
+}
+
+// PeekWorst returns the maximum item per the comparator without removing it.
+func (q *BoundedPriorityQueue[T]) PeekWorst() (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if len(q.items) == 0 {
+		return item, false
+	}
+	return q.items[q.worstIdx()], true
+}
+
+// Len returns the number of items.
+func (q *BoundedPriorityQueue[T]) Len() int {
+	q.mu.RLock()
+	l := len(q.items)
+	q.mu.RUnlock()
+	return l
+}
+
+// Cap returns the maximum number of items the queue holds.
+func (q *BoundedPriorityQueue[T]) Cap() int {
+	return q.capacity
+}
+
+// Clear removes all items.
+func (q *BoundedPriorityQueue[T]) Clear() {
+	q.mu.Lock()
+	q.items = nil
+	q.mu.Unlock()
+}
+
+// Range iterates over a snapshot of items in arbitrary internal order. Mutations during range
+// does not affect the current iteration.
+func (q *BoundedPriorityQueue[T]) Range(f func(item T) bool) {
+	q.mu.RLock()
+	snap := make([]T, len(q.items))
+	copy(snap, q.items)
+	q.mu.RUnlock()
+	for _, it := range snap {
+		if !f(it) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue in internal heap order (not sorted).
+// The iteration order is implementation-defined and not guaranteed to be priority-sorted.
+func (q *BoundedPriorityQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := make([]T, len(q.items))
+		copy(snapshot, q.items)
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *BoundedPriorityQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *BoundedPriorityQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AllSorted returns an iterator over a snapshot of the items in priority order, without
+// modifying the queue.
+func (q *BoundedPriorityQueue[T]) AllSorted() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := make([]T, len(q.items))
+		copy(snapshot, q.items)
+		q.mu.RUnlock()
+		yieldSorted(snapshot, q.less, yield)
+	}
+}
+
+// SliceSorted returns a copy of the items in priority order, without modifying the queue.
+func (q *BoundedPriorityQueue[T]) SliceSorted() []T {
+	return slices.Collect(q.AllSorted())
+}
+
+// Internal helpers (callers must hold write lock)
+//
+// In the min-max heap, items on even levels are no worse than their descendants and items on odd
+// levels are no better than their descendants.
+
+// isMinLevel reports whether index i sits on an even (min) level.
+func isMinLevel(i int) bool {
+	return bits.Len(uint(i+1))%2 == 1
+}
+
+// worstIdx returns the index of the maximum item. The heap must not be empty.
+func (q *BoundedPriorityQueue[T]) worstIdx() int {
+	switch len(q.items) {
+	case 1:
+		return 0
+	case 2:
+		return 1
+	}
+	if q.less(q.items[1], q.items[2]) {
+		return 2
+	}
+	return 1
+}
+
+// removeAt removes and returns the item at index i, replacing it with the last item.
+func (q *BoundedPriorityQueue[T]) removeAt(i int) T {
+	last := len(q.items) - 1
+	item := q.items[i]
+	q.items[i] = q.items[last]
+	var zero T
+	q.items[last] = zero
+	q.items = q.items[:last]
+	if i < last {
+		q.down(i)
+	}
+	return item
+}
+
+// before reports whether the item at i must be above the item at j on a level of the given kind.
+func (q *BoundedPriorityQueue[T]) before(i, j int, minLevel bool) bool {
+	if minLevel {
+		return q.less(q.items[i], q.items[j])
+	}
+	return q.less(q.items[j], q.items[i])
+}
+
+func (q *BoundedPriorityQueue[T]) up(i int) {
+	if i == 0 {
+		return
+	}
+	p := (i - 1) / 2
+	minLevel := isMinLevel(i)
+	if q.before(i, p, !minLevel) {
+		// The item belongs on the levels of the other kind.
+		q.items[i], q.items[p] = q.items[p], q.items[i]
+		q.upLevels(p, !minLevel)
+		return
+	}
+	q.upLevels(i, minLevel)
+}
+
+// upLevels moves the item at i up through its grandparents, which sit on levels of the same kind.
+func (q *BoundedPriorityQueue[T]) upLevels(i int, minLevel bool) {
+	for i > 2 {
+		g := ((i-1)/2 - 1) / 2
+		if !q.before(i, g, minLevel) {
+			break
+		}
+		q.items[i], q.items[g] = q.items[g], q.items[i]
+		i = g
+	}
+}
+
+func (q *BoundedPriorityQueue[T]) down(i int) {
+	minLevel := isMinLevel(i)
+	n := len(q.items)
+	for {
+		// Find the best of the children and grandchildren for this level kind.
+		m := -1
+		for _, c := range [...]int{2*i + 1, 2*i + 2} {
+			if c >= n {
+				break
+			}
+			if m < 0 || q.before(c, m, minLevel) {
+				m = c
+			}
+			for _, g := range [...]int{2*c + 1, 2*c + 2} {
+				if g < n && q.before(g, m, minLevel) {
+					m = g
+				}
+			}
+		}
+		if m < 0 || !q.before(m, i, minLevel) {
+			return
+		}
+		q.items[i], q.items[m] = q.items[m], q.items[i]
+		if m <= 2*i+2 {
+			return // m is a child, so no grandchild ranks before the moved item
+		}
+		if p := (m - 1) / 2; q.before(p, m, minLevel) {
+			q.items[m], q.items[p] = q.items[p], q.items[m]
+		}
+		i = m
+	}
+}
+
+// NewBoundedPriorityQueue creates a new priority queue holding at most capacity items, using the
+// given comparator. capacity must be >0; if <=0, it is coerced to 1.
+func NewBoundedPriorityQueue[T any](less func(a, b T) bool, capacity int) *BoundedPriorityQueue[T] {
+	return &BoundedPriorityQueue[T]{less: less, capacity: max(capacity, 1)}
+}
@@ -2,8 +2,14 @@
 package threadsafe
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"io"
 	"iter"
+	"slices"
 	"sync"
+	"time"
 )
 
 // CorePriorityQueue is a thread-safe priority queue that implements the core PriorityQueue
@@ -15,21 +21,36 @@ import (
 //
 // Complexity: Push/Pop O(log n), Peek O(1); Range does not mutate the heap.
 type CorePriorityQueue[T any] struct {
-	mu    sync.RWMutex
-	items []T
-	less  func(a, b T) bool
+	mu      sync.RWMutex
+	items   []T
+	less    func(a, b T) bool
+	stats   pqStats
+	changed broadcaster // signaled on push, for PopWait
 }
 
-// Push inserts one or more items into the queue.
+// Push inserts one or more items into the queue. Batches at least as large as the queue are
+// added by rebuilding the heap bottom-up rather than sifting up each item.
 func (q *CorePriorityQueue[T]) Push(items ...T) {
 	if len(items) == 0 {
 		return
 	}
 	q.mu.Lock()
+	n := len(q.items)
+	q.items = slices.Grow(q.items, len(items))
 	for _, x := range items {
 		q.items = append(q.items, x)
-		q.up(len(q.items) - 1)
+		q.stats.push(len(q.items))
+	}
+	if heapifyBatch(n, len(items)) {
+		for i := len(q.items)/2 - 1; i >= 0; i-- {
+			q.down(i)
+		}
+	} else {
+		for i := n; i < len(q.items); i++ {
+			q.up(i)
+		}
 	}
+	q.changed.broadcast()
 	q.mu.Unlock()
 }
 
@@ -40,14 +61,32 @@ func (q *CorePriorityQueue[T]) Pop() (item T, ok bool) {
 	if len(q.items) == 0 {
 		return item, false
 	}
-	last := len(q.items) - 1
-	q.swap(0, last)
-	item = q.items[last]
-	q.items = q.items[:last]
-	if len(q.items) > 0 {
-		q.down(0)
+	return q.popLocked(), true
+}
+
+// PopWait removes and returns the minimum item per the comparator, blocking while the queue is
+// empty until ctx is done, in which case ctx.Err() is returned.
+func (q *CorePriorityQueue[T]) PopWait(ctx context.Context) (item T, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 {
+		if err := q.changed.waitLocked(ctx, &q.mu); err != nil {
+			return item, err
+		}
+	}
+	return q.popLocked(), nil
+}
+
+// PopIf atomically removes and returns the minimum item only if pred accepts it, e.g. when its
+// deadline has passed. pred is called under the write lock and must not call back into the
+// queue. If the queue is empty or pred returns false, it returns ok == false.
+func (q *CorePriorityQueue[T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 || !pred(q.items[0]) {
+		return item, false
 	}
-	return item, true
+	return q.popLocked(), true
 }
 
 // Peek returns the minimum item without removing it.
@@ -72,6 +111,7 @@ func (q *CorePriorityQueue[T]) Len() int {
 func (q *CorePriorityQueue[T]) Clear() {
 	q.mu.Lock()
 	q.items = nil
+	q.stats.clear()
 	q.mu.Unlock()
 }
 
@@ -106,7 +146,164 @@ func (q *CorePriorityQueue[T]) All() iter.Seq[T] {
 	}
 }
 
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *CorePriorityQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *CorePriorityQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// Clone returns a new queue with the same comparator and a copy of each item made by copyFn,
+// under a single lock so the clone is a consistent snapshot. copyFn must not call back into the
+// queue; if nil, items are copied shallowly. Activity counters are not carried over.
+func (q *CorePriorityQueue[T]) Clone(copyFn func(T) T) *CorePriorityQueue[T] {
+	clone := NewCorePriorityQueue(q.less)
+	q.cloneInto(clone, copyFn)
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the queue, for capacity planning
+// and memory accounting: the queue itself, its backing array and wait tracking timestamps, plus
+// sizeFn(item) per item if sizeFn is not nil, e.g. to count string data. sizeFn must not call
+// back into the queue.
+func (q *CorePriorityQueue[T]) EstimateBytes(sizeFn func(item T) int) int {
+	return sizeOf[CorePriorityQueue[T]]() + q.itemsBytes(sizeFn)
+}
+
+// AllSorted returns an iterator over a snapshot of the items in priority order, without
+// modifying the queue.
+func (q *CorePriorityQueue[T]) AllSorted() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := make([]T, len(q.items))
+		copy(snapshot, q.items)
+		q.mu.RUnlock()
+		yieldSorted(snapshot, q.less, yield)
+	}
+}
+
+// SliceSorted returns a copy of the items in priority order, without modifying the queue.
+func (q *CorePriorityQueue[T]) SliceSorted() []T {
+	return slices.Collect(q.AllSorted())
+}
+
+// Reserve grows the queue's storage, if necessary, to fit n more items without reallocating, so
+// that seeding it with a large known volume avoids repeated slice growth under the write lock.
+func (q *CorePriorityQueue[T]) Reserve(n int) {
+	if n <= 0 {
+		return
+	}
+	q.mu.Lock()
+	q.items = slices.Grow(q.items, n)
+	q.mu.Unlock()
+}
+
+// DrainTo continuously pops items in priority order and sends them on ch, waiting for new items
+// while the queue is empty, until ctx is done; it then returns ctx.Err(). An item popped while
+// ch was not ready is pushed back when ctx is done, so no item is lost. ch is not closed.
+func (q *CorePriorityQueue[T]) DrainTo(ctx context.Context, ch chan<- T) error {
+	for {
+		item, err := q.PopWait(ctx)
+		if err != nil {
+			return err
+		}
+		select {
+		case ch <- item:
+		case <-ctx.Done():
+			q.Push(item)
+			return ctx.Err()
+		}
+	}
+}
+
+// Stats returns a snapshot of the queue's activity counters. AvgWait is only reported after
+// EnableWaitTracking.
+func (q *CorePriorityQueue[T]) Stats() PriorityQueueStats {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.stats.snapshot(len(q.items))
+}
+
+// EnableWaitTracking starts recording push timestamps so that Stats reports the average time
+// items spend queued. Items already queued count as pushed now. Tracking costs one timestamp per
+// item and cannot be disabled.
+func (q *CorePriorityQueue[T]) EnableWaitTracking() {
+	q.mu.Lock()
+	q.stats.enableWait(len(q.items))
+	q.mu.Unlock()
+}
+
+// SaveTo writes a snapshot of the queue contents to w using codec, so that pending work can be
+// persisted, e.g. across restarts, and restored with LoadFrom.
+func (q *CorePriorityQueue[T]) SaveTo(w io.Writer, codec Codec[T]) error {
+	q.mu.RLock()
+	snapshot := make([]T, len(q.items))
+	copy(snapshot, q.items)
+	q.mu.RUnlock()
+	return writeSnapshot(w, codec, snapshot)
+}
+
+// LoadFrom replaces the queue contents with a snapshot written by SaveTo, rebuilding the heap in
+// O(n) with the current comparator. On error the queue is left unchanged.
+func (q *CorePriorityQueue[T]) LoadFrom(r io.Reader, codec Codec[T]) error {
+	items, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.replaceLocked(items)
+	q.mu.Unlock()
+	return nil
+}
+
+// MarshalJSON encodes the queue contents as a JSON array in priority order.
+func (q *CorePriorityQueue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.SliceSorted())
+}
+
+// UnmarshalJSON replaces the queue contents with the items of a JSON array, rebuilding the heap
+// in O(n) with the current comparator. On error the queue is left unchanged.
+func (q *CorePriorityQueue[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.replaceLocked(items)
+	q.mu.Unlock()
+	return nil
+}
+
 // Internal helpers (write-locked callers)
+
+// replaceLocked replaces the queue contents with items and heapifies them.
+func (q *CorePriorityQueue[T]) replaceLocked(items []T) {
+	q.items = items
+	q.stats.replace(len(items))
+	for i := len(q.items)/2 - 1; i >= 0; i-- {
+		q.down(i)
+	}
+	q.changed.broadcast()
+}
+
+// popLocked removes and returns the minimum item; the queue must not be empty.
+func (q *CorePriorityQueue[T]) popLocked() T {
+	last := len(q.items) - 1
+	q.swap(0, last)
+	item := q.items[last]
+	var zero T
+	q.items[last] = zero
+	q.items = q.items[:last]
+	q.stats.pop(last)
+	if len(q.items) > 0 {
+		q.down(0)
+	}
+	return item
+}
+
 func (q *CorePriorityQueue[T]) lessIdx(i, j int) bool { return q.less(q.items[i], q.items[j]) }
 
 func (q *CorePriorityQueue[T]) swap(i, j int) {
@@ -114,6 +311,7 @@ func (q *CorePriorityQueue[T]) swap(i, j int) {
 		return
 	}
 	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.stats.swap(i, j)
 }
 
 func (q *CorePriorityQueue[T]) up(i int) {
@@ -157,3 +355,35 @@ func (q *CorePriorityQueue[T]) down(i int) bool {
 func NewCorePriorityQueue[T any](less func(a, b T) bool) *CorePriorityQueue[T] {
 	return &CorePriorityQueue[T]{less: less}
 }
+
+// NewMinPriorityQueue creates a new priority queue that pops the smallest item first, per
+// cmp.Less.
+func NewMinPriorityQueue[T cmp.Ordered]() *CorePriorityQueue[T] {
+	return NewCorePriorityQueue(cmp.Less[T])
+}
+
+// NewMaxPriorityQueue creates a new priority queue that pops the largest item first, per
+// cmp.Less.
+func NewMaxPriorityQueue[T cmp.Ordered]() *CorePriorityQueue[T] {
+	return NewCorePriorityQueue(func(a, b T) bool { return cmp.Less(b, a) })
+}
+
+// cloneInto copies the heap of q into the empty queue clone, each item copied by copyFn if not
+// nil.
+func (q *CorePriorityQueue[T]) cloneInto(clone *CorePriorityQueue[T], copyFn func(T) T) {
+	q.mu.RLock()
+	items := cloneSlice(q.items, copyFn)
+	q.mu.RUnlock()
+
+	clone.mu.Lock()
+	clone.replaceLocked(items)
+	clone.mu.Unlock()
+}
+
+// itemsBytes estimates the bytes of the heap and of the wait tracking timestamps, adding
+// sizeFn(item) per item if sizeFn is not nil.
+func (q *CorePriorityQueue[T]) itemsBytes(sizeFn func(T) int) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return sliceBytes(q.items, cap(q.items), sizeFn) + cap(q.stats.pushedAt)*sizeOf[time.Time]()
+}
@@ -2,7 +2,12 @@
 package threadsafe
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"iter"
+	"reflect"
+	"slices"
 	"sync"
 )
 
@@ -19,24 +24,45 @@ import (
 // when indices change. Note that index values refer to internal heap storage and are unstable
 // across operations.
 //
+// As a safer alternative to onSwap, items implementing Indexed (typically pointer types) have
+// their heap index maintained automatically, and can be fixed or removed with FixItem and
+// RemoveItem without tracking indices by hand.
+//
 // Complexity: Push/Pop/Fix/RemoveAt O(log n), Peek O(1); Range does not mutate the heap.
 type IndexedPriorityQueue[T any] struct {
-	mu     sync.RWMutex
-	items  []T
-	cmp    func(a, b T) bool
-	onSwap func(i, j int, items []T)
+	mu        sync.RWMutex
+	items     []T
+	cmp       func(a, b T) bool
+	onSwap    func(i, j int, items []T)
+	autoIndex bool // T implements Indexed
+	stats     pqStats
+	changed   broadcaster // signaled on push, for PopWait
 }
 
-// Push inserts one or more items into the heap.
+// Push inserts one or more items into the heap. Batches at least as large as the heap are
+// added by rebuilding the heap bottom-up rather than sifting up each item.
 func (q *IndexedPriorityQueue[T]) Push(items ...T) {
 	if len(items) == 0 {
 		return
 	}
 	q.mu.Lock()
+	n := len(q.items)
+	q.items = slices.Grow(q.items, len(items))
 	for _, x := range items {
 		q.items = append(q.items, x)
-		q.up(len(q.items) - 1)
+		q.stats.push(len(q.items))
+		q.setIndex(len(q.items) - 1)
 	}
+	if heapifyBatch(n, len(items)) {
+		for i := len(q.items)/2 - 1; i >= 0; i-- {
+			q.down(i)
+		}
+	} else {
+		for i := n; i < len(q.items); i++ {
+			q.up(i)
+		}
+	}
+	q.changed.broadcast()
 	q.mu.Unlock()
 }
 
@@ -47,14 +73,32 @@ func (q *IndexedPriorityQueue[T]) Pop() (item T, ok bool) {
 	if len(q.items) == 0 {
 		return item, false
 	}
-	last := len(q.items) - 1
-	q.swap(0, last)
-	item = q.items[last]
-	q.items = q.items[:last]
-	if len(q.items) > 0 {
-		q.down(0)
+	return q.popLocked(), true
+}
+
+// PopWait removes and returns the minimum item per the comparator, blocking while the queue is
+// empty until ctx is done, in which case ctx.Err() is returned.
+func (q *IndexedPriorityQueue[T]) PopWait(ctx context.Context) (item T, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 {
+		if err := q.changed.waitLocked(ctx, &q.mu); err != nil {
+			return item, err
+		}
+	}
+	return q.popLocked(), nil
+}
+
+// PopIf atomically removes and returns the minimum item only if pred accepts it, e.g. when its
+// deadline has passed. pred is called under the write lock and must not call back into the
+// queue. If the queue is empty or pred returns false, it returns ok == false.
+func (q *IndexedPriorityQueue[T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 || !pred(q.items[0]) {
+		return item, false
 	}
-	return item, true
+	return q.popLocked(), true
 }
 
 // Peek returns the minimum item without removing it.
@@ -78,7 +122,11 @@ func (q *IndexedPriorityQueue[T]) Len() int {
 // Clear removes all items.
 func (q *IndexedPriorityQueue[T]) Clear() {
 	q.mu.Lock()
+	for _, x := range q.items {
+		q.clearIndex(x)
+	}
 	q.items = nil
+	q.stats.clear()
 	q.mu.Unlock()
 }
 
@@ -113,6 +161,48 @@ func (q *IndexedPriorityQueue[T]) All() iter.Seq[T] {
 	}
 }
 
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *IndexedPriorityQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *IndexedPriorityQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// Find returns the first item in internal heap order for which pred returns true, together with
+// its index for use with Fix, RemoveAt and UpdateAt. The search runs under the read lock, so pred
+// must not call back into the queue. The index is only valid until the queue is next modified,
+// so callers racing with other writers must re-check the item at that index.
+func (q *IndexedPriorityQueue[T]) Find(pred func(item T) bool) (item T, index int, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	for i, x := range q.items {
+		if pred(x) {
+			return x, i, true
+		}
+	}
+	return item, -1, false
+}
+
+// AllSorted returns an iterator over a snapshot of the items in priority order, without
+// modifying the queue.
+func (q *IndexedPriorityQueue[T]) AllSorted() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := make([]T, len(q.items))
+		copy(snapshot, q.items)
+		q.mu.RUnlock()
+		yieldSorted(snapshot, q.cmp, yield)
+	}
+}
+
+// SliceSorted returns a copy of the items in priority order, without modifying the queue.
+func (q *IndexedPriorityQueue[T]) SliceSorted() []T {
+	return slices.Collect(q.AllSorted())
+}
+
 // Fix restores heap order after the item at index i may have changed.
 func (q *IndexedPriorityQueue[T]) Fix(i int) {
 	q.mu.Lock()
@@ -132,18 +222,7 @@ func (q *IndexedPriorityQueue[T]) RemoveAt(i int) (item T, ok bool) {
 	if i < 0 || i >= len(q.items) {
 		return item, false
 	}
-	last := len(q.items) - 1
-	if i != last {
-		q.swap(i, last)
-	}
-	item = q.items[last]
-	q.items = q.items[:last]
-	if i < len(q.items) {
-		if !q.down(i) {
-			q.up(i)
-		}
-	}
-	return item, true
+	return q.removeAtLocked(i), true
 }
 
 // UpdateAt replaces the element at index i and restores invariants.
@@ -153,15 +232,215 @@ func (q *IndexedPriorityQueue[T]) UpdateAt(i int, x T) bool {
 	if i < 0 || i >= len(q.items) {
 		return false
 	}
+	if q.autoIndex && any(q.items[i]) != any(x) {
+		q.clearIndex(q.items[i])
+	}
 	q.items[i] = x
+	q.setIndex(i)
 	if !q.down(i) {
 		q.up(i)
 	}
 	return true
 }
 
+// FixItem restores heap order after the ordering fields of x, an item implementing Indexed, may
+// have changed. It returns false if x is not in the queue or T does not implement Indexed.
+func (q *IndexedPriorityQueue[T]) FixItem(x T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i, ok := q.indexOfLocked(x)
+	if !ok {
+		return false
+	}
+	if !q.down(i) {
+		q.up(i)
+	}
+	return true
+}
+
+// RemoveItem removes x, an item implementing Indexed, from the queue. It returns false if x is not
+// in the queue or T does not implement Indexed.
+func (q *IndexedPriorityQueue[T]) RemoveItem(x T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i, ok := q.indexOfLocked(x)
+	if !ok {
+		return false
+	}
+	q.removeAtLocked(i)
+	return true
+}
+
+// Reserve grows the queue's storage, if necessary, to fit n more items without reallocating, so
+// that seeding it with a large known volume avoids repeated slice growth under the write lock.
+func (q *IndexedPriorityQueue[T]) Reserve(n int) {
+	if n <= 0 {
+		return
+	}
+	q.mu.Lock()
+	q.items = slices.Grow(q.items, n)
+	q.mu.Unlock()
+}
+
+// DrainTo continuously pops items in priority order and sends them on ch, waiting for new items
+// while the queue is empty, until ctx is done; it then returns ctx.Err(). An item popped while
+// ch was not ready is pushed back when ctx is done, so no item is lost. ch is not closed.
+func (q *IndexedPriorityQueue[T]) DrainTo(ctx context.Context, ch chan<- T) error {
+	for {
+		item, err := q.PopWait(ctx)
+		if err != nil {
+			return err
+		}
+		select {
+		case ch <- item:
+		case <-ctx.Done():
+			q.Push(item)
+			return ctx.Err()
+		}
+	}
+}
+
+// Stats returns a snapshot of the queue's activity counters. AvgWait is only reported after
+// EnableWaitTracking.
+func (q *IndexedPriorityQueue[T]) Stats() PriorityQueueStats {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.stats.snapshot(len(q.items))
+}
+
+// EnableWaitTracking starts recording push timestamps so that Stats reports the average time
+// items spend queued. Items already queued count as pushed now. Tracking costs one timestamp per
+// item and cannot be disabled.
+func (q *IndexedPriorityQueue[T]) EnableWaitTracking() {
+	q.mu.Lock()
+	q.stats.enableWait(len(q.items))
+	q.mu.Unlock()
+}
+
+// SaveTo writes a snapshot of the queue contents to w using codec, so that pending work can be
+// persisted, e.g. across restarts, and restored with LoadFrom.
+func (q *IndexedPriorityQueue[T]) SaveTo(w io.Writer, codec Codec[T]) error {
+	q.mu.RLock()
+	snapshot := make([]T, len(q.items))
+	copy(snapshot, q.items)
+	q.mu.RUnlock()
+	return writeSnapshot(w, codec, snapshot)
+}
+
+// LoadFrom replaces the queue contents with a snapshot written by SaveTo, rebuilding the heap in
+// O(n) with the current comparator. If set, onSwap is called as onSwap(i, i, items) for every
+// loaded item so that external indices start out in sync. On error the queue is left unchanged.
+func (q *IndexedPriorityQueue[T]) LoadFrom(r io.Reader, codec Codec[T]) error {
+	items, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.replaceLocked(items)
+	q.mu.Unlock()
+	return nil
+}
+
+// MarshalJSON encodes the queue contents as a JSON array in priority order.
+func (q *IndexedPriorityQueue[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.SliceSorted())
+}
+
+// UnmarshalJSON replaces the queue contents with the items of a JSON array, rebuilding the heap
+// in O(n) with the current comparator. As with LoadFrom, onSwap is called as onSwap(i, i, items)
+// for every decoded item. On error the queue is left unchanged.
+func (q *IndexedPriorityQueue[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.replaceLocked(items)
+	q.mu.Unlock()
+	return nil
+}
+
 // Internal helpers (callers must hold write lock)
 
+// replaceLocked replaces the queue contents with items, syncs their indices and heapifies them.
+func (q *IndexedPriorityQueue[T]) replaceLocked(items []T) {
+	q.items = items
+	q.stats.replace(len(items))
+	for i := range q.items {
+		q.setIndex(i)
+		if q.onSwap != nil {
+			q.onSwap(i, i, q.items)
+		}
+	}
+	for i := len(q.items)/2 - 1; i >= 0; i-- {
+		q.down(i)
+	}
+	q.changed.broadcast()
+}
+
+// popLocked removes and returns the minimum item; the queue must not be empty.
+func (q *IndexedPriorityQueue[T]) popLocked() T {
+	last := len(q.items) - 1
+	q.swap(0, last)
+	item := q.items[last]
+	var zero T
+	q.items[last] = zero
+	q.items = q.items[:last]
+	q.stats.pop(last)
+	q.clearIndex(item)
+	if len(q.items) > 0 {
+		q.down(0)
+	}
+	return item
+}
+
+// removeAtLocked removes and returns the item at index i, which must be valid.
+func (q *IndexedPriorityQueue[T]) removeAtLocked(i int) T {
+	last := len(q.items) - 1
+	if i != last {
+		q.swap(i, last)
+	}
+	item := q.items[last]
+	var zero T
+	q.items[last] = zero
+	q.items = q.items[:last]
+	q.stats.pop(last)
+	q.clearIndex(item)
+	if i < len(q.items) {
+		if !q.down(i) {
+			q.up(i)
+		}
+	}
+	return item
+}
+
+// setIndex records index i in the item stored there, if T implements Indexed.
+func (q *IndexedPriorityQueue[T]) setIndex(i int) {
+	if q.autoIndex {
+		any(q.items[i]).(Indexed).SetHeapIndex(i)
+	}
+}
+
+// clearIndex marks an item that left the queue with index -1, if T implements Indexed.
+func (q *IndexedPriorityQueue[T]) clearIndex(x T) {
+	if q.autoIndex {
+		any(x).(Indexed).SetHeapIndex(-1)
+	}
+}
+
+// indexOfLocked returns the index of x read from its HeapIndex, verifying that x is stored there.
+// Callers must hold at least a read lock.
+func (q *IndexedPriorityQueue[T]) indexOfLocked(x T) (int, bool) {
+	if !q.autoIndex {
+		return -1, false
+	}
+	i := any(x).(Indexed).HeapIndex()
+	if i < 0 || i >= len(q.items) || any(q.items[i]) != any(x) {
+		return -1, false
+	}
+	return i, true
+}
+
 func (q *IndexedPriorityQueue[T]) lessIdx(i, j int) bool { return q.cmp(q.items[i], q.items[j]) }
 
 func (q *IndexedPriorityQueue[T]) swap(i, j int) {
@@ -169,6 +448,9 @@ func (q *IndexedPriorityQueue[T]) swap(i, j int) {
 		return
 	}
 	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.stats.swap(i, j)
+	q.setIndex(i)
+	q.setIndex(j)
 	if q.onSwap != nil {
 		q.onSwap(i, j, q.items)
 	}
@@ -219,5 +501,9 @@ func NewIndexedPriorityQueue[T any](
 	less func(a, b T) bool,
 	onSwap func(i, j int, items []T),
 ) *IndexedPriorityQueue[T] {
-	return &IndexedPriorityQueue[T]{cmp: less, onSwap: onSwap}
+	var zero T
+	_, autoIndex := any(zero).(Indexed)
+	// Items are matched by identity, which requires a comparable (typically pointer) type.
+	autoIndex = autoIndex && reflect.TypeFor[T]().Comparable()
+	return &IndexedPriorityQueue[T]{cmp: less, onSwap: onSwap, autoIndex: autoIndex}
 }
@@ -0,0 +1,287 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+// keyedEntry is an item of a KeyedPriorityQueue together with its key.
+type keyedEntry[K comparable, T any] struct {
+	key  K
+	item T
+}
+
+// KeyedPriorityQueue is a thread-safe binary min-heap where every item is identified by a key
+// derived through a caller-supplied key function. An internal key→index map tracks the position
+// of each item, so items can be looked up, updated and removed by key without tracking heap
+// indices, which is the decrease-key operation needed by Dijkstra-style algorithms and
+// schedulers.
+//
+// Keys are unique: pushing an item whose key is already present replaces the existing item.
+//
+// The zero value is not ready to use; construct via NewKeyedPriorityQueue. The less(a,b)
+// comparator must define a strict weak ordering (irreflexive, transitive, consistent).
+//
+// Complexity: Push/Pop/UpdatePriority/Remove O(log n), Peek/Get/Contains O(1).
+type KeyedPriorityQueue[K comparable, T any] struct {
+	mu      sync.RWMutex
+	entries []keyedEntry[K, T]
+	index   map[K]int
+	less    func(a, b T) bool
+	key     func(item T) K
+}
+
+// Push inserts one or more items into the queue. An item whose key is already present replaces
+// the existing item, as with UpdatePriority.
+func (q *KeyedPriorityQueue[K, T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	for _, x := range items {
+		q.setLocked(q.key(x), x)
+	}
+	q.mu.Unlock()
+}
+
+// Pop removes and returns the minimum item per the comparator.
+func (q *KeyedPriorityQueue[K, T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.entries) == 0 {
+		return item, false
+	}
+	return q.removeAtLocked(0), true
+}
+
+// PopIf atomically removes and returns the minimum item only if pred accepts it, e.g. when its
+// deadline has passed. pred is called under the write lock and must not call back into the
+// queue. If the queue is empty or pred returns false, it returns ok == false.
+func (q *KeyedPriorityQueue[K, T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.entries) == 0 || !pred(q.entries[0].item) {
+		return item, false
+	}
+	return q.removeAtLocked(0), true
+}
+
+// Peek returns the minimum item without removing it.
+func (q *KeyedPriorityQueue[K, T]) Peek() (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if len(q.entries) == 0 {
+		return item, false
+	}
+	return q.entries[0].item, true
+}
+
+// Get returns the item stored under key.
+func (q *KeyedPriorityQueue[K, T]) Get(key K) (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	i, ok := q.index[key]
+	if !ok {
+		return item, false
+	}
+	return q.entries[i].item, true
+}
+
+// Contains reports whether an item with the given key is in the queue.
+func (q *KeyedPriorityQueue[K, T]) Contains(key K) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	_, ok := q.index[key]
+	return ok
+}
+
+// UpdatePriority replaces the item stored under key with item and restores the queue ordering.
+// item is expected to map to key through the key function. It returns false, leaving the queue
+// unchanged, if key is not present.
+func (q *KeyedPriorityQueue[K, T]) UpdatePriority(key K, item T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.index[key]; !ok {
+		return false
+	}
+	q.setLocked(key, item)
+	return true
+}
+
+// Remove removes and returns the item stored under key.
+func (q *KeyedPriorityQueue[K, T]) Remove(key K) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i, ok := q.index[key]
+	if !ok {
+		return item, false
+	}
+	return q.removeAtLocked(i), true
+}
+
+// Len returns the number of items.
+func (q *KeyedPriorityQueue[K, T]) Len() int {
+	q.mu.RLock()
+	l := len(q.entries)
+	q.mu.RUnlock()
+	return l
+}
+
+// Clear removes all items.
+func (q *KeyedPriorityQueue[K, T]) Clear() {
+	q.mu.Lock()
+	q.entries = nil
+	clear(q.index)
+	q.mu.Unlock()
+}
+
+// Range iterates over a snapshot of items in arbitrary internal order. Mutations during range
+// does not affect the current iteration.
+func (q *KeyedPriorityQueue[K, T]) Range(f func(item T) bool) {
+	for item := range q.All() {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue in internal heap order (not sorted).
+// The iteration order is implementation-defined and not guaranteed to be priority-sorted.
+func (q *KeyedPriorityQueue[K, T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := make([]T, len(q.entries))
+		for i, e := range q.entries {
+			snapshot[i] = e.item
+		}
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *KeyedPriorityQueue[K, T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *KeyedPriorityQueue[K, T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AllSorted returns an iterator over a snapshot of the items in priority order, without
+// modifying the queue.
+func (q *KeyedPriorityQueue[K, T]) AllSorted() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := make([]T, len(q.entries))
+		for i, e := range q.entries {
+			snapshot[i] = e.item
+		}
+		q.mu.RUnlock()
+		yieldSorted(snapshot, q.less, yield)
+	}
+}
+
+// SliceSorted returns a copy of the items in priority order, without modifying the queue.
+func (q *KeyedPriorityQueue[K, T]) SliceSorted() []T {
+	return slices.Collect(q.AllSorted())
+}
+
+// Internal helpers (callers must hold write lock)
+
+// setLocked inserts item under key, or replaces the item already stored under key.
+func (q *KeyedPriorityQueue[K, T]) setLocked(key K, item T) {
+	if i, ok := q.index[key]; ok {
+		q.entries[i].item = item
+		if !q.down(i) {
+			q.up(i)
+		}
+		return
+	}
+	q.entries = append(q.entries, keyedEntry[K, T]{key: key, item: item})
+	q.index[key] = len(q.entries) - 1
+	q.up(len(q.entries) - 1)
+}
+
+// removeAtLocked removes and returns the item at index i, which must be valid.
+func (q *KeyedPriorityQueue[K, T]) removeAtLocked(i int) T {
+	last := len(q.entries) - 1
+	q.swap(i, last)
+	e := q.entries[last]
+	q.entries[last] = keyedEntry[K, T]{}
+	q.entries = q.entries[:last]
+	delete(q.index, e.key)
+	if i < len(q.entries) {
+		if !q.down(i) {
+			q.up(i)
+		}
+	}
+	return e.item
+}
+
+func (q *KeyedPriorityQueue[K, T]) lessIdx(i, j int) bool {
+	return q.less(q.entries[i].item, q.entries[j].item)
+}
+
+func (q *KeyedPriorityQueue[K, T]) swap(i, j int) {
+	if i == j {
+		return
+	}
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	q.index[q.entries[i].key] = i
+	q.index[q.entries[j].key] = j
+}
+
+func (q *KeyedPriorityQueue[K, T]) up(i int) {
+	idx := i
+	for {
+		p := (idx - 1) / 2
+		if idx == 0 || !q.lessIdx(idx, p) {
+			break
+		}
+		q.swap(idx, p)
+		idx = p
+	}
+}
+
+// down moves item at i down; returns true if moved down.
+func (q *KeyedPriorityQueue[K, T]) down(i int) bool {
+	idx := i
+	n := len(q.entries)
+	moved := false
+	for {
+		l := 2*idx + 1
+		if l >= n {
+			break
+		}
+		smallest := l
+		r := l + 1
+		if r < n && q.lessIdx(r, l) {
+			smallest = r
+		}
+		if !q.lessIdx(smallest, idx) {
+			break
+		}
+		q.swap(idx, smallest)
+		idx = smallest
+		moved = true
+	}
+	return moved
+}
+
+// NewKeyedPriorityQueue creates a new keyed priority queue ordered by less, where key derives the
+// unique key of each item.
+func NewKeyedPriorityQueue[K comparable, T any](
+	less func(a, b T) bool,
+	key func(item T) K,
+) *KeyedPriorityQueue[K, T] {
+	return &KeyedPriorityQueue[K, T]{index: make(map[K]int), less: less, key: key}
+}
@@ -0,0 +1,156 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"cmp"
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// kvEntry is an item of a PriorityQueueKV together with its priority.
+type kvEntry[P cmp.Ordered, T any] struct {
+	priority P
+	seq      uint64 // push order, used to keep items with equal priorities in FIFO order
+	item     T
+}
+
+func lessKV[P cmp.Ordered, T any](a, b kvEntry[P, T]) bool {
+	if c := cmp.Compare(a.priority, b.priority); c != 0 {
+		return c < 0
+	}
+	return a.seq < b.seq
+}
+
+// PriorityQueueKV is a thread-safe priority queue where each item is pushed together with an
+// explicit priority, so callers don't have to wrap values in structs just to carry a priority
+// field or supply a comparator over T.
+//
+// Lower priorities are popped first, consistent with the min-heap PriorityQueue implementations;
+// negate the priority for max-first ordering. Items with equal priorities are popped in the order
+// they were pushed.
+//
+// Since priorities are cmp.Ordered, the zero value is ready to use.
+//
+// Complexity: Push/Pop O(log n), Peek O(1).
+type PriorityQueueKV[P cmp.Ordered, T any] struct {
+	once sync.Once
+	pq   CorePriorityQueue[kvEntry[P, T]]
+	seq  atomic.Uint64
+}
+
+// Push inserts item with the given priority.
+func (q *PriorityQueueKV[P, T]) Push(priority P, item T) {
+	q.core().Push(kvEntry[P, T]{priority: priority, seq: q.seq.Add(1), item: item})
+}
+
+// Pop removes and returns the item with the lowest priority, together with its priority.
+// If empty, returns ok == false and zero values.
+func (q *PriorityQueueKV[P, T]) Pop() (item T, priority P, ok bool) {
+	e, ok := q.core().Pop()
+	return e.item, e.priority, ok
+}
+
+// PopIf atomically removes and returns the item with the lowest priority only if pred accepts it
+// and its priority. pred is called under the write lock and must not call back into the queue.
+func (q *PriorityQueueKV[P, T]) PopIf(
+	pred func(priority P, item T) bool,
+) (item T, priority P, ok bool) {
+	e, ok := q.core().PopIf(func(min kvEntry[P, T]) bool {
+		return pred(min.priority, min.item)
+	})
+	return e.item, e.priority, ok
+}
+
+// Peek returns the item with the lowest priority, together with its priority, without removing
+// it. If empty, returns ok == false and zero values.
+func (q *PriorityQueueKV[P, T]) Peek() (item T, priority P, ok bool) {
+	e, ok := q.core().Peek()
+	return e.item, e.priority, ok
+}
+
+// Len returns the number of items.
+func (q *PriorityQueueKV[P, T]) Len() int {
+	return q.core().Len()
+}
+
+// Clear removes all items.
+func (q *PriorityQueueKV[P, T]) Clear() {
+	q.core().Clear()
+}
+
+// Range iterates over a snapshot of priorities and items in arbitrary internal order. Mutations
+// during range does not affect the current iteration.
+func (q *PriorityQueueKV[P, T]) Range(f func(priority P, item T) bool) {
+	q.core().Range(func(e kvEntry[P, T]) bool {
+		return f(e.priority, e.item)
+	})
+}
+
+// All returns an iterator over priorities and items in internal heap order (not sorted).
+func (q *PriorityQueueKV[P, T]) All() iter.Seq2[P, T] {
+	return func(yield func(P, T) bool) {
+		for e := range q.core().All() {
+			if !yield(e.priority, e.item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first entries of the queue, for logging and debugging.
+func (q *PriorityQueueKV[P, T]) String() string {
+	return formatEntries(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first entries of the queue, for the %#v verb.
+func (q *PriorityQueueKV[P, T]) GoString() string {
+	return goFormatEntries(q, q.Len(), q.All())
+}
+
+// AllSorted returns an iterator over a snapshot of priorities and items in priority order, as Pop
+// would return them, without modifying the queue.
+func (q *PriorityQueueKV[P, T]) AllSorted() iter.Seq2[P, T] {
+	return func(yield func(P, T) bool) {
+		for e := range q.core().AllSorted() {
+			if !yield(e.priority, e.item) {
+				return
+			}
+		}
+	}
+}
+
+// SliceSorted returns a copy of the items in priority order, without modifying the queue.
+func (q *PriorityQueueKV[P, T]) SliceSorted() []T {
+	sorted := make([]T, 0, q.Len())
+	for _, item := range q.AllSorted() {
+		sorted = append(sorted, item)
+	}
+	return sorted
+}
+
+// Stats returns a snapshot of the queue's activity counters. AvgWait is only reported after
+// EnableWaitTracking.
+func (q *PriorityQueueKV[P, T]) Stats() PriorityQueueStats {
+	return q.core().Stats()
+}
+
+// EnableWaitTracking starts recording push timestamps so that Stats reports the average time
+// items spend queued.
+func (q *PriorityQueueKV[P, T]) EnableWaitTracking() {
+	q.core().EnableWaitTracking()
+}
+
+// NewPriorityQueueKV creates a new priority queue ordered by explicit priorities.
+func NewPriorityQueueKV[P cmp.Ordered, T any]() *PriorityQueueKV[P, T] {
+	return &PriorityQueueKV[P, T]{}
+}
+
+// core returns the underlying queue, setting its comparator on first use so that the zero value
+// is ready to use.
+func (q *PriorityQueueKV[P, T]) core() *CorePriorityQueue[kvEntry[P, T]] {
+	q.once.Do(func() {
+		q.pq.less = lessKV[P, T]
+	})
+	return &q.pq
+}
@@ -0,0 +1,370 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+const lazyCompactThreshold = 64 // minimum number of tombstones before a LazyPriorityQueue compacts
+
+// lazyEntry is an item of a LazyPriorityQueue together with its bookkeeping.
+type lazyEntry[K comparable, T any] struct {
+	item    T
+	key     K
+	seq     uint64 // push order, compared against key tombstones
+	deleted bool   // set by RemoveFunc
+}
+
+// LazyPriorityQueue is a thread-safe binary min-heap supporting cheap removal by lazy deletion:
+// Remove and RemoveFunc only mark items as deleted (tombstones), and Pop and Peek transparently
+// skip and discard them when they reach the top of the heap. This amortizes removals for callers
+// that do not track heap indices.
+//
+// Every item has a key derived by a caller-supplied key function; keys need not be unique, and
+// Remove deletes all items with the given key in O(1). Tombstones occupy memory until popped, and
+// the heap is compacted when they outnumber the live items.
+//
+// The zero value is not ready to use; construct via NewLazyPriorityQueue. The less(a,b) comparator
+// must define a strict weak ordering (irreflexive, transitive, consistent).
+//
+// Complexity: Push/Pop O(log n) amortized, Peek O(1) amortized, Remove O(1), RemoveFunc O(n).
+type LazyPriorityQueue[K comparable, T any] struct {
+	mu         sync.RWMutex
+	entries    []lazyEntry[K, T]
+	less       func(a, b T) bool
+	key        func(item T) K
+	seq        uint64
+	live       map[K]int    // live items per key
+	stored     map[K]int    // stored entries per key, including tombstones
+	tombstones map[K]uint64 // items with a key pushed up to this seq are deleted
+	size       int          // total live items
+}
+
+// Push inserts one or more items into the queue.
+func (q *LazyPriorityQueue[K, T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	for _, x := range items {
+		k := q.key(x)
+		q.seq++
+		q.entries = append(q.entries, lazyEntry[K, T]{item: x, key: k, seq: q.seq})
+		q.up(len(q.entries) - 1)
+		q.live[k]++
+		q.stored[k]++
+		q.size++
+	}
+	q.mu.Unlock()
+}
+
+// Pop removes and returns the minimum live item per the comparator.
+func (q *LazyPriorityQueue[K, T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.skipDeadLocked() {
+		return item, false
+	}
+	return q.popLiveLocked(), true
+}
+
+// PopIf atomically removes and returns the minimum live item only if pred accepts it, e.g. when
+// its deadline has passed. pred is called under the write lock and must not call back into the
+// queue. If the queue is empty or pred returns false, it returns ok == false.
+func (q *LazyPriorityQueue[K, T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.skipDeadLocked() || !pred(q.entries[0].item) {
+		return item, false
+	}
+	return q.popLiveLocked(), true
+}
+
+// Peek returns the minimum live item without removing it. Tombstones at the top of the heap are
+// discarded on the way.
+func (q *LazyPriorityQueue[K, T]) Peek() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.skipDeadLocked() {
+		return item, false
+	}
+	return q.entries[0].item, true
+}
+
+// Remove marks all items with the given key as deleted and returns how many were.
+func (q *LazyPriorityQueue[K, T]) Remove(key K) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := q.live[key]
+	if n == 0 {
+		return 0
+	}
+	delete(q.live, key)
+	q.tombstones[key] = q.seq
+	q.size -= n
+	q.maybeCompactLocked()
+	return n
+}
+
+// RemoveFunc marks all items for which pred returns true as deleted and returns how many were.
+// pred is called under the write lock and must not call back into the queue.
+func (q *LazyPriorityQueue[K, T]) RemoveFunc(pred func(item T) bool) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for i := range q.entries {
+		e := &q.entries[i]
+		if q.isDead(e) || !pred(e.item) {
+			continue
+		}
+		e.deleted = true
+		q.live[e.key]--
+		if q.live[e.key] == 0 {
+			delete(q.live, e.key)
+		}
+		n++
+	}
+	q.size -= n
+	q.maybeCompactLocked()
+	return n
+}
+
+// Contains reports whether a live item with the given key is in the queue.
+func (q *LazyPriorityQueue[K, T]) Contains(key K) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.live[key] > 0
+}
+
+// Len returns the number of live items.
+func (q *LazyPriorityQueue[K, T]) Len() int {
+	q.mu.RLock()
+	l := q.size
+	q.mu.RUnlock()
+	return l
+}
+
+// Compact discards all tombstones and rebuilds the heap from the live items.
+func (q *LazyPriorityQueue[K, T]) Compact() {
+	q.mu.Lock()
+	q.compactLocked()
+	q.mu.Unlock()
+}
+
+// Clear removes all items.
+func (q *LazyPriorityQueue[K, T]) Clear() {
+	q.mu.Lock()
+	q.entries = nil
+	clear(q.live)
+	clear(q.stored)
+	clear(q.tombstones)
+	q.size = 0
+	q.mu.Unlock()
+}
+
+// Range iterates over a snapshot of live items in arbitrary internal order. Mutations during
+// range does not affect the current iteration.
+func (q *LazyPriorityQueue[K, T]) Range(f func(item T) bool) {
+	for item := range q.All() {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over live items in the queue in internal heap order (not sorted).
+// The iteration order is implementation-defined and not guaranteed to be priority-sorted.
+func (q *LazyPriorityQueue[K, T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := q.liveLocked()
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *LazyPriorityQueue[K, T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *LazyPriorityQueue[K, T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AllSorted returns an iterator over a snapshot of the items in priority order, without
+// modifying the queue.
+func (q *LazyPriorityQueue[K, T]) AllSorted() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := q.liveLocked()
+		q.mu.RUnlock()
+		yieldSorted(snapshot, q.less, yield)
+	}
+}
+
+// SliceSorted returns a copy of the items in priority order, without modifying the queue.
+func (q *LazyPriorityQueue[K, T]) SliceSorted() []T {
+	return slices.Collect(q.AllSorted())
+}
+
+// Internal helpers (callers must hold write lock unless stated otherwise)
+
+// isDead reports whether e is a tombstone. Callers must hold at least a read lock.
+func (q *LazyPriorityQueue[K, T]) isDead(e *lazyEntry[K, T]) bool {
+	if e.deleted {
+		return true
+	}
+	seq, ok := q.tombstones[e.key]
+	return ok && e.seq <= seq
+}
+
+// liveLocked returns a copy of the live items. Callers must hold at least a read lock.
+func (q *LazyPriorityQueue[K, T]) liveLocked() []T {
+	live := make([]T, 0, q.size)
+	for i := range q.entries {
+		if !q.isDead(&q.entries[i]) {
+			live = append(live, q.entries[i].item)
+		}
+	}
+	return live
+}
+
+// skipDeadLocked discards tombstones from the top of the heap and reports whether a live item is
+// left on top.
+func (q *LazyPriorityQueue[K, T]) skipDeadLocked() bool {
+	for len(q.entries) > 0 && q.isDead(&q.entries[0]) {
+		q.popLocked()
+	}
+	return len(q.entries) > 0
+}
+
+// popLocked removes and returns the top entry, releasing the key bookkeeping it holds.
+func (q *LazyPriorityQueue[K, T]) popLocked() lazyEntry[K, T] {
+	last := len(q.entries) - 1
+	q.swap(0, last)
+	e := q.entries[last]
+	q.entries[last] = lazyEntry[K, T]{}
+	q.entries = q.entries[:last]
+	if len(q.entries) > 0 {
+		q.down(0)
+	}
+	q.releaseLocked(e.key)
+	return e
+}
+
+// popLiveLocked removes and returns the live item on top of the heap.
+func (q *LazyPriorityQueue[K, T]) popLiveLocked() T {
+	e := q.popLocked()
+	q.live[e.key]--
+	if q.live[e.key] == 0 {
+		delete(q.live, e.key)
+	}
+	q.size--
+	return e.item
+}
+
+// releaseLocked accounts for a stored entry with key leaving the heap.
+func (q *LazyPriorityQueue[K, T]) releaseLocked(key K) {
+	q.stored[key]--
+	if q.stored[key] == 0 {
+		delete(q.stored, key)
+		delete(q.tombstones, key)
+	}
+}
+
+// maybeCompactLocked compacts the heap when tombstones outnumber live items.
+func (q *LazyPriorityQueue[K, T]) maybeCompactLocked() {
+	dead := len(q.entries) - q.size
+	if dead >= lazyCompactThreshold && dead > q.size {
+		q.compactLocked()
+	}
+}
+
+// compactLocked drops all tombstones and re-establishes the heap in O(n).
+func (q *LazyPriorityQueue[K, T]) compactLocked() {
+	kept := q.entries[:0]
+	for i := range q.entries {
+		if q.isDead(&q.entries[i]) {
+			q.releaseLocked(q.entries[i].key)
+			continue
+		}
+		kept = append(kept, q.entries[i])
+	}
+	clear(q.entries[len(kept):])
+	q.entries = kept
+	for i := len(q.entries)/2 - 1; i >= 0; i-- {
+		q.down(i)
+	}
+}
+
+func (q *LazyPriorityQueue[K, T]) lessIdx(i, j int) bool {
+	return q.less(q.entries[i].item, q.entries[j].item)
+}
+
+func (q *LazyPriorityQueue[K, T]) swap(i, j int) {
+	if i == j {
+		return
+	}
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+}
+
+func (q *LazyPriorityQueue[K, T]) up(i int) {
+	idx := i
+	for {
+		p := (idx - 1) / 2
+		if idx == 0 || !q.lessIdx(idx, p) {
+			break
+		}
+		q.swap(idx, p)
+		idx = p
+	}
+}
+
+// down moves item at i down; returns true if moved down.
+func (q *LazyPriorityQueue[K, T]) down(i int) bool {
+	idx := i
+	n := len(q.entries)
+	moved := false
+	for {
+		l := 2*idx + 1
+		if l >= n {
+			break
+		}
+		smallest := l
+		r := l + 1
+		if r < n && q.lessIdx(r, l) {
+			smallest = r
+		}
+		if !q.lessIdx(smallest, idx) {
+			break
+		}
+		q.swap(idx, smallest)
+		idx = smallest
+		moved = true
+	}
+	return moved
+}
+
+// NewLazyPriorityQueue creates a new lazily deleting priority queue ordered by less, where key
+// derives the key used by Remove and Contains.
+func NewLazyPriorityQueue[K comparable, T any](
+	less func(a, b T) bool,
+	key func(item T) K,
+) *LazyPriorityQueue[K, T] {
+	return &LazyPriorityQueue[K, T]{
+		less:       less,
+		key:        key,
+		live:       make(map[K]int),
+		stored:     make(map[K]int),
+		tombstones: make(map[K]uint64),
+	}
+}
@@ -0,0 +1,165 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"iter"
+	"sync"
+)
+
+// OrderedPriorityQueue is a thread-safe min-heap priority queue for cmp.Ordered types that orders
+// items naturally, per cmp.Less. Unlike the comparator-based queues, its zero value is ready to
+// use, matching the zero-value friendliness of RWMutexQueue and the maps:
+//
+//	var pq threadsafe.OrderedPriorityQueue[int]
+//	pq.Push(3, 1, 2)
+//
+// For max-first ordering, use NewMaxPriorityQueue.
+//
+// Complexity: Push/Pop O(log n), Peek O(1); Range does not mutate the heap.
+type OrderedPriorityQueue[T cmp.Ordered] struct {
+	once sync.Once
+	pq   CorePriorityQueue[T]
+}
+
+// Push inserts one or more items into the queue.
+func (q *OrderedPriorityQueue[T]) Push(items ...T) {
+	q.core().Push(items...)
+}
+
+// Pop removes and returns the smallest item.
+func (q *OrderedPriorityQueue[T]) Pop() (item T, ok bool) {
+	return q.core().Pop()
+}
+
+// PopWait removes and returns the smallest item, blocking while the queue is empty until ctx is
+// done, in which case ctx.Err() is returned.
+func (q *OrderedPriorityQueue[T]) PopWait(ctx context.Context) (item T, err error) {
+	return q.core().PopWait(ctx)
+}
+
+// PopIf atomically removes and returns the smallest item only if pred accepts it. pred is called
+// under the write lock and must not call back into the queue.
+func (q *OrderedPriorityQueue[T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	return q.core().PopIf(pred)
+}
+
+// Peek returns the smallest item without removing it.
+func (q *OrderedPriorityQueue[T]) Peek() (item T, ok bool) {
+	return q.core().Peek()
+}
+
+// Len returns the number of items.
+func (q *OrderedPriorityQueue[T]) Len() int {
+	return q.core().Len()
+}
+
+// Clear removes all items.
+func (q *OrderedPriorityQueue[T]) Clear() {
+	q.core().Clear()
+}
+
+// Range iterates over a snapshot of items in arbitrary internal order. Mutations during range
+// does not affect the current iteration.
+func (q *OrderedPriorityQueue[T]) Range(f func(item T) bool) {
+	q.core().Range(f)
+}
+
+// All returns an iterator over items in the queue in internal heap order (not sorted).
+// The iteration order is implementation-defined and not guaranteed to be priority-sorted.
+func (q *OrderedPriorityQueue[T]) All() iter.Seq[T] {
+	return q.core().All()
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *OrderedPriorityQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *OrderedPriorityQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// Clone returns a new queue holding a copy of each item made by copyFn, under a single lock so
+// the clone is a consistent snapshot. copyFn must not call back into the queue; if nil, items are
+// copied shallowly. Activity counters are not carried over.
+func (q *OrderedPriorityQueue[T]) Clone(copyFn func(T) T) *OrderedPriorityQueue[T] {
+	clone := &OrderedPriorityQueue[T]{}
+	q.core().cloneInto(clone.core(), copyFn)
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the queue, for capacity planning
+// and memory accounting: the queue itself, its backing array and wait tracking timestamps, plus
+// sizeFn(item) per item if sizeFn is not nil, e.g. to count string data. sizeFn must not call
+// back into the queue.
+func (q *OrderedPriorityQueue[T]) EstimateBytes(sizeFn func(item T) int) int {
+	return sizeOf[OrderedPriorityQueue[T]]() + q.core().itemsBytes(sizeFn)
+}
+
+// AllSorted returns an iterator over a snapshot of the items in ascending order, without
+// modifying the queue.
+func (q *OrderedPriorityQueue[T]) AllSorted() iter.Seq[T] {
+	return q.core().AllSorted()
+}
+
+// SliceSorted returns a copy of the items in ascending order, without modifying the queue.
+func (q *OrderedPriorityQueue[T]) SliceSorted() []T {
+	return q.core().SliceSorted()
+}
+
+// Reserve grows the queue's storage, if necessary, to fit n more items without reallocating.
+func (q *OrderedPriorityQueue[T]) Reserve(n int) {
+	q.core().Reserve(n)
+}
+
+// DrainTo continuously pops items in ascending order and sends them on ch until ctx is done,
+// returning ctx.Err(). See CorePriorityQueue.DrainTo.
+func (q *OrderedPriorityQueue[T]) DrainTo(ctx context.Context, ch chan<- T) error {
+	return q.core().DrainTo(ctx, ch)
+}
+
+// Stats returns a snapshot of the queue's activity counters. AvgWait is only reported after
+// EnableWaitTracking.
+func (q *OrderedPriorityQueue[T]) Stats() PriorityQueueStats {
+	return q.core().Stats()
+}
+
+// EnableWaitTracking starts recording push timestamps so that Stats reports the average time
+// items spend queued.
+func (q *OrderedPriorityQueue[T]) EnableWaitTracking() {
+	q.core().EnableWaitTracking()
+}
+
+// SaveTo writes a snapshot of the queue contents to w using codec, to be restored with LoadFrom.
+func (q *OrderedPriorityQueue[T]) SaveTo(w io.Writer, codec Codec[T]) error {
+	return q.core().SaveTo(w, codec)
+}
+
+// LoadFrom replaces the queue contents with a snapshot written by SaveTo. On error the queue is
+// left unchanged.
+func (q *OrderedPriorityQueue[T]) LoadFrom(r io.Reader, codec Codec[T]) error {
+	return q.core().LoadFrom(r, codec)
+}
+
+// MarshalJSON encodes the queue contents as a JSON array in ascending order.
+func (q *OrderedPriorityQueue[T]) MarshalJSON() ([]byte, error) {
+	return q.core().MarshalJSON()
+}
+
+// UnmarshalJSON replaces the queue contents with the items of a JSON array. On error the queue is
+// left unchanged.
+func (q *OrderedPriorityQueue[T]) UnmarshalJSON(data []byte) error {
+	return q.core().UnmarshalJSON(data)
+}
+
+// core returns the underlying queue, installing the natural ordering on first use.
+func (q *OrderedPriorityQueue[T]) core() *CorePriorityQueue[T] {
+	q.once.Do(func() {
+		q.pq.less = cmp.Less[T]
+	})
+	return &q.pq
+}
@@ -0,0 +1,343 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+// PairingHandle refers to an item pushed into a PairingPriorityQueue with PushHandle. It stays
+// valid until the item is popped or removed, and can be used to update or remove the item
+// without searching for it. Handles are only meaningful to the queue that issued them, or to the
+// queue it was melded into.
+type PairingHandle[T any] struct {
+	item    T
+	child   *PairingHandle[T] // first child
+	sibling *PairingHandle[T] // next sibling
+	prev    *PairingHandle[T] // parent if first child, previous sibling otherwise
+	inHeap  bool
+}
+
+// PairingPriorityQueue is a thread-safe priority queue backed by a pairing heap and protected by
+// a sync.RWMutex.
+//
+// Unlike the binary heaps, items never move between memory slots, so the handles returned by
+// PushHandle are stable, and decrease-key and meld are cheap. This suits graph algorithms and
+// schedulers that frequently reprioritize or merge queues.
+//
+// The zero value is not ready to use; construct via NewPairingPriorityQueue. The less(a,b)
+// comparator must define a strict weak ordering (irreflexive, transitive, consistent).
+//
+// Complexity: Push/Meld/Peek O(1), decreasing Update O(1) amortized in practice, Pop/Remove and
+// other Updates O(log n) amortized.
+type PairingPriorityQueue[T any] struct {
+	mu   sync.RWMutex
+	root *PairingHandle[T]
+	size int
+	less func(a, b T) bool
+}
+
+// Push inserts one or more items into the queue.
+func (q *PairingPriorityQueue[T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	for _, x := range items {
+		q.insertLocked(x)
+	}
+	q.mu.Unlock()
+}
+
+// PushHandle inserts an item into the queue and returns a handle to it for use with Update and
+// Remove.
+func (q *PairingPriorityQueue[T]) PushHandle(item T) *PairingHandle[T] {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.insertLocked(item)
+}
+
+// Pop removes and returns the minimum item per the comparator.
+func (q *PairingPriorityQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.root == nil {
+		return item, false
+	}
+	return q.popLocked(), true
+}
+
+// PopIf atomically removes and returns the minimum item only if pred accepts it, e.g. when its
+// deadline has passed. pred is called under the write lock and must not call back into the
+// queue. If the queue is empty or pred returns false, it returns ok == false.
+func (q *PairingPriorityQueue[T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.root == nil || !pred(q.root.item) {
+		return item, false
+	}
+	return q.popLocked(), true
+}
+
+// Peek returns the minimum item without removing it.
+func (q *PairingPriorityQueue[T]) Peek() (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.root == nil {
+		return item, false
+	}
+	return q.root.item, true
+}
+
+// Update replaces the item referred to by h and restores the queue ordering. It returns false if
+// the item is no longer in the queue.
+func (q *PairingPriorityQueue[T]) Update(h *PairingHandle[T], item T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if h == nil || !h.inHeap {
+		return false
+	}
+	decrease := !q.less(h.item, item)
+	h.item = item
+	if h == q.root {
+		if !decrease {
+			// Sift the root down by re-inserting it above its merged children.
+			q.root = q.mergePairs(h.child)
+			h.child = nil
+			q.root = q.meld(q.root, h)
+		}
+		return true
+	}
+	q.cut(h)
+	if !decrease {
+		// The children may now rank before h: detach them and meld them separately.
+		children := q.mergePairs(h.child)
+		h.child = nil
+		q.root = q.meld(q.root, children)
+	}
+	q.root = q.meld(q.root, h)
+	return true
+}
+
+// Remove removes the item referred to by h. It returns false if the item is no longer in the
+// queue.
+func (q *PairingPriorityQueue[T]) Remove(h *PairingHandle[T]) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if h == nil || !h.inHeap {
+		return item, false
+	}
+	if h == q.root {
+		q.root = q.mergePairs(h.child)
+	} else {
+		q.cut(h)
+		q.root = q.meld(q.root, q.mergePairs(h.child))
+	}
+	q.size--
+	item = h.item
+	q.release(h)
+	return item, true
+}
+
+// Meld moves all items of other into the queue in O(1), leaving other empty. Handles issued by
+// other remain valid and now refer to items in this queue. Both queues must use equivalent
+// comparators.
+func (q *PairingPriorityQueue[T]) Meld(other *PairingPriorityQueue[T]) {
+	if other == nil || other == q {
+		return
+	}
+	other.mu.Lock()
+	root, size := other.root, other.size
+	other.root, other.size = nil, 0
+	other.mu.Unlock()
+
+	q.mu.Lock()
+	q.root = q.meld(q.root, root)
+	q.size += size
+	q.mu.Unlock()
+}
+
+// Len returns the number of items.
+func (q *PairingPriorityQueue[T]) Len() int {
+	q.mu.RLock()
+	l := q.size
+	q.mu.RUnlock()
+	return l
+}
+
+// Clear removes all items, invalidating all outstanding handles.
+func (q *PairingPriorityQueue[T]) Clear() {
+	q.mu.Lock()
+	stack := []*PairingHandle[T]{q.root}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if h == nil {
+			continue
+		}
+		stack = append(stack, h.child, h.sibling)
+		q.release(h)
+	}
+	q.root = nil
+	q.size = 0
+	q.mu.Unlock()
+}
+
+// Range iterates over a snapshot of items in arbitrary internal order. Mutations during range
+// does not affect the current iteration.
+func (q *PairingPriorityQueue[T]) Range(f func(item T) bool) {
+	for item := range q.All() {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue in internal heap order (not sorted).
+// The iteration order is implementation-defined and not guaranteed to be priority-sorted.
+func (q *PairingPriorityQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := q.snapshotLocked()
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *PairingPriorityQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *PairingPriorityQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AllSorted returns an iterator over a snapshot of the items in priority order, without
+// modifying the queue.
+func (q *PairingPriorityQueue[T]) AllSorted() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := q.snapshotLocked()
+		q.mu.RUnlock()
+		yieldSorted(snapshot, q.less, yield)
+	}
+}
+
+// SliceSorted returns a copy of the items in priority order, without modifying the queue.
+func (q *PairingPriorityQueue[T]) SliceSorted() []T {
+	return slices.Collect(q.AllSorted())
+}
+
+// Internal helpers (callers must hold write lock unless stated otherwise)
+
+func (q *PairingPriorityQueue[T]) insertLocked(item T) *PairingHandle[T] {
+	h := &PairingHandle[T]{item: item, inHeap: true}
+	q.root = q.meld(q.root, h)
+	q.size++
+	return h
+}
+
+// popLocked removes and returns the root item; the queue must not be empty.
+func (q *PairingPriorityQueue[T]) popLocked() T {
+	h := q.root
+	q.root = q.mergePairs(h.child)
+	q.size--
+	item := h.item
+	q.release(h)
+	return item
+}
+
+// meld links two detached trees and returns the new root.
+func (q *PairingPriorityQueue[T]) meld(a, b *PairingHandle[T]) *PairingHandle[T] {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if q.less(b.item, a.item) {
+		a, b = b, a
+	}
+	b.prev = a
+	b.sibling = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	a.child = b
+	return a
+}
+
+// mergePairs melds a list of sibling trees with the standard two-pass strategy and returns the
+// resulting detached tree.
+func (q *PairingPriorityQueue[T]) mergePairs(first *PairingHandle[T]) *PairingHandle[T] {
+	var pairs []*PairingHandle[T]
+	for first != nil {
+		a := first
+		b := a.sibling
+		if b == nil {
+			first = nil
+		} else {
+			first = b.sibling
+			b.prev, b.sibling = nil, nil
+		}
+		a.prev, a.sibling = nil, nil
+		pairs = append(pairs, q.meld(a, b))
+	}
+	var root *PairingHandle[T]
+	for i := len(pairs) - 1; i >= 0; i-- {
+		root = q.meld(pairs[i], root)
+	}
+	return root
+}
+
+// cut detaches the subtree rooted at h, which must not be the root.
+func (q *PairingPriorityQueue[T]) cut(h *PairingHandle[T]) {
+	if h.prev.child == h {
+		h.prev.child = h.sibling
+	} else {
+		h.prev.sibling = h.sibling
+	}
+	if h.sibling != nil {
+		h.sibling.prev = h.prev
+	}
+	h.prev, h.sibling = nil, nil
+}
+
+// release marks a removed handle as no longer in the queue.
+func (q *PairingPriorityQueue[T]) release(h *PairingHandle[T]) {
+	var zero T
+	h.item = zero
+	h.child, h.sibling, h.prev = nil, nil, nil
+	h.inHeap = false
+}
+
+// snapshotLocked copies the items. Callers must hold at least a read lock.
+func (q *PairingPriorityQueue[T]) snapshotLocked() []T {
+	snapshot := make([]T, 0, q.size)
+	var stack []*PairingHandle[T]
+	if q.root != nil {
+		stack = append(stack, q.root)
+	}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		snapshot = append(snapshot, h.item)
+		for c := h.child; c != nil; c = c.sibling {
+			stack = append(stack, c)
+		}
+	}
+	return snapshot
+}
+
+// NewPairingPriorityQueue creates a new pairing heap priority queue using the given comparator.
+func NewPairingPriorityQueue[T any](less func(a, b T) bool) *PairingPriorityQueue[T] {
+	return &PairingPriorityQueue[T]{less: less}
+}
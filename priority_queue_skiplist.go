@@ -0,0 +1,307 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+const skipListMaxLevel = 32 // supports well over 2^32 items at p = 1/2
+
+// skipNode is a node of a SkipListPriorityQueue. Sentinels use kind -1 (head) and +1 (tail).
+type skipNode[T any] struct {
+	item        T
+	seq         uint64 // push order, making every node unique and equal items FIFO
+	kind        int8
+	next        []atomic.Pointer[skipNode[T]]
+	mu          sync.Mutex
+	marked      atomic.Bool // logically removed
+	fullyLinked atomic.Bool // linked at all its levels
+}
+
+// SkipListPriorityQueue is a concurrent priority queue backed by a lazily locked skip list, for
+// heavily contended producer/consumer workloads where the single lock around a binary heap is the
+// bottleneck.
+//
+// There is no queue-wide lock: traversals are lock-free, and Push and Pop only lock the few nodes
+// adjacent to the one they link or unlink, so operations on different parts of the list proceed
+// in parallel. Pop claims the minimum by marking it, then unlinks it.
+//
+// Under concurrent modification, Len, Peek and the iterators are weakly consistent: they reflect
+// the operations completed when they run, and possibly some in progress. Items comparing equal
+// are popped in push order.
+//
+// The zero value is not ready to use; construct via NewSkipListPriorityQueue. The less(a,b)
+// comparator must define a strict weak ordering (irreflexive, transitive, consistent).
+//
+// Complexity: Push O(log n) expected, Pop/PopIf O(log n) expected, Peek O(1) expected.
+type SkipListPriorityQueue[T any] struct {
+	head *skipNode[T]
+	less func(a, b T) bool
+	seq  atomic.Uint64
+	size atomic.Int64
+}
+
+// Push inserts one or more items into the queue.
+func (q *SkipListPriorityQueue[T]) Push(items ...T) {
+	for _, x := range items {
+		q.insert(x)
+	}
+}
+
+// Pop removes and returns the minimum item per the comparator.
+func (q *SkipListPriorityQueue[T]) Pop() (item T, ok bool) {
+	return q.popMin(nil)
+}
+
+// PopIf atomically removes and returns the minimum item only if pred accepts it. pred is called
+// while the item is locked and must not call back into the queue. If the queue is empty or pred
+// returns false, it returns ok == false.
+func (q *SkipListPriorityQueue[T]) PopIf(pred func(min T) bool) (item T, ok bool) {
+	return q.popMin(pred)
+}
+
+// Peek returns the minimum item without removing it.
+func (q *SkipListPriorityQueue[T]) Peek() (item T, ok bool) {
+	if n := q.first(); n != nil {
+		return n.item, true
+	}
+	return item, false
+}
+
+// Len returns the number of items.
+func (q *SkipListPriorityQueue[T]) Len() int {
+	return int(q.size.Load())
+}
+
+// Clear removes all items. Items pushed concurrently may or may not be removed.
+func (q *SkipListPriorityQueue[T]) Clear() {
+	for {
+		if _, ok := q.Pop(); !ok {
+			return
+		}
+	}
+}
+
+// Range iterates over a snapshot of items in priority order. Mutations during range does not
+// affect the current iteration.
+func (q *SkipListPriorityQueue[T]) Range(f func(item T) bool) {
+	for item := range q.All() {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over a snapshot of the items. Since the skip list is kept sorted, the
+// items are yielded in priority order.
+func (q *SkipListPriorityQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range q.snapshot() {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *SkipListPriorityQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *SkipListPriorityQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AllSorted returns an iterator over a snapshot of the items in priority order, without
+// modifying the queue.
+func (q *SkipListPriorityQueue[T]) AllSorted() iter.Seq[T] {
+	return q.All()
+}
+
+// SliceSorted returns a copy of the items in priority order, without modifying the queue.
+func (q *SkipListPriorityQueue[T]) SliceSorted() []T {
+	return slices.Collect(q.All())
+}
+
+// Internal helpers
+
+// before reports whether node a sorts strictly before node b.
+func (q *SkipListPriorityQueue[T]) before(a, b *skipNode[T]) bool {
+	switch {
+	case a == b:
+		return false
+	case a.kind < 0 || b.kind > 0:
+		return true
+	case a.kind > 0 || b.kind < 0:
+		return false
+	case q.less(a.item, b.item):
+		return true
+	case q.less(b.item, a.item):
+		return false
+	}
+	return a.seq < b.seq
+}
+
+// find fills preds and succs with, for each level, the last node before x and the node after it.
+func (q *SkipListPriorityQueue[T]) find(x *skipNode[T], preds, succs []*skipNode[T]) {
+	pred := q.head
+	for level := skipListMaxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for q.before(curr, x) {
+			pred = curr
+			curr = pred.next[level].Load()
+		}
+		preds[level], succs[level] = pred, curr
+	}
+}
+
+// lockPreds locks the distinct predecessors up to level top, in bottom-up (right-to-left) order,
+// and validates them with valid. It returns the highest level locked, for unlockPreds, and
+// whether all levels were valid.
+func lockPreds[T any](
+	preds []*skipNode[T],
+	top int,
+	valid func(level int) bool,
+) (highest int, ok bool) {
+	highest = -1
+	var prev *skipNode[T]
+	for level := 0; level <= top; level++ {
+		if preds[level] != prev {
+			preds[level].mu.Lock()
+			highest = level
+			prev = preds[level]
+		}
+		if !valid(level) {
+			return highest, false
+		}
+	}
+	return highest, true
+}
+
+// unlockPreds unlocks the predecessors locked by lockPreds.
+func unlockPreds[T any](preds []*skipNode[T], highest int) {
+	var prev *skipNode[T]
+	for level := 0; level <= highest; level++ {
+		if preds[level] != prev {
+			preds[level].mu.Unlock()
+			prev = preds[level]
+		}
+	}
+}
+
+func (q *SkipListPriorityQueue[T]) insert(item T) {
+	top := min(bits.TrailingZeros64(rand.Uint64()), skipListMaxLevel-1)
+	n := &skipNode[T]{
+		item: item,
+		seq:  q.seq.Add(1),
+		next: make([]atomic.Pointer[skipNode[T]], top+1),
+	}
+	var preds, succs [skipListMaxLevel]*skipNode[T]
+	for {
+		q.find(n, preds[:], succs[:])
+		highest, ok := lockPreds(preds[:], top, func(level int) bool {
+			pred, succ := preds[level], succs[level]
+			return !pred.marked.Load() && !succ.marked.Load() && pred.next[level].Load() == succ
+		})
+		if !ok {
+			unlockPreds(preds[:], highest)
+			continue
+		}
+		for level := 0; level <= top; level++ {
+			n.next[level].Store(succs[level])
+		}
+		for level := 0; level <= top; level++ {
+			preds[level].next[level].Store(n)
+		}
+		n.fullyLinked.Store(true)
+		unlockPreds(preds[:], highest)
+		q.size.Add(1)
+		return
+	}
+}
+
+// first returns the first fully linked, unremoved node, or nil if there is none.
+func (q *SkipListPriorityQueue[T]) first() *skipNode[T] {
+	for n := q.head.next[0].Load(); n.kind == 0; n = n.next[0].Load() {
+		if n.fullyLinked.Load() && !n.marked.Load() {
+			return n
+		}
+	}
+	return nil
+}
+
+// popMin claims the first node by marking it under its lock, if pred (when non-nil) accepts its
+// item, and then unlinks it.
+func (q *SkipListPriorityQueue[T]) popMin(pred func(min T) bool) (item T, ok bool) {
+	for {
+		victim := q.first()
+		if victim == nil {
+			return item, false
+		}
+		victim.mu.Lock()
+		if victim.marked.Load() {
+			victim.mu.Unlock()
+			continue
+		}
+		if pred != nil && !pred(victim.item) {
+			victim.mu.Unlock()
+			return item, false
+		}
+		victim.marked.Store(true)
+		q.unlink(victim)
+		victim.mu.Unlock()
+		q.size.Add(-1)
+		return victim.item, true
+	}
+}
+
+// unlink physically removes a marked node, whose lock the caller holds, from all its levels.
+func (q *SkipListPriorityQueue[T]) unlink(victim *skipNode[T]) {
+	top := len(victim.next) - 1
+	var preds, succs [skipListMaxLevel]*skipNode[T]
+	for {
+		q.find(victim, preds[:], succs[:])
+		highest, ok := lockPreds(preds[:], top, func(level int) bool {
+			pred := preds[level]
+			return !pred.marked.Load() && pred.next[level].Load() == victim
+		})
+		if !ok {
+			unlockPreds(preds[:], highest)
+			continue
+		}
+		for level := top; level >= 0; level-- {
+			preds[level].next[level].Store(victim.next[level].Load())
+		}
+		unlockPreds(preds[:], highest)
+		return
+	}
+}
+
+// snapshot returns the items of the fully linked, unremoved nodes in order.
+func (q *SkipListPriorityQueue[T]) snapshot() []T {
+	items := make([]T, 0, max(q.size.Load(), 0))
+	for n := q.head.next[0].Load(); n.kind == 0; n = n.next[0].Load() {
+		if n.fullyLinked.Load() && !n.marked.Load() {
+			items = append(items, n.item)
+		}
+	}
+	return items
+}
+
+// NewSkipListPriorityQueue creates a new skip-list priority queue using the given comparator.
+func NewSkipListPriorityQueue[T any](less func(a, b T) bool) *SkipListPriorityQueue[T] {
+	head := &skipNode[T]{kind: -1, next: make([]atomic.Pointer[skipNode[T]], skipListMaxLevel)}
+	tail := &skipNode[T]{kind: 1, next: make([]atomic.Pointer[skipNode[T]], skipListMaxLevel)}
+	for level := range head.next {
+		head.next[level].Store(tail)
+	}
+	tail.fullyLinked.Store(true)
+	return &SkipListPriorityQueue[T]{head: head, less: less}
+}
@@ -1,8 +1,13 @@
 package threadsafe
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
 	"math/rand"
 	"sort"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -35,6 +40,34 @@ func TestIndexedPriorityQueueImplementsInterface(_ *testing.T) {
 	var _ PriorityQueueIndexed[int] = &IndexedPriorityQueue[int]{}
 }
 
+func TestBoundedPriorityQueueImplementsInterface(_ *testing.T) {
+	var _ PriorityQueue[int] = &BoundedPriorityQueue[int]{}
+}
+
+func TestLazyPriorityQueueImplementsInterface(_ *testing.T) {
+	var _ PriorityQueue[int] = &LazyPriorityQueue[int, int]{}
+}
+
+func TestPairingPriorityQueueImplementsInterface(_ *testing.T) {
+	var _ PriorityQueue[int] = &PairingPriorityQueue[int]{}
+}
+
+func TestAgingPriorityQueueImplementsInterface(_ *testing.T) {
+	var _ PriorityQueue[int] = &AgingPriorityQueue[int]{}
+}
+
+func TestKeyedPriorityQueueImplementsInterface(_ *testing.T) {
+	var _ PriorityQueue[int] = &KeyedPriorityQueue[int, int]{}
+}
+
+func TestOrderedPriorityQueueImplementsInterface(_ *testing.T) {
+	var _ PriorityQueue[int] = &OrderedPriorityQueue[int]{}
+}
+
+func TestSkipListPriorityQueueImplementsInterface(_ *testing.T) {
+	var _ PriorityQueue[int] = &SkipListPriorityQueue[int]{}
+}
+
 // priorityQueueTestSuite defines a reusable test suite for PriorityQueue[T].
 // newPQ constructs a fresh queue for each test.
 type priorityQueueTestSuite[T any] struct {
@@ -173,12 +206,84 @@ func (s *priorityQueueTestSuite[T]) TestAllIterator(t *testing.T) {
 	assert.Equal(t, len(itms)+1, pq.Len())
 }
 
+func (s *priorityQueueTestSuite[T]) TestAllSorted(t *testing.T) {
+	pq := s.newPQ()
+	assert.Empty(t, collectSeq(pq.AllSorted()))
+
+	itms := s.items()
+	pq.Push(itms...)
+	sorted := collectSeq(pq.AllSorted())
+	assert.Len(t, sorted, len(itms))
+	assert.True(t, sort.SliceIsSorted(sorted, func(i, j int) bool {
+		return s.less(sorted[i], sorted[j])
+	}))
+	// The queue is left untouched
+	assert.Equal(t, len(itms), pq.Len())
+
+	// Stopping early yields the top item only
+	for item := range pq.AllSorted() {
+		top, _ := pq.Peek()
+		assert.Equal(t, s.prio(top), s.prio(item))
+		break
+	}
+
+	// Yields in the same order as Pop
+	for _, want := range sorted {
+		got, ok := pq.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, s.prio(want), s.prio(got))
+	}
+}
+
+func (s *priorityQueueTestSuite[T]) TestSliceSorted(t *testing.T) {
+	pq := s.newPQ()
+	assert.Empty(t, pq.SliceSorted())
+
+	itms := s.items()
+	pq.Push(itms...)
+	sorted := pq.SliceSorted()
+	assert.Len(t, sorted, len(itms))
+	assert.Equal(t, len(itms), pq.Len())
+	for _, want := range sorted {
+		got, ok := pq.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, s.prio(want), s.prio(got))
+	}
+}
+
+func (s *priorityQueueTestSuite[T]) TestPopIf(t *testing.T) {
+	pq := s.newPQ()
+	_, ok := pq.PopIf(func(T) bool { return true })
+	assert.False(t, ok)
+
+	itms := s.items()
+	pq.Push(itms...)
+	top, _ := pq.Peek()
+	calls := 0
+	_, ok = pq.PopIf(func(min T) bool {
+		calls++
+		assert.Equal(t, s.prio(top), s.prio(min))
+		return false
+	})
+	assert.False(t, ok)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, len(itms), pq.Len())
+
+	got, ok := pq.PopIf(func(T) bool { return true })
+	assert.True(t, ok)
+	assert.Equal(t, s.prio(top), s.prio(got))
+	assert.Equal(t, len(itms)-1, pq.Len())
+}
+
 // runPriorityQueueTestSuite runs common tests for a PriorityQueue implementation.
 func runPriorityQueueTestSuite[T any](t *testing.T, s *priorityQueueTestSuite[T]) {
 	t.Run("BasicOperations", s.TestBasicOperations)
 	t.Run("FixUpdateRemove", s.TestFixUpdateRemove)
 	t.Run("ConcurrentOperations", s.TestConcurrentOperations)
 	t.Run("AllIterator", s.TestAllIterator)
+	t.Run("AllSorted", s.TestAllSorted)
+	t.Run("SliceSorted", s.TestSliceSorted)
+	t.Run("PopIf", s.TestPopIf)
 }
 
 // TestPriorityQueueImplementations runs the test suite for both implementations.
@@ -208,6 +313,137 @@ func TestPriorityQueueImplementations(t *testing.T) {
 		}
 		runPriorityQueueTestSuite(t, s)
 	})
+
+	t.Run("LazyPriorityQueue", func(t *testing.T) {
+		s := &priorityQueueTestSuite[heapTestItem]{
+			newPQ: func() PriorityQueue[heapTestItem] {
+				return NewLazyPriorityQueue(lessItem, func(x heapTestItem) string { return x.ID })
+			},
+			less:  lessItem,
+			prio:  func(x heapTestItem) int { return x.Prio },
+			items: items,
+		}
+		runPriorityQueueTestSuite(t, s)
+	})
+
+	t.Run("PairingPriorityQueue", func(t *testing.T) {
+		s := &priorityQueueTestSuite[heapTestItem]{
+			newPQ: func() PriorityQueue[heapTestItem] { return NewPairingPriorityQueue(lessItem) },
+			less:  lessItem,
+			prio:  func(x heapTestItem) int { return x.Prio },
+			items: items,
+		}
+		runPriorityQueueTestSuite(t, s)
+	})
+
+	t.Run("BoundedPriorityQueue", func(t *testing.T) {
+		s := &priorityQueueTestSuite[heapTestItem]{
+			newPQ: func() PriorityQueue[heapTestItem] {
+				return NewBoundedPriorityQueue(lessItem, 1024)
+			},
+			less:  lessItem,
+			prio:  func(x heapTestItem) int { return x.Prio },
+			items: items,
+		}
+		runPriorityQueueTestSuite(t, s)
+	})
+
+	t.Run("AgingPriorityQueue", func(t *testing.T) {
+		s := &priorityQueueTestSuite[heapTestItem]{
+			newPQ: func() PriorityQueue[heapTestItem] {
+				// Without a boost, aging reduces to plain priority order
+				return NewAgingPriorityQueue(func(x heapTestItem, _ time.Duration) float64 {
+					return float64(x.Prio)
+				}, time.Minute)
+			},
+			less:  lessItem,
+			prio:  func(x heapTestItem) int { return x.Prio },
+			items: items,
+		}
+		runPriorityQueueTestSuite(t, s)
+	})
+
+	t.Run("SkipListPriorityQueue", func(t *testing.T) {
+		s := &priorityQueueTestSuite[heapTestItem]{
+			newPQ: func() PriorityQueue[heapTestItem] { return NewSkipListPriorityQueue(lessItem) },
+			less:  lessItem,
+			prio:  func(x heapTestItem) int { return x.Prio },
+			items: items,
+		}
+		runPriorityQueueTestSuite(t, s)
+	})
+}
+
+func TestKeyedPriorityQueue(t *testing.T) {
+	pq := NewKeyedPriorityQueue(lessItem, func(x heapTestItem) string { return x.ID })
+	pq.Push(
+		heapTestItem{ID: "a", Prio: 5},
+		heapTestItem{ID: "b", Prio: 3},
+		heapTestItem{ID: "c", Prio: 8},
+		heapTestItem{ID: "d", Prio: 1},
+	)
+	assert.Equal(t, 4, pq.Len())
+	assert.True(t, pq.Contains("c"))
+	assert.False(t, pq.Contains("z"))
+
+	// Decrease-key moves the item to the front
+	assert.True(t, pq.UpdatePriority("c", heapTestItem{ID: "c", Prio: 0}))
+	top, ok := pq.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, "c", top.ID)
+	assert.False(t, pq.UpdatePriority("z", heapTestItem{ID: "z"}))
+	assert.Equal(t, 4, pq.Len())
+
+	// Pushing an existing key replaces the item
+	pq.Push(heapTestItem{ID: "a", Prio: 2})
+	assert.Equal(t, 4, pq.Len())
+	item, ok := pq.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, item.Prio)
+
+	item, ok = pq.Remove("d")
+	assert.True(t, ok)
+	assert.Equal(t, 1, item.Prio)
+	_, ok = pq.Remove("d")
+	assert.False(t, ok)
+	_, ok = pq.Get("d")
+	assert.False(t, ok)
+
+	var order []string
+	for {
+		item, ok := pq.Pop()
+		if !ok {
+			break
+		}
+		order = append(order, item.ID)
+	}
+	assert.Equal(t, []string{"c", "a", "b"}, order)
+	assert.False(t, pq.Contains("c"))
+
+	// Randomized updates and removals keep the heap and index consistent
+	pq2 := NewKeyedPriorityQueue(
+		func(a, b int) bool { return a < b },
+		func(x int) int { return x % 100 },
+	)
+	r := rand.New(rand.NewSource(1))
+	for range 2000 {
+		k := r.Intn(100)
+		switch r.Intn(3) {
+		case 0:
+			pq2.Push(k + 100*r.Intn(50))
+		case 1:
+			pq2.UpdatePriority(k, k+100*r.Intn(50))
+		default:
+			pq2.Remove(k)
+		}
+	}
+	var popped []int
+	for pq2.Len() > 0 {
+		x, _ := pq2.Pop()
+		assert.False(t, pq2.Contains(x%100))
+		popped = append(popped, x)
+	}
+	assert.True(t, sort.IntsAreSorted(popped))
 }
 
 //
@@ -290,4 +526,837 @@ func BenchmarkPriorityQueueImplementations(b *testing.B) {
 			return NewIndexedPriorityQueue(func(a, b int) bool { return a < b }, nil)
 		})
 	})
+
+	b.Run("SkipListPriorityQueue", func(b *testing.B) {
+		benchmarkPriorityQueue(b, func() PriorityQueue[int] {
+			return NewSkipListPriorityQueue(func(a, b int) bool { return a < b })
+		})
+	})
+}
+
+// BenchmarkPriorityQueueContended compares queues under producer/consumer contention, where every
+// goroutine alternates between pushing and popping.
+func BenchmarkPriorityQueueContended(b *testing.B) {
+	less := func(a, b int) bool { return a < b }
+	for _, impl := range []struct {
+		name  string
+		newPQ func() PriorityQueue[int]
+	}{
+		{"CorePriorityQueue", func() PriorityQueue[int] { return NewCorePriorityQueue(less) }},
+		{"SkipListPriorityQueue", func() PriorityQueue[int] {
+			return NewSkipListPriorityQueue(less)
+		}},
+	} {
+		b.Run(impl.name, func(b *testing.B) {
+			pq := impl.newPQ()
+			fillPQ(pq, 10000)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewSource(rand.Int63()))
+				for pb.Next() {
+					pq.Push(r.Intn(1 << 20))
+					pq.Pop()
+				}
+			})
+		})
+	}
+}
+
+func TestBoundedPriorityQueue(t *testing.T) {
+	pq := NewBoundedPriorityQueue(lessItem, 3)
+	assert.Equal(t, 3, pq.Cap())
+
+	assert.Nil(t, pq.PushEvict(
+		heapTestItem{ID: "a", Prio: 5},
+		heapTestItem{ID: "b", Prio: 3},
+		heapTestItem{ID: "c", Prio: 8},
+	))
+	worst, ok := pq.PeekWorst()
+	assert.True(t, ok)
+	assert.Equal(t, "c", worst.ID)
+
+	// A better item evicts the worst one, a worse item is rejected
+	assert.Equal(t, []heapTestItem{{ID: "c", Prio: 8}}, pq.PushEvict(heapTestItem{ID: "d", Prio: 1}))
+	assert.Equal(t, []heapTestItem{{ID: "e", Prio: 9}}, pq.PushEvict(heapTestItem{ID: "e", Prio: 9}))
+	assert.Equal(t, 3, pq.Len())
+
+	best, ok := pq.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, "d", best.ID)
+	worst, ok = pq.PopWorst()
+	assert.True(t, ok)
+	assert.Equal(t, "a", worst.ID)
+
+	var order []string
+	for {
+		item, ok := pq.Pop()
+		if !ok {
+			break
+		}
+		order = append(order, item.ID)
+	}
+	assert.Equal(t, []string{"d", "b"}, order)
+	_, ok = pq.PeekWorst()
+	assert.False(t, ok)
+	assert.Equal(t, 1, NewBoundedPriorityQueue(lessItem, 0).Cap())
+
+	// Randomized: the queue keeps the K best items and pops them from both ends in order
+	const k = 50
+	ints := NewBoundedPriorityQueue(func(a, b int) bool { return a < b }, k)
+	r := rand.New(rand.NewSource(1))
+	var all []int
+	for range 1000 {
+		x := r.Intn(10000)
+		all = append(all, x)
+		ints.Push(x)
+	}
+	sort.Ints(all)
+	want := all[:k]
+	for len(want) > 0 {
+		if r.Intn(2) == 0 {
+			x, _ := ints.Pop()
+			assert.Equal(t, want[0], x)
+			want = want[1:]
+		} else {
+			x, _ := ints.PopWorst()
+			assert.Equal(t, want[len(want)-1], x)
+			want = want[:len(want)-1]
+		}
+	}
+	assert.Equal(t, 0, ints.Len())
+}
+
+func TestLazyPriorityQueue(t *testing.T) {
+	pq := NewLazyPriorityQueue(lessItem, func(x heapTestItem) string { return x.ID })
+	pq.Push(
+		heapTestItem{ID: "a", Prio: 1},
+		heapTestItem{ID: "b", Prio: 2},
+		heapTestItem{ID: "a", Prio: 3},
+		heapTestItem{ID: "c", Prio: 4},
+		heapTestItem{ID: "d", Prio: 5},
+	)
+
+	// Remove deletes all items with the key
+	assert.Equal(t, 2, pq.Remove("a"))
+	assert.Equal(t, 0, pq.Remove("a"))
+	assert.False(t, pq.Contains("a"))
+	assert.Equal(t, 3, pq.Len())
+	top, ok := pq.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, "b", top.ID)
+
+	// Items pushed after a removal are live
+	pq.Push(heapTestItem{ID: "a", Prio: 0})
+	assert.True(t, pq.Contains("a"))
+
+	assert.Equal(t, 1, pq.RemoveFunc(func(x heapTestItem) bool { return x.Prio == 4 }))
+	assert.Equal(t, 3, pq.Len())
+	assert.ElementsMatch(t, []string{"a", "b", "d"}, func() []string {
+		var ids []string
+		pq.Range(func(x heapTestItem) bool { ids = append(ids, x.ID); return true })
+		return ids
+	}())
+
+	var order []string
+	for {
+		item, ok := pq.Pop()
+		if !ok {
+			break
+		}
+		order = append(order, item.ID)
+	}
+	assert.Equal(t, []string{"a", "b", "d"}, order)
+	assert.Empty(t, pq.entries)
+	assert.Empty(t, pq.tombstones)
+	assert.Empty(t, pq.stored)
+
+	// Randomized: compare against a reference multiset
+	ints := NewLazyPriorityQueue(
+		func(a, b int) bool { return a < b },
+		func(x int) int { return x % 10 },
+	)
+	r := rand.New(rand.NewSource(1))
+	ref := map[int]int{}
+	for range 3000 {
+		switch r.Intn(4) {
+		case 0, 1:
+			x := r.Intn(1000)
+			ints.Push(x)
+			ref[x]++
+		case 2:
+			k := r.Intn(10)
+			removed := 0
+			for x, n := range ref {
+				if x%10 == k {
+					removed += n
+					delete(ref, x)
+				}
+			}
+			assert.Equal(t, removed, ints.Remove(k))
+		default:
+			if x, ok := ints.Pop(); ok {
+				for y := range ref {
+					assert.GreaterOrEqual(t, y, x)
+				}
+				ref[x]--
+				if ref[x] == 0 {
+					delete(ref, x)
+				}
+			}
+		}
+	}
+	total := 0
+	for _, n := range ref {
+		total += n
+	}
+	assert.Equal(t, total, ints.Len())
+	ints.Compact()
+	assert.Len(t, ints.entries, total)
+}
+
+func TestPriorityQueueAllSortedRandom(t *testing.T) {
+	pq := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	r := rand.New(rand.NewSource(1))
+	for range 500 {
+		pq.Push(r.Intn(1000))
+	}
+	sorted := collectSeq(pq.AllSorted())
+	assert.Len(t, sorted, 500)
+	assert.True(t, sort.IntsAreSorted(sorted))
+	assert.Equal(t, 500, pq.Len())
+}
+
+func TestPairingPriorityQueue(t *testing.T) {
+	pq := NewPairingPriorityQueue(lessItem)
+	a := pq.PushHandle(heapTestItem{ID: "a", Prio: 5})
+	b := pq.PushHandle(heapTestItem{ID: "b", Prio: 3})
+	c := pq.PushHandle(heapTestItem{ID: "c", Prio: 8})
+	pq.Push(heapTestItem{ID: "d", Prio: 4})
+
+	// Decrease-key
+	assert.True(t, pq.Update(c, heapTestItem{ID: "c", Prio: 1}))
+	top, _ := pq.Peek()
+	assert.Equal(t, "c", top.ID)
+
+	// Increase-key, including on the root
+	assert.True(t, pq.Update(c, heapTestItem{ID: "c", Prio: 9}))
+	top, _ = pq.Peek()
+	assert.Equal(t, "b", top.ID)
+
+	item, ok := pq.Remove(a)
+	assert.True(t, ok)
+	assert.Equal(t, "a", item.ID)
+	_, ok = pq.Remove(a)
+	assert.False(t, ok)
+	assert.False(t, pq.Update(a, heapTestItem{ID: "a"}))
+	assert.Equal(t, 3, pq.Len())
+
+	// Meld keeps handles of the other queue usable
+	other := NewPairingPriorityQueue(lessItem)
+	e := other.PushHandle(heapTestItem{ID: "e", Prio: 7})
+	pq.Meld(other)
+	assert.Equal(t, 0, other.Len())
+	assert.Equal(t, 4, pq.Len())
+	assert.True(t, pq.Update(e, heapTestItem{ID: "e", Prio: 2}))
+
+	var order []string
+	for item := range pq.AllSorted() {
+		order = append(order, item.ID)
+	}
+	assert.Equal(t, []string{"e", "b", "d", "c"}, order)
+
+	item, ok = pq.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, "e", item.ID)
+	assert.False(t, pq.Update(e, heapTestItem{ID: "e"}))
+
+	pq.Clear()
+	assert.Equal(t, 0, pq.Len())
+	_, ok = pq.Remove(b)
+	assert.False(t, ok)
+
+	// Randomized updates and removals keep the heap consistent
+	ints := NewPairingPriorityQueue(func(a, b int) bool { return a < b })
+	r := rand.New(rand.NewSource(1))
+	handles := map[*PairingHandle[int]]int{}
+	for range 3000 {
+		switch r.Intn(4) {
+		case 0:
+			x := r.Intn(1000)
+			handles[ints.PushHandle(x)] = x
+		case 1, 2:
+			for h := range handles {
+				if r.Intn(2) == 0 {
+					x := r.Intn(1000)
+					assert.True(t, ints.Update(h, x))
+					handles[h] = x
+				} else {
+					x, ok := ints.Remove(h)
+					assert.True(t, ok)
+					assert.Equal(t, handles[h], x)
+					delete(handles, h)
+				}
+				break
+			}
+		default:
+			if x, ok := ints.Pop(); ok {
+				for h, y := range handles {
+					assert.GreaterOrEqual(t, y, x)
+					if y == x && !h.inHeap {
+						delete(handles, h)
+					}
+				}
+			}
+		}
+	}
+	assert.Equal(t, len(handles), ints.Len())
+	var popped []int
+	for ints.Len() > 0 {
+		x, _ := ints.Pop()
+		popped = append(popped, x)
+	}
+	assert.True(t, sort.IntsAreSorted(popped))
+}
+
+func TestIndexedPriorityQueueFind(t *testing.T) {
+	pq := NewIndexedPriorityQueue(lessItem, onSwapItem)
+	pq.Push(
+		heapTestItem{ID: "a", Prio: 5},
+		heapTestItem{ID: "b", Prio: 3},
+		heapTestItem{ID: "c", Prio: 8},
+	)
+
+	item, idx, ok := pq.Find(func(x heapTestItem) bool { return x.ID == "c" })
+	assert.True(t, ok)
+	assert.Equal(t, "c", item.ID)
+
+	// The index is usable with the index-based helpers
+	item.Prio = 1
+	assert.True(t, pq.UpdateAt(idx, item))
+	top, _ := pq.Peek()
+	assert.Equal(t, "c", top.ID)
+
+	_, idx, ok = pq.Find(func(x heapTestItem) bool { return x.ID == "a" })
+	assert.True(t, ok)
+	removed, ok := pq.RemoveAt(idx)
+	assert.True(t, ok)
+	assert.Equal(t, "a", removed.ID)
+
+	_, idx, ok = pq.Find(func(x heapTestItem) bool { return x.ID == "z" })
+	assert.False(t, ok)
+	assert.Equal(t, -1, idx)
+}
+
+func TestPriorityQueueKV(t *testing.T) {
+	pq := NewPriorityQueueKV[int, string]()
+	pq.Push(5, "e")
+	pq.Push(1, "a")
+	pq.Push(3, "c1")
+	pq.Push(3, "c2")
+	assert.Equal(t, 4, pq.Len())
+
+	item, prio, ok := pq.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, "a", item)
+	assert.Equal(t, 1, prio)
+
+	// Equal priorities keep push order
+	assert.Equal(t, []string{"a", "c1", "c2", "e"}, pq.SliceSorted())
+	var prios []int
+	for p := range pq.AllSorted() {
+		prios = append(prios, p)
+	}
+	assert.Equal(t, []int{1, 3, 3, 5}, prios)
+
+	var popped []string
+	for {
+		item, _, ok := pq.Pop()
+		if !ok {
+			break
+		}
+		popped = append(popped, item)
+	}
+	assert.Equal(t, []string{"a", "c1", "c2", "e"}, popped)
+
+	_, prio, ok = pq.Pop()
+	assert.False(t, ok)
+	assert.Zero(t, prio)
+
+	pq.Push(2, "x")
+	pq.Clear()
+	assert.Equal(t, 0, pq.Len())
+}
+
+func TestPriorityQueueStats(t *testing.T) {
+	pq := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	pq.Push(3, 1, 2)
+	_, _ = pq.Pop()
+	stats := pq.Stats()
+	assert.Equal(t, uint64(3), stats.Pushes)
+	assert.Equal(t, uint64(1), stats.Pops)
+	assert.Equal(t, 2, stats.Len)
+	assert.Equal(t, 3, stats.MaxLen)
+	assert.Zero(t, stats.AvgWait)
+
+	pq.EnableWaitTracking()
+	pq.Push(0, 5)
+	time.Sleep(5 * time.Millisecond)
+	for pq.Len() > 0 {
+		_, _ = pq.Pop()
+	}
+	stats = pq.Stats()
+	assert.Equal(t, uint64(5), stats.Pushes)
+	assert.Equal(t, uint64(5), stats.Pops)
+	assert.Equal(t, 0, stats.Len)
+	assert.Equal(t, 4, stats.MaxLen)
+	assert.GreaterOrEqual(t, stats.AvgWait, 5*time.Millisecond)
+
+	// Timestamps follow items through swaps and removals
+	ipq := NewIndexedPriorityQueue(lessItem, onSwapItem)
+	ipq.EnableWaitTracking()
+	ipq.Push(heapTestItem{ID: "a", Prio: 2}, heapTestItem{ID: "b", Prio: 1})
+	_, idx, _ := ipq.Find(func(x heapTestItem) bool { return x.ID == "a" })
+	_, ok := ipq.RemoveAt(idx)
+	assert.True(t, ok)
+	ipq.Push(heapTestItem{ID: "c", Prio: 0})
+	ipq.Clear()
+	ipq.Push(heapTestItem{ID: "d", Prio: 3})
+	_, _ = ipq.Pop()
+	stats = ipq.Stats()
+	assert.Equal(t, uint64(4), stats.Pushes)
+	assert.Equal(t, uint64(2), stats.Pops)
+	assert.Equal(t, 0, stats.Len)
+	assert.Equal(t, 2, stats.MaxLen)
+
+	kv := NewPriorityQueueKV[int, string]()
+	kv.Push(1, "a")
+	assert.Equal(t, uint64(1), kv.Stats().Pushes)
+
+	// Restored items replace the contents without counting as pushes
+	restored := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	restored.EnableWaitTracking()
+	restored.Push(9)
+	assert.NoError(t, json.Unmarshal([]byte(`[3, 1, 2, 4]`), restored))
+	for restored.Len() > 0 {
+		_, _ = restored.Pop()
+	}
+	stats = restored.Stats()
+	assert.Equal(t, uint64(1), stats.Pushes)
+	assert.Equal(t, uint64(4), stats.Pops)
+	assert.Equal(t, 4, stats.MaxLen)
+
+	ipq = NewIndexedPriorityQueue(lessItem, onSwapItem)
+	assert.NoError(t, json.Unmarshal([]byte(`[{"ID":"a","Prio":1}]`), ipq))
+	stats = ipq.Stats()
+	assert.Equal(t, uint64(0), stats.Pushes)
+	assert.Equal(t, 1, stats.Len)
+}
+
+func TestOrderedPriorityQueueConstructors(t *testing.T) {
+	minPQ := NewMinPriorityQueue[float64]()
+	minPQ.Push(2.5, -1, 0)
+	assert.Equal(t, []float64{-1, 0, 2.5}, minPQ.SliceSorted())
+
+	maxPQ := NewMaxPriorityQueue[time.Duration]()
+	maxPQ.Push(time.Second, time.Hour, time.Minute)
+	top, _ := maxPQ.Pop()
+	assert.Equal(t, time.Hour, top)
+}
+
+func TestOrderedPriorityQueueZeroValue(t *testing.T) {
+	var pq OrderedPriorityQueue[string]
+	_, ok := pq.Pop()
+	assert.False(t, ok)
+
+	pq.Push("c", "a", "b")
+	top, ok := pq.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, "a", top)
+	assert.Equal(t, []string{"a", "b", "c"}, pq.SliceSorted())
+
+	var ints OrderedPriorityQueue[int]
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 50 {
+				ints.Push(g*50 + i)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 400, ints.Len())
+	assert.True(t, sort.IntsAreSorted(ints.SliceSorted()))
+}
+
+func TestPriorityQueueKVZeroValue(t *testing.T) {
+	var pq PriorityQueueKV[int, string]
+	_, _, ok := pq.Pop()
+	assert.False(t, ok)
+
+	pq.Push(2, "b")
+	pq.Push(1, "a")
+	item, priority, ok := pq.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, "a", item)
+	assert.Equal(t, 1, priority)
+	assert.Equal(t, 1, pq.Len())
+}
+
+func TestPriorityQueueBulkPush(t *testing.T) {
+	rnd := rand.New(rand.NewSource(5))
+	ipq := NewIndexedPriorityQueue(lessItem, onSwapItem)
+	ipq.EnableWaitTracking()
+	for _, size := range []int{2, 50, 10, 200} {
+		batch := make([]heapTestItem, size)
+		for i := range batch {
+			batch[i] = heapTestItem{ID: strconv.Itoa(i), Prio: rnd.Intn(1000)}
+		}
+		ipq.Push(batch...)
+	}
+	assert.Equal(t, uint64(262), ipq.Stats().Pushes)
+
+	var prios []int
+	for ipq.Len() > 0 {
+		item, _ := ipq.Pop()
+		prios = append(prios, item.Prio)
+	}
+	assert.True(t, sort.IntsAreSorted(prios))
+}
+
+func BenchmarkPriorityQueueBulkPush(b *testing.B) {
+	batch := make([]int, 10000)
+	for i := range batch {
+		batch[i] = len(batch) - i
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		pq := NewMinPriorityQueue[int]()
+		pq.Push(batch...)
+	}
+}
+
+func TestPriorityQueueSaveLoad(t *testing.T) {
+	src := NewCorePriorityQueue(lessItem)
+	src.Push(
+		heapTestItem{ID: "a", Prio: 3},
+		heapTestItem{ID: "b", Prio: 1},
+		heapTestItem{ID: "c", Prio: 2},
+	)
+	var buf bytes.Buffer
+	assert.NoError(t, src.SaveTo(&buf, JSONCodec[heapTestItem]{}))
+	data := bytes.Clone(buf.Bytes())
+
+	dst := NewCorePriorityQueue(lessItem)
+	dst.Push(heapTestItem{ID: "stale", Prio: 0})
+	assert.NoError(t, dst.LoadFrom(bytes.NewReader(data), JSONCodec[heapTestItem]{}))
+	assert.Equal(t, src.SliceSorted(), dst.SliceSorted())
+
+	// A truncated snapshot is rejected and leaves the queue unchanged
+	err := dst.LoadFrom(bytes.NewReader(data[:len(data)-2]), JSONCodec[heapTestItem]{})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, 3, dst.Len())
+
+	// Loading with an inverted comparator rebuilds the heap for the new ordering
+	maxPQ := NewMaxPriorityQueue[int]()
+	var ints OrderedPriorityQueue[int]
+	ints.Push(5, 1, 4, 2, 3)
+	buf.Reset()
+	assert.NoError(t, ints.SaveTo(&buf, GobCodec[int]{}))
+	assert.NoError(t, maxPQ.LoadFrom(&buf, GobCodec[int]{}))
+	assert.Equal(t, []int{5, 4, 3, 2, 1}, maxPQ.SliceSorted())
+
+	// Indexed queues resynchronize external indices on load
+	ipq := NewIndexedPriorityQueue(lessItem, onSwapItem)
+	assert.NoError(t, ipq.LoadFrom(bytes.NewReader(data), JSONCodec[heapTestItem]{}))
+	ipq.Range(func(item heapTestItem) bool {
+		_, idx, _ := ipq.Find(func(x heapTestItem) bool { return x.ID == item.ID })
+		assert.Equal(t, idx, item.Idx)
+		return true
+	})
+	first, _ := ipq.Pop()
+	assert.Equal(t, "b", first.ID)
+}
+
+func TestPriorityQueueJSON(t *testing.T) {
+	pq := NewCorePriorityQueue(lessItem)
+	pq.Push(heapTestItem{ID: "a", Prio: 3}, heapTestItem{ID: "b", Prio: 1})
+	data, err := json.Marshal(pq)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"ID":"b","Prio":1,"Idx":0},{"ID":"a","Prio":3,"Idx":0}]`, string(data))
+
+	// Decoding rebuilds the heap, and syncs external indices on indexed queues
+	ipq := NewIndexedPriorityQueue(lessItem, onSwapItem)
+	assert.NoError(t, json.Unmarshal(data, ipq))
+	assert.Equal(t, []string{"b", "a"}, itemIDs(ipq.SliceSorted()))
+	ipq.Range(func(item heapTestItem) bool {
+		_, idx, _ := ipq.Find(func(x heapTestItem) bool { return x.ID == item.ID })
+		assert.Equal(t, idx, item.Idx)
+		return true
+	})
+
+	// Invalid input leaves the queue unchanged
+	assert.Error(t, json.Unmarshal([]byte(`[1,`), pq))
+	assert.Equal(t, 2, pq.Len())
+
+	var ordered OrderedPriorityQueue[int]
+	assert.NoError(t, json.Unmarshal([]byte(`[3,1,2]`), &ordered))
+	top, _ := ordered.Pop()
+	assert.Equal(t, 1, top)
+	data, err = json.Marshal(&ordered)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[2,3]`, string(data))
+}
+
+func TestPriorityQueueDrainTo(t *testing.T) {
+	var pq OrderedPriorityQueue[int]
+	pq.Push(3, 1, 2)
+	ch := make(chan int)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- pq.DrainTo(ctx, ch) }()
+
+	assert.Equal(t, []int{1, 2, 3}, []int{<-ch, <-ch, <-ch})
+	// Items pushed while draining are picked up
+	pq.Push(4)
+	assert.Equal(t, 4, <-ch)
+	cancel()
+	assert.ErrorIs(t, <-errc, context.Canceled)
+
+	// An item popped but not delivered is pushed back on cancellation
+	ipq := NewIndexedPriorityQueue(lessItem, nil)
+	ipq.Push(heapTestItem{ID: "a", Prio: 1}, heapTestItem{ID: "b", Prio: 2})
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	blocked := make(chan heapTestItem)
+	assert.ErrorIs(t, ipq.DrainTo(ctx, blocked), context.DeadlineExceeded)
+	assert.Equal(t, []string{"a", "b"}, itemIDs(ipq.SliceSorted()))
+
+	// A done context returns right away
+	assert.ErrorIs(t, NewCorePriorityQueue(lessItem).DrainTo(ctx, blocked), context.DeadlineExceeded)
+}
+
+func TestPriorityQueuePopWait(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	implementations := map[string]PopWaiter[int]{
+		"Core":    NewCorePriorityQueue(less),
+		"Ordered": &OrderedPriorityQueue[int]{},
+		"Bounded": NewBoundedPriorityQueue(less, 4),
+	}
+	for name, pq := range implementations {
+		t.Run(name, func(t *testing.T) {
+			result := make(chan int, 1)
+			go func() {
+				item, err := pq.PopWait(context.Background())
+				assert.NoError(t, err)
+				result <- item
+			}()
+			time.Sleep(5 * time.Millisecond) // let the consumer block
+			pq.(PriorityQueue[int]).Push(2)
+			assert.Equal(t, 2, <-result)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			_, err := pq.PopWait(ctx)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		})
+	}
+
+	ipq := NewIndexedPriorityQueue(lessItem, nil)
+	ipq.Push(heapTestItem{ID: "b", Prio: 2}, heapTestItem{ID: "a", Prio: 1})
+	item, err := ipq.PopWait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "a", item.ID)
+}
+
+func TestPriorityQueueReserve(t *testing.T) {
+	pq := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	pq.Reserve(0)
+	pq.Reserve(100)
+	assert.GreaterOrEqual(t, cap(pq.items), 100)
+	assert.Equal(t, 0, pq.Len())
+
+	ipq := NewIndexedPriorityQueue(lessItem, nil)
+	ipq.Push(heapTestItem{ID: "a", Prio: 1})
+	ipq.Reserve(50)
+	assert.GreaterOrEqual(t, cap(ipq.items), 51)
+	assert.Equal(t, 1, ipq.Len())
+
+	var ordered OrderedPriorityQueue[int]
+	ordered.Reserve(10)
+	ordered.Push(2, 1)
+	top, _ := ordered.Peek()
+	assert.Equal(t, 1, top)
+
+	h := NewMinHeap[int]()
+	h.Reserve(20)
+	assert.GreaterOrEqual(t, cap(h.data), 20)
+}
+
+// indexedTask tracks its own heap index through the Indexed interface.
+type indexedTask struct {
+	name  string
+	prio  int
+	index int
+}
+
+func (x *indexedTask) SetHeapIndex(i int) { x.index = i }
+func (x *indexedTask) HeapIndex() int     { return x.index }
+
+func TestIndexedPriorityQueueAutoIndex(t *testing.T) {
+	pq := NewIndexedPriorityQueue(func(a, b *indexedTask) bool { return a.prio < b.prio }, nil)
+	rnd := rand.New(rand.NewSource(11))
+	tasks := make([]*indexedTask, 50)
+	for i := range tasks {
+		tasks[i] = &indexedTask{name: strconv.Itoa(i), prio: rnd.Intn(100)}
+	}
+	pq.Push(tasks[:5]...)
+	pq.Push(tasks[5:]...)
+
+	checkIndices := func() {
+		i := 0
+		pq.Range(func(x *indexedTask) bool {
+			assert.Equal(t, i, x.HeapIndex())
+			i++
+			return true
+		})
+	}
+	checkIndices()
+
+	// Reprioritize and remove through the items themselves
+	tasks[7].prio = -1
+	assert.True(t, pq.FixItem(tasks[7]))
+	top, _ := pq.Peek()
+	assert.Same(t, tasks[7], top)
+	assert.True(t, pq.RemoveItem(tasks[3]))
+	assert.Equal(t, -1, tasks[3].HeapIndex())
+	assert.False(t, pq.RemoveItem(tasks[3]))
+	assert.False(t, pq.FixItem(&indexedTask{}))
+	checkIndices()
+
+	popped, _ := pq.Pop()
+	assert.Same(t, tasks[7], popped)
+	assert.Equal(t, -1, popped.HeapIndex())
+	assert.True(t, pq.UpdateAt(tasks[9].HeapIndex(), tasks[9]))
+	checkIndices()
+
+	pq.Clear()
+	assert.Equal(t, -1, tasks[0].HeapIndex())
+
+	// Value types do not get automatic indices
+	plain := NewIndexedPriorityQueue(lessItem, nil)
+	plain.Push(heapTestItem{ID: "a"})
+	assert.False(t, plain.RemoveItem(heapTestItem{ID: "a"}))
+}
+
+func TestAgingPriorityQueue(t *testing.T) {
+	// One priority level per 10ms queued
+	score := func(x heapTestItem, waited time.Duration) float64 {
+		return float64(x.Prio) - float64(waited)/float64(10*time.Millisecond)
+	}
+	pq := NewAgingPriorityQueue(score, 0)
+	pq.Push(heapTestItem{ID: "old", Prio: 5})
+	time.Sleep(60 * time.Millisecond)
+	pq.Push(heapTestItem{ID: "new", Prio: 1})
+
+	// The long-waiting low-priority item has overtaken the new one
+	assert.Equal(t, []string{"old", "new"}, itemIDs(pq.SliceSorted()))
+	top, _ := pq.Peek()
+	assert.Equal(t, "old", top.ID)
+
+	// With a long reweight interval, scores stay as of push time until Reweight
+	lazy := NewAgingPriorityQueue(score, time.Hour)
+	lazy.Push(heapTestItem{ID: "old", Prio: 5})
+	time.Sleep(60 * time.Millisecond)
+	lazy.Push(heapTestItem{ID: "new", Prio: 1})
+	top, _ = lazy.Peek()
+	assert.Equal(t, "new", top.ID)
+	lazy.Reweight()
+	top, _ = lazy.Pop()
+	assert.Equal(t, "old", top.ID)
+	assert.Equal(t, 1, lazy.Len())
+
+	// Equal scores keep push order
+	fifo := NewAgingPriorityQueue(func(heapTestItem, time.Duration) float64 { return 0 }, 0)
+	fifo.Push(heapTestItem{ID: "a"}, heapTestItem{ID: "b"}, heapTestItem{ID: "c"})
+	var order []string
+	for fifo.Len() > 0 {
+		x, _ := fifo.Pop()
+		order = append(order, x.ID)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, order)
+}
+
+func TestSkipListPriorityQueue(t *testing.T) {
+	// Equal items keep push order
+	pq := NewSkipListPriorityQueue(lessItem)
+	pq.Push(heapTestItem{ID: "a"}, heapTestItem{ID: "b", Prio: -1}, heapTestItem{ID: "c"})
+	assert.Equal(t, []string{"b", "a", "c"}, itemIDs(pq.SliceSorted()))
+
+	// Concurrent producers and consumers pop every item exactly once
+	const goroutines = 8
+	const perGoroutine = 500
+	ints := NewSkipListPriorityQueue(func(a, b int) bool { return a < b })
+	var mu sync.Mutex
+	seen := make(map[int]int)
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Go(func() {
+			for i := range perGoroutine {
+				ints.Push(g*perGoroutine + i)
+				if x, ok := ints.Pop(); ok {
+					mu.Lock()
+					seen[x]++
+					mu.Unlock()
+				}
+			}
+		})
+	}
+	wg.Wait()
+	for {
+		x, ok := ints.Pop()
+		if !ok {
+			break
+		}
+		seen[x]++
+	}
+	assert.Len(t, seen, goroutines*perGoroutine)
+	for x, n := range seen {
+		if !assert.Equal(t, 1, n, "item %d popped %d times", x, n) {
+			break
+		}
+	}
+	assert.Equal(t, 0, ints.Len())
+
+	// Concurrent PopIf never removes a rejected item
+	ints.Push(1, 2, 3, 4)
+	for range goroutines {
+		wg.Go(func() { ints.PopIf(func(x int) bool { return x < 3 }) })
+	}
+	wg.Wait()
+	assert.Equal(t, []int{3, 4}, ints.SliceSorted())
+}
+
+func itemIDs(items []heapTestItem) []string {
+	ids := make([]string, len(items))
+	for i, x := range items {
+		ids[i] = x.ID
+	}
+	return ids
+}
+
+func TestPriorityQueuePopReleasesItems(t *testing.T) {
+	less := func(a, b *int) bool { return *a < *b }
+	one, two, three := 1, 2, 3
+
+	q := NewCorePriorityQueue(less)
+	q.Push(&one, &two)
+	q.Pop()
+	assert.Nil(t, q.items[:2][1], "the vacated slot is zeroed")
+
+	iq := NewIndexedPriorityQueue(less, nil)
+	iq.Push(&one, &two, &three)
+	iq.Pop()
+	assert.Nil(t, iq.items[:3][2], "the vacated slot is zeroed")
+	iq.RemoveAt(0)
+	assert.Nil(t, iq.items[:2][1], "the vacated slot is zeroed")
 }
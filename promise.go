@@ -0,0 +1,90 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"sync"
+)
+
+// Future is a read-only handle to a value produced asynchronously through a Promise. It can be
+// read any number of times, from any number of goroutines.
+//
+// The zero value is not ready to use; obtain a Future via Promise.Future.
+type Future[T any] struct {
+	done chan struct{}
+	v    T
+	err  error
+}
+
+// Get waits for the future to be settled and returns its value and error, or returns ctx.Err()
+// if ctx is done first.
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.v, f.err
+	default:
+	}
+	select {
+	case <-f.done:
+		return f.v, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done returns a channel that is closed once the future is settled.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Promise is the write side of a Future: a producer settles it exactly once with Resolve or
+// Reject, and consumers wait for the outcome through Future.
+//
+// The zero value is an unsettled promise ready to use. A Promise must not be copied after first
+// use.
+type Promise[T any] struct {
+	f    *Future[T]
+	init sync.Once // allocates f for zero-value usage
+	once sync.Once
+}
+
+// NewPromise creates a new, unsettled Promise.
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{}
+}
+
+// Future returns the read side of the promise.
+func (p *Promise[T]) Future() *Future[T] {
+	p.init.Do(func() {
+		p.f = &Future[T]{done: make(chan struct{})}
+	})
+	return p.f
+}
+
+// Resolve settles the promise with v. It returns false if the promise was already settled, in
+// which case v is discarded.
+func (p *Promise[T]) Resolve(v T) bool {
+	return p.settle(v, nil)
+}
+
+// Reject settles the promise with err. It returns false if the promise was already settled, in
+// which case err is discarded. Rejecting with a nil error is equivalent to resolving with the
+// zero value of T.
+func (p *Promise[T]) Reject(err error) bool {
+	var zero T
+	return p.settle(zero, err)
+}
+
+// Internal helpers
+
+func (p *Promise[T]) settle(v T, err error) bool {
+	settled := false
+	p.once.Do(func() {
+		f := p.Future()
+		f.v, f.err = v, err
+		close(f.done)
+		settled = true
+	})
+	return settled
+}
@@ -0,0 +1,69 @@
+package threadsafe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromise(t *testing.T) {
+	p := NewPromise[int]()
+	f := p.Future()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			v, err := f.Get(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, 42, v)
+		})
+	}
+	assert.True(t, p.Resolve(42))
+	wg.Wait()
+
+	// Only the first outcome counts
+	assert.False(t, p.Resolve(1))
+	assert.False(t, p.Reject(errors.New("late")))
+	select {
+	case <-f.Done():
+	default:
+		t.Error("Done not closed after Resolve")
+	}
+	v, err := f.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 42, v)
+}
+
+func TestPromiseReject(t *testing.T) {
+	errFailed := errors.New("failed")
+	p := NewPromise[string]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := p.Future().Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.True(t, p.Reject(errFailed))
+	// A settled future is returned even with a done context
+	_, err = p.Future().Get(ctx)
+	assert.ErrorIs(t, err, errFailed)
+}
+
+func TestPromiseZeroValue(t *testing.T) {
+	var p Promise[string]
+	f := p.Future()
+	assert.Same(t, f, p.Future())
+
+	assert.True(t, p.Resolve("done"))
+	v, err := f.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "done", v)
+
+	var rejected Promise[int]
+	assert.True(t, rejected.Reject(errors.New("failed")))
+	_, err = rejected.Future().Get(context.Background())
+	assert.EqualError(t, err, "failed")
+}
@@ -0,0 +1,155 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "sync"
+
+// subscriber is a single subscription of a PubSub.
+type subscriber[T any] struct {
+	ch     chan T
+	policy OverflowPolicy
+	mu     sync.Mutex    // serializes sends with closing ch
+	quit   chan struct{} // closed on cancel, releasing blocked publishers
+	closed bool
+}
+
+// PubSub distributes messages to subscribers by topic, in process. Each subscription has its own
+// buffered channel and OverflowPolicy, applied when its buffer is full: OverflowReject and
+// OverflowDropNewest discard the message for that subscriber, OverflowDropOldest discards its
+// oldest buffered message instead, and OverflowBlock waits for room or for the subscription to be
+// canceled. A slow subscriber thus only delays publishers if its policy is OverflowBlock.
+//
+// Topics are kept in a RWMutexMap of RWMutexSets of subscribers, and a topic is removed when its
+// last subscription is canceled.
+//
+// The zero value is ready to use, with unbuffered subscriptions and OverflowReject.
+type PubSub[K comparable, T any] struct {
+	mu     sync.Mutex // serializes subscription changes; Publish doesn't take it
+	topics RWMutexMap[K, *RWMutexSet[*subscriber[T]]]
+	buffer int
+	policy OverflowPolicy
+}
+
+// NewPubSub creates a new PubSub whose subscriptions default to the given buffer size, coerced to
+// at least 0, and overflow policy.
+func NewPubSub[K comparable, T any](buffer int, policy OverflowPolicy) *PubSub[K, T] {
+	return &PubSub[K, T]{
+		buffer: max(buffer, 0),
+		policy: policy,
+	}
+}
+
+// Subscribe subscribes to topic with the default buffer size and overflow policy. It returns the
+// channel messages are delivered on, and a function canceling the subscription and closing the
+// channel. cancel may be called more than once.
+func (ps *PubSub[K, T]) Subscribe(topic K) (ch <-chan T, cancel func()) {
+	return ps.SubscribeBuffered(topic, ps.buffer, ps.policy)
+}
+
+// SubscribeBuffered is like Subscribe, with a buffer size and overflow policy for this
+// subscription.
+func (ps *PubSub[K, T]) SubscribeBuffered(
+	topic K,
+	buffer int,
+	policy OverflowPolicy,
+) (ch <-chan T, cancel func()) {
+	sub := &subscriber[T]{
+		ch:     make(chan T, max(buffer, 0)),
+		policy: policy,
+		quit:   make(chan struct{}),
+	}
+	ps.mu.Lock()
+	subs, ok := ps.topics.Get(topic)
+	if !ok {
+		subs = NewRWMutexSet[*subscriber[T]]()
+		ps.topics.Set(topic, subs)
+	}
+	subs.Add(sub)
+	ps.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() { once.Do(func() { ps.unsubscribe(topic, sub) }) }
+}
+
+// Publish delivers msg to the current subscribers of topic according to their overflow policies,
+// and returns the number of subscribers it was delivered to.
+func (ps *PubSub[K, T]) Publish(topic K, msg T) int {
+	subs, ok := ps.topics.Get(topic)
+	if !ok {
+		return 0
+	}
+	delivered := 0
+	for sub := range subs.All() {
+		if sub.send(msg) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+// Subscribers returns the number of subscriptions to topic.
+func (ps *PubSub[K, T]) Subscribers(topic K) int {
+	if subs, ok := ps.topics.Get(topic); ok {
+		return subs.Len()
+	}
+	return 0
+}
+
+// Topics returns the number of topics with at least one subscription.
+func (ps *PubSub[K, T]) Topics() int {
+	return ps.topics.Len()
+}
+
+// Internal helpers
+
+func (ps *PubSub[K, T]) unsubscribe(topic K, sub *subscriber[T]) {
+	close(sub.quit)
+	ps.mu.Lock()
+	if subs, ok := ps.topics.Get(topic); ok {
+		subs.Delete(sub)
+		if subs.Len() == 0 {
+			ps.topics.Delete(topic)
+		}
+	}
+	ps.mu.Unlock()
+
+	sub.mu.Lock()
+	sub.closed = true
+	close(sub.ch)
+	sub.mu.Unlock()
+}
+
+// send delivers msg according to the subscriber's policy and reports whether it was delivered.
+func (s *subscriber[T]) send(msg T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.ch <- msg:
+		return true
+	default:
+	}
+	switch s.policy {
+	case OverflowDropOldest:
+		select {
+		case <-s.ch:
+		default:
+		}
+		select {
+		case s.ch <- msg:
+			return true
+		default:
+			return false
+		}
+	case OverflowBlock:
+		select {
+		case s.ch <- msg:
+			return true
+		case <-s.quit:
+			return false
+		}
+	default:
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubSub(t *testing.T) {
+	ps := NewPubSub[string, int](4, OverflowDropNewest)
+	a, cancelA := ps.Subscribe("news")
+	b, cancelB := ps.Subscribe("news")
+	other, cancelOther := ps.Subscribe("sports")
+	assert.Equal(t, 2, ps.Subscribers("news"))
+	assert.Equal(t, 2, ps.Topics())
+
+	assert.Equal(t, 2, ps.Publish("news", 1))
+	assert.Equal(t, 0, ps.Publish("weather", 1))
+	assert.Equal(t, 1, <-a)
+	assert.Equal(t, 1, <-b)
+	select {
+	case <-other:
+		t.Error("message delivered to another topic")
+	default:
+	}
+
+	// Canceling closes the channel, stops delivery and removes empty topics
+	cancelA()
+	cancelA()
+	_, ok := <-a
+	assert.False(t, ok)
+	assert.Equal(t, 1, ps.Publish("news", 2))
+	cancelB()
+	cancelOther()
+	assert.Equal(t, 0, ps.Topics())
+	assert.Equal(t, 0, ps.Subscribers("news"))
+}
+
+func TestPubSubOverflow(t *testing.T) {
+	ps := NewPubSub[string, int](2, OverflowDropNewest)
+	newest, cancelNewest := ps.Subscribe("t")
+	defer cancelNewest()
+	oldest, cancelOldest := ps.SubscribeBuffered("t", 2, OverflowDropOldest)
+	defer cancelOldest()
+	for i := range 4 {
+		ps.Publish("t", i)
+	}
+	assert.Equal(t, []int{0, 1}, []int{<-newest, <-newest})
+	assert.Equal(t, []int{2, 3}, []int{<-oldest, <-oldest})
+}
+
+func TestPubSubBlock(t *testing.T) {
+	ps := NewPubSub[string, int](0, OverflowBlock)
+	ch, cancel := ps.Subscribe("t")
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for i := range 3 {
+			assert.Equal(t, 1, ps.Publish("t", i))
+		}
+	})
+	assert.Equal(t, []int{0, 1, 2}, []int{<-ch, <-ch, <-ch})
+	wg.Wait()
+
+	// Canceling releases a publisher blocked on the subscription
+	done := make(chan int)
+	go func() { done <- ps.Publish("t", 3) }()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.Equal(t, 0, <-done)
+}
+
+func TestPubSubZeroValue(t *testing.T) {
+	var ps PubSub[string, int]
+	assert.Equal(t, 0, ps.Publish("news", 1))
+
+	ch, cancel := ps.SubscribeBuffered("news", 1, OverflowReject)
+	assert.Equal(t, 1, ps.Topics())
+	assert.Equal(t, 1, ps.Publish("news", 2))
+	assert.Equal(t, 2, <-ch)
+	cancel()
+	assert.Equal(t, 0, ps.Topics())
+}
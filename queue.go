@@ -1,13 +1,19 @@
 // Package threadsafe implements thread-safe operations.
 package threadsafe
 
-import "iter"
+import (
+	"iter"
+	"slices"
+)
 
 // Queue is a generic FIFO queue interface for any type T.
 // All operations must be safe for concurrent use by multiple goroutines.
 //
 // The contract is intentionally similar in style to Set and Map interfaces in this
 // repository to provide a consistent developer experience.
+//
+// Push is the canonical name for adding items to the queue. RWMutexQueue also provides Enqueue
+// as an alias, but implementations only need to provide Push to satisfy the interface.
 type Queue[T any] interface {
 	// Push adds one or more items to the back of the queue.
 	Push(items ...T)
@@ -20,12 +26,27 @@ type Queue[T any] interface {
 	// If the queue is empty, it returns ok == false and the zero value of T.
 	Peek() (item T, ok bool)
 
+	// PeekN returns a copy of up to n items from the front of the queue without removing them.
+	PeekN(n int) []T
+
+	// PeekAt returns the item at position i from the front of the queue without removing it.
+	// If i is out of range, it returns ok == false and the zero value of T.
+	PeekAt(i int) (item T, ok bool)
+
 	// Len returns the current number of items stored in the queue.
 	Len() int
 
 	// Clear removes all items from the queue.
 	Clear()
 
+	// Drain atomically removes all items from the queue and returns them from front to back.
+	Drain() []T
+
+	// Equals reports whether the logical content of this queue and the other queue is the same,
+	// comparing items from front to back. Requires an equal function since T is not of type
+	// comparable.
+	Equals(other Queue[T], equalFn func(a, b T) bool) bool
+
 	// Slice returns a copy of the current queue contents from front to back.
 	// The returned slice is safe to read but may be stale if new items are added
 	// concurrently.
@@ -45,3 +66,9 @@ type Queue[T any] interface {
 	//	}
 	All() iter.Seq[T]
 }
+
+// queueEquals compares the contents of two queues from front to back, snapshotting each queue
+// once so that every side is compared in a consistent state.
+func queueEquals[T any](a, b Queue[T], equalFn func(T, T) bool) bool {
+	return slices.EqualFunc(a.Slice(), b.Slice(), equalFn)
+}
@@ -0,0 +1,141 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sync"
+	"time"
+)
+
+// BatchingQueue is a thread-safe push-side decorator that buffers pushed items and emits them in
+// batches once a size or time threshold is hit, amortizing per-item overhead for downstream sinks
+// such as databases or network writers.
+//
+// A batch is emitted when the buffer reaches maxSize items, or when maxDelay has elapsed since the
+// first item entered an empty buffer, whichever comes first. Batches are emitted one at a time in
+// push order, while holding the internal lock: the sink must not push to the same BatchingQueue.
+//
+// The zero value is not ready to use; construct via NewBatchingQueue or NewBatchingQueueFunc.
+type BatchingQueue[T any] struct {
+	mu       sync.Mutex
+	buf      []T
+	flush    func(batch []T)
+	maxSize  int
+	maxDelay time.Duration
+	timer    *time.Timer
+	gen      uint64 // incremented on each emit, so that stale timers are ignored
+	closed   bool
+}
+
+// NewBatchingQueue creates a new BatchingQueue emitting each batch to next with a single Push.
+// A non-positive maxSize disables the size threshold, and a non-positive maxDelay disables the
+// time threshold.
+func NewBatchingQueue[T any](next Queue[T], maxSize int, maxDelay time.Duration) *BatchingQueue[T] {
+	return NewBatchingQueueFunc(func(batch []T) { next.Push(batch...) }, maxSize, maxDelay)
+}
+
+// NewBatchingQueueFunc creates a new BatchingQueue calling flush with each batch. The batch slice
+// is owned by flush and is not reused. A non-positive maxSize disables the size threshold, and a
+// non-positive maxDelay disables the time threshold.
+func NewBatchingQueueFunc[T any](
+	flush func(batch []T),
+	maxSize int,
+	maxDelay time.Duration,
+) *BatchingQueue[T] {
+	return &BatchingQueue[T]{flush: flush, maxSize: maxSize, maxDelay: maxDelay}
+}
+
+// Push adds one or more items to the buffer, emitting full batches of maxSize items. After Close,
+// the items are emitted immediately as a single batch.
+func (q *BatchingQueue[T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		q.flush(append([]T(nil), items...))
+		return
+	}
+	if len(q.buf) == 0 && q.maxDelay > 0 {
+		gen := q.gen
+		q.timer = time.AfterFunc(q.maxDelay, func() { q.flushGen(gen) })
+	}
+	q.buf = append(q.buf, items...)
+	for q.maxSize > 0 && len(q.buf) >= q.maxSize {
+		batch := q.buf[:q.maxSize:q.maxSize]
+		q.buf = append([]T(nil), q.buf[q.maxSize:]...)
+		q.emitLocked(batch)
+	}
+}
+
+// Flush emits the buffered items as a batch right away, if there are any.
+func (q *BatchingQueue[T]) Flush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.flushLocked()
+}
+
+// Len returns the number of buffered items not yet emitted.
+func (q *BatchingQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.buf)
+}
+
+// String returns the length of the queue, for logging and debugging. Items are not shown, as they
+// cannot be inspected without removing them.
+func (q *BatchingQueue[T]) String() string {
+	return formatLen(q, q.Len())
+}
+
+// GoString returns the Go type and length of the queue, for the %#v verb.
+func (q *BatchingQueue[T]) GoString() string {
+	return goFormatLen(q, q.Len())
+}
+
+// Close emits the buffered items and stops buffering: items pushed afterwards are emitted
+// immediately. Close is idempotent.
+func (q *BatchingQueue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.flushLocked()
+	q.closed = true
+}
+
+// Internal helpers
+
+// flushGen emits the buffered items on behalf of a timer, unless a batch was emitted since the
+// timer was started.
+func (q *BatchingQueue[T]) flushGen(gen uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if gen == q.gen {
+		q.flushLocked()
+	}
+}
+
+// flushLocked emits all buffered items. Callers must hold the lock.
+func (q *BatchingQueue[T]) flushLocked() {
+	if len(q.buf) == 0 {
+		return
+	}
+	batch := q.buf
+	q.buf = nil
+	q.emitLocked(batch)
+}
+
+// emitLocked hands batch to the sink and resets the time threshold. Callers must hold the lock.
+func (q *BatchingQueue[T]) emitLocked(batch []T) {
+	q.gen++
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	q.flush(batch)
+	if len(q.buf) > 0 && q.maxDelay > 0 {
+		// Leftover items start a new time window.
+		gen := q.gen
+		q.timer = time.AfterFunc(q.maxDelay, func() { q.flushGen(gen) })
+	}
+}
@@ -0,0 +1,324 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"sync"
+)
+
+// BlockingQueue is a thread-safe FIFO queue where consumers can block until items become
+// available and, when a capacity is set, producers block until space frees up.
+//
+// PopWait and PushWait honor context cancellation and deadlines, which makes the queue suitable
+// for producer/consumer pipelines without polling Pop in a loop. A capacity of zero or less makes
+// the queue unbounded, in which case pushing never blocks.
+//
+// The zero value is an unbounded queue ready to use.
+type BlockingQueue[T any] struct {
+	mu       sync.Mutex
+	items    []T
+	head     int // index of the current front element in items slice
+	capacity int
+	changed  broadcaster
+}
+
+// NewBlockingQueue creates a new BlockingQueue holding at most capacity items. A capacity of zero
+// or less makes the queue unbounded.
+func NewBlockingQueue[T any](capacity int) *BlockingQueue[T] {
+	return &BlockingQueue[T]{capacity: capacity}
+}
+
+// Push adds one or more items to the back of the queue, blocking while the queue is full.
+// It is equivalent to PushWait with a background context.
+func (q *BlockingQueue[T]) Push(items ...T) {
+	_ = q.PushWait(context.Background(), items...)
+}
+
+// PushWait adds items to the back of the queue, blocking while the queue is full until ctx is
+// done. Items are added in order as space becomes available; if ctx is done before all items fit,
+// the items pushed so far remain in the queue and ctx.Err() is returned.
+func (q *BlockingQueue[T]) PushWait(ctx context.Context, items ...T) error {
+	q.mu.Lock()
+	for len(items) > 0 {
+		free := len(items)
+		if q.capacity > 0 {
+			free = min(free, q.capacity-q.lenLocked())
+		}
+		if free > 0 {
+			q.items = append(q.items, items[:free]...)
+			items = items[free:]
+			q.changed.broadcast()
+			continue
+		}
+		if err := q.waitLocked(ctx); err != nil {
+			q.mu.Unlock()
+			return err
+		}
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+// TryPush adds items to the back of the queue only if they all fit without blocking, and reports
+// whether they were added. If the queue lacks room for all of them, it is left unchanged, so
+// callers can shed load instead of waiting.
+func (q *BlockingQueue[T]) TryPush(items ...T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.capacity > 0 && len(items) > q.capacity-q.lenLocked() {
+		return false
+	}
+	if len(items) > 0 {
+		q.items = append(q.items, items...)
+		q.changed.broadcast()
+	}
+	return true
+}
+
+// Pop removes and returns the item at the front of the queue without blocking.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q *BlockingQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.lenLocked() == 0 {
+		return item, false
+	}
+	return q.popLocked(), true
+}
+
+// PopWait removes and returns the item at the front of the queue, blocking while the queue is
+// empty until ctx is done, in which case ctx.Err() is returned.
+func (q *BlockingQueue[T]) PopWait(ctx context.Context) (item T, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.lenLocked() == 0 {
+		if err := q.waitLocked(ctx); err != nil {
+			return item, err
+		}
+	}
+	return q.popLocked(), nil
+}
+
+// TryPop removes and returns the item at the front of the queue without blocking, like Pop. It
+// returns ok == false if the queue is empty.
+func (q *BlockingQueue[T]) TryPop() (item T, ok bool) {
+	return q.Pop()
+}
+
+// Peek returns the item at the front without removing it.
+func (q *BlockingQueue[T]) Peek() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.lenLocked() == 0 {
+		return item, false
+	}
+	return q.items[q.head], true
+}
+
+// PeekN returns a copy of up to n items from the front of the queue without removing them.
+func (q *BlockingQueue[T]) PeekN(n int) []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n = min(max(n, 0), q.lenLocked())
+	peeked := make([]T, n)
+	copy(peeked, q.items[q.head:])
+	return peeked
+}
+
+// PeekAt returns the item at position i from the front of the queue without removing it.
+func (q *BlockingQueue[T]) PeekAt(i int) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if i < 0 || i >= q.lenLocked() {
+		return item, false
+	}
+	return q.items[q.head+i], true
+}
+
+// Len returns the current number of items.
+func (q *BlockingQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.lenLocked()
+}
+
+// Cap returns the capacity of the queue, or zero if the queue is unbounded.
+func (q *BlockingQueue[T]) Cap() int {
+	return max(q.capacity, 0)
+}
+
+// Clear removes all items from the queue and wakes up any blocked producers.
+func (q *BlockingQueue[T]) Clear() {
+	q.mu.Lock()
+	q.items = nil
+	q.head = 0
+	q.changed.broadcast()
+	q.mu.Unlock()
+}
+
+// Drain atomically removes all items from the queue and returns them from front to back, waking
+// up any blocked producers.
+func (q *BlockingQueue[T]) Drain() []T {
+	q.mu.Lock()
+	drained := make([]T, len(q.items)-q.head)
+	copy(drained, q.items[q.head:])
+	q.items = nil
+	q.head = 0
+	q.changed.broadcast()
+	q.mu.Unlock()
+	return drained
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *BlockingQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents from front to back.
+func (q *BlockingQueue[T]) Slice() []T {
+	return slices.Collect(q.All())
+}
+
+// Range calls f sequentially for each item from front to back. This action does not modify
+// the queue or its items.
+func (q *BlockingQueue[T]) Range(f func(item T) bool) {
+	for item := range q.All() {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue from front to back.
+// The iteration order matches the queue order (FIFO).
+func (q *BlockingQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.Lock()
+		snapshot := make([]T, len(q.items)-q.head)
+		copy(snapshot, q.items[q.head:])
+		q.mu.Unlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *BlockingQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *BlockingQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *BlockingQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Clone returns a new queue with the same capacity and a copy of each item made by copyFn, under
+// a single lock so the clone is a consistent snapshot. copyFn must not call back into the queue;
+// if nil, items are copied shallowly. Goroutines blocked on the queue are not carried over.
+func (q *BlockingQueue[T]) Clone(copyFn func(T) T) *BlockingQueue[T] {
+	clone := NewBlockingQueue[T](q.capacity)
+	q.cloneInto(clone, copyFn)
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the queue, for capacity planning
+// and memory accounting: the queue itself and its allocated slots, plus sizeFn(item) per item if
+// sizeFn is not nil, e.g. to count string data. sizeFn must not call back into the queue.
+func (q *BlockingQueue[T]) EstimateBytes(sizeFn func(item T) int) int {
+	return sizeOf[BlockingQueue[T]]() + q.itemsBytes(sizeFn)
+}
+
+// Internal helpers (callers must hold the lock)
+
+func (q *BlockingQueue[T]) lenLocked() int { return len(q.items) - q.head }
+
+func (q *BlockingQueue[T]) popLocked() T {
+	var zero T
+	item := q.items[q.head]
+	q.items[q.head] = zero
+	q.head++
+	if q.head > shrinkThreshold && q.head*2 >= len(q.items) {
+		newItems := make([]T, len(q.items)-q.head)
+		copy(newItems, q.items[q.head:])
+		q.items = newItems
+		q.head = 0
+	}
+	q.changed.broadcast()
+	return item
+}
+
+// waitLocked releases the lock until the queue changes or ctx is done, then reacquires it.
+func (q *BlockingQueue[T]) waitLocked(ctx context.Context) error {
+	return q.changed.waitLocked(ctx, &q.mu)
+}
+
+// cloneInto copies the items of q into the empty queue clone, each copied by copyFn if not nil.
+func (q *BlockingQueue[T]) cloneInto(clone *BlockingQueue[T], copyFn func(T) T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	clone.items = cloneSlice(q.items[q.head:], copyFn)
+}
+
+// itemsBytes estimates the bytes of the items slice, adding sizeFn(item) per queued item if
+// sizeFn is not nil.
+func (q *BlockingQueue[T]) itemsBytes(sizeFn func(T) int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return sliceBytes(q.items[q.head:], cap(q.items), sizeFn)
+}
+
+// broadcaster wakes up all goroutines waiting for a state change. It is not safe for concurrent
+// use on its own; the owning structure must guard it with its own lock.
+//
+// The zero value is ready to use.
+type broadcaster struct {
+	ch chan struct{}
+}
+
+// wait returns a channel that is closed on the next broadcast.
+func (b *broadcaster) wait() <-chan struct{} {
+	if b.ch == nil {
+		b.ch = make(chan struct{})
+	}
+	return b.ch
+}
+
+// waitLocked releases mu, which the caller holds and which guards b, until the next broadcast or
+// until ctx is done, then reacquires it. It returns ctx.Err() if ctx is done first.
+func (b *broadcaster) waitLocked(ctx context.Context, mu sync.Locker) error {
+	ch := b.wait()
+	mu.Unlock()
+	defer mu.Lock()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// broadcast wakes up all current waiters.
+func (b *broadcaster) broadcast() {
+	if b.ch != nil {
+		close(b.ch)
+		b.ch = nil
+	}
+}
+
+// Ensure BlockingQueue implements Queue.
+var _ Queue[any] = (*BlockingQueue[any])(nil)
@@ -0,0 +1,216 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// ErrQueueFull is returned when an item cannot be added to a bounded queue because it is full.
+var ErrQueueFull = errors.New("threadsafe: queue is full")
+
+// OverflowPolicy decides what a BoundedQueue does when items are pushed while it is full.
+type OverflowPolicy int
+
+const (
+	// OverflowReject rejects the whole batch of pushed items with ErrQueueFull.
+	OverflowReject OverflowPolicy = iota
+	// OverflowDropOldest evicts items from the front of the queue to make room.
+	OverflowDropOldest
+	// OverflowDropNewest discards the pushed items that do not fit.
+	OverflowDropNewest
+	// OverflowBlock blocks the producer until room becomes available.
+	OverflowBlock
+)
+
+// BoundedQueue is a thread-safe FIFO queue with a fixed capacity, where the behavior of pushing
+// into a full queue is decided by an OverflowPolicy. It keeps memory usage bounded when consumers
+// fall behind producers.
+//
+// Push satisfies the Queue interface and silently applies the policy; use PushWait to observe
+// rejections or to bound the time spent blocking.
+//
+// The zero value is not ready to use; construct via NewBoundedQueue.
+type BoundedQueue[T any] struct {
+	queue  BlockingQueue[T]
+	policy OverflowPolicy
+}
+
+// NewBoundedQueue creates a new BoundedQueue holding at most capacity items, applying policy when
+// full. capacity must be >0; if <=0, it is coerced to 1.
+func NewBoundedQueue[T any](capacity int, policy OverflowPolicy) *BoundedQueue[T] {
+	q := &BoundedQueue[T]{policy: policy}
+	q.queue.capacity = max(capacity, 1)
+	return q
+}
+
+// Push adds one or more items to the back of the queue, applying the overflow policy if the
+// queue is full. Items rejected by the policy are discarded.
+func (q *BoundedQueue[T]) Push(items ...T) {
+	_ = q.PushWait(context.Background(), items...)
+}
+
+// PushWait adds one or more items to the back of the queue, applying the overflow policy if the
+// queue is full. With OverflowReject it returns ErrQueueFull if the items do not all fit, leaving
+// the queue unchanged. With OverflowBlock it waits for room until ctx is done, in which case the
+// items pushed so far remain in the queue and ctx.Err() is returned. The other policies never
+// return an error.
+func (q *BoundedQueue[T]) PushWait(ctx context.Context, items ...T) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if q.policy == OverflowBlock {
+		return q.queue.PushWait(ctx, items...)
+	}
+
+	b := &q.queue
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	free := b.capacity - b.lenLocked()
+	switch q.policy {
+	case OverflowReject:
+		if len(items) > free {
+			return ErrQueueFull
+		}
+	case OverflowDropNewest:
+		items = items[:min(len(items), free)]
+	case OverflowDropOldest:
+		if len(items) > b.capacity {
+			items = items[len(items)-b.capacity:]
+		}
+		for range len(items) - free {
+			b.popLocked()
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	b.items = append(b.items, items...)
+	b.changed.broadcast()
+	return nil
+}
+
+// TryPush adds items to the back of the queue only if they all fit, and reports whether they were
+// added. Unlike Push, it ignores the overflow policy: it never blocks nor evicts, and leaves the
+// queue unchanged if it lacks room, so callers can shed load themselves.
+func (q *BoundedQueue[T]) TryPush(items ...T) bool {
+	return q.queue.TryPush(items...)
+}
+
+// Pop removes and returns the item at the front of the queue without blocking.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q *BoundedQueue[T]) Pop() (item T, ok bool) {
+	return q.queue.Pop()
+}
+
+// PopWait removes and returns the item at the front of the queue, blocking while the queue is
+// empty until ctx is done, in which case ctx.Err() is returned.
+func (q *BoundedQueue[T]) PopWait(ctx context.Context) (item T, err error) {
+	return q.queue.PopWait(ctx)
+}
+
+// TryPop removes and returns the item at the front of the queue without blocking, like Pop. It
+// returns ok == false if the queue is empty.
+func (q *BoundedQueue[T]) TryPop() (item T, ok bool) {
+	return q.queue.TryPop()
+}
+
+// Peek returns the item at the front without removing it.
+func (q *BoundedQueue[T]) Peek() (item T, ok bool) {
+	return q.queue.Peek()
+}
+
+// PeekN returns a copy of up to n items from the front of the queue without removing them.
+func (q *BoundedQueue[T]) PeekN(n int) []T {
+	return q.queue.PeekN(n)
+}
+
+// PeekAt returns the item at position i from the front of the queue without removing it.
+func (q *BoundedQueue[T]) PeekAt(i int) (item T, ok bool) {
+	return q.queue.PeekAt(i)
+}
+
+// Len returns the current number of items.
+func (q *BoundedQueue[T]) Len() int {
+	return q.queue.Len()
+}
+
+// Cap returns the capacity of the queue.
+func (q *BoundedQueue[T]) Cap() int {
+	return q.queue.Cap()
+}
+
+// Policy returns the overflow policy of the queue.
+func (q *BoundedQueue[T]) Policy() OverflowPolicy {
+	return q.policy
+}
+
+// Clear removes all items from the queue and wakes up any blocked producers.
+func (q *BoundedQueue[T]) Clear() {
+	q.queue.Clear()
+}
+
+// Drain atomically removes all items from the queue and returns them from front to back.
+func (q *BoundedQueue[T]) Drain() []T {
+	return q.queue.Drain()
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *BoundedQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents from front to back.
+func (q *BoundedQueue[T]) Slice() []T {
+	return q.queue.Slice()
+}
+
+// Range calls f sequentially for each item from front to back. This action does not modify
+// the queue or its items.
+func (q *BoundedQueue[T]) Range(f func(item T) bool) {
+	q.queue.Range(f)
+}
+
+// All returns an iterator over items in the queue from front to back.
+// The iteration order matches the queue order (FIFO).
+func (q *BoundedQueue[T]) All() iter.Seq[T] {
+	return q.queue.All()
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *BoundedQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *BoundedQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *BoundedQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Clone returns a new queue with the same capacity and overflow policy, and a copy of each item
+// made by copyFn, under a single lock so the clone is a consistent snapshot. copyFn must not call
+// back into the queue; if nil, items are copied shallowly.
+func (q *BoundedQueue[T]) Clone(copyFn func(T) T) *BoundedQueue[T] {
+	clone := NewBoundedQueue[T](q.queue.capacity, q.policy)
+	q.queue.cloneInto(&clone.queue, copyFn)
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the queue, for capacity planning
+// and memory accounting: the queue itself and its allocated slots, plus sizeFn(item) per item if
+// sizeFn is not nil, e.g. to count string data. sizeFn must not call back into the queue.
+func (q *BoundedQueue[T]) EstimateBytes(sizeFn func(item T) int) int {
+	return sizeOf[BoundedQueue[T]]() + q.queue.itemsBytes(sizeFn)
+}
+
+// Ensure BoundedQueue implements Queue.
+var _ Queue[any] = (*BoundedQueue[any])(nil)
@@ -0,0 +1,154 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "iter"
+
+// CircularQueue is a thread-safe FIFO queue with a fixed capacity that keeps only the most recent
+// items: pushing into a full queue overwrites the oldest item. It suits "recent events" buffers
+// that are read or consumed concurrently.
+//
+// The buffer is allocated once by NewCircularQueue and never grows. Push satisfies the Queue
+// interface and silently overwrites; use PushEvict to observe the overwritten items.
+//
+// The zero value is not ready to use; construct via NewCircularQueue.
+type CircularQueue[T any] struct {
+	ring RingQueue[T]
+}
+
+// NewCircularQueue creates a new CircularQueue holding at most capacity items. capacity must be
+// >0; if <=0, it is coerced to 1.
+func NewCircularQueue[T any](capacity int) *CircularQueue[T] {
+	q := &CircularQueue[T]{}
+	q.ring.buf = make([]T, max(capacity, 1))
+	return q
+}
+
+// Push adds one or more items to the back of the queue, overwriting the oldest items if the
+// queue is full.
+func (q *CircularQueue[T]) Push(items ...T) {
+	_ = q.PushEvict(items...)
+}
+
+// PushEvict adds one or more items to the back of the queue and returns the items it overwrote,
+// oldest first. When more items than the capacity are pushed at once, the earliest of them are
+// among the evicted items. It returns nil if nothing was evicted.
+func (q *CircularQueue[T]) PushEvict(items ...T) (evicted []T) {
+	if len(items) == 0 {
+		return nil
+	}
+	r := &q.ring
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	capacity := len(r.buf)
+	for _, item := range items {
+		tail := (r.head + r.size) % capacity
+		if r.size == capacity {
+			evicted = append(evicted, r.buf[r.head])
+			r.head = (r.head + 1) % capacity
+		} else {
+			r.size++
+		}
+		r.buf[tail] = item
+	}
+	return evicted
+}
+
+// Pop removes and returns the oldest item in the queue.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q *CircularQueue[T]) Pop() (item T, ok bool) {
+	return q.ring.Pop()
+}
+
+// Peek returns the oldest item without removing it.
+func (q *CircularQueue[T]) Peek() (item T, ok bool) {
+	return q.ring.Peek()
+}
+
+// PeekN returns a copy of up to n items from the front of the queue without removing them.
+func (q *CircularQueue[T]) PeekN(n int) []T {
+	return q.ring.PeekN(n)
+}
+
+// PeekAt returns the item at position i from the front of the queue without removing it.
+func (q *CircularQueue[T]) PeekAt(i int) (item T, ok bool) {
+	return q.ring.PeekAt(i)
+}
+
+// Len returns the current number of items.
+func (q *CircularQueue[T]) Len() int {
+	return q.ring.Len()
+}
+
+// Cap returns the maximum number of items the queue holds.
+func (q *CircularQueue[T]) Cap() int {
+	return q.ring.Cap()
+}
+
+// Clear removes all items from the queue.
+func (q *CircularQueue[T]) Clear() {
+	q.ring.Clear()
+}
+
+// Drain atomically removes all items from the queue and returns them from front to back.
+func (q *CircularQueue[T]) Drain() []T {
+	return q.ring.Drain()
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *CircularQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents from front to back.
+func (q *CircularQueue[T]) Slice() []T {
+	return q.ring.Slice()
+}
+
+// Range calls f sequentially for each item from front to back. This action does not modify
+// the queue or its items.
+func (q *CircularQueue[T]) Range(f func(item T) bool) {
+	q.ring.Range(f)
+}
+
+// All returns an iterator over items in the queue from front to back.
+// The iteration order matches the queue order (FIFO).
+func (q *CircularQueue[T]) All() iter.Seq[T] {
+	return q.ring.All()
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *CircularQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *CircularQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *CircularQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Clone returns a new queue with the same capacity and a copy of each item made by copyFn, under
+// a single lock so the clone is a consistent snapshot. copyFn must not call back into the queue;
+// if nil, items are copied shallowly.
+func (q *CircularQueue[T]) Clone(copyFn func(T) T) *CircularQueue[T] {
+	clone := &CircularQueue[T]{}
+	q.ring.cloneInto(&clone.ring, copyFn)
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the queue, for capacity planning
+// and memory accounting: the queue itself and its allocated slots, plus sizeFn(item) per item if
+// sizeFn is not nil, e.g. to count string data. sizeFn must not call back into the queue.
+func (q *CircularQueue[T]) EstimateBytes(sizeFn func(item T) int) int {
+	return sizeOf[CircularQueue[T]]() + q.ring.itemsBytes(sizeFn)
+}
+
+// Ensure CircularQueue implements Queue.
+var _ Queue[any] = (*CircularQueue[any])(nil)
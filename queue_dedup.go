@@ -0,0 +1,162 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"sync"
+)
+
+// DedupQueue is a thread-safe FIFO queue that suppresses pushing items whose key is already
+// present in the queue. Keys are derived from items by a caller-supplied key function and tracked
+// in an internal index, so duplicate detection is O(1).
+//
+// Once an item is popped its key may be pushed again, which suits reconcile-loop style consumers
+// that must process each key at most once per cycle.
+//
+// The zero value is not ready to use; construct via NewDedupQueue.
+type DedupQueue[T any, K comparable] struct {
+	mu    sync.Mutex
+	queue RWMutexQueue[T]
+	index map[K]struct{}
+	key   func(T) K
+}
+
+// NewDedupQueue creates a new DedupQueue using key to derive the identity of items.
+func NewDedupQueue[T any, K comparable](key func(item T) K) *DedupQueue[T, K] {
+	return &DedupQueue[T, K]{index: make(map[K]struct{}), key: key}
+}
+
+// Push adds one or more items to the back of the queue, skipping items whose key is already
+// present, including duplicates within items.
+func (q *DedupQueue[T, K]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unique := make([]T, 0, len(items))
+	for _, item := range items {
+		k := q.key(item)
+		if _, exists := q.index[k]; exists {
+			continue
+		}
+		q.index[k] = struct{}{}
+		unique = append(unique, item)
+	}
+	q.queue.Push(unique...)
+}
+
+// Add adds the item to the back of the queue unless its key is already present, and reports
+// whether it was added.
+func (q *DedupQueue[T, K]) Add(item T) (added bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	k := q.key(item)
+	if _, exists := q.index[k]; exists {
+		return false
+	}
+	q.index[k] = struct{}{}
+	q.queue.Push(item)
+	return true
+}
+
+// Has reports whether an item with the given key is present in the queue.
+func (q *DedupQueue[T, K]) Has(key K) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, exists := q.index[key]
+	return exists
+}
+
+// Pop removes and returns the item at the front of the queue, allowing its key to be pushed
+// again. If the queue is empty it returns ok == false and the zero value of T.
+func (q *DedupQueue[T, K]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok = q.queue.Pop()
+	if ok {
+		delete(q.index, q.key(item))
+	}
+	return item, ok
+}
+
+// Peek returns the item at the front without removing it.
+func (q *DedupQueue[T, K]) Peek() (item T, ok bool) {
+	return q.queue.Peek()
+}
+
+// PeekN returns a copy of up to n items from the front of the queue without removing them.
+func (q *DedupQueue[T, K]) PeekN(n int) []T {
+	return q.queue.PeekN(n)
+}
+
+// PeekAt returns the item at position i from the front of the queue without removing it.
+func (q *DedupQueue[T, K]) PeekAt(i int) (item T, ok bool) {
+	return q.queue.PeekAt(i)
+}
+
+// Len returns the current number of items.
+func (q *DedupQueue[T, K]) Len() int {
+	return q.queue.Len()
+}
+
+// Clear removes all items from the queue.
+func (q *DedupQueue[T, K]) Clear() {
+	q.mu.Lock()
+	q.queue.Clear()
+	clear(q.index)
+	q.mu.Unlock()
+}
+
+// Drain atomically removes all items from the queue and returns them from front to back.
+func (q *DedupQueue[T, K]) Drain() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	clear(q.index)
+	return q.queue.Drain()
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *DedupQueue[T, K]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents from front to back.
+func (q *DedupQueue[T, K]) Slice() []T {
+	return q.queue.Slice()
+}
+
+// Range calls f sequentially for each item from front to back. This action does not modify
+// the queue or its items.
+func (q *DedupQueue[T, K]) Range(f func(item T) bool) {
+	q.queue.Range(f)
+}
+
+// All returns an iterator over items in the queue from front to back.
+// The iteration order matches the queue order (FIFO).
+func (q *DedupQueue[T, K]) All() iter.Seq[T] {
+	return q.queue.All()
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *DedupQueue[T, K]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *DedupQueue[T, K]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *DedupQueue[T, K]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Ensure DedupQueue implements Queue.
+var _ Queue[any] = (*DedupQueue[any, int])(nil)
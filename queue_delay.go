@@ -0,0 +1,147 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// delayedItem is an item held by a DelayQueue until readyAt.
+type delayedItem[T any] struct {
+	item    T
+	readyAt time.Time
+	seq     uint64 // insertion order, used to keep items with equal readyAt in FIFO order
+}
+
+func lessDelayed[T any](a, b delayedItem[T]) bool {
+	if a.readyAt.Equal(b.readyAt) {
+		return a.seq < b.seq
+	}
+	return a.readyAt.Before(b.readyAt)
+}
+
+// DelayQueue is a thread-safe queue where each item becomes visible only once its ready time has
+// passed. Items are handed out in order of their ready time, with ties resolved in insertion
+// order. It is backed by a CorePriorityQueue ordered by ready time.
+//
+// Typical uses are retries with backoff and scheduled jobs: producers Push items with the time
+// they should be processed, and consumers block in PopWait until the earliest item is due.
+//
+// The zero value is ready to use.
+type DelayQueue[T any] struct {
+	mu      sync.Mutex
+	pq      CorePriorityQueue[delayedItem[T]]
+	seq     uint64
+	changed broadcaster
+}
+
+// NewDelayQueue creates a new instance of DelayQueue.
+func NewDelayQueue[T any]() *DelayQueue[T] {
+	return &DelayQueue[T]{}
+}
+
+// Push adds an item that becomes visible once readyAt has passed.
+func (q *DelayQueue[T]) Push(item T, readyAt time.Time) {
+	q.mu.Lock()
+	q.ensureInitialized()
+	q.seq++
+	q.pq.Push(delayedItem[T]{item: item, readyAt: readyAt, seq: q.seq})
+	q.changed.broadcast()
+	q.mu.Unlock()
+}
+
+// PushAfter adds an item that becomes visible once delay has elapsed.
+func (q *DelayQueue[T]) PushAfter(item T, delay time.Duration) {
+	q.Push(item, time.Now().Add(delay))
+}
+
+// Pop removes and returns the earliest item whose ready time has passed, without blocking.
+// If no item is ready, it returns ok == false and the zero value of T.
+func (q *DelayQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	next, ok := q.pq.Peek()
+	if !ok || next.readyAt.After(time.Now()) {
+		return item, false
+	}
+	next, _ = q.pq.Pop()
+	return next.item, true
+}
+
+// PopWait removes and returns the earliest item, blocking until its ready time has passed or ctx
+// is done, in which case ctx.Err() is returned. Items pushed while waiting with an earlier ready
+// time are taken into account.
+func (q *DelayQueue[T]) PopWait(ctx context.Context) (item T, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		next, ok := q.pq.Peek()
+		if ok {
+			wait := time.Until(next.readyAt)
+			if wait <= 0 {
+				next, _ = q.pq.Pop()
+				return next.item, nil
+			}
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		changed := q.changed.wait()
+		q.mu.Unlock()
+		select {
+		case <-changed:
+		case <-timeout:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		q.mu.Lock()
+		if err != nil {
+			return item, err
+		}
+	}
+}
+
+// NextReady returns the ready time of the earliest item, and false if the queue is empty.
+func (q *DelayQueue[T]) NextReady() (readyAt time.Time, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	next, ok := q.pq.Peek()
+	return next.readyAt, ok
+}
+
+// Len returns the number of items in the queue, whether they are ready or not.
+func (q *DelayQueue[T]) Len() int {
+	return q.pq.Len()
+}
+
+// String returns the length of the queue, for logging and debugging. Items are not shown, as they
+// cannot be inspected without removing them.
+func (q *DelayQueue[T]) String() string {
+	return formatLen(q, q.Len())
+}
+
+// GoString returns the Go type and length of the queue, for the %#v verb.
+func (q *DelayQueue[T]) GoString() string {
+	return goFormatLen(q, q.Len())
+}
+
+// Clear removes all items from the queue.
+func (q *DelayQueue[T]) Clear() {
+	q.mu.Lock()
+	q.pq.Clear()
+	q.changed.broadcast()
+	q.mu.Unlock()
+}
+
+// ensureInitialized lazily sets the comparator for zero-value usage. Callers must hold the lock.
+func (q *DelayQueue[T]) ensureInitialized() {
+	if q.pq.less == nil {
+		q.pq.less = lessDelayed[T]
+	}
+}
@@ -0,0 +1,292 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+	"time"
+)
+
+// expiringItem is an item held by an ExpiringQueue together with its deadline.
+type expiringItem[T any] struct {
+	item     T
+	deadline time.Time // zero means the item never expires
+}
+
+func (e expiringItem[T]) expired(now time.Time) bool {
+	return !e.deadline.IsZero() && !now.Before(e.deadline)
+}
+
+// ExpiringQueue is a thread-safe FIFO queue where each item carries a deadline. Expired items are
+// transparently skipped by Pop and Peek and are never returned to consumers, so stale work pulled
+// from a backlog is not executed after it is no longer relevant.
+//
+// Expired items are removed lazily when they reach the front of the queue, or eagerly via Purge.
+// If an onExpire callback is provided, it is called for every removed expired item, outside of
+// the queue's lock.
+//
+// Len, Slice, Range and All only account for items that have not expired, which makes Len an O(n)
+// operation.
+//
+// The zero value is ready to use, with items that never expire unless pushed with a deadline.
+type ExpiringQueue[T any] struct {
+	mu       sync.Mutex
+	queue    RWMutexQueue[expiringItem[T]]
+	ttl      time.Duration
+	onExpire func(item T)
+}
+
+// NewExpiringQueue creates a new ExpiringQueue. Items added with Push expire after ttl; a ttl of
+// zero or less means they never expire. onExpire is optional; if non-nil it is called with every
+// expired item removed from the queue.
+func NewExpiringQueue[T any](ttl time.Duration, onExpire func(item T)) *ExpiringQueue[T] {
+	return &ExpiringQueue[T]{ttl: ttl, onExpire: onExpire}
+}
+
+// Push adds one or more items to the back of the queue, expiring after the queue's default TTL.
+func (q *ExpiringQueue[T]) Push(items ...T) {
+	q.PushWithTTL(q.ttl, items...)
+}
+
+// PushWithTTL adds one or more items to the back of the queue, expiring after ttl. A ttl of zero
+// or less means the items never expire.
+func (q *ExpiringQueue[T]) PushWithTTL(ttl time.Duration, items ...T) {
+	var deadline time.Time
+	if ttl > 0 {
+		deadline = time.Now().Add(ttl)
+	}
+	q.PushWithDeadline(deadline, items...)
+}
+
+// PushWithDeadline adds one or more items to the back of the queue, expiring at deadline. A zero
+// deadline means the items never expire.
+func (q *ExpiringQueue[T]) PushWithDeadline(deadline time.Time, items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	wrapped := make([]expiringItem[T], len(items))
+	for i, item := range items {
+		wrapped[i] = expiringItem[T]{item: item, deadline: deadline}
+	}
+	q.mu.Lock()
+	q.queue.Push(wrapped...)
+	q.mu.Unlock()
+}
+
+// Pop removes and returns the first item at the front of the queue that has not expired, removing
+// any expired items in front of it. If no such item exists, it returns ok == false and the zero
+// value of T.
+func (q *ExpiringQueue[T]) Pop() (item T, ok bool) {
+	var expired []T
+	defer func() { q.reportExpired(expired) }()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	for {
+		next, ok := q.queue.Pop()
+		if !ok {
+			return item, false
+		}
+		if !next.expired(now) {
+			return next.item, true
+		}
+		expired = append(expired, next.item)
+	}
+}
+
+// Peek returns the first item at the front of the queue that has not expired without removing it.
+// Expired items in front of it are removed.
+func (q *ExpiringQueue[T]) Peek() (item T, ok bool) {
+	var expired []T
+	defer func() { q.reportExpired(expired) }()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	for {
+		next, ok := q.queue.Peek()
+		if !ok {
+			return item, false
+		}
+		if !next.expired(now) {
+			return next.item, true
+		}
+		_, _ = q.queue.Pop()
+		expired = append(expired, next.item)
+	}
+}
+
+// PeekN returns a copy of up to n unexpired items from the front of the queue without removing
+// them.
+func (q *ExpiringQueue[T]) PeekN(n int) []T {
+	peeked := make([]T, 0, max(n, 0))
+	if n <= 0 {
+		return peeked
+	}
+	q.rangeUnexpired(func(item T) bool {
+		peeked = append(peeked, item)
+		return len(peeked) < n
+	})
+	return peeked
+}
+
+// PeekAt returns the unexpired item at position i from the front of the queue without removing
+// it. Expired items are not counted.
+func (q *ExpiringQueue[T]) PeekAt(i int) (item T, ok bool) {
+	if i < 0 {
+		return item, false
+	}
+	pos := 0
+	q.rangeUnexpired(func(it T) bool {
+		if pos == i {
+			item, ok = it, true
+			return false
+		}
+		pos++
+		return true
+	})
+	return item, ok
+}
+
+// Purge removes all expired items from the queue and returns how many were removed.
+func (q *ExpiringQueue[T]) Purge() int {
+	var expired []T
+	defer func() { q.reportExpired(expired) }()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	all := q.queue.Drain()
+	live := all[:0]
+	for _, it := range all {
+		if it.expired(now) {
+			expired = append(expired, it.item)
+			continue
+		}
+		live = append(live, it)
+	}
+	q.queue.Push(live...)
+	return len(expired)
+}
+
+// Len returns the number of items in the queue that have not expired.
+func (q *ExpiringQueue[T]) Len() int {
+	count := 0
+	for range q.All() {
+		count++
+	}
+	return count
+}
+
+// Clear removes all items from the queue. Removed items are not reported as expired.
+func (q *ExpiringQueue[T]) Clear() {
+	q.mu.Lock()
+	q.queue.Clear()
+	q.mu.Unlock()
+}
+
+// Drain atomically removes all items from the queue and returns those that have not expired from
+// front to back. Expired items are reported.
+func (q *ExpiringQueue[T]) Drain() []T {
+	var expired []T
+	defer func() { q.reportExpired(expired) }()
+
+	q.mu.Lock()
+	all := q.queue.Drain()
+	q.mu.Unlock()
+
+	now := time.Now()
+	drained := make([]T, 0, len(all))
+	for _, it := range all {
+		if it.expired(now) {
+			expired = append(expired, it.item)
+			continue
+		}
+		drained = append(drained, it.item)
+	}
+	return drained
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *ExpiringQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the unexpired queue contents from front to back.
+func (q *ExpiringQueue[T]) Slice() []T {
+	return slices.Collect(q.All())
+}
+
+// Range calls f sequentially for each unexpired item from front to back. This action does not
+// modify the queue or its items.
+func (q *ExpiringQueue[T]) Range(f func(item T) bool) {
+	for item := range q.All() {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over unexpired items in the queue from front to back.
+// The iteration order matches the queue order (FIFO).
+func (q *ExpiringQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		now := time.Now()
+		for it := range q.queue.All() {
+			if it.expired(now) {
+				continue
+			}
+			if !yield(it.item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *ExpiringQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *ExpiringQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *ExpiringQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// rangeUnexpired calls f for each unexpired item from front to back while holding the read lock of
+// the underlying queue, without copying it. f must not call back into the queue.
+func (q *ExpiringQueue[T]) rangeUnexpired(f func(item T) bool) {
+	q.queue.mu.RLock()
+	defer q.queue.mu.RUnlock()
+	now := time.Now()
+	for _, it := range q.queue.items[q.queue.head:] {
+		if it.expired(now) {
+			continue
+		}
+		if !f(it.item) {
+			return
+		}
+	}
+}
+
+// reportExpired calls onExpire for each expired item. Must be called without holding the lock.
+func (q *ExpiringQueue[T]) reportExpired(expired []T) {
+	if q.onExpire == nil {
+		return
+	}
+	for _, item := range expired {
+		q.onExpire(item)
+	}
+}
+
+// Ensure ExpiringQueue implements Queue.
+var _ Queue[any] = (*ExpiringQueue[any])(nil)
@@ -0,0 +1,191 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sync"
+	"time"
+)
+
+// expiryEntry is an item scheduled in an ExpiryQueue.
+type expiryEntry[K comparable, T any] struct {
+	key  K
+	item T
+	at   time.Time
+	seq  uint64 // scheduling order, used to keep entries with equal fire times in FIFO order
+}
+
+func lessExpiry[K comparable, T any](a, b expiryEntry[K, T]) bool {
+	if a.at.Equal(b.at) {
+		return a.seq < b.seq
+	}
+	return a.at.Before(b.at)
+}
+
+// ExpiryQueue is a thread-safe timer queue: items are scheduled under a key with a fire time, and
+// a single background goroutine delivers each item on the channel returned by C once its time has
+// come. A pending item can be rescheduled with Reset or dropped with Cancel by key.
+//
+// It replaces one time.Timer per pending item with a single timer driven by a KeyedPriorityQueue
+// ordered by fire time, which keeps scheduling-heavy services cheap. Unlike ExpiringQueue, which
+// drops items once their TTL passes, ExpiryQueue hands items out when they are due.
+//
+// The zero value is not ready to use; construct via NewExpiryQueue, and call Close to stop the
+// background goroutine.
+type ExpiryQueue[K comparable, T any] struct {
+	mu     sync.Mutex
+	pq     *KeyedPriorityQueue[K, expiryEntry[K, T]]
+	seq    uint64
+	c      chan T
+	wake   chan struct{}
+	done   chan struct{}
+	exited chan struct{}
+	once   sync.Once
+}
+
+// NewExpiryQueue creates a new ExpiryQueue and starts its delivery goroutine. buffer is the
+// capacity of the delivery channel; while it is full, due items wait in the queue.
+func NewExpiryQueue[K comparable, T any](buffer int) *ExpiryQueue[K, T] {
+	q := &ExpiryQueue[K, T]{
+		pq: NewKeyedPriorityQueue(lessExpiry[K, T], func(e expiryEntry[K, T]) K {
+			return e.key
+		}),
+		c:      make(chan T, max(buffer, 0)),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		exited: make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// C returns the channel on which items are delivered when due. It is closed by Close.
+func (q *ExpiryQueue[K, T]) C() <-chan T {
+	return q.c
+}
+
+// Schedule schedules item to be delivered at the given time under key, replacing any item
+// pending under the same key.
+func (q *ExpiryQueue[K, T]) Schedule(key K, item T, at time.Time) {
+	q.mu.Lock()
+	q.seq++
+	q.pq.Push(expiryEntry[K, T]{key: key, item: item, at: at, seq: q.seq})
+	q.mu.Unlock()
+	q.signal()
+}
+
+// ScheduleAfter schedules item to be delivered once d has elapsed under key, replacing any item
+// pending under the same key.
+func (q *ExpiryQueue[K, T]) ScheduleAfter(key K, item T, d time.Duration) {
+	q.Schedule(key, item, time.Now().Add(d))
+}
+
+// Reset changes the fire time of the item pending under key. It returns false if no item is
+// pending under key, including when it was already delivered.
+func (q *ExpiryQueue[K, T]) Reset(key K, at time.Time) bool {
+	q.mu.Lock()
+	e, ok := q.pq.Get(key)
+	if ok {
+		q.seq++
+		e.at, e.seq = at, q.seq
+		q.pq.UpdatePriority(key, e)
+	}
+	q.mu.Unlock()
+	if ok {
+		q.signal()
+	}
+	return ok
+}
+
+// Cancel removes the item pending under key so that it is not delivered. It returns false if no
+// item is pending under key, including when it was already delivered.
+func (q *ExpiryQueue[K, T]) Cancel(key K) bool {
+	q.mu.Lock()
+	_, ok := q.pq.Remove(key)
+	q.mu.Unlock()
+	if ok {
+		q.signal()
+	}
+	return ok
+}
+
+// Pending reports whether an item is pending under key.
+func (q *ExpiryQueue[K, T]) Pending(key K) bool {
+	return q.pq.Contains(key)
+}
+
+// Len returns the number of pending items.
+func (q *ExpiryQueue[K, T]) Len() int {
+	return q.pq.Len()
+}
+
+// String returns the length of the queue, for logging and debugging. Items are not shown, as they
+// cannot be inspected without removing them.
+func (q *ExpiryQueue[K, T]) String() string {
+	return formatLen(q, q.Len())
+}
+
+// GoString returns the Go type and length of the queue, for the %#v verb.
+func (q *ExpiryQueue[K, T]) GoString() string {
+	return goFormatLen(q, q.Len())
+}
+
+// Close stops the delivery goroutine and closes the delivery channel. Pending items are
+// discarded. Close is idempotent.
+func (q *ExpiryQueue[K, T]) Close() {
+	q.once.Do(func() {
+		close(q.done)
+		<-q.exited
+		close(q.c)
+	})
+}
+
+// Internal helpers
+
+// signal wakes up the delivery goroutine to re-evaluate the earliest fire time.
+func (q *ExpiryQueue[K, T]) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers due items until Close is called.
+func (q *ExpiryQueue[K, T]) run() {
+	defer close(q.exited)
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		q.mu.Lock()
+		next, ok := q.pq.Peek()
+		var wait time.Duration
+		if ok {
+			wait = time.Until(next.at)
+			if wait <= 0 {
+				_, _ = q.pq.Pop()
+			}
+		}
+		q.mu.Unlock()
+
+		switch {
+		case ok && wait <= 0:
+			select {
+			case q.c <- next.item:
+			case <-q.done:
+				return
+			}
+			continue
+		case ok:
+			timer.Reset(wait)
+		}
+
+		select {
+		case <-q.wake:
+		case <-timer.C:
+		case <-q.done:
+			return
+		}
+		timer.Stop()
+	}
+}
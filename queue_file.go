@@ -0,0 +1,372 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"sync"
+)
+
+// ErrQueueClosed is returned when operating on a queue that has been closed.
+var ErrQueueClosed = errors.New("threadsafe: queue is closed")
+
+// Write-ahead log record types used by FileQueue.
+const (
+	walOpPush byte = iota + 1
+	walOpPop
+)
+
+// fileQueueCompactThreshold is the number of stale log records after which FileQueue rewrites its
+// log, provided the stale records also outnumber the live items.
+const fileQueueCompactThreshold = 1024
+
+// FileQueue is a thread-safe FIFO queue persisted to an append-only write-ahead log, so queued
+// items survive process restarts. Items are kept in memory for reads and encoded with the
+// provided Codec for the log.
+//
+// Every Push appends a record per item and every Pop appends a pop record. The log is compacted
+// into a checkpoint holding only the live items once enough stale records accumulate, or
+// explicitly via Checkpoint. Writes are not synced to stable storage unless Sync, Checkpoint or
+// Close is called.
+//
+// The methods of the Queue interface do not return errors. The first write error encountered by
+// them is retained and reported by Err; the in-memory state is not modified by a failed write,
+// and any partially written record is truncated from the log.
+//
+// The zero value is not ready to use; construct via OpenFileQueue.
+type FileQueue[T any] struct {
+	mu    sync.Mutex
+	queue RWMutexQueue[T]
+	codec Codec[T]
+	path  string
+	file  *os.File
+	stale int   // number of log records not backing a live item
+	err   error // first write error encountered by methods without an error result
+
+	// failed is set when a write error left a partial record in the log that could not be
+	// removed; later writes are refused until Checkpoint or Drain rewrites the log
+	failed error
+}
+
+// OpenFileQueue opens the queue persisted at path, creating the file if it does not exist, and
+// replays its log to restore the queue contents. A partially written record at the end of the
+// log, as left behind by a crash, is discarded.
+func OpenFileQueue[T any](path string, codec Codec[T]) (*FileQueue[T], error) {
+	q := &FileQueue[T]{codec: codec, path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	valid, err := q.replay(data)
+	if err != nil {
+		return nil, err
+	}
+
+	q.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.file.Truncate(int64(valid)); err != nil {
+		_ = q.file.Close()
+		return nil, err
+	}
+	if _, err := q.file.Seek(int64(valid), 0); err != nil {
+		_ = q.file.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// Push adds one or more items to the back of the queue and appends them to the log.
+func (q *FileQueue[T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var buf []byte
+	for _, item := range items {
+		payload, err := q.codec.Encode(item)
+		if err != nil {
+			q.setErr(fmt.Errorf("encode item: %w", err))
+			return
+		}
+		buf = appendWALRecord(buf, walOpPush, payload)
+	}
+	if err := q.writeLocked(buf); err != nil {
+		q.setErr(err)
+		return
+	}
+	q.queue.Push(items...)
+}
+
+// Pop removes and returns the item at the front of the queue and records the removal in the log.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q *FileQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.queue.Peek(); !ok {
+		return item, false
+	}
+	if err := q.writeLocked([]byte{walOpPop}); err != nil {
+		q.setErr(err)
+		return item, false
+	}
+	item, ok = q.queue.Pop()
+	q.stale += 2 // the popped item's push record and the pop record
+	if q.stale >= fileQueueCompactThreshold && q.stale >= q.queue.Len() {
+		if err := q.checkpointLocked(); err != nil {
+			q.setErr(err)
+		}
+	}
+	return item, ok
+}
+
+// Peek returns the item at the front without removing it.
+func (q *FileQueue[T]) Peek() (item T, ok bool) {
+	return q.queue.Peek()
+}
+
+// PeekN returns a copy of up to n items from the front of the queue without removing them.
+func (q *FileQueue[T]) PeekN(n int) []T {
+	return q.queue.PeekN(n)
+}
+
+// PeekAt returns the item at position i from the front of the queue without removing it.
+func (q *FileQueue[T]) PeekAt(i int) (item T, ok bool) {
+	return q.queue.PeekAt(i)
+}
+
+// Len returns the current number of items.
+func (q *FileQueue[T]) Len() int {
+	return q.queue.Len()
+}
+
+// Clear removes all items from the queue and truncates the log.
+func (q *FileQueue[T]) Clear() {
+	_ = q.Drain()
+}
+
+// Drain atomically removes all items from the queue, truncates the log and returns the items
+// from front to back.
+func (q *FileQueue[T]) Drain() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.file == nil {
+		q.setErr(ErrQueueClosed)
+		return []T{}
+	}
+	if err := q.file.Truncate(0); err != nil {
+		q.setErr(err)
+		return []T{}
+	}
+	if _, err := q.file.Seek(0, 0); err != nil {
+		q.setErr(err)
+		return []T{}
+	}
+	q.stale = 0
+	q.failed = nil
+	return q.queue.Drain()
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *FileQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents from front to back.
+func (q *FileQueue[T]) Slice() []T {
+	return q.queue.Slice()
+}
+
+// Range calls f sequentially for each item from front to back. This action does not modify
+// the queue or its items.
+func (q *FileQueue[T]) Range(f func(item T) bool) {
+	q.queue.Range(f)
+}
+
+// All returns an iterator over items in the queue from front to back.
+// The iteration order matches the queue order (FIFO).
+func (q *FileQueue[T]) All() iter.Seq[T] {
+	return q.queue.All()
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *FileQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *FileQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *FileQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Checkpoint rewrites the log so it only holds the live items, and syncs it to stable storage.
+// The new log replaces the old one atomically.
+func (q *FileQueue[T]) Checkpoint() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.checkpointLocked()
+}
+
+// Sync commits the log to stable storage.
+func (q *FileQueue[T]) Sync() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.file == nil {
+		return ErrQueueClosed
+	}
+	return q.file.Sync()
+}
+
+// Err returns the first write error encountered by Push, Pop, Clear or Drain, if any.
+func (q *FileQueue[T]) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err
+}
+
+// Close syncs and closes the log. The queue must not be modified after Close.
+func (q *FileQueue[T]) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.file == nil {
+		return ErrQueueClosed
+	}
+	err := errors.Join(q.file.Sync(), q.file.Close())
+	q.file = nil
+	return err
+}
+
+// replay rebuilds the in-memory queue from log data and returns the length of the valid prefix.
+func (q *FileQueue[T]) replay(data []byte) (valid int, err error) {
+	for valid < len(data) {
+		op := data[valid]
+		switch op {
+		case walOpPush:
+			size, n := binary.Uvarint(data[valid+1:])
+			// Check the length prefix against the remaining data before computing the end of
+			// the record, as a corrupted prefix may overflow int
+			if n <= 0 || size > uint64(len(data)-valid-1-n) {
+				return valid, nil // partially written record
+			}
+			end := valid + 1 + n + int(size)
+			item, err := q.codec.Decode(data[valid+1+n : end])
+			if err != nil {
+				return 0, fmt.Errorf("decode item at offset %d: %w", valid, err)
+			}
+			q.queue.Push(item)
+			valid = end
+		case walOpPop:
+			if _, ok := q.queue.Pop(); !ok {
+				return 0, fmt.Errorf("pop record at offset %d on empty queue", valid)
+			}
+			q.stale += 2
+			valid++
+		default:
+			return 0, fmt.Errorf("unknown record type %d at offset %d", op, valid)
+		}
+	}
+	return valid, nil
+}
+
+// Internal helpers (callers must hold the lock)
+
+// writeLocked appends buf to the log. If the write fails, the log is truncated back to its
+// previous size, so later records are not appended after a partial one.
+func (q *FileQueue[T]) writeLocked(buf []byte) error {
+	if q.file == nil {
+		return ErrQueueClosed
+	}
+	if q.failed != nil {
+		return q.failed
+	}
+	offset, err := q.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := q.file.Write(buf); err != nil {
+		if truncErr := q.file.Truncate(offset); truncErr != nil {
+			q.failed = fmt.Errorf("log left with a partial record: %w", errors.Join(err, truncErr))
+			return q.failed
+		}
+		return err
+	}
+	return nil
+}
+
+func (q *FileQueue[T]) checkpointLocked() error {
+	if q.file == nil {
+		return ErrQueueClosed
+	}
+	var buf []byte
+	for item := range q.queue.All() {
+		payload, err := q.codec.Encode(item)
+		if err != nil {
+			return fmt.Errorf("encode item: %w", err)
+		}
+		buf = appendWALRecord(buf, walOpPush, payload)
+	}
+
+	tmp := q.path + ".tmp"
+	if err := writeFileSync(tmp, buf); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		return err
+	}
+	_ = q.file.Close()
+	file, err := os.OpenFile(q.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		q.file = nil
+		return err
+	}
+	q.file = file
+	q.stale = 0
+	q.failed = nil
+	return nil
+}
+
+func (q *FileQueue[T]) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// appendWALRecord appends a log record with the given type and length-prefixed payload to buf.
+func appendWALRecord(buf []byte, op byte, payload []byte) []byte {
+	buf = append(buf, op)
+	buf = binary.AppendUvarint(buf, uint64(len(payload)))
+	return append(buf, payload...)
+}
+
+// writeFileSync writes data to a new file at path and syncs it to stable storage.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return errors.Join(f.Sync(), f.Close())
+}
+
+// Ensure FileQueue implements Queue.
+var _ Queue[any] = (*FileQueue[any])(nil)
@@ -0,0 +1,238 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+// LanedQueue is a thread-safe queue with a fixed number of priority lanes, protected by a
+// sync.RWMutex. Lane 0 has the highest priority. Pop drains higher-priority lanes first, while
+// items within a lane keep their FIFO order.
+//
+// It is a lighter-weight alternative to the comparator-based priority queues for coarse
+// prioritization such as high/normal/low: all operations on a single lane are O(1).
+//
+// Push adds items to the lowest-priority lane, so a LanedQueue with a single lane behaves like a
+// plain FIFO queue. Lane indexes outside the valid range are clamped to it.
+//
+// The zero value is not ready to use; construct via NewLanedQueue.
+type LanedQueue[T any] struct {
+	mu    sync.RWMutex
+	lanes []RingQueue[T]
+}
+
+// NewLanedQueue creates a new LanedQueue with the given number of lanes, which is coerced to at
+// least 1.
+func NewLanedQueue[T any](lanes int) *LanedQueue[T] {
+	return &LanedQueue[T]{lanes: make([]RingQueue[T], max(lanes, 1))}
+}
+
+// Lanes returns the number of lanes of the queue.
+func (q *LanedQueue[T]) Lanes() int {
+	return len(q.lanes)
+}
+
+// Push adds one or more items to the back of the lowest-priority lane.
+func (q *LanedQueue[T]) Push(items ...T) {
+	q.PushLane(len(q.lanes)-1, items...)
+}
+
+// PushLane adds one or more items to the back of the given lane.
+func (q *LanedQueue[T]) PushLane(lane int, items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	q.lanes[q.clampLane(lane)].Push(items...)
+	q.mu.Unlock()
+}
+
+// Pop removes and returns the front item of the highest-priority non-empty lane.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q *LanedQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.lanes {
+		if item, ok = q.lanes[i].Pop(); ok {
+			return item, true
+		}
+	}
+	return item, false
+}
+
+// PopLane removes and returns the front item of the given lane, ignoring other lanes.
+// If the lane is empty it returns ok == false and the zero value of T.
+func (q *LanedQueue[T]) PopLane(lane int) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.lanes[q.clampLane(lane)].Pop()
+}
+
+// Peek returns the item Pop would return next without removing it.
+func (q *LanedQueue[T]) Peek() (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	for i := range q.lanes {
+		if item, ok = q.lanes[i].Peek(); ok {
+			return item, true
+		}
+	}
+	return item, false
+}
+
+// PeekN returns a copy of up to n items in pop order without removing them.
+func (q *LanedQueue[T]) PeekN(n int) []T {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	peeked := make([]T, 0, min(max(n, 0), q.lenLocked()))
+	for i := range q.lanes {
+		if len(peeked) == cap(peeked) {
+			break
+		}
+		peeked = append(peeked, q.lanes[i].PeekN(cap(peeked)-len(peeked))...)
+	}
+	return peeked
+}
+
+// PeekAt returns the item at position i in pop order without removing it.
+func (q *LanedQueue[T]) PeekAt(i int) (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if i < 0 {
+		return item, false
+	}
+	for l := range q.lanes {
+		n := q.lanes[l].Len()
+		if i < n {
+			return q.lanes[l].PeekAt(i)
+		}
+		i -= n
+	}
+	return item, false
+}
+
+// Len returns the current number of items across all lanes.
+func (q *LanedQueue[T]) Len() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.lenLocked()
+}
+
+// LaneLen returns the current number of items in the given lane.
+func (q *LanedQueue[T]) LaneLen(lane int) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.lanes[q.clampLane(lane)].Len()
+}
+
+// Clear removes all items from all lanes.
+func (q *LanedQueue[T]) Clear() {
+	q.mu.Lock()
+	for i := range q.lanes {
+		q.lanes[i].Clear()
+	}
+	q.mu.Unlock()
+}
+
+// Drain atomically removes all items from the queue and returns them in pop order.
+func (q *LanedQueue[T]) Drain() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	drained := make([]T, 0, q.lenLocked())
+	for i := range q.lanes {
+		drained = append(drained, q.lanes[i].Drain()...)
+	}
+	return drained
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *LanedQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents in pop order.
+func (q *LanedQueue[T]) Slice() []T {
+	return slices.Collect(q.All())
+}
+
+// Range calls f sequentially for each item in pop order. This action does not modify the queue
+// or its items.
+func (q *LanedQueue[T]) Range(f func(item T) bool) {
+	q.mu.RLock()
+	snapshot := q.snapshotLocked()
+	q.mu.RUnlock()
+
+	for _, it := range snapshot {
+		if !f(it) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue in pop order: lane by lane from the highest
+// priority, and FIFO within each lane.
+func (q *LanedQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := q.snapshotLocked()
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *LanedQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *LanedQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *LanedQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Internal helpers
+
+// clampLane maps lane into the range of valid lane indexes.
+func (q *LanedQueue[T]) clampLane(lane int) int {
+	return min(max(lane, 0), len(q.lanes)-1)
+}
+
+// lenLocked returns the number of items across all lanes. Callers must hold the lock.
+func (q *LanedQueue[T]) lenLocked() int {
+	n := 0
+	for i := range q.lanes {
+		n += q.lanes[i].Len()
+	}
+	return n
+}
+
+// snapshotLocked copies the items in pop order. Callers must hold at least a read lock.
+func (q *LanedQueue[T]) snapshotLocked() []T {
+	snapshot := make([]T, 0, q.lenLocked())
+	for i := range q.lanes {
+		snapshot = append(snapshot, q.lanes[i].Slice()...)
+	}
+	return snapshot
+}
+
+// Ensure LanedQueue implements Queue.
+var _ Queue[any] = (*LanedQueue[any])(nil)
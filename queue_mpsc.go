@@ -0,0 +1,91 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// mpscNode is a node of the linked list backing an MPSCQueue.
+type mpscNode[T any] struct {
+	next atomic.Pointer[mpscNode[T]]
+	item T
+}
+
+// MPSCQueue is a lock-free multi-producer single-consumer FIFO queue backed by an intrusive
+// linked list, following Dmitry Vyukov's design. Producers only perform a single atomic swap per
+// item, and the consumer never contends with producers on a lock, which suits the common
+// "many goroutines feed one writer loop" topology.
+//
+// Push is safe for concurrent use by any number of goroutines, while Pop must only be called by a
+// single consumer goroutine at a time. A Pop racing with an in-flight Push may briefly report the
+// queue as empty until the producer completes.
+//
+// The zero value is ready to use.
+type MPSCQueue[T any] struct {
+	once sync.Once                   // allocates the initial sentinel
+	head atomic.Pointer[mpscNode[T]] // most recently pushed node, written by producers
+	tail *mpscNode[T]                // sentinel before the front item, owned by the consumer
+	size atomic.Int64
+}
+
+// NewMPSCQueue creates a new instance of MPSCQueue.
+func NewMPSCQueue[T any]() *MPSCQueue[T] {
+	q := &MPSCQueue[T]{}
+	q.ensureInitialized()
+	return q
+}
+
+// Push adds one or more items to the back of the queue. It is safe to call from any goroutine.
+func (q *MPSCQueue[T]) Push(items ...T) {
+	q.ensureInitialized()
+	for _, item := range items {
+		n := &mpscNode[T]{item: item}
+		q.size.Add(1)
+		prev := q.head.Swap(n)
+		prev.next.Store(n)
+	}
+}
+
+// Pop removes and returns the item at the front of the queue. Only the consumer goroutine may
+// call Pop. If the queue is empty, it returns ok == false and the zero value of T.
+func (q *MPSCQueue[T]) Pop() (item T, ok bool) {
+	q.ensureInitialized()
+	next := q.tail.next.Load()
+	if next == nil {
+		return item, false
+	}
+	item = next.item
+	// next becomes the new sentinel; release its item for garbage collection.
+	var zero T
+	next.item = zero
+	q.tail = next
+	q.size.Add(-1)
+	return item, true
+}
+
+// Len returns the number of items in the queue. The result is approximate while items are being
+// pushed or popped concurrently.
+func (q *MPSCQueue[T]) Len() int {
+	return int(max(q.size.Load(), 0))
+}
+
+// String returns the length of the queue, for logging and debugging. Items are not shown, as they
+// cannot be inspected without removing them.
+func (q *MPSCQueue[T]) String() string {
+	return formatLen(q, q.Len())
+}
+
+// GoString returns the Go type and length of the queue, for the %#v verb.
+func (q *MPSCQueue[T]) GoString() string {
+	return goFormatLen(q, q.Len())
+}
+
+// ensureInitialized lazily allocates the sentinel node for zero-value usage.
+func (q *MPSCQueue[T]) ensureInitialized() {
+	q.once.Do(func() {
+		stub := &mpscNode[T]{}
+		q.tail = stub
+		q.head.Store(stub)
+	})
+}
@@ -0,0 +1,257 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Receipt identifies a single delivery of an item popped from a ReliableQueue. Each delivery of
+// the same item gets a new receipt; the zero Receipt is never issued.
+type Receipt uint64
+
+// reliableDelivery is an item popped from a ReliableQueue awaiting acknowledgement.
+type reliableDelivery[T any] struct {
+	item     T
+	deadline time.Time
+}
+
+// reliableLease records when a delivery becomes visible again. Leases are removed lazily: a lease
+// whose receipt was acked, or whose deadline was extended since, is skipped when it expires.
+type reliableLease struct {
+	receipt  Receipt
+	deadline time.Time
+}
+
+func lessLease(a, b reliableLease) bool {
+	if a.deadline.Equal(b.deadline) {
+		return a.receipt < b.receipt
+	}
+	return a.deadline.Before(b.deadline)
+}
+
+// ReliableQueue is a thread-safe FIFO queue with at-least-once delivery. Pop hands out an item
+// together with a Receipt, and the item stays in flight until it is acknowledged with Ack. If it
+// is not acknowledged within the visibility timeout, for instance because the consumer crashed or
+// gave up, the item becomes visible again and is redelivered ahead of items never delivered.
+//
+// Consumers must therefore be prepared to process an item more than once.
+//
+// The zero value is ready to use, and never redelivers items: they stay in flight until they are
+// acked or nacked.
+type ReliableQueue[T any] struct {
+	mu       sync.Mutex
+	ready    RWMutexQueue[T]
+	inflight map[Receipt]reliableDelivery[T]
+	leases   CorePriorityQueue[reliableLease]
+	timeout  time.Duration
+	receipt  Receipt // last issued receipt
+	changed  broadcaster
+}
+
+// NewReliableQueue creates a new ReliableQueue where popped items become visible again once
+// visibilityTimeout has elapsed without an Ack. A non-positive visibilityTimeout disables
+// redelivery: items stay in flight until they are acked or nacked.
+func NewReliableQueue[T any](visibilityTimeout time.Duration) *ReliableQueue[T] {
+	q := &ReliableQueue[T]{timeout: visibilityTimeout}
+	q.ensureInitialized()
+	return q
+}
+
+// Push adds one or more items to the back of the queue.
+func (q *ReliableQueue[T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	q.ready.Push(items...)
+	q.changed.broadcast()
+	q.mu.Unlock()
+}
+
+// Pop removes the item at the front of the queue and returns it with the receipt used to Ack it,
+// without blocking. If no item is visible it returns ok == false and the zero value of T.
+func (q *ReliableQueue[T]) Pop() (item T, receipt Receipt, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reclaimLocked(time.Now())
+	return q.deliverLocked()
+}
+
+// PopWait removes the item at the front of the queue and returns it with the receipt used to Ack
+// it, blocking until an item is pushed or redelivered, or until ctx is done, in which case
+// ctx.Err() is returned.
+func (q *ReliableQueue[T]) PopWait(ctx context.Context) (item T, receipt Receipt, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		q.reclaimLocked(time.Now())
+		if item, receipt, ok := q.deliverLocked(); ok {
+			return item, receipt, nil
+		}
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if next, ok := q.leases.Peek(); ok {
+			timer = time.NewTimer(time.Until(next.deadline))
+			timeout = timer.C
+		}
+
+		changed := q.changed.wait()
+		q.mu.Unlock()
+		select {
+		case <-changed:
+		case <-timeout:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		q.mu.Lock()
+		if err != nil {
+			return item, 0, err
+		}
+	}
+}
+
+// Ack acknowledges the delivery identified by receipt, removing its item permanently. It returns
+// false if the receipt is unknown, already acked or nacked, or expired, in which case the item
+// may have been redelivered.
+func (q *ReliableQueue[T]) Ack(receipt Receipt) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reclaimLocked(time.Now())
+
+	if _, ok := q.inflight[receipt]; !ok {
+		return false
+	}
+	delete(q.inflight, receipt)
+	return true
+}
+
+// Nack rejects the delivery identified by receipt, making its item visible again immediately at
+// the front of the queue. It returns false if the receipt is unknown, already acked or nacked, or
+// expired.
+func (q *ReliableQueue[T]) Nack(receipt Receipt) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reclaimLocked(time.Now())
+
+	d, ok := q.inflight[receipt]
+	if !ok {
+		return false
+	}
+	delete(q.inflight, receipt)
+	q.ready.PushFront(d.item)
+	q.changed.broadcast()
+	return true
+}
+
+// Extend resets the visibility timeout of the delivery identified by receipt, so that its item
+// stays in flight until timeout has elapsed from now. It is meant for consumers processing an item
+// for longer than the visibility timeout. It returns false if the receipt is unknown, already
+// acked or nacked, or expired.
+func (q *ReliableQueue[T]) Extend(receipt Receipt, timeout time.Duration) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	q.reclaimLocked(now)
+
+	d, ok := q.inflight[receipt]
+	if !ok {
+		return false
+	}
+	d.deadline = now.Add(timeout)
+	q.inflight[receipt] = d
+	q.leases.Push(reliableLease{receipt: receipt, deadline: d.deadline})
+	q.changed.broadcast()
+	return true
+}
+
+// Len returns the number of items visible to Pop, excluding items in flight.
+func (q *ReliableQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reclaimLocked(time.Now())
+	return q.ready.Len()
+}
+
+// String returns the length of the queue, for logging and debugging. Items are not shown, as they
+// cannot be inspected without removing them.
+func (q *ReliableQueue[T]) String() string {
+	return formatLen(q, q.Len())
+}
+
+// GoString returns the Go type and length of the queue, for the %#v verb.
+func (q *ReliableQueue[T]) GoString() string {
+	return goFormatLen(q, q.Len())
+}
+
+// InFlight returns the number of items delivered and awaiting acknowledgement.
+func (q *ReliableQueue[T]) InFlight() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reclaimLocked(time.Now())
+	return len(q.inflight)
+}
+
+// Clear removes all items from the queue, including items in flight. Receipts issued before the
+// call can no longer be acked.
+func (q *ReliableQueue[T]) Clear() {
+	q.mu.Lock()
+	q.ready.Clear()
+	clear(q.inflight)
+	q.leases.Clear()
+	q.changed.broadcast()
+	q.mu.Unlock()
+}
+
+// Internal helpers (callers must hold the lock)
+
+// ensureInitialized lazily allocates the in-flight deliveries and sets the lease ordering for
+// zero-value usage.
+func (q *ReliableQueue[T]) ensureInitialized() {
+	if q.inflight == nil {
+		q.inflight = make(map[Receipt]reliableDelivery[T])
+		q.leases.less = lessLease
+	}
+}
+
+// deliverLocked pops the front item and records it as in flight.
+func (q *ReliableQueue[T]) deliverLocked() (item T, receipt Receipt, ok bool) {
+	item, ok = q.ready.Pop()
+	if !ok {
+		return item, 0, false
+	}
+	q.ensureInitialized()
+	q.receipt++
+	d := reliableDelivery[T]{item: item}
+	if q.timeout > 0 {
+		d.deadline = time.Now().Add(q.timeout)
+		q.leases.Push(reliableLease{receipt: q.receipt, deadline: d.deadline})
+	}
+	q.inflight[q.receipt] = d
+	return item, q.receipt, true
+}
+
+// reclaimLocked makes the items whose visibility timeout has passed by now visible again, ahead
+// of the items never delivered and in the order their timeouts expired.
+func (q *ReliableQueue[T]) reclaimLocked(now time.Time) {
+	var expired []T
+	for {
+		lease, ok := q.leases.Peek()
+		if !ok || lease.deadline.After(now) {
+			break
+		}
+		_, _ = q.leases.Pop()
+		d, ok := q.inflight[lease.receipt]
+		if !ok || !d.deadline.Equal(lease.deadline) {
+			continue // acked, nacked or extended since
+		}
+		delete(q.inflight, lease.receipt)
+		expired = append(expired, d.item)
+	}
+	q.ready.PushFront(expired...)
+}
@@ -0,0 +1,252 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+const ringQueueMinCap = 8 // capacity allocated by the first Push on a zero-value RingQueue
+
+// RingQueue is a thread-safe FIFO queue backed by a circular buffer and protected by a
+// sync.RWMutex.
+//
+// Pop is O(1) and never copies the live items, and the buffer is reused as items wrap around, so
+// memory usage stays stable for workloads with a known maximum backlog. When a Push exceeds the
+// capacity, the buffer grows by doubling; it never shrinks except through Clear and Drain, which
+// keep the current capacity.
+//
+// The zero value of RingQueue is ready to use.
+type RingQueue[T any] struct {
+	mu   sync.RWMutex
+	buf  []T
+	head int // index of the front item in buf
+	size int
+}
+
+// NewRingQueue creates a new RingQueue with room for initialCap items before growing.
+func NewRingQueue[T any](initialCap int) *RingQueue[T] {
+	return &RingQueue[T]{buf: make([]T, max(initialCap, 0))}
+}
+
+// Push adds one or more items to the back of the queue, growing the buffer if needed.
+func (q *RingQueue[T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.size+len(items) > len(q.buf) {
+		q.growLocked(q.size + len(items))
+	}
+	for _, item := range items {
+		q.buf[(q.head+q.size)%len(q.buf)] = item
+		q.size++
+	}
+}
+
+// Pop removes and returns the item at the front of the queue.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q *RingQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.size == 0 {
+		return item, false
+	}
+	var zero T
+	item = q.buf[q.head]
+	q.buf[q.head] = zero
+	q.head = (q.head + 1) % len(q.buf)
+	q.size--
+	return item, true
+}
+
+// Peek returns the item at the front without removing it.
+func (q *RingQueue[T]) Peek() (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.size == 0 {
+		return item, false
+	}
+	return q.buf[q.head], true
+}
+
+// PeekN returns a copy of up to n items from the front of the queue without removing them.
+func (q *RingQueue[T]) PeekN(n int) []T {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.copyLocked(min(max(n, 0), q.size))
+}
+
+// PeekAt returns the item at position i from the front of the queue without removing it.
+func (q *RingQueue[T]) PeekAt(i int) (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if i < 0 || i >= q.size {
+		return item, false
+	}
+	return q.buf[(q.head+i)%len(q.buf)], true
+}
+
+// Len returns the current number of items.
+func (q *RingQueue[T]) Len() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.size
+}
+
+// Cap returns the number of items the queue can hold before its buffer grows.
+func (q *RingQueue[T]) Cap() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return len(q.buf)
+}
+
+// Clear removes all items from the queue, keeping the buffer for reuse.
+func (q *RingQueue[T]) Clear() {
+	q.mu.Lock()
+	clear(q.buf)
+	q.head, q.size = 0, 0
+	q.mu.Unlock()
+}
+
+// Drain atomically removes all items from the queue and returns them from front to back, keeping
+// the buffer for reuse.
+func (q *RingQueue[T]) Drain() []T {
+	q.mu.Lock()
+	drained := q.copyLocked(q.size)
+	clear(q.buf)
+	q.head, q.size = 0, 0
+	q.mu.Unlock()
+	return drained
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *RingQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents from front to back.
+func (q *RingQueue[T]) Slice() []T {
+	return slices.Collect(q.All())
+}
+
+// Range calls f sequentially for each item from front to back. This action does not modify
+// the queue or its items.
+func (q *RingQueue[T]) Range(f func(item T) bool) {
+	q.mu.RLock()
+	snapshot := q.copyLocked(q.size)
+	q.mu.RUnlock()
+
+	for _, it := range snapshot {
+		if !f(it) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue from front to back.
+// The iteration order matches the queue order (FIFO).
+func (q *RingQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := q.copyLocked(q.size)
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *RingQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *RingQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *RingQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Clone returns a new queue with the same capacity and a copy of each item made by copyFn, under
+// a single lock so the clone is a consistent snapshot. copyFn must not call back into the queue;
+// if nil, items are copied shallowly.
+func (q *RingQueue[T]) Clone(copyFn func(T) T) *RingQueue[T] {
+	clone := &RingQueue[T]{}
+	q.cloneInto(clone, copyFn)
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the queue, for capacity planning
+// and memory accounting: the queue itself and its allocated slots, plus sizeFn(item) per item if
+// sizeFn is not nil, e.g. to count string data. sizeFn must not call back into the queue.
+func (q *RingQueue[T]) EstimateBytes(sizeFn func(item T) int) int {
+	return sizeOf[RingQueue[T]]() + q.itemsBytes(sizeFn)
+}
+
+// Internal helpers (callers must hold the lock)
+
+// copyLocked returns the first n items from front to back.
+func (q *RingQueue[T]) copyLocked(n int) []T {
+	out := make([]T, n)
+	if n == 0 {
+		return out
+	}
+	copied := copy(out, q.buf[q.head:min(q.head+n, len(q.buf))])
+	copy(out[copied:], q.buf[:n-copied])
+	return out
+}
+
+// growLocked reallocates the buffer to hold at least need items, unwrapping it in the process.
+func (q *RingQueue[T]) growLocked(need int) {
+	newCap := max(2*len(q.buf), need, ringQueueMinCap)
+	buf := make([]T, newCap)
+	if q.size > 0 {
+		copied := copy(buf, q.buf[q.head:min(q.head+q.size, len(q.buf))])
+		copy(buf[copied:], q.buf[:q.size-copied])
+	}
+	q.buf = buf
+	q.head = 0
+}
+
+// cloneInto copies the buffer of q into the empty queue clone, keeping its capacity, with each
+// item copied by copyFn if not nil.
+func (q *RingQueue[T]) cloneInto(clone *RingQueue[T], copyFn func(T) T) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	clone.buf = make([]T, len(q.buf))
+	copy(clone.buf, cloneSlice(q.copyLocked(q.size), copyFn))
+	clone.size = q.size
+}
+
+// itemsBytes estimates the bytes of the buffer, adding sizeFn(item) per queued item if sizeFn is
+// not nil.
+func (q *RingQueue[T]) itemsBytes(sizeFn func(T) int) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	n := len(q.buf) * sizeOf[T]()
+	if sizeFn != nil {
+		for i := range q.size {
+			n += sizeFn(q.buf[(q.head+i)%len(q.buf)])
+		}
+	}
+	return n
+}
+
+// Ensure RingQueue implements Queue.
+var _ Queue[any] = (*RingQueue[any])(nil)
@@ -2,6 +2,10 @@
 package threadsafe
 
 import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"io"
 	"iter"
 	"slices"
 	"sync"
@@ -14,13 +18,15 @@ const shrinkThreshold = 64 // when head exceeds this and half the slice is unuse
 //
 // The implementation aims for amortized O(1) Push and Pop by keeping a head index instead
 // of shifting the slice on every Pop. When the internal slice has too much unused prefix,
-// it is resliced to reclaim memory.
+// it is resliced to reclaim memory. Popped slots are zeroed meanwhile, so the items they held can
+// be garbage collected promptly.
 //
 // The zero value of RWMutexQueue is ready to use.
 type RWMutexQueue[T any] struct {
-	mu    sync.RWMutex
-	items []T
-	head  int // index of the current front element in items slice
+	mu     observedRWMutex
+	items  []T
+	head   int           // index of the current front element in items slice
+	notify chan struct{} // lazily created by Notify, signaled when items are added
 }
 
 // NewRWMutexQueue creates a new instance of RWMutexQueue.
@@ -30,26 +36,98 @@ func NewRWMutexQueue[T any]() *RWMutexQueue[T] {
 
 // Push adds one or more items to the back of the queue.
 func (q *RWMutexQueue[T]) Push(items ...T) {
+	defer q.mu.observe("Push")()
 	if len(items) == 0 {
 		return
 	}
 	q.mu.Lock()
 	q.items = append(q.items, items...)
+	q.signalLocked()
 	q.mu.Unlock()
 }
 
+// PushFront adds one or more items to the front of the queue, preserving their order, so that the
+// next Pop returns items[0]. This is useful to requeue items whose processing failed.
+func (q *RWMutexQueue[T]) PushFront(items ...T) {
+	defer q.mu.observe("PushFront")()
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Reuse the unused prefix left behind by Pop when it is large enough.
+	defer q.signalLocked()
+	if q.head >= len(items) {
+		q.head -= len(items)
+		copy(q.items[q.head:], items)
+		return
+	}
+	newItems := make([]T, 0, len(items)+len(q.items)-q.head)
+	newItems = append(newItems, items...)
+	newItems = append(newItems, q.items[q.head:]...)
+	q.items = newItems
+	q.head = 0
+}
+
+// Notify returns a channel that receives a value after items are added to the queue, so a
+// consumer can select on it alongside other channels instead of polling Pop. Signals are
+// coalesced: the channel holds at most one pending value no matter how many pushes occurred, so
+// consumers should Pop until the queue is empty after each receive. If the queue holds items when
+// the channel is first requested, a signal is already pending.
+//
+// All callers share the same channel, making it best suited for a single consumer.
+func (q *RWMutexQueue[T]) Notify() <-chan struct{} {
+	defer q.mu.observe("Notify")()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.notify == nil {
+		q.notify = make(chan struct{}, 1)
+		if len(q.items)-q.head > 0 {
+			q.signalLocked()
+		}
+	}
+	return q.notify
+}
+
+// signalLocked sends a coalesced signal on the notify channel, if any. Callers must hold the
+// write lock.
+func (q *RWMutexQueue[T]) signalLocked() {
+	if q.notify == nil {
+		return
+	}
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue is an alias for Push, provided for callers who prefer the conventional queue naming.
+func (q *RWMutexQueue[T]) Enqueue(items ...T) {
+	q.Push(items...)
+}
+
 // Pop removes and returns the item at the front of the queue.
 // If the queue is empty it returns ok == false and the zero value of T.
 func (q *RWMutexQueue[T]) Pop() (item T, ok bool) {
+	defer q.mu.observe("Pop")()
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	return q.popLocked()
+}
+
+// popLocked removes and returns the item at the front of the queue. Callers must hold the write
+// lock.
+func (q *RWMutexQueue[T]) popLocked() (item T, ok bool) {
 	if q.head >= len(q.items) {
 		return item, false
 	}
 
+	var zero T
 	item = q.items[q.head]
 	ok = true
+	q.items[q.head] = zero
 	q.head++
 
 	// Periodically reclaim memory when head grows large.
@@ -66,6 +144,7 @@ func (q *RWMutexQueue[T]) Pop() (item T, ok bool) {
 
 // Peek returns the item at the front without removing it.
 func (q *RWMutexQueue[T]) Peek() (item T, ok bool) {
+	defer q.mu.observe("Peek")()
 	q.mu.RLock()
 	defer q.mu.RUnlock()
 
@@ -75,22 +154,83 @@ func (q *RWMutexQueue[T]) Peek() (item T, ok bool) {
 	return q.items[q.head], true
 }
 
+// PeekN returns a copy of up to n items from the front of the queue without removing them.
+func (q *RWMutexQueue[T]) PeekN(n int) []T {
+	defer q.mu.observe("PeekN")()
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	n = min(max(n, 0), len(q.items)-q.head)
+	peeked := make([]T, n)
+	copy(peeked, q.items[q.head:])
+	return peeked
+}
+
+// PeekAt returns the item at position i from the front of the queue without removing it.
+func (q *RWMutexQueue[T]) PeekAt(i int) (item T, ok bool) {
+	defer q.mu.observe("PeekAt")()
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if i < 0 || i >= len(q.items)-q.head {
+		return item, false
+	}
+	return q.items[q.head+i], true
+}
+
 // Len returns the current number of items.
 func (q *RWMutexQueue[T]) Len() int {
+	defer q.mu.observe("Len")()
 	q.mu.RLock()
 	l := len(q.items) - q.head
 	q.mu.RUnlock()
 	return l
 }
 
+// Instrument makes the queue report its operations and lock waits to ins, or stop reporting if ins
+// is nil. It must be called before the queue is shared between goroutines.
+func (q *RWMutexQueue[T]) Instrument(ins Instrumentation) {
+	q.mu.ins = ins
+}
+
+// InTx returns the view of the queue inside tx, which must be a transaction run by RunTx with the
+// queue among its participants.
+func (q *RWMutexQueue[T]) InTx(tx *Tx) QueueTx[T] {
+	tx.checkLocks(&q.mu)
+	return QueueTx[T]{tx: tx, q: q}
+}
+
+func (q *RWMutexQueue[T]) txLocker() sync.Locker {
+	return &q.mu
+}
+
 // Clear removes all items from the queue.
 func (q *RWMutexQueue[T]) Clear() {
+	defer q.mu.observe("Clear")()
 	q.mu.Lock()
 	q.items = nil
 	q.head = 0
 	q.mu.Unlock()
 }
 
+// Drain atomically removes all items from the queue and returns them from front to back.
+func (q *RWMutexQueue[T]) Drain() []T {
+	defer q.mu.observe("Drain")()
+	q.mu.Lock()
+	drained := make([]T, len(q.items)-q.head)
+	copy(drained, q.items[q.head:])
+	q.items = nil
+	q.head = 0
+	q.mu.Unlock()
+	return drained
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *RWMutexQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
 // Slice returns a copy of the queue contents from front to back.
 func (q *RWMutexQueue[T]) Slice() []T {
 	return slices.Collect(q.All())
@@ -128,5 +268,130 @@ func (q *RWMutexQueue[T]) All() iter.Seq[T] {
 	}
 }
 
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *RWMutexQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *RWMutexQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *RWMutexQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Clone returns a new queue holding a copy of each item made by copyFn, under a single lock so
+// the clone is a consistent snapshot. copyFn must not call back into the queue; if nil, items are
+// copied shallowly.
+func (q *RWMutexQueue[T]) Clone(copyFn func(T) T) *RWMutexQueue[T] {
+	defer q.mu.observe("Clone")()
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return &RWMutexQueue[T]{items: cloneSlice(q.items[q.head:], copyFn)}
+}
+
+// EstimateBytes returns an approximation of the memory held by the queue, for capacity planning
+// and memory accounting: the queue itself and its allocated slots, plus sizeFn(item) per item if
+// sizeFn is not nil, e.g. to count string data. sizeFn must not call back into the queue.
+func (q *RWMutexQueue[T]) EstimateBytes(sizeFn func(item T) int) int {
+	defer q.mu.observe("EstimateBytes")()
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return sizeOf[RWMutexQueue[T]]() + sliceBytes(q.items[q.head:], cap(q.items), sizeFn)
+}
+
+// MarshalJSON encodes the queue contents as a JSON array from front to back.
+func (q *RWMutexQueue[T]) MarshalJSON() ([]byte, error) {
+	defer q.mu.observe("MarshalJSON")()
+	q.mu.RLock()
+	snapshot := make([]T, len(q.items)-q.head)
+	copy(snapshot, q.items[q.head:])
+	q.mu.RUnlock()
+	return json.Marshal(snapshot)
+}
+
+// UnmarshalJSON replaces the queue contents with the items of a JSON array, in front to back
+// order.
+func (q *RWMutexQueue[T]) UnmarshalJSON(data []byte) error {
+	defer q.mu.observe("UnmarshalJSON")()
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.items = items
+	q.head = 0
+	if len(items) > 0 {
+		q.signalLocked()
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+// GobEncode encodes the queue contents from front to back using encoding/gob.
+func (q *RWMutexQueue[T]) GobEncode() ([]byte, error) {
+	defer q.mu.observe("GobEncode")()
+	q.mu.RLock()
+	snapshot := make([]T, len(q.items)-q.head)
+	copy(snapshot, q.items[q.head:])
+	q.mu.RUnlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snapshot); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode replaces the queue contents with the items encoded by GobEncode, in front to back
+// order.
+func (q *RWMutexQueue[T]) GobDecode(data []byte) error {
+	defer q.mu.observe("GobDecode")()
+	var items []T
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&items); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.items = items
+	q.head = 0
+	if len(items) > 0 {
+		q.signalLocked()
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+// SaveTo writes a snapshot of the queue contents to w using codec, from front to back.
+func (q *RWMutexQueue[T]) SaveTo(w io.Writer, codec Codec[T]) error {
+	defer q.mu.observe("SaveTo")()
+	q.mu.RLock()
+	snapshot := make([]T, len(q.items)-q.head)
+	copy(snapshot, q.items[q.head:])
+	q.mu.RUnlock()
+	return writeSnapshot(w, codec, snapshot)
+}
+
+// LoadFrom replaces the queue contents with a snapshot written by SaveTo. On error the queue is
+// left unchanged.
+func (q *RWMutexQueue[T]) LoadFrom(r io.Reader, codec Codec[T]) error {
+	defer q.mu.observe("LoadFrom")()
+	items, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.items = items
+	q.head = 0
+	if len(items) > 0 {
+		q.signalLocked()
+	}
+	q.mu.Unlock()
+	return nil
+}
+
 // Ensure RWMutexQueue implements Queue.
 var _ Queue[any] = (*RWMutexQueue[any])(nil)
@@ -0,0 +1,270 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+const queueSegmentSize = 128 // number of items per SegmentedQueue chunk
+
+// queueSegment is a fixed-size chunk of a SegmentedQueue.
+type queueSegment[T any] struct {
+	items [queueSegmentSize]T
+	next  *queueSegment[T]
+}
+
+// SegmentedQueue is a thread-safe FIFO queue backed by a linked list of fixed-size chunks and
+// protected by a sync.RWMutex.
+//
+// Unlike RWMutexQueue, Pop never copies the live items to reclaim memory: chunks are released
+// one by one as they are consumed, which keeps Pop latency flat for large queues. Popped slots are
+// zeroed so the items they held can be garbage collected promptly.
+//
+// Complexity: Push/Pop O(1), Peek O(1), Len O(1).
+//
+// The zero value of SegmentedQueue is ready to use.
+type SegmentedQueue[T any] struct {
+	mu      sync.RWMutex
+	head    *queueSegment[T]
+	tail    *queueSegment[T]
+	headIdx int // index of the front item in head
+	tailIdx int // index of the next free slot in tail
+	size    int
+}
+
+// NewSegmentedQueue creates a new instance of SegmentedQueue.
+func NewSegmentedQueue[T any]() *SegmentedQueue[T] {
+	return &SegmentedQueue[T]{}
+}
+
+// Push adds one or more items to the back of the queue.
+func (q *SegmentedQueue[T]) Push(items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.tail == nil {
+		q.tail = &queueSegment[T]{}
+		q.head = q.tail
+	}
+	for len(items) > 0 {
+		if q.tailIdx == queueSegmentSize {
+			q.tail.next = &queueSegment[T]{}
+			q.tail = q.tail.next
+			q.tailIdx = 0
+		}
+		n := copy(q.tail.items[q.tailIdx:], items)
+		q.tailIdx += n
+		q.size += n
+		items = items[n:]
+	}
+}
+
+// Pop removes and returns the item at the front of the queue.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q *SegmentedQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.size == 0 {
+		return item, false
+	}
+	var zero T
+	item = q.head.items[q.headIdx]
+	q.head.items[q.headIdx] = zero
+	q.headIdx++
+	q.size--
+
+	switch {
+	case q.size == 0:
+		// Reuse the current chunk from the start.
+		q.head = q.tail
+		q.headIdx, q.tailIdx = 0, 0
+	case q.headIdx == queueSegmentSize:
+		// Release the consumed chunk.
+		q.head = q.head.next
+		q.headIdx = 0
+	}
+	return item, true
+}
+
+// Peek returns the item at the front without removing it.
+func (q *SegmentedQueue[T]) Peek() (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.size == 0 {
+		return item, false
+	}
+	return q.head.items[q.headIdx], true
+}
+
+// PeekN returns a copy of up to n items from the front of the queue without removing them.
+func (q *SegmentedQueue[T]) PeekN(n int) []T {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	n = min(max(n, 0), q.size)
+	peeked := make([]T, 0, n)
+	start := q.headIdx
+	for seg := q.head; len(peeked) < n; seg = seg.next {
+		end := min(queueSegmentSize, start+n-len(peeked))
+		peeked = append(peeked, seg.items[start:end]...)
+		start = 0
+	}
+	return peeked
+}
+
+// PeekAt returns the item at position i from the front of the queue without removing it.
+func (q *SegmentedQueue[T]) PeekAt(i int) (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if i < 0 || i >= q.size {
+		return item, false
+	}
+	pos := q.headIdx + i
+	seg := q.head
+	for pos >= queueSegmentSize {
+		seg = seg.next
+		pos -= queueSegmentSize
+	}
+	return seg.items[pos], true
+}
+
+// Len returns the current number of items.
+func (q *SegmentedQueue[T]) Len() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.size
+}
+
+// Clear removes all items from the queue.
+func (q *SegmentedQueue[T]) Clear() {
+	q.mu.Lock()
+	q.head, q.tail = nil, nil
+	q.headIdx, q.tailIdx = 0, 0
+	q.size = 0
+	q.mu.Unlock()
+}
+
+// Drain atomically removes all items from the queue and returns them from front to back.
+func (q *SegmentedQueue[T]) Drain() []T {
+	q.mu.Lock()
+	drained := q.snapshotLocked()
+	q.head, q.tail = nil, nil
+	q.headIdx, q.tailIdx = 0, 0
+	q.size = 0
+	q.mu.Unlock()
+	return drained
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *SegmentedQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents from front to back.
+func (q *SegmentedQueue[T]) Slice() []T {
+	return slices.Collect(q.All())
+}
+
+// Range calls f sequentially for each item from front to back. This action does not modify
+// the queue or its items.
+func (q *SegmentedQueue[T]) Range(f func(item T) bool) {
+	q.mu.RLock()
+	snapshot := q.snapshotLocked()
+	q.mu.RUnlock()
+
+	for _, it := range snapshot {
+		if !f(it) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue from front to back.
+// The iteration order matches the queue order (FIFO).
+func (q *SegmentedQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := q.snapshotLocked()
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *SegmentedQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *SegmentedQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *SegmentedQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Clone returns a new queue holding a copy of each item made by copyFn, under a single lock so
+// the clone is a consistent snapshot. copyFn must not call back into the queue; if nil, items are
+// copied shallowly.
+func (q *SegmentedQueue[T]) Clone(copyFn func(T) T) *SegmentedQueue[T] {
+	q.mu.RLock()
+	items := cloneSlice(q.snapshotLocked(), copyFn)
+	q.mu.RUnlock()
+
+	clone := NewSegmentedQueue[T]()
+	clone.Push(items...)
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the queue, for capacity planning
+// and memory accounting: the queue itself and its allocated slots, plus sizeFn(item) per item if
+// sizeFn is not nil, e.g. to count string data. sizeFn must not call back into the queue.
+func (q *SegmentedQueue[T]) EstimateBytes(sizeFn func(item T) int) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	n := sizeOf[SegmentedQueue[T]]()
+	for seg := q.head; seg != nil; seg = seg.next {
+		n += sizeOf[queueSegment[T]]()
+	}
+	if sizeFn != nil {
+		n += sliceBytes(q.snapshotLocked(), 0, sizeFn)
+	}
+	return n
+}
+
+// snapshotLocked copies the items from front to back. Callers must hold at least a read lock.
+func (q *SegmentedQueue[T]) snapshotLocked() []T {
+	snapshot := make([]T, 0, q.size)
+	for seg := q.head; seg != nil; seg = seg.next {
+		start, end := 0, queueSegmentSize
+		if seg == q.head {
+			start = q.headIdx
+		}
+		if seg == q.tail {
+			end = q.tailIdx
+		}
+		snapshot = append(snapshot, seg.items[start:end]...)
+	}
+	return snapshot
+}
+
+// Ensure SegmentedQueue implements Queue.
+var _ Queue[any] = (*SegmentedQueue[any])(nil)
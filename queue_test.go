@@ -1,11 +1,20 @@
 package threadsafe
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -23,6 +32,46 @@ func TestRWMutexQueueImplementsQueue(_ *testing.T) {
 	var _ Queue[string] = &RWMutexQueue[string]{}
 }
 
+func TestBlockingQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &BlockingQueue[string]{}
+}
+
+func TestBoundedQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &BoundedQueue[string]{}
+}
+
+func TestExpiringQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &ExpiringQueue[string]{}
+}
+
+func TestSegmentedQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &SegmentedQueue[string]{}
+}
+
+func TestRingQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &RingQueue[string]{}
+}
+
+func TestLanedQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &LanedQueue[string]{}
+}
+
+func TestWeightedQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &WeightedQueue[string]{}
+}
+
+func TestCircularQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &CircularQueue[string]{}
+}
+
+func TestDedupQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &DedupQueue[string, string]{}
+}
+
+func TestFileQueueImplementsQueue(_ *testing.T) {
+	var _ Queue[string] = &FileQueue[string]{}
+}
+
 // TestBasicOperations verifies Push, Pop, Peek, Len, Clear.
 func (s *queueTestSuite[T]) TestBasicOperations(t *testing.T) {
 	q := s.newQueue()
@@ -58,6 +107,78 @@ func (s *queueTestSuite[T]) TestBasicOperations(t *testing.T) {
 	assert.Equal(t, 0, q.Len())
 }
 
+func (s *queueTestSuite[T]) TestPeekNAndPeekAt(t *testing.T) {
+	q := s.newQueue()
+
+	// Empty queue
+	assert.Empty(t, q.PeekN(2))
+	_, ok := q.PeekAt(0)
+	assert.False(t, ok)
+
+	q.Push(s.item1, s.item2, s.item3)
+	assert.Equal(t, []T{s.item1, s.item2}, q.PeekN(2))
+	assert.Equal(t, []T{s.item1, s.item2, s.item3}, q.PeekN(10))
+	assert.Empty(t, q.PeekN(-1))
+
+	item, ok := q.PeekAt(2)
+	assert.True(t, ok)
+	assert.Equal(t, s.item3, item)
+	_, ok = q.PeekAt(3)
+	assert.False(t, ok)
+	_, ok = q.PeekAt(-1)
+	assert.False(t, ok)
+
+	// Positions are relative to the current front
+	_, _ = q.Pop()
+	item, ok = q.PeekAt(0)
+	assert.True(t, ok)
+	assert.Equal(t, s.item2, item)
+
+	// Peeking does not remove items
+	assert.Equal(t, 2, q.Len())
+}
+
+func (s *queueTestSuite[T]) TestDrain(t *testing.T) {
+	q := s.newQueue()
+
+	// Draining an empty queue returns an empty slice
+	assert.Empty(t, q.Drain())
+
+	q.Push(s.item1, s.item2, s.item3)
+	_, _ = q.Pop()
+	assert.Equal(t, []T{s.item2, s.item3}, q.Drain())
+	assert.Equal(t, 0, q.Len())
+
+	// The queue remains usable after draining
+	q.Push(s.item1)
+	item, ok := q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, s.item1, item)
+}
+
+func (s *queueTestSuite[T]) TestEquals(t *testing.T) {
+	eq := func(a, b T) bool { return reflect.DeepEqual(a, b) }
+	q := s.newQueue()
+	other := NewRWMutexQueue[T]()
+
+	// Empty queues are equal
+	assert.True(t, q.Equals(other, eq))
+	assert.True(t, q.Equals(q, eq))
+
+	q.Push(s.item1, s.item2)
+	other.Push(s.item1)
+	assert.False(t, q.Equals(other, eq))
+
+	other.Push(s.item2)
+	assert.True(t, q.Equals(other, eq))
+	assert.True(t, other.Equals(q, eq))
+
+	// Same items in a different order are not equal
+	other.Clear()
+	other.Push(s.item2, s.item1)
+	assert.False(t, q.Equals(other, eq))
+}
+
 func (s *queueTestSuite[T]) TestSlice(t *testing.T) {
 	q := s.newQueue()
 
@@ -146,6 +267,9 @@ func (s *queueTestSuite[T]) TestRangeSnapshot(t *testing.T) {
 
 func runQueueTestSuite[T any](t *testing.T, s *queueTestSuite[T]) {
 	t.Run("BasicOperations", s.TestBasicOperations)
+	t.Run("PeekNAndPeekAt", s.TestPeekNAndPeekAt)
+	t.Run("Drain", s.TestDrain)
+	t.Run("Equals", s.TestEquals)
 	t.Run("Slice", s.TestSlice)
 	t.Run("Range", s.TestRange)
 	t.Run("RangeSnapshot", s.TestRangeSnapshot)
@@ -163,6 +287,90 @@ func TestQueueImplementations(t *testing.T) {
 			}
 			runQueueTestSuite(t, suite)
 		})
+
+		t.Run("BlockingQueue", func(t *testing.T) {
+			suite := &queueTestSuite[string]{
+				newQueue: func() Queue[string] { return NewBlockingQueue[string](0) },
+				item1:    "a",
+				item2:    "b",
+				item3:    "c",
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("ExpiringQueue", func(t *testing.T) {
+			suite := &queueTestSuite[string]{
+				newQueue: func() Queue[string] { return NewExpiringQueue[string](0, nil) },
+				item1:    "a",
+				item2:    "b",
+				item3:    "c",
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("SegmentedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[string]{
+				newQueue: func() Queue[string] { return NewSegmentedQueue[string]() },
+				item1:    "a",
+				item2:    "b",
+				item3:    "c",
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("RingQueue", func(t *testing.T) {
+			suite := &queueTestSuite[string]{
+				newQueue: func() Queue[string] { return NewRingQueue[string](2) },
+				item1:    "a",
+				item2:    "b",
+				item3:    "c",
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("LanedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[string]{
+				newQueue: func() Queue[string] { return NewLanedQueue[string](3) },
+				item1:    "a",
+				item2:    "b",
+				item3:    "c",
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("WeightedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[string]{
+				newQueue: func() Queue[string] { return NewWeightedQueue[string](3, 1) },
+				item1:    "a",
+				item2:    "b",
+				item3:    "c",
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("CircularQueue", func(t *testing.T) {
+			suite := &queueTestSuite[string]{
+				newQueue: func() Queue[string] { return NewCircularQueue[string](1024) },
+				item1:    "a",
+				item2:    "b",
+				item3:    "c",
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("FileQueue", func(t *testing.T) {
+			suite := &queueTestSuite[string]{
+				newQueue: func() Queue[string] {
+					q, err := OpenFileQueue(filepath.Join(t.TempDir(), "queue.wal"), JSONCodec[string]{})
+					assert.NoError(t, err)
+					return q
+				},
+				item1: "a",
+				item2: "b",
+				item3: "c",
+			}
+			runQueueTestSuite(t, suite)
+		})
 	})
 
 	t.Run("int", func(t *testing.T) {
@@ -175,6 +383,76 @@ func TestQueueImplementations(t *testing.T) {
 			}
 			runQueueTestSuite(t, suite)
 		})
+
+		t.Run("BlockingQueue", func(t *testing.T) {
+			suite := &queueTestSuite[int]{
+				newQueue: func() Queue[int] { return NewBlockingQueue[int](0) },
+				item1:    1,
+				item2:    2,
+				item3:    3,
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("ExpiringQueue", func(t *testing.T) {
+			suite := &queueTestSuite[int]{
+				newQueue: func() Queue[int] { return NewExpiringQueue[int](0, nil) },
+				item1:    1,
+				item2:    2,
+				item3:    3,
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("SegmentedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[int]{
+				newQueue: func() Queue[int] { return NewSegmentedQueue[int]() },
+				item1:    1,
+				item2:    2,
+				item3:    3,
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("RingQueue", func(t *testing.T) {
+			suite := &queueTestSuite[int]{
+				newQueue: func() Queue[int] { return NewRingQueue[int](2) },
+				item1:    1,
+				item2:    2,
+				item3:    3,
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("LanedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[int]{
+				newQueue: func() Queue[int] { return NewLanedQueue[int](3) },
+				item1:    1,
+				item2:    2,
+				item3:    3,
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("WeightedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[int]{
+				newQueue: func() Queue[int] { return NewWeightedQueue[int](3, 1) },
+				item1:    1,
+				item2:    2,
+				item3:    3,
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("CircularQueue", func(t *testing.T) {
+			suite := &queueTestSuite[int]{
+				newQueue: func() Queue[int] { return NewCircularQueue[int](1024) },
+				item1:    1,
+				item2:    2,
+				item3:    3,
+			}
+			runQueueTestSuite(t, suite)
+		})
 	})
 
 	t.Run("struct", func(t *testing.T) {
@@ -188,6 +466,76 @@ func TestQueueImplementations(t *testing.T) {
 			}
 			runQueueTestSuite(t, suite)
 		})
+
+		t.Run("BlockingQueue", func(t *testing.T) {
+			suite := &queueTestSuite[testStruct]{
+				newQueue: func() Queue[testStruct] { return NewBlockingQueue[testStruct](0) },
+				item1:    testStruct{1},
+				item2:    testStruct{2},
+				item3:    testStruct{3},
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("ExpiringQueue", func(t *testing.T) {
+			suite := &queueTestSuite[testStruct]{
+				newQueue: func() Queue[testStruct] { return NewExpiringQueue[testStruct](0, nil) },
+				item1:    testStruct{1},
+				item2:    testStruct{2},
+				item3:    testStruct{3},
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("SegmentedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[testStruct]{
+				newQueue: func() Queue[testStruct] { return NewSegmentedQueue[testStruct]() },
+				item1:    testStruct{1},
+				item2:    testStruct{2},
+				item3:    testStruct{3},
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("RingQueue", func(t *testing.T) {
+			suite := &queueTestSuite[testStruct]{
+				newQueue: func() Queue[testStruct] { return NewRingQueue[testStruct](2) },
+				item1:    testStruct{1},
+				item2:    testStruct{2},
+				item3:    testStruct{3},
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("LanedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[testStruct]{
+				newQueue: func() Queue[testStruct] { return NewLanedQueue[testStruct](3) },
+				item1:    testStruct{1},
+				item2:    testStruct{2},
+				item3:    testStruct{3},
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("WeightedQueue", func(t *testing.T) {
+			suite := &queueTestSuite[testStruct]{
+				newQueue: func() Queue[testStruct] { return NewWeightedQueue[testStruct](3, 1) },
+				item1:    testStruct{1},
+				item2:    testStruct{2},
+				item3:    testStruct{3},
+			}
+			runQueueTestSuite(t, suite)
+		})
+
+		t.Run("CircularQueue", func(t *testing.T) {
+			suite := &queueTestSuite[testStruct]{
+				newQueue: func() Queue[testStruct] { return NewCircularQueue[testStruct](1024) },
+				item1:    testStruct{1},
+				item2:    testStruct{2},
+				item3:    testStruct{3},
+			}
+			runQueueTestSuite(t, suite)
+		})
 	})
 }
 
@@ -303,3 +651,1162 @@ func TestRWMutexQueueZeroValue(t *testing.T) {
 	q3.Clear()
 	assert.Equal(t, 0, q3.Len())
 }
+
+func TestMPSCQueueZeroValue(t *testing.T) {
+	var q MPSCQueue[int]
+	_, ok := q.Pop()
+	assert.False(t, ok)
+
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Go(func() {
+			for i := range 50 {
+				q.Push(g*50 + i)
+			}
+		})
+	}
+	wg.Wait()
+	popped := 0
+	for _, ok := q.Pop(); ok; _, ok = q.Pop() {
+		popped++
+	}
+	assert.Equal(t, 200, popped)
+}
+
+func TestRWMutexQueueEnqueueAlias(t *testing.T) {
+	q := NewRWMutexQueue[int]()
+	q.Enqueue(1, 2)
+	q.Push(3)
+	assert.Equal(t, []int{1, 2, 3}, q.Slice())
+
+	// Usable through the Queue interface
+	var iface Queue[int] = q
+	item, ok := iface.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 1, item)
+}
+
+func TestBlockingQueuePopWait(t *testing.T) {
+	q := NewBlockingQueue[int](0)
+
+	// PopWait blocks until an item is pushed
+	done := make(chan int)
+	go func() {
+		item, err := q.PopWait(context.Background())
+		assert.NoError(t, err)
+		done <- item
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Push(42)
+	select {
+	case item := <-done:
+		assert.Equal(t, 42, item)
+	case <-time.After(time.Second):
+		t.Fatal("PopWait did not return after Push")
+	}
+
+	// PopWait on an empty queue honors the context deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := q.PopWait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestBlockingQueuePushWait(t *testing.T) {
+	q := NewBlockingQueue[int](2)
+	assert.Equal(t, 2, q.Cap())
+	assert.NoError(t, q.PushWait(context.Background(), 1, 2))
+
+	// PushWait on a full queue honors cancellation and keeps the items that fit
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, q.PushWait(ctx, 3), context.Canceled)
+	assert.Equal(t, []int{1, 2}, q.Slice())
+
+	// A blocked producer resumes once a consumer frees up space
+	done := make(chan error)
+	go func() {
+		done <- q.PushWait(context.Background(), 3, 4)
+	}()
+	for _, want := range []int{1, 2, 3, 4} {
+		item, err := q.PopWait(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, want, item)
+	}
+	assert.NoError(t, <-done)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestQueueTryPushTryPop(t *testing.T) {
+	t.Run("BlockingQueue", func(t *testing.T) {
+		q := NewBlockingQueue[int](3)
+		assert.True(t, q.TryPush(1, 2))
+		assert.False(t, q.TryPush(3, 4), "a batch that does not fit is rejected whole")
+		assert.Equal(t, []int{1, 2}, q.Slice())
+		assert.True(t, q.TryPush(3))
+		assert.False(t, q.TryPush(4))
+		assert.True(t, q.TryPush(), "an empty batch always fits")
+
+		item, ok := q.TryPop()
+		assert.True(t, ok)
+		assert.Equal(t, 1, item)
+		assert.True(t, q.TryPush(4))
+
+		unbounded := NewBlockingQueue[int](0)
+		assert.True(t, unbounded.TryPush(make([]int, 100)...))
+		_, ok = NewBlockingQueue[int](1).TryPop()
+		assert.False(t, ok)
+	})
+
+	t.Run("BoundedQueue", func(t *testing.T) {
+		for _, policy := range []OverflowPolicy{
+			OverflowReject, OverflowDropOldest, OverflowDropNewest, OverflowBlock,
+		} {
+			q := NewBoundedQueue[int](2, policy)
+			assert.True(t, q.TryPush(1, 2))
+			assert.False(t, q.TryPush(3), "TryPush ignores the overflow policy")
+			assert.Equal(t, []int{1, 2}, q.Slice())
+
+			item, ok := q.TryPop()
+			assert.True(t, ok)
+			assert.Equal(t, 1, item)
+		}
+	})
+}
+
+func TestBlockingQueueConcurrentProducersConsumers(t *testing.T) {
+	const producers = 4
+	const perProducer = 250
+	q := NewBlockingQueue[int](8)
+
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Go(func() {
+			for i := range perProducer {
+				assert.NoError(t, q.PushWait(context.Background(), p*perProducer+i))
+			}
+		})
+	}
+
+	seen := make(map[int]bool, producers*perProducer)
+	for range producers * perProducer {
+		item, err := q.PopWait(context.Background())
+		assert.NoError(t, err)
+		seen[item] = true
+	}
+	wg.Wait()
+	assert.Len(t, seen, producers*perProducer)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestBoundedQueueOverflowPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Reject", func(t *testing.T) {
+		q := NewBoundedQueue[int](3, OverflowReject)
+		assert.NoError(t, q.PushWait(ctx, 1, 2))
+		assert.ErrorIs(t, q.PushWait(ctx, 3, 4), ErrQueueFull)
+		assert.Equal(t, []int{1, 2}, q.Slice())
+		assert.NoError(t, q.PushWait(ctx, 3))
+		q.Push(4) // discarded silently
+		assert.Equal(t, []int{1, 2, 3}, q.Slice())
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		q := NewBoundedQueue[int](3, OverflowDropOldest)
+		q.Push(1, 2, 3)
+		q.Push(4)
+		assert.Equal(t, []int{2, 3, 4}, q.Slice())
+		q.Push(5, 6, 7, 8)
+		assert.Equal(t, []int{6, 7, 8}, q.Slice())
+	})
+
+	t.Run("DropNewest", func(t *testing.T) {
+		q := NewBoundedQueue[int](3, OverflowDropNewest)
+		q.Push(1, 2)
+		assert.NoError(t, q.PushWait(ctx, 3, 4, 5))
+		assert.Equal(t, []int{1, 2, 3}, q.Slice())
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		q := NewBoundedQueue[int](1, OverflowBlock)
+		q.Push(1)
+		timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, q.PushWait(timeout, 2), context.DeadlineExceeded)
+
+		done := make(chan struct{})
+		go func() {
+			q.Push(2)
+			close(done)
+		}()
+		item, err := q.PopWait(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, item)
+		<-done
+		assert.Equal(t, []int{2}, q.Slice())
+	})
+
+	t.Run("CapacityCoerced", func(t *testing.T) {
+		q := NewBoundedQueue[int](0, OverflowDropOldest)
+		assert.Equal(t, 1, q.Cap())
+		assert.Equal(t, OverflowDropOldest, q.Policy())
+	})
+}
+
+func TestDelayQueue(t *testing.T) {
+	q := NewDelayQueue[string]()
+	now := time.Now()
+
+	q.Push("later", now.Add(time.Hour))
+	q.Push("past", now.Add(-time.Second))
+	q.PushAfter("soon", 20*time.Millisecond)
+	assert.Equal(t, 3, q.Len())
+
+	// Only the item whose ready time has passed is visible
+	item, ok := q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, "past", item)
+	_, ok = q.Pop()
+	assert.False(t, ok)
+
+	readyAt, ok := q.NextReady()
+	assert.True(t, ok)
+	assert.True(t, readyAt.Before(now.Add(time.Hour)))
+
+	// PopWait blocks until the next item is due
+	start := time.Now()
+	item, err := q.PopWait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "soon", item)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	// PopWait honors the context while the remaining item is not due
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = q.PopWait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	q.Clear()
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestDelayQueueWakesOnEarlierPush(t *testing.T) {
+	var q DelayQueue[int] // zero value is ready to use
+	q.Push(1, time.Now().Add(time.Hour))
+
+	done := make(chan int)
+	go func() {
+		item, err := q.PopWait(context.Background())
+		assert.NoError(t, err)
+		done <- item
+	}()
+	time.Sleep(10 * time.Millisecond)
+	q.Push(2, time.Now())
+
+	select {
+	case item := <-done:
+		assert.Equal(t, 2, item)
+	case <-time.After(time.Second):
+		t.Fatal("PopWait did not pick up the earlier item")
+	}
+
+	// Items with equal ready times come out in insertion order
+	at := time.Now().Add(-time.Second)
+	q.Push(3, at)
+	q.Push(4, at)
+	first, _ := q.Pop()
+	second, _ := q.Pop()
+	assert.Equal(t, []int{3, 4}, []int{first, second})
+}
+
+func TestRWMutexQueuePushFront(t *testing.T) {
+	q := NewRWMutexQueue[int]()
+
+	// PushFront on an empty queue
+	q.PushFront(2, 3)
+	q.Push(4)
+	q.PushFront(1)
+	assert.Equal(t, []int{1, 2, 3, 4}, q.Slice())
+
+	// Requeue a popped item, reusing the space left by Pop
+	item, ok := q.Pop()
+	assert.True(t, ok)
+	q.PushFront(item)
+	assert.Equal(t, []int{1, 2, 3, 4}, q.Slice())
+
+	// Works on the zero value
+	var zq RWMutexQueue[string]
+	zq.PushFront("a")
+	assert.Equal(t, []string{"a"}, zq.Slice())
+}
+
+func TestExpiringQueueSkipsExpiredItems(t *testing.T) {
+	var expired []string
+	var mu sync.Mutex
+	q := NewExpiringQueue[string](time.Hour, func(item string) {
+		mu.Lock()
+		expired = append(expired, item)
+		mu.Unlock()
+	})
+
+	past := time.Now().Add(-time.Second)
+	q.PushWithDeadline(past, "stale1")
+	q.Push("fresh1")
+	q.PushWithDeadline(past, "stale2")
+	q.PushWithTTL(0, "forever")
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, []string{"fresh1", "forever"}, q.Slice())
+
+	// Peek and Pop skip and report expired items in front
+	item, ok := q.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, "fresh1", item)
+	assert.Equal(t, []string{"stale1"}, expired)
+
+	item, ok = q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, "fresh1", item)
+
+	item, ok = q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, "forever", item)
+	assert.Equal(t, []string{"stale1", "stale2"}, expired)
+
+	_, ok = q.Pop()
+	assert.False(t, ok)
+
+	// Purge removes expired items anywhere in the queue
+	q.Push("a")
+	q.PushWithDeadline(past, "stale3")
+	q.Push("b")
+	assert.Equal(t, 1, q.Purge())
+	assert.Equal(t, []string{"a", "b"}, q.Drain())
+	assert.Equal(t, []string{"stale1", "stale2", "stale3"}, expired)
+
+	// Items expire once their TTL has elapsed
+	q.PushWithTTL(10*time.Millisecond, "short")
+	assert.Equal(t, 1, q.Len())
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, q.Len())
+	_, ok = q.Pop()
+	assert.False(t, ok)
+}
+
+func TestFileQueuePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.wal")
+
+	q, err := OpenFileQueue(path, GobCodec[int]{})
+	assert.NoError(t, err)
+	q.Push(1, 2, 3, 4)
+	item, ok := q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 1, item)
+	assert.NoError(t, q.Err())
+	assert.NoError(t, q.Close())
+
+	// Reopening replays the log
+	q, err = OpenFileQueue(path, GobCodec[int]{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 4}, q.Slice())
+
+	// Checkpoint compacts the log without changing the contents
+	before, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, q.Checkpoint())
+	after, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+	q.Push(5)
+	assert.NoError(t, q.Close())
+
+	q, err = OpenFileQueue(path, GobCodec[int]{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 4, 5}, q.Slice())
+
+	// Operations after Close are reported through Err
+	assert.NoError(t, q.Close())
+	q.Push(6)
+	assert.ErrorIs(t, q.Err(), ErrQueueClosed)
+	assert.Equal(t, 4, q.Len())
+}
+
+func TestFileQueueTornWriteAndCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.wal")
+
+	q, err := OpenFileQueue(path, JSONCodec[string]{})
+	assert.NoError(t, err)
+	q.Push("a", "b")
+	assert.NoError(t, q.Close())
+
+	// Simulate a crash in the middle of writing a record
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = f.Write(appendWALRecord(nil, walOpPush, []byte(`"c"`))[:3])
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	q, err = OpenFileQueue(path, JSONCodec[string]{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, q.Slice())
+	assert.NoError(t, q.Close())
+
+	// A corrupted length prefix larger than the rest of the log is discarded as well
+	f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	record := binary.AppendUvarint([]byte{walOpPush}, math.MaxUint64)
+	_, err = f.Write(append(record, `"c"`...))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	q, err = OpenFileQueue(path, JSONCodec[string]{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, q.Slice())
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(appendWALRecord(nil, walOpPush, []byte(`"a"`)))*2), info.Size())
+
+	// Enough pops trigger a compaction of the log
+	for i := range fileQueueCompactThreshold {
+		q.Push(strconv.Itoa(i))
+		q.Pop()
+	}
+	assert.NoError(t, q.Err())
+	info, err = os.Stat(path)
+	assert.NoError(t, err)
+	assert.Less(t, info.Size(), int64(fileQueueCompactThreshold))
+	assert.NoError(t, q.Close())
+
+	q, err = OpenFileQueue(path, JSONCodec[string]{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, q.Len())
+	assert.NoError(t, q.Close())
+}
+
+func TestFileQueueFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.wal")
+
+	q, err := OpenFileQueue(path, JSONCodec[string]{})
+	assert.NoError(t, err)
+	q.Push("a")
+
+	// A log that can neither be written nor truncated marks the queue as failed
+	file := q.file
+	q.file, err = os.Open(path)
+	assert.NoError(t, err)
+	q.Push("b")
+	assert.Error(t, q.Err())
+	assert.NoError(t, q.file.Close())
+
+	// Later writes are refused even once the log is writable again
+	q.file = file
+	q.Push("c")
+	_, ok := q.Pop()
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a"}, q.Slice())
+
+	// A checkpoint rewrites the log and clears the failure
+	assert.NoError(t, q.Checkpoint())
+	q.Push("d")
+	assert.NoError(t, q.Close())
+
+	q, err = OpenFileQueue(path, JSONCodec[string]{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "d"}, q.Slice())
+	assert.NoError(t, q.Close())
+}
+
+func TestSegmentedQueueAcrossSegments(t *testing.T) {
+	var q SegmentedQueue[int] // zero value is ready to use
+	const n = 3*queueSegmentSize + 7
+
+	for i := range n {
+		q.Push(i)
+	}
+	assert.Equal(t, n, q.Len())
+
+	// Interleave pops and pushes across segment boundaries
+	for i := range n {
+		item, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, i, item)
+		q.Push(n + i)
+	}
+	expected := make([]int, n)
+	for i := range expected {
+		expected[i] = n + i
+	}
+	assert.Equal(t, expected, q.Slice())
+
+	// Popped slots are released so only live segments remain reachable
+	segments := 0
+	for seg := q.head; seg != nil; seg = seg.next {
+		segments++
+	}
+	assert.LessOrEqual(t, segments, n/queueSegmentSize+1)
+
+	// Peeking across segment boundaries
+	assert.Equal(t, expected[:2*queueSegmentSize], q.PeekN(2*queueSegmentSize))
+	item, ok := q.PeekAt(2*queueSegmentSize + 1)
+	assert.True(t, ok)
+	assert.Equal(t, expected[2*queueSegmentSize+1], item)
+
+	// Bulk push larger than a segment
+	assert.Equal(t, expected, q.Drain())
+	q.Push(expected...)
+	assert.Equal(t, expected, q.Slice())
+}
+
+func TestDedupQueueSuppressesDuplicates(t *testing.T) {
+	type job struct {
+		Key     string
+		Attempt int
+	}
+	q := NewDedupQueue(func(j job) string { return j.Key })
+
+	q.Push(job{"a", 1}, job{"b", 1}, job{"a", 2})
+	assert.Equal(t, []job{{"a", 1}, {"b", 1}}, q.Slice())
+	assert.False(t, q.Add(job{"b", 2}))
+	assert.True(t, q.Add(job{"c", 1}))
+	assert.True(t, q.Has("a"))
+
+	// Popping a key allows it to be pushed again
+	item, ok := q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, job{"a", 1}, item)
+	assert.False(t, q.Has("a"))
+	assert.True(t, q.Add(job{"a", 3}))
+	assert.Equal(t, []job{{"b", 1}, {"c", 1}, {"a", 3}}, q.Slice())
+
+	// Drain and Clear reset the index
+	assert.Len(t, q.Drain(), 3)
+	assert.False(t, q.Has("b"))
+	q.Push(job{"b", 2})
+	q.Clear()
+	assert.True(t, q.Add(job{"b", 3}))
+}
+
+func TestRWMutexQueueJSON(t *testing.T) {
+	q := NewRWMutexQueue[int]()
+	q.Push(1, 2, 3)
+	q.Pop()
+
+	data, err := json.Marshal(q)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[2,3]`, string(data))
+
+	// Round trip into a zero value, also as a struct field
+	var restored struct {
+		Backlog RWMutexQueue[int] `json:"backlog"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"backlog":[2,3,4]}`), &restored))
+	assert.Equal(t, []int{2, 3, 4}, restored.Backlog.Slice())
+
+	// Unmarshaling replaces existing contents
+	assert.NoError(t, json.Unmarshal([]byte(`[5]`), q))
+	assert.Equal(t, []int{5}, q.Slice())
+
+	// Invalid input leaves the queue unchanged
+	assert.Error(t, json.Unmarshal([]byte(`{"a":1}`), q))
+	assert.Equal(t, []int{5}, q.Slice())
+
+	// An empty queue encodes as an empty array
+	data, err = json.Marshal(NewRWMutexQueue[string]())
+	assert.NoError(t, err)
+	assert.Equal(t, `[]`, string(data))
+}
+
+func TestRWMutexQueueGob(t *testing.T) {
+	type state struct {
+		Name    string
+		Backlog *RWMutexQueue[string]
+	}
+	q := NewRWMutexQueue[string]()
+	q.Push("a", "b", "c")
+	q.Pop()
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(state{Name: "app", Backlog: q}))
+
+	var restored state
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&restored))
+	assert.Equal(t, "app", restored.Name)
+	assert.Equal(t, []string{"b", "c"}, restored.Backlog.Slice())
+
+	// Decoding replaces existing contents, and an empty queue round-trips
+	data, err := NewRWMutexQueue[string]().GobEncode()
+	assert.NoError(t, err)
+	assert.NoError(t, q.GobDecode(data))
+	assert.Equal(t, 0, q.Len())
+
+	// Invalid input leaves the queue unchanged
+	q.Push("x")
+	assert.Error(t, q.GobDecode([]byte("garbage")))
+	assert.Equal(t, []string{"x"}, q.Slice())
+}
+
+func TestRWMutexQueueNotify(t *testing.T) {
+	var q RWMutexQueue[int]
+
+	// Items pushed before the channel is requested leave a pending signal
+	q.Push(1)
+	notify := q.Notify()
+	assert.Equal(t, notify, q.Notify())
+	select {
+	case <-notify:
+	default:
+		t.Fatal("expected a pending signal")
+	}
+
+	// Signals are coalesced
+	q.Push(2)
+	q.PushFront(0)
+	<-notify
+	select {
+	case <-notify:
+		t.Fatal("expected signals to be coalesced")
+	default:
+	}
+
+	// A consumer loop driven by Notify sees every item
+	q.Clear()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	received := make(chan []int)
+	go func() {
+		var got []int
+		for len(got) < 100 {
+			select {
+			case <-notify:
+				for {
+					item, ok := q.Pop()
+					if !ok {
+						break
+					}
+					got = append(got, item)
+				}
+			case <-ctx.Done():
+				received <- got
+				return
+			}
+		}
+		received <- got
+	}()
+	for i := range 100 {
+		q.Push(i)
+	}
+	assert.Len(t, <-received, 100)
+}
+
+func TestMPSCQueue(t *testing.T) {
+	q := NewMPSCQueue[int]()
+	_, ok := q.Pop()
+	assert.False(t, ok)
+
+	q.Push(1, 2, 3)
+	assert.Equal(t, 3, q.Len())
+	for _, want := range []int{1, 2, 3} {
+		item, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, want, item)
+	}
+	_, ok = q.Pop()
+	assert.False(t, ok)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestMPSCQueueConcurrentProducers(t *testing.T) {
+	const producers = 8
+	const perProducer = 1000
+	q := NewMPSCQueue[int]()
+
+	var wg sync.WaitGroup
+	for p := range producers {
+		wg.Go(func() {
+			for i := range perProducer {
+				q.Push(p*perProducer + i)
+			}
+		})
+	}
+
+	// Single consumer: items from each producer arrive in the order they were pushed
+	last := make([]int, producers)
+	for i := range last {
+		last[i] = -1
+	}
+	for received := 0; received < producers*perProducer; {
+		item, ok := q.Pop()
+		if !ok {
+			continue
+		}
+		p, i := item/perProducer, item%perProducer
+		assert.Greater(t, i, last[p])
+		last[p] = i
+		received++
+	}
+	wg.Wait()
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestRingQueueWrapAndGrow(t *testing.T) {
+	q := NewRingQueue[int](4)
+	assert.Equal(t, 4, q.Cap())
+
+	// Wrap around without growing
+	q.Push(1, 2, 3)
+	q.Pop()
+	q.Pop()
+	q.Push(4, 5, 6)
+	assert.Equal(t, 4, q.Cap())
+	assert.Equal(t, []int{3, 4, 5, 6}, q.Slice())
+	assert.Equal(t, []int{3, 4, 5}, q.PeekN(3))
+	item, ok := q.PeekAt(3)
+	assert.True(t, ok)
+	assert.Equal(t, 6, item)
+
+	// Growing while wrapped keeps the order
+	q.Push(7)
+	assert.Equal(t, 8, q.Cap())
+	assert.Equal(t, []int{3, 4, 5, 6, 7}, q.Slice())
+
+	// Drain keeps the capacity for reuse
+	assert.Equal(t, []int{3, 4, 5, 6, 7}, q.Drain())
+	assert.Equal(t, 8, q.Cap())
+
+	// Zero value grows on first push
+	var zq RingQueue[string]
+	zq.Push("a")
+	assert.Equal(t, ringQueueMinCap, zq.Cap())
+	item2, ok := zq.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, "a", item2)
+}
+
+func TestLanedQueue(t *testing.T) {
+	const (
+		high = iota
+		normal
+		low
+	)
+	q := NewLanedQueue[string](3)
+	assert.Equal(t, 3, q.Lanes())
+
+	q.Push("low1")
+	q.PushLane(normal, "normal1", "normal2")
+	q.PushLane(high, "high1")
+	q.PushLane(low, "low2")
+	q.PushLane(-5, "high2") // clamped to the highest lane
+	assert.Equal(t, 6, q.Len())
+	assert.Equal(t, 2, q.LaneLen(high))
+	assert.Equal(t, 2, q.LaneLen(normal))
+	assert.Equal(t, 2, q.LaneLen(low))
+
+	want := []string{"high1", "high2", "normal1", "normal2", "low1", "low2"}
+	assert.Equal(t, want, q.Slice())
+	assert.Equal(t, want[:3], q.PeekN(3))
+	item, ok := q.PeekAt(4)
+	assert.True(t, ok)
+	assert.Equal(t, "low1", item)
+	item, ok = q.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, "high1", item)
+
+	item, ok = q.PopLane(low)
+	assert.True(t, ok)
+	assert.Equal(t, "low1", item)
+
+	for _, w := range []string{"high1", "high2", "normal1"} {
+		item, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, w, item)
+	}
+
+	// A new high-priority item jumps ahead of the remaining ones
+	q.PushLane(high, "high3")
+	assert.Equal(t, []string{"high3", "normal2", "low2"}, q.Drain())
+	assert.Equal(t, 0, q.Len())
+	_, ok = q.PopLane(high)
+	assert.False(t, ok)
+
+	// Fewer than one lane is coerced to one
+	assert.Equal(t, 1, NewLanedQueue[int](0).Lanes())
+}
+
+func TestWeightedQueue(t *testing.T) {
+	q := NewWeightedQueue[string](3, 1, 0)
+	assert.Equal(t, 3, q.Lanes())
+	assert.Equal(t, 1, q.Weight(2)) // coerced
+	q.PushLane(0, "a1", "a2", "a3", "a4", "a5", "a6")
+	q.PushLane(1, "b1", "b2", "b3")
+	q.PushLane(9, "c1") // clamped to the last lane
+	assert.Equal(t, 10, q.Len())
+	assert.Equal(t, 1, q.LaneLen(2))
+
+	// Lanes are served in proportion to their weights, interleaved, and empty lanes are skipped
+	want := []string{"a1", "b1", "a2", "c1", "a3", "a4", "a5", "b2", "a6", "b3"}
+	assert.Equal(t, want, q.Slice())
+	peeked, ok := q.PeekAt(3)
+	assert.True(t, ok)
+	assert.Equal(t, "c1", peeked)
+	var popped []string
+	for {
+		item, ok := q.Pop()
+		if !ok {
+			break
+		}
+		popped = append(popped, item)
+	}
+	assert.Equal(t, want, popped)
+
+	// Over many rounds each backlogged lane gets its weighted share
+	q.SetWeight(2, 2)
+	for i := range 600 {
+		q.PushLane(i%3, strconv.Itoa(i%3))
+	}
+	counts := map[string]int{}
+	for range 300 {
+		item, _ := q.Pop()
+		counts[item]++
+	}
+	assert.Equal(t, map[string]int{"0": 150, "1": 50, "2": 100}, counts)
+
+	// PopLane bypasses the round-robin order
+	item, ok := q.PopLane(1)
+	assert.True(t, ok)
+	assert.Equal(t, "1", item)
+	assert.Len(t, q.Drain(), 299)
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, 1, NewWeightedQueue[int]().Lanes())
+}
+
+func TestCircularQueueOverwrite(t *testing.T) {
+	q := NewCircularQueue[int](3)
+	assert.Equal(t, 3, q.Cap())
+
+	assert.Nil(t, q.PushEvict(1, 2, 3))
+	assert.Equal(t, []int{1}, q.PushEvict(4))
+	assert.Equal(t, []int{2, 3, 4}, q.Slice())
+
+	// Overwriting continues after wrap-around
+	q.Push(5)
+	assert.Equal(t, []int{3, 4, 5}, q.Slice())
+	item, ok := q.PeekAt(2)
+	assert.True(t, ok)
+	assert.Equal(t, 5, item)
+
+	// Popping frees room without evicting
+	item, ok = q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, 3, item)
+	assert.Nil(t, q.PushEvict(6))
+	assert.Equal(t, []int{4, 5, 6}, q.Slice())
+
+	// A batch larger than the capacity keeps only its last items
+	assert.Equal(t, []int{4, 5, 6, 7, 8}, q.PushEvict(7, 8, 9, 10, 11))
+	assert.Equal(t, []int{9, 10, 11}, q.Slice())
+	assert.Equal(t, 3, q.Cap())
+
+	assert.Equal(t, []int{9, 10, 11}, q.Drain())
+	assert.Equal(t, 3, q.Cap())
+	assert.Equal(t, 1, NewCircularQueue[int](0).Cap())
+}
+
+func TestReliableQueue(t *testing.T) {
+	t.Run("AckRemovesItem", func(t *testing.T) {
+		q := NewReliableQueue[string](time.Hour)
+		q.Push("a", "b")
+
+		item, receipt, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, "a", item)
+		assert.Equal(t, 1, q.Len())
+		assert.Equal(t, 1, q.InFlight())
+
+		assert.True(t, q.Ack(receipt))
+		assert.False(t, q.Ack(receipt))
+		assert.Equal(t, 0, q.InFlight())
+		assert.False(t, q.Ack(Receipt(0)))
+	})
+
+	t.Run("NackRequeuesAtFront", func(t *testing.T) {
+		q := NewReliableQueue[string](time.Hour)
+		q.Push("a", "b")
+
+		_, receipt, _ := q.Pop()
+		assert.True(t, q.Nack(receipt))
+		assert.False(t, q.Ack(receipt))
+
+		item, newReceipt, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, "a", item)
+		assert.NotEqual(t, receipt, newReceipt)
+	})
+
+	t.Run("RedeliveryAfterTimeout", func(t *testing.T) {
+		q := NewReliableQueue[string](20 * time.Millisecond)
+		q.Push("a", "b")
+
+		_, receipt, _ := q.Pop()
+		time.Sleep(40 * time.Millisecond)
+
+		// The expired item is redelivered ahead of "b" and its old receipt is void
+		assert.Equal(t, 0, q.InFlight())
+		assert.False(t, q.Ack(receipt))
+		item, _, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, "a", item)
+	})
+
+	t.Run("Extend", func(t *testing.T) {
+		q := NewReliableQueue[string](20 * time.Millisecond)
+		q.Push("a")
+
+		_, receipt, _ := q.Pop()
+		assert.True(t, q.Extend(receipt, time.Hour))
+		time.Sleep(40 * time.Millisecond)
+		assert.Equal(t, 1, q.InFlight())
+		assert.True(t, q.Ack(receipt))
+		assert.False(t, q.Extend(receipt, time.Hour))
+	})
+
+	t.Run("NoTimeout", func(t *testing.T) {
+		q := NewReliableQueue[int](0)
+		q.Push(1)
+		_, receipt, _ := q.Pop()
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, 1, q.InFlight())
+		assert.True(t, q.Ack(receipt))
+	})
+
+	t.Run("PopWait", func(t *testing.T) {
+		q := NewReliableQueue[int](30 * time.Millisecond)
+		q.Push(1)
+		_, _, ok := q.Pop()
+		assert.True(t, ok)
+
+		// Blocks until the unacked item is redelivered
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		item, _, err := q.PopWait(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, item)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			q.Push(2)
+		}()
+		item, _, err = q.PopWait(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, item)
+
+		q.Clear()
+		ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, _, err = q.PopWait(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ZeroValue", func(t *testing.T) {
+		var q ReliableQueue[string]
+		assert.False(t, q.Ack(Receipt(1)))
+		q.Push("a", "b")
+
+		item, receipt, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, "a", item)
+		assert.True(t, q.Extend(receipt, time.Hour))
+		assert.True(t, q.Nack(receipt))
+
+		// Without a visibility timeout, items are never redelivered on their own
+		item, receipt, ok = q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, "a", item)
+		assert.Equal(t, 1, q.InFlight())
+		assert.True(t, q.Ack(receipt))
+	})
+
+	t.Run("ConcurrentAtLeastOnce", func(t *testing.T) {
+		const items = 500
+		q := NewReliableQueue[int](time.Hour)
+		for i := range items {
+			q.Push(i)
+		}
+
+		var mu sync.Mutex
+		seen := make(map[int]int)
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Go(func() {
+				for {
+					item, receipt, ok := q.Pop()
+					if !ok {
+						return
+					}
+					// Reject every third delivery once to exercise redelivery
+					mu.Lock()
+					seen[item]++
+					retry := seen[item] == 1 && item%3 == 0
+					mu.Unlock()
+					if retry {
+						q.Nack(receipt)
+					} else {
+						q.Ack(receipt)
+					}
+				}
+			})
+		}
+		wg.Wait()
+
+		assert.Len(t, seen, items)
+		assert.Equal(t, 0, q.Len())
+		assert.Equal(t, 0, q.InFlight())
+	})
+}
+
+func TestBatchingQueue(t *testing.T) {
+	t.Run("SizeThreshold", func(t *testing.T) {
+		var batches [][]int
+		q := NewBatchingQueueFunc(func(batch []int) { batches = append(batches, batch) }, 3, 0)
+
+		q.Push(1, 2)
+		assert.Empty(t, batches)
+		assert.Equal(t, 2, q.Len())
+
+		q.Push(3, 4, 5, 6, 7)
+		assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}}, batches)
+		assert.Equal(t, 1, q.Len())
+
+		q.Flush()
+		assert.Equal(t, [][]int{{1, 2, 3}, {4, 5, 6}, {7}}, batches)
+		q.Flush()
+		assert.Len(t, batches, 3)
+	})
+
+	t.Run("TimeThreshold", func(t *testing.T) {
+		sink := NewBlockingQueue[int](0)
+		q := NewBatchingQueue[int](sink, 100, 20*time.Millisecond)
+		q.Push(1, 2)
+		assert.Equal(t, 0, sink.Len())
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		item, err := sink.PopWait(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, item)
+		assert.Equal(t, 0, q.Len())
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		sink := NewRWMutexQueue[string]()
+		q := NewBatchingQueue[string](sink, 10, time.Hour)
+		q.Push("a")
+		q.Close()
+		assert.Equal(t, []string{"a"}, sink.Slice())
+
+		// Pushes after Close are emitted immediately
+		q.Push("b", "c")
+		assert.Equal(t, []string{"a", "b", "c"}, sink.Slice())
+		q.Close()
+	})
+
+	t.Run("ConcurrentPush", func(t *testing.T) {
+		var mu sync.Mutex
+		total := 0
+		q := NewBatchingQueueFunc(func(batch []int) {
+			mu.Lock()
+			total += len(batch)
+			mu.Unlock()
+		}, 7, time.Millisecond)
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				for i := range 100 {
+					q.Push(i)
+				}
+			})
+		}
+		wg.Wait()
+		q.Close()
+		assert.Equal(t, 800, total)
+	})
+}
+
+func TestExpiryQueue(t *testing.T) {
+	t.Run("DeliversInFireTimeOrder", func(t *testing.T) {
+		q := NewExpiryQueue[string, string](0)
+		defer q.Close()
+		now := time.Now()
+		q.Schedule("c", "c", now.Add(30*time.Millisecond))
+		q.Schedule("a", "a", now.Add(10*time.Millisecond))
+		q.Schedule("b", "b", now.Add(20*time.Millisecond))
+		assert.Equal(t, 3, q.Len())
+
+		var got []string
+		for range 3 {
+			select {
+			case item := <-q.C():
+				got = append(got, item)
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for delivery")
+			}
+		}
+		assert.Equal(t, []string{"a", "b", "c"}, got)
+		assert.Equal(t, 0, q.Len())
+	})
+
+	t.Run("ResetAndCancel", func(t *testing.T) {
+		q := NewExpiryQueue[int, string](4)
+		defer q.Close()
+		q.ScheduleAfter(1, "one", time.Hour)
+		q.ScheduleAfter(2, "two", time.Hour)
+		assert.True(t, q.Pending(1))
+
+		assert.True(t, q.Cancel(2))
+		assert.False(t, q.Cancel(2))
+		assert.False(t, q.Pending(2))
+
+		// Pulling the fire time in wakes up the delivery goroutine
+		assert.True(t, q.Reset(1, time.Now()))
+		select {
+		case item := <-q.C():
+			assert.Equal(t, "one", item)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for delivery")
+		}
+		assert.False(t, q.Reset(1, time.Now()))
+		assert.Equal(t, 0, q.Len())
+	})
+
+	t.Run("ScheduleReplacesByKey", func(t *testing.T) {
+		q := NewExpiryQueue[string, int](1)
+		defer q.Close()
+		q.ScheduleAfter("k", 1, time.Hour)
+		q.ScheduleAfter("k", 2, 0)
+		assert.Equal(t, 2, <-q.C())
+		assert.Equal(t, 0, q.Len())
+	})
+
+	t.Run("CloseClosesChannel", func(t *testing.T) {
+		q := NewExpiryQueue[string, string](0)
+		q.ScheduleAfter("a", "a", time.Hour)
+		q.Close()
+		q.Close()
+		_, ok := <-q.C()
+		assert.False(t, ok)
+	})
+}
+
+func TestQueuePopReleasesItems(t *testing.T) {
+	a, b := new(int), new(int)
+
+	q := NewRWMutexQueue[*int]()
+	q.Push(a, b)
+	q.Pop()
+	assert.Nil(t, q.items[0], "popped slots are zeroed")
+
+	bq := NewBlockingQueue[*int](0)
+	bq.Push(a, b)
+	bq.Pop()
+	assert.Nil(t, bq.items[0], "popped slots are zeroed")
+}
@@ -0,0 +1,285 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"sync"
+)
+
+// WeightedQueue is a thread-safe queue multiplexing several FIFO lanes with configurable weights,
+// protected by a sync.RWMutex. Pop serves non-empty lanes in proportion to their weights using
+// smooth weighted round-robin, so a lane with weight 3 gets three pops for every one of a lane
+// with weight 1, interleaved rather than in bursts. Items within a lane keep their FIFO order.
+//
+// Unlike LanedQueue, where higher lanes always go first, no lane can starve another, which suits
+// multi-tenant work distribution where small tenants must still make progress. Empty lanes are
+// skipped and accrue no credit, so the queue never idles while any lane holds items.
+//
+// Push adds items to lane 0. Lane indexes outside the valid range are clamped to it, and weights
+// are coerced to at least 1.
+//
+// The zero value is not ready to use; construct via NewWeightedQueue.
+type WeightedQueue[T any] struct {
+	mu      sync.RWMutex
+	lanes   []RingQueue[T]
+	weights []int
+	credit  []int // smooth weighted round-robin state per lane
+}
+
+// NewWeightedQueue creates a new WeightedQueue with one lane per weight, or a single lane of
+// weight 1 if no weights are given. Weights are coerced to at least 1.
+func NewWeightedQueue[T any](weights ...int) *WeightedQueue[T] {
+	if len(weights) == 0 {
+		weights = []int{1}
+	}
+	q := &WeightedQueue[T]{
+		lanes:   make([]RingQueue[T], len(weights)),
+		weights: make([]int, len(weights)),
+		credit:  make([]int, len(weights)),
+	}
+	for i, w := range weights {
+		q.weights[i] = max(w, 1)
+	}
+	return q
+}
+
+// Lanes returns the number of lanes of the queue.
+func (q *WeightedQueue[T]) Lanes() int {
+	return len(q.lanes)
+}
+
+// Weight returns the weight of the given lane.
+func (q *WeightedQueue[T]) Weight(lane int) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.weights[q.clampLane(lane)]
+}
+
+// SetWeight changes the weight of the given lane, coerced to at least 1, taking effect from the
+// next Pop.
+func (q *WeightedQueue[T]) SetWeight(lane, weight int) {
+	q.mu.Lock()
+	q.weights[q.clampLane(lane)] = max(weight, 1)
+	q.mu.Unlock()
+}
+
+// Push adds one or more items to the back of lane 0.
+func (q *WeightedQueue[T]) Push(items ...T) {
+	q.PushLane(0, items...)
+}
+
+// PushLane adds one or more items to the back of the given lane.
+func (q *WeightedQueue[T]) PushLane(lane int, items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	q.mu.Lock()
+	q.lanes[q.clampLane(lane)].Push(items...)
+	q.mu.Unlock()
+}
+
+// Pop removes and returns the front item of the lane next in weighted round-robin order.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q *WeightedQueue[T]) Pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	lane := q.pick(q.credit, func(i int) int { return q.lanes[i].Len() })
+	if lane < 0 {
+		return item, false
+	}
+	item, ok = q.lanes[lane].Pop()
+	if q.lanes[lane].Len() == 0 {
+		q.credit[lane] = 0
+	}
+	return item, ok
+}
+
+// PopLane removes and returns the front item of the given lane, ignoring other lanes and without
+// affecting the round-robin order. If the lane is empty it returns ok == false and the zero value
+// of T.
+func (q *WeightedQueue[T]) PopLane(lane int) (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.lanes[q.clampLane(lane)].Pop()
+}
+
+// Peek returns the item Pop would return next without removing it.
+func (q *WeightedQueue[T]) Peek() (item T, ok bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if peeked := q.snapshotLocked(1); len(peeked) > 0 {
+		return peeked[0], true
+	}
+	return item, false
+}
+
+// PeekN returns a copy of up to n items in pop order without removing them.
+func (q *WeightedQueue[T]) PeekN(n int) []T {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.snapshotLocked(max(n, 0))
+}
+
+// PeekAt returns the item at position i in pop order without removing it.
+func (q *WeightedQueue[T]) PeekAt(i int) (item T, ok bool) {
+	if i < 0 {
+		return item, false
+	}
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if peeked := q.snapshotLocked(i + 1); len(peeked) > i {
+		return peeked[i], true
+	}
+	return item, false
+}
+
+// Len returns the current number of items across all lanes.
+func (q *WeightedQueue[T]) Len() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.lenLocked()
+}
+
+// LaneLen returns the current number of items in the given lane.
+func (q *WeightedQueue[T]) LaneLen(lane int) int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.lanes[q.clampLane(lane)].Len()
+}
+
+// Clear removes all items from all lanes and resets the round-robin order.
+func (q *WeightedQueue[T]) Clear() {
+	q.mu.Lock()
+	for i := range q.lanes {
+		q.lanes[i].Clear()
+	}
+	clear(q.credit)
+	q.mu.Unlock()
+}
+
+// Drain atomically removes all items from the queue and returns them in pop order.
+func (q *WeightedQueue[T]) Drain() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	drained := q.snapshotLocked(q.lenLocked())
+	for i := range q.lanes {
+		q.lanes[i].Clear()
+	}
+	clear(q.credit)
+	return drained
+}
+
+// Equals reports whether this queue and the other queue hold the same items in the same order.
+// Requires equalFn to be provided to decide how two items of type T are compared.
+func (q *WeightedQueue[T]) Equals(other Queue[T], equalFn func(a, b T) bool) bool {
+	return queueEquals(q, other, equalFn)
+}
+
+// Slice returns a copy of the queue contents in pop order.
+func (q *WeightedQueue[T]) Slice() []T {
+	return slices.Collect(q.All())
+}
+
+// Range calls f sequentially for each item in pop order. This action does not modify the queue
+// or its items.
+func (q *WeightedQueue[T]) Range(f func(item T) bool) {
+	for item := range q.All() {
+		if !f(item) {
+			break
+		}
+	}
+}
+
+// All returns an iterator over items in the queue in the order Pop would return them, assuming
+// no further pushes.
+func (q *WeightedQueue[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		q.mu.RLock()
+		snapshot := q.snapshotLocked(q.lenLocked())
+		q.mu.RUnlock()
+
+		for _, item := range snapshot {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// String returns the length and the first items of the queue, for logging and debugging.
+func (q *WeightedQueue[T]) String() string {
+	return formatItems(q, q.Len(), q.All())
+}
+
+// GoString returns the Go type, length and first items of the queue, for the %#v verb.
+func (q *WeightedQueue[T]) GoString() string {
+	return goFormatItems(q, q.Len(), q.All())
+}
+
+// AsReadOnly returns a read-only view of the queue, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (q *WeightedQueue[T]) AsReadOnly() ReadOnlyQueue[T] {
+	return readOnlyQueue[T]{q}
+}
+
+// Internal helpers
+
+// clampLane maps lane into the range of valid lane indexes.
+func (q *WeightedQueue[T]) clampLane(lane int) int {
+	return min(max(lane, 0), len(q.lanes)-1)
+}
+
+// pick selects the next lane with smooth weighted round-robin among the lanes with items left,
+// updating credit, and returns -1 if no lane has items left.
+func (q *WeightedQueue[T]) pick(credit []int, left func(lane int) int) int {
+	best, total := -1, 0
+	for i, w := range q.weights {
+		if left(i) == 0 {
+			continue
+		}
+		credit[i] += w
+		total += w
+		if best < 0 || credit[i] > credit[best] {
+			best = i
+		}
+	}
+	if best >= 0 {
+		credit[best] -= total
+	}
+	return best
+}
+
+// lenLocked returns the number of items across all lanes. Callers must hold the lock.
+func (q *WeightedQueue[T]) lenLocked() int {
+	n := 0
+	for i := range q.lanes {
+		n += q.lanes[i].Len()
+	}
+	return n
+}
+
+// snapshotLocked returns up to n items in pop order by simulating Pop on a copy of the
+// round-robin state. Callers must hold at least a read lock.
+func (q *WeightedQueue[T]) snapshotLocked(n int) []T {
+	n = min(n, q.lenLocked())
+	snapshot := make([]T, 0, n)
+	credit := slices.Clone(q.credit)
+	taken := make([]int, len(q.lanes))
+	left := func(i int) int { return q.lanes[i].Len() - taken[i] }
+	for len(snapshot) < n {
+		lane := q.pick(credit, left)
+		item, _ := q.lanes[lane].PeekAt(taken[lane])
+		snapshot = append(snapshot, item)
+		taken[lane]++
+		if left(lane) == 0 {
+			credit[lane] = 0
+		}
+	}
+	return snapshot
+}
+
+// Ensure WeightedQueue implements Queue.
+var _ Queue[any] = (*WeightedQueue[any])(nil)
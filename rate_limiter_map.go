@@ -0,0 +1,182 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tokenBucket is the rate limiting state of a single key.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time // time tokens was last brought up to date
+	dead   bool      // evicted from the map, so callers must fetch the key's bucket again
+}
+
+// RateLimiterMap applies an independent token bucket to every key, such as per-client rate
+// limits: each key may perform up to burst operations at once, refilled at rate per second.
+//
+// Buckets are created on first use in a SyncMap, and evicted once idle for the idle TTL, so the
+// map does not grow with the number of keys ever seen. An evicted bucket would be full again by
+// then, so eviction does not change the limits applied. Eviction runs at most once per idle TTL,
+// piggybacking on calls to Allow and Wait, or explicitly through Evict.
+//
+// The zero value is not ready to use; construct via NewRateLimiterMap.
+type RateLimiterMap[K comparable] struct {
+	rate      float64
+	burst     float64
+	idleTTL   time.Duration
+	buckets   *SyncMap[K, *tokenBucket]
+	lastEvict atomic.Int64 // unix nanoseconds of the last eviction sweep
+}
+
+// NewRateLimiterMap creates a new RateLimiterMap allowing burst operations at once per key,
+// refilled at rate per second. A non-positive rate defaults to 1, and burst is coerced to at
+// least 1. idleTTL is coerced to at least the time a bucket takes to refill completely.
+func NewRateLimiterMap[K comparable](
+	rate float64,
+	burst int,
+	idleTTL time.Duration,
+) *RateLimiterMap[K] {
+	if rate <= 0 {
+		rate = 1
+	}
+	burst = max(burst, 1)
+	refill := time.Duration(float64(burst) / rate * float64(time.Second))
+	m := &RateLimiterMap[K]{
+		rate:    rate,
+		burst:   float64(burst),
+		idleTTL: max(idleTTL, refill),
+		buckets: NewSyncMap[K, *tokenBucket](nil),
+	}
+	m.lastEvict.Store(time.Now().UnixNano())
+	return m
+}
+
+// Allow reports whether an operation for key may happen now, consuming a token if so.
+func (m *RateLimiterMap[K]) Allow(key K) bool {
+	now := time.Now()
+	b := m.lockBucket(key, now)
+	defer b.mu.Unlock()
+	m.refillLocked(b, now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait blocks until an operation for key may happen, consuming a token, or until ctx is done, in
+// which case the token is given back and ctx.Err() is returned. Waiters are served in the order
+// they called Wait.
+func (m *RateLimiterMap[K]) Wait(ctx context.Context, key K) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	now := time.Now()
+	b := m.lockBucket(key, now)
+	m.refillLocked(b, now)
+	b.tokens-- // reserve a token, possibly going into debt
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / m.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		m.refillLocked(b, time.Now())
+		b.tokens = min(b.tokens+1, m.burst)
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Tokens returns the number of tokens currently available for key, which is negative while
+// callers of Wait are queued.
+func (m *RateLimiterMap[K]) Tokens(key K) float64 {
+	b, ok := m.buckets.Get(key)
+	if !ok {
+		return m.burst
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m.refillLocked(b, time.Now())
+	return b.tokens
+}
+
+// Len returns the number of keys with a bucket. It is O(n).
+func (m *RateLimiterMap[K]) Len() int {
+	return m.buckets.Len()
+}
+
+// Evict removes the buckets of keys idle for at least the idle TTL and full again, and returns
+// how many were removed.
+func (m *RateLimiterMap[K]) Evict() int {
+	now := time.Now()
+	m.lastEvict.Store(now.UnixNano())
+	evicted := 0
+	for key, b := range m.buckets.All() {
+		b.mu.Lock()
+		elapsed := now.Sub(b.last)
+		// A bucket still paying off reservations is not idle, even past the TTL
+		idle := !b.dead && elapsed >= m.idleTTL && b.tokens+elapsed.Seconds()*m.rate >= m.burst
+		if idle {
+			// Delete the bucket while holding its lock, so that callers which fetched it
+			// before see it dead and fetch a new one, rather than spending its tokens
+			b.dead = true
+			m.buckets.Delete(key)
+			evicted++
+		}
+		b.mu.Unlock()
+	}
+	return evicted
+}
+
+// Internal helpers
+
+// bucket returns the bucket of key, creating a full one if needed, and runs an eviction sweep if
+// one is due.
+func (m *RateLimiterMap[K]) bucket(key K, now time.Time) *tokenBucket {
+	if last := m.lastEvict.Load(); now.UnixNano()-last >= int64(m.idleTTL) &&
+		m.lastEvict.CompareAndSwap(last, now.UnixNano()) {
+		m.Evict()
+	}
+	if b, ok := m.buckets.Get(key); ok {
+		return b
+	}
+	b, _ := m.buckets.LoadOrStore(key, &tokenBucket{tokens: m.burst, last: now})
+	return b
+}
+
+// lockBucket returns the bucket of key locked, like bucket, fetching it again if it was evicted
+// in the meantime.
+func (m *RateLimiterMap[K]) lockBucket(key K, now time.Time) *tokenBucket {
+	for {
+		b := m.bucket(key, now)
+		b.mu.Lock()
+		if !b.dead {
+			return b
+		}
+		b.mu.Unlock()
+	}
+}
+
+// refillLocked adds the tokens accrued since the bucket was last updated. Callers must hold b.mu.
+func (m *RateLimiterMap[K]) refillLocked(b *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*m.rate, m.burst)
+		b.last = now
+	}
+}
@@ -0,0 +1,94 @@
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterMap(t *testing.T) {
+	m := NewRateLimiterMap[string](1, 3, time.Minute)
+
+	// Each key gets its own burst
+	for range 3 {
+		assert.True(t, m.Allow("a"))
+	}
+	assert.False(t, m.Allow("a"))
+	assert.True(t, m.Allow("b"))
+	assert.Equal(t, 2, m.Len())
+	assert.InDelta(t, 2, m.Tokens("b"), 0.1)
+	assert.InDelta(t, 3, m.Tokens("unseen"), 0)
+
+	// Concurrent callers never exceed the burst
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			if m.Allow("c") {
+				allowed.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), allowed.Load())
+}
+
+func TestRateLimiterMapWait(t *testing.T) {
+	m := NewRateLimiterMap[int](50, 1, 0) // one token per 20ms
+	ctx := context.Background()
+	start := time.Now()
+	for range 3 {
+		assert.NoError(t, m.Wait(ctx, 1))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 35*time.Millisecond)
+
+	// A canceled wait gives its reserved token back
+	assert.NoError(t, m.Wait(ctx, 2))
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, m.Wait(cctx, 2), context.DeadlineExceeded)
+	assert.ErrorIs(t, m.Wait(cctx, 2), context.DeadlineExceeded)
+	assert.Greater(t, m.Tokens(2), -0.5)
+}
+
+func TestRateLimiterMapEvict(t *testing.T) {
+	m := NewRateLimiterMap[int](1000, 1, 0) // idle TTL coerced to the 1ms refill time
+	for i := range 10 {
+		m.Allow(i)
+	}
+	assert.Equal(t, 10, m.Len())
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, 10, m.Evict())
+	assert.Equal(t, 0, m.Len())
+
+	// Sweeps also happen as a side effect of use
+	m.Allow(1)
+	m.Allow(2)
+	time.Sleep(5 * time.Millisecond)
+	m.Allow(3)
+	assert.Equal(t, 1, m.Len())
+}
+
+func TestRateLimiterMapEvictRace(t *testing.T) {
+	m := NewRateLimiterMap[int](1000, 1, 0)
+	m.Allow(1)
+	time.Sleep(5 * time.Millisecond)
+
+	// A caller that fetched the bucket before it was evicted must not spend its tokens, or the key
+	// would get a second, full bucket on its next call
+	stale, ok := m.buckets.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, 1, m.Evict())
+	stale.mu.Lock()
+	assert.True(t, stale.dead)
+	stale.mu.Unlock()
+
+	assert.True(t, m.Allow(1))
+	fresh := m.lockBucket(1, time.Now())
+	fresh.mu.Unlock()
+	assert.NotSame(t, stale, fresh)
+}
@@ -0,0 +1,90 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "iter"
+
+// ReadOnlyMap is the read-only subset of Map. It lets an API expose a shared map to callers that
+// must not modify it; views returned by AsReadOnly cannot be converted back to the map.
+type ReadOnlyMap[K comparable, V any] interface {
+	// Get retrieves the value for the given key.
+	Get(key K) (value V, loaded bool)
+	// Len returns the number of items in the map.
+	Len() int
+	// GetAll returns all key-value pairs in the map.
+	GetAll() map[K]V
+	// GetMany retrieves select key-value pairs.
+	GetMany(keys []K) map[K]V
+	// Equals reports whether the logical content of this map and the other map is the same.
+	Equals(other Map[K, V], equalFn func(a, b V) bool) bool
+	// Range calls f sequentially for each key and value present in the map.
+	// If f returns false, range stops the iteration.
+	Range(f func(key K, value V) bool)
+	// All returns an iterator over key-value pairs in the map.
+	All() iter.Seq2[K, V]
+	// Keys returns an iterator over keys in the map.
+	Keys() iter.Seq[K]
+	// Values returns an iterator over values in the map.
+	Values() iter.Seq[V]
+}
+
+// ReadOnlySet is the read-only subset of Set. Views returned by AsReadOnly cannot be converted
+// back to the set.
+type ReadOnlySet[T comparable] interface {
+	// Has returns true if the item is in the set, otherwise false.
+	Has(item T) bool
+	// Len returns the number of items in the set.
+	Len() int
+	// Slice returns a copy of the set as a slice.
+	Slice() []T
+	// Range calls f sequentially for each item present in the set.
+	// If f returns false, range stops the iteration.
+	Range(f func(item T) bool)
+	// All returns an iterator over all items in the set.
+	All() iter.Seq[T]
+}
+
+// ReadOnlySlice is the read-only subset of Slice. Views returned by AsReadOnly cannot be
+// converted back to the slice.
+type ReadOnlySlice[T any] interface {
+	// Len returns the current number of items in the buffer.
+	Len() int
+	// Peek returns a copy of the current buffer contents.
+	Peek() []T
+	// All returns an iterator over all items in the slice in order.
+	All() iter.Seq[T]
+}
+
+// ReadOnlyQueue is the read-only subset of Queue. Views returned by AsReadOnly cannot be
+// converted back to the queue.
+type ReadOnlyQueue[T any] interface {
+	// Peek returns the item at the front of the queue without removing it.
+	Peek() (item T, ok bool)
+	// PeekN returns a copy of up to n items from the front of the queue without removing them.
+	PeekN(n int) []T
+	// PeekAt returns the item at position i from the front of the queue without removing it.
+	PeekAt(i int) (item T, ok bool)
+	// Len returns the current number of items stored in the queue.
+	Len() int
+	// Equals reports whether the logical content of this queue and the other queue is the same.
+	Equals(other Queue[T], equalFn func(a, b T) bool) bool
+	// Slice returns a copy of the current queue contents from front to back.
+	Slice() []T
+	// Range calls f sequentially for each item present in the queue from front to back.
+	// If f returns false, Range stops the iteration early.
+	Range(f func(item T) bool)
+	// All returns an iterator over items in the queue from front to back.
+	All() iter.Seq[T]
+}
+
+// Internal helpers
+
+// The views embed the read-only interface in a struct, so only its methods are promoted and the
+// underlying collection cannot be recovered with a type assertion.
+
+type readOnlyMap[K comparable, V any] struct{ ReadOnlyMap[K, V] }
+
+type readOnlySet[T comparable] struct{ ReadOnlySet[T] }
+
+type readOnlySlice[T any] struct{ ReadOnlySlice[T] }
+
+type readOnlyQueue[T any] struct{ ReadOnlyQueue[T] }
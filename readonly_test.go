@@ -0,0 +1,53 @@
+package threadsafe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyViews(t *testing.T) {
+	t.Run("Map", func(t *testing.T) {
+		m := NewRWMutexMap[string, int](nil)
+		view := m.AsReadOnly()
+		m.Set("a", 1)
+		value, ok := view.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		assert.Equal(t, map[string]int{"a": 1}, view.GetAll())
+
+		_, mutable := view.(Map[string, int])
+		assert.False(t, mutable)
+		_, mutable = view.(*RWMutexMap[string, int])
+		assert.False(t, mutable)
+	})
+	t.Run("Set", func(t *testing.T) {
+		s := NewSyncMapSet[int]()
+		view := s.AsReadOnly()
+		s.Add(1)
+		assert.True(t, view.Has(1))
+		assert.Equal(t, 1, view.Len())
+		_, mutable := view.(Set[int])
+		assert.False(t, mutable)
+	})
+	t.Run("Slice", func(t *testing.T) {
+		s := NewMutexSlice[int](0)
+		view := s.AsReadOnly()
+		s.Append(1, 2)
+		assert.Equal(t, []int{1, 2}, view.Peek())
+		_, mutable := view.(Slice[int])
+		assert.False(t, mutable)
+	})
+	t.Run("Queue", func(t *testing.T) {
+		q := NewRWMutexQueue[int]()
+		view := q.AsReadOnly()
+		q.Push(1, 2)
+		item, ok := view.Peek()
+		assert.True(t, ok)
+		assert.Equal(t, 1, item)
+		assert.Equal(t, []int{1, 2}, view.Slice())
+		assert.True(t, view.Equals(q, func(a, b int) bool { return a == b }))
+		_, mutable := view.(Queue[int])
+		assert.False(t, mutable)
+	})
+}
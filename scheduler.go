@@ -0,0 +1,164 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScheduledTask is a handle to a function scheduled on a Scheduler.
+type ScheduledTask struct {
+	id       uint64
+	fn       func(ctx context.Context)
+	interval time.Duration // zero for one-shot tasks
+	next     time.Time     // fire time, owned by the dispatcher once scheduled
+	canceled atomic.Bool
+	s        *Scheduler
+}
+
+// Cancel prevents any future run of the task. It returns false if the task was already canceled,
+// or if it was a one-shot task that has already been dispatched. A run already in progress is
+// not interrupted.
+func (t *ScheduledTask) Cancel() bool {
+	if t.canceled.Swap(true) {
+		return false
+	}
+	return t.s.queue.Cancel(t.id) || t.interval > 0
+}
+
+// Scheduler runs functions at given times or at fixed intervals. All pending tasks are kept in a
+// single ExpiryQueue ordered by fire time, so one timer goroutine drives any number of tasks.
+//
+// Each run happens in its own goroutine, so a slow task does not delay others; runs of a periodic
+// task may overlap if it takes longer than its interval. A panic in a task is recovered and
+// reported to OnPanic if set.
+//
+// The zero value is not ready to use; construct via NewScheduler.
+type Scheduler struct {
+	// OnPanic, if set, is called with the recovered value when a task panics. It must be set
+	// before Start.
+	OnPanic func(recovered any)
+
+	queue *ExpiryQueue[uint64, *ScheduledTask]
+	ids   atomic.Uint64
+
+	mu      sync.Mutex
+	started bool
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a new Scheduler. Tasks may be scheduled right away, but only run once
+// Start is called.
+func NewScheduler() *Scheduler {
+	return &Scheduler{queue: NewExpiryQueue[uint64, *ScheduledTask](0)}
+}
+
+// Schedule runs fn once at the given time, or as soon as possible if it is in the past.
+func (s *Scheduler) Schedule(at time.Time, fn func(ctx context.Context)) *ScheduledTask {
+	return s.add(at, 0, fn)
+}
+
+// ScheduleAfter runs fn once after d has elapsed.
+func (s *Scheduler) ScheduleAfter(d time.Duration, fn func(ctx context.Context)) *ScheduledTask {
+	return s.add(time.Now().Add(d), 0, fn)
+}
+
+// ScheduleEvery runs fn every interval, starting one interval from now, until the task is
+// canceled. Runs missed while the scheduler lagged behind are skipped, as with time.Ticker. It
+// panics if interval is not positive.
+func (s *Scheduler) ScheduleEvery(
+	interval time.Duration,
+	fn func(ctx context.Context),
+) *ScheduledTask {
+	if interval <= 0 {
+		panic("threadsafe: non-positive interval for Scheduler.ScheduleEvery")
+	}
+	return s.add(time.Now().Add(interval), interval, fn)
+}
+
+// Len returns the number of tasks waiting for their next run.
+func (s *Scheduler) Len() int {
+	return s.queue.Len()
+}
+
+// Start launches the dispatcher. Tasks receive ctx, and no further runs are dispatched once ctx
+// is done. Calling Start more than once has no effect.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	s.wg.Go(func() { s.dispatch(ctx) })
+}
+
+// Shutdown gracefully stops the scheduler: pending tasks are discarded and Shutdown waits for the
+// runs in progress to complete, or for ctx to be done, in which case ctx.Err() is returned.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.queue.Close()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Internal helpers
+
+func (s *Scheduler) add(
+	at time.Time,
+	interval time.Duration,
+	fn func(ctx context.Context),
+) *ScheduledTask {
+	t := &ScheduledTask{id: s.ids.Add(1), fn: fn, interval: interval, next: at, s: s}
+	s.queue.Schedule(t.id, t, at)
+	return t
+}
+
+// dispatch runs due tasks until the queue is closed or ctx is done.
+func (s *Scheduler) dispatch(ctx context.Context) {
+	for {
+		var t *ScheduledTask
+		var ok bool
+		select {
+		case t, ok = <-s.queue.C():
+		case <-ctx.Done():
+			return
+		}
+		if !ok {
+			return
+		}
+		if t.canceled.Load() {
+			continue
+		}
+		if t.interval > 0 {
+			now := time.Now()
+			t.next = t.next.Add(t.interval)
+			if !t.next.After(now) {
+				t.next = now.Add(t.interval)
+			}
+			s.queue.Schedule(t.id, t, t.next)
+		}
+		s.wg.Go(func() { s.run(ctx, t) })
+	}
+}
+
+// run runs a task, recovering from panics.
+func (s *Scheduler) run(ctx context.Context, t *ScheduledTask) {
+	defer func() {
+		if r := recover(); r != nil && s.OnPanic != nil {
+			s.OnPanic(r)
+		}
+	}()
+	t.fn(ctx)
+}
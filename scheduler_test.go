@@ -0,0 +1,109 @@
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerRunsTasksInOrder(t *testing.T) {
+	s := NewScheduler()
+	var mu sync.Mutex
+	var order []string
+	done := make(chan struct{})
+	record := func(name string) func(context.Context) {
+		return func(context.Context) {
+			mu.Lock()
+			order = append(order, name)
+			if len(order) == 3 {
+				close(done)
+			}
+			mu.Unlock()
+		}
+	}
+	now := time.Now()
+	s.Schedule(now.Add(30*time.Millisecond), record("c"))
+	s.Schedule(now.Add(10*time.Millisecond), record("a"))
+	s.ScheduleAfter(20*time.Millisecond, record("b"))
+	s.Start(context.Background())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for tasks")
+	}
+	mu.Lock()
+	assert.Equal(t, []string{"a", "b", "c"}, order)
+	mu.Unlock()
+	assert.Equal(t, 0, s.Len())
+	assert.NoError(t, s.Shutdown(context.Background()))
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := NewScheduler()
+	s.Start(context.Background())
+	defer func() { assert.NoError(t, s.Shutdown(context.Background())) }()
+
+	var ran atomic.Bool
+	task := s.ScheduleAfter(20*time.Millisecond, func(context.Context) { ran.Store(true) })
+	assert.True(t, task.Cancel())
+	assert.False(t, task.Cancel())
+	time.Sleep(40 * time.Millisecond)
+	assert.False(t, ran.Load())
+
+	fired := make(chan struct{})
+	oneShot := s.ScheduleAfter(0, func(context.Context) { close(fired) })
+	<-fired
+	assert.False(t, oneShot.Cancel())
+}
+
+func TestSchedulerScheduleEvery(t *testing.T) {
+	s := NewScheduler()
+	s.Start(context.Background())
+
+	var runs atomic.Int32
+	task := s.ScheduleEvery(5*time.Millisecond, func(context.Context) { runs.Add(1) })
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	assert.True(t, task.Cancel())
+
+	time.Sleep(10 * time.Millisecond)
+	after := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, after, runs.Load())
+	assert.NoError(t, s.Shutdown(context.Background()))
+
+	assert.Panics(t, func() { s.ScheduleEvery(0, func(context.Context) {}) })
+}
+
+func TestSchedulerPanicAndShutdown(t *testing.T) {
+	s := NewScheduler()
+	recovered := make(chan any, 1)
+	s.OnPanic = func(r any) { recovered <- r }
+	s.Start(context.Background())
+
+	s.ScheduleAfter(0, func(context.Context) { panic("boom") })
+	select {
+	case r := <-recovered:
+		assert.Equal(t, "boom", r)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for panic")
+	}
+
+	// Shutdown waits for runs in progress, bounded by its ctx
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s.ScheduleAfter(0, func(context.Context) {
+		close(started)
+		<-release
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+	close(release)
+	assert.NoError(t, s.Shutdown(context.Background()))
+}
@@ -0,0 +1,102 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "iter"
+
+// The helpers below compose the iterators returned by All, Keys and Values, and feed them back
+// into the collections, without building intermediate slices. They are lazy: nothing is read
+// from the source until the returned iterator is ranged over. As with the collections' own
+// iterators, the source is snapshotted when iteration starts, so the callbacks may safely call
+// back into the source collection.
+
+// FilterSeq returns an iterator over the items of seq for which keep returns true.
+func FilterSeq[T any](seq iter.Seq[T], keep func(item T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for item := range seq {
+			if keep(item) && !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// FilterSeq2 returns an iterator over the pairs of seq for which keep returns true, e.g. the
+// entries of a map's All iterator.
+func FilterSeq2[K, V any](seq iter.Seq2[K, V], keep func(key K, value V) bool) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range seq {
+			if keep(k, v) && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// MapSeq returns an iterator over the results of calling f on each item of seq.
+func MapSeq[T, U any](seq iter.Seq[T], f func(item T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for item := range seq {
+			if !yield(f(item)) {
+				return
+			}
+		}
+	}
+}
+
+// Take returns an iterator over at most the first n items of seq. It stops reading seq once n
+// items were yielded; if n <= 0, it yields nothing.
+func Take[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		taken := 0
+		for item := range seq {
+			taken++
+			if !yield(item) || taken == n {
+				return
+			}
+		}
+	}
+}
+
+// Zip returns an iterator over pairs of items taken in step from a and b. It stops when either
+// sequence ends.
+func Zip[A, B any](a iter.Seq[A], b iter.Seq[B]) iter.Seq2[A, B] {
+	return func(yield func(A, B) bool) {
+		next, stop := iter.Pull(b)
+		defer stop()
+		for itemA := range a {
+			itemB, ok := next()
+			if !ok || !yield(itemA, itemB) {
+				return
+			}
+		}
+	}
+}
+
+// CollectIntoSet adds every item of seq to set, and returns the number of items that were not
+// already present.
+func CollectIntoSet[T comparable](set Set[T], seq iter.Seq[T]) (added int) {
+	for item := range seq {
+		if set.Add(item) {
+			added++
+		}
+	}
+	return added
+}
+
+// CollectIntoMap sets every key-value pair of seq in m, later pairs overwriting earlier ones
+// with the same key.
+func CollectIntoMap[K comparable, V any](m Map[K, V], seq iter.Seq2[K, V]) {
+	for k, v := range seq {
+		m.Set(k, v)
+	}
+}
+
+// CollectIntoSlice appends every item of seq to s, in order.
+func CollectIntoSlice[T any](s Slice[T], seq iter.Seq[T]) {
+	for item := range seq {
+		s.Append(item)
+	}
+}
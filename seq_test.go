@@ -0,0 +1,52 @@
+package threadsafe
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeqHelpers(t *testing.T) {
+	s := NewRWMutexSlice[int](0)
+	s.Append(1, 2, 3, 4, 5, 6)
+
+	even := FilterSeq(s.All(), func(x int) bool { return x%2 == 0 })
+	assert.Equal(t, []int{2, 4, 6}, slices.Collect(even))
+	assert.Equal(t, []string{"2", "4"}, slices.Collect(Take(MapSeq(even, strconv.Itoa), 2)))
+	assert.Empty(t, slices.Collect(Take(s.All(), 0)))
+	assert.Equal(t, []int{1}, slices.Collect(Take(s.All(), 1)))
+
+	names := slices.Values([]string{"a", "b", "c"})
+	zipped := map[int]string{}
+	for x, name := range Zip(s.All(), names) {
+		zipped[x] = name
+	}
+	assert.Equal(t, map[int]string{1: "a", 2: "b", 3: "c"}, zipped)
+	for range Zip(s.All(), names) {
+		break // stopping early releases the pulled iterator
+	}
+}
+
+func TestCollectInto(t *testing.T) {
+	src := NewRWMutexMap[string, int](nil)
+	src.SetMany(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	dst := NewSyncMap[string, int](nil)
+	CollectIntoMap(dst, FilterSeq2(src.All(), func(_ string, v int) bool { return v > 1 }))
+	assert.Equal(t, map[string]int{"b": 2, "c": 3}, dst.GetAll())
+
+	set := NewRWMutexSet[int]()
+	set.Add(2)
+	assert.Equal(t, 2, CollectIntoSet(set, src.Values()))
+	assert.ElementsMatch(t, []int{1, 2, 3}, set.Slice())
+
+	slice := NewMutexSlice[int](0)
+	CollectIntoSlice(slice, MapSeq(slices.Values([]int{1, 2}), func(x int) int { return x * 10 }))
+	assert.Equal(t, []int{10, 20}, slice.Peek())
+
+	// Collecting a collection into itself is safe, as its iterator reads a snapshot
+	CollectIntoSlice(slice, slice.All())
+	assert.Equal(t, []int{10, 20, 10, 20}, slice.Peek())
+}
@@ -2,22 +2,40 @@
 package threadsafe
 
 import (
+	"io"
 	"iter"
 	"sync"
 )
 
 // RWMutexSet is a thread-safe implementation of Set using sync.RWMutex.
+//
+// The zero value is ready to use.
 type RWMutexSet[T comparable] struct {
-	mu    sync.RWMutex
+	mu    observedRWMutex
 	items map[T]struct{}
 	size  int // Separate size counter for O(1) Len
 }
 
 // Add stores an item in the set.
 func (s *RWMutexSet[T]) Add(item T) (added bool) {
+	defer s.mu.observe("Add")()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.addLocked(item)
+}
+
+// Delete removes an item from the set.
+func (s *RWMutexSet[T]) Delete(item T) (removed bool) {
+	defer s.mu.observe("Delete")()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deleteLocked(item)
+}
+
+// addLocked stores item in the set. Callers must hold the write lock.
+func (s *RWMutexSet[T]) addLocked(item T) (added bool) {
 	if s.items == nil {
 		s.items = make(map[T]struct{})
 	}
@@ -30,15 +48,8 @@ func (s *RWMutexSet[T]) Add(item T) (added bool) {
 	return false
 }
 
-// Delete removes an item from the set.
-func (s *RWMutexSet[T]) Delete(item T) (removed bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.items == nil {
-		return false
-	}
-
+// deleteLocked removes item from the set. Callers must hold the write lock.
+func (s *RWMutexSet[T]) deleteLocked(item T) (removed bool) {
 	if _, exists := s.items[item]; exists {
 		delete(s.items, item)
 		s.size--
@@ -49,6 +60,7 @@ func (s *RWMutexSet[T]) Delete(item T) (removed bool) {
 
 // Has returns true if the item is in the set, otherwise false.
 func (s *RWMutexSet[T]) Has(item T) bool {
+	defer s.mu.observe("Has")()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -58,14 +70,33 @@ func (s *RWMutexSet[T]) Has(item T) bool {
 
 // Len returns the number of items in the set.
 func (s *RWMutexSet[T]) Len() int {
+	defer s.mu.observe("Len")()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	return s.size
 }
 
+// Instrument makes the set report its operations and lock waits to ins, or stop reporting if ins
+// is nil. It must be called before the set is shared between goroutines.
+func (s *RWMutexSet[T]) Instrument(ins Instrumentation) {
+	s.mu.ins = ins
+}
+
+// InTx returns the view of the set inside tx, which must be a transaction run by RunTx with the
+// set among its participants.
+func (s *RWMutexSet[T]) InTx(tx *Tx) SetTx[T] {
+	tx.checkLocks(&s.mu)
+	return SetTx[T]{tx: tx, set: s}
+}
+
+func (s *RWMutexSet[T]) txLocker() sync.Locker {
+	return &s.mu
+}
+
 // Clear removes all items from the set.
 func (s *RWMutexSet[T]) Clear() {
+	defer s.mu.observe("Clear")()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -75,6 +106,7 @@ func (s *RWMutexSet[T]) Clear() {
 
 // Slice returns a copy of the set as a slice.
 func (s *RWMutexSet[T]) Slice() []T {
+	defer s.mu.observe("Slice")()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -117,6 +149,74 @@ func (s *RWMutexSet[T]) All() iter.Seq[T] {
 	}
 }
 
+// String returns the length and the first items of the set, for logging and debugging.
+func (s *RWMutexSet[T]) String() string {
+	return formatItems(s, s.Len(), s.All())
+}
+
+// GoString returns the Go type, length and first items of the set, for the %#v verb.
+func (s *RWMutexSet[T]) GoString() string {
+	return goFormatItems(s, s.Len(), s.All())
+}
+
+// AsReadOnly returns a read-only view of the set, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (s *RWMutexSet[T]) AsReadOnly() ReadOnlySet[T] {
+	return readOnlySet[T]{s}
+}
+
+// Clone returns a new set holding a copy of each item made by copyFn, under a single lock so the
+// clone is a consistent snapshot. copyFn must not call back into the set; if nil, items are
+// copied shallowly. Items that copyFn maps to equal values are merged.
+func (s *RWMutexSet[T]) Clone(copyFn func(T) T) *RWMutexSet[T] {
+	defer s.mu.observe("Clone")()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make(map[T]struct{}, len(s.items))
+	for item := range s.items {
+		if copyFn != nil {
+			item = copyFn(item)
+		}
+		items[item] = struct{}{}
+	}
+	return &RWMutexSet[T]{items: items, size: len(items)}
+}
+
+// EstimateBytes returns an approximation of the memory held by the set, for capacity planning
+// and memory accounting: the set itself and its items, plus sizeFn(item) per item if sizeFn is
+// not nil, e.g. to count the data of string items. sizeFn must not call back into the set.
+func (s *RWMutexSet[T]) EstimateBytes(sizeFn func(item T) int) int {
+	defer s.mu.observe("EstimateBytes")()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sizeOf[RWMutexSet[T]]() + mapBytes(s.items, keySizer(sizeFn))
+}
+
+// SaveTo writes a snapshot of the set items to w using codec.
+func (s *RWMutexSet[T]) SaveTo(w io.Writer, codec Codec[T]) error {
+	return writeSnapshot(w, codec, s.Slice())
+}
+
+// LoadFrom replaces the set items with a snapshot written by SaveTo. On error the set is left
+// unchanged.
+func (s *RWMutexSet[T]) LoadFrom(r io.Reader, codec Codec[T]) error {
+	defer s.mu.observe("LoadFrom")()
+	loaded, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	items := make(map[T]struct{}, len(loaded))
+	for _, item := range loaded {
+		items[item] = struct{}{}
+	}
+	s.mu.Lock()
+	s.items = items
+	s.size = len(items)
+	s.mu.Unlock()
+	return nil
+}
+
 // NewRWMutexSet creates a new instance of RWMutexSet.
 func NewRWMutexSet[T comparable]() *RWMutexSet[T] {
 	return &RWMutexSet[T]{
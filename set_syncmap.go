@@ -2,6 +2,7 @@
 package threadsafe
 
 import (
+	"io"
 	"iter"
 	"slices"
 	"sync"
@@ -15,6 +16,8 @@ import (
 // run in O(n) time and may allocate, just like their RWMutex counterpart.
 // If you need high-frequency Len or Slice under heavy write load, prefer the
 // RWMutex variant which can maintain a separate atomic counter.
+//
+// The zero value is ready to use.
 type SyncMapSet[T comparable] struct {
 	items sync.Map // key T -> struct{}
 }
@@ -79,3 +82,62 @@ func (s *SyncMapSet[T]) All() iter.Seq[T] {
 		})
 	}
 }
+
+// String returns the length and the first items of the set, for logging and debugging.
+func (s *SyncMapSet[T]) String() string {
+	return formatItems(s, s.Len(), s.All())
+}
+
+// GoString returns the Go type, length and first items of the set, for the %#v verb.
+func (s *SyncMapSet[T]) GoString() string {
+	return goFormatItems(s, s.Len(), s.All())
+}
+
+// AsReadOnly returns a read-only view of the set, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (s *SyncMapSet[T]) AsReadOnly() ReadOnlySet[T] {
+	return readOnlySet[T]{s}
+}
+
+// Clone returns a new set holding a copy of each item made by copyFn; if nil, items are copied
+// shallowly. Items that copyFn maps to equal values are merged. As with Range, the clone does not
+// necessarily correspond to any consistent state of the set under concurrent modification.
+func (s *SyncMapSet[T]) Clone(copyFn func(T) T) *SyncMapSet[T] {
+	clone := NewSyncMapSet[T]()
+	for item := range s.All() {
+		if copyFn != nil {
+			item = copyFn(item)
+		}
+		clone.Add(item)
+	}
+	return clone
+}
+
+// EstimateBytes returns an approximation of the memory held by the set, for capacity planning
+// and memory accounting: the set itself and its items, plus sizeFn(item) per item if sizeFn is
+// not nil, e.g. to count the data of string items. Like Range, it does not
+// observe a consistent snapshot under concurrent writes.
+func (s *SyncMapSet[T]) EstimateBytes(sizeFn func(item T) int) int {
+	return sizeOf[SyncMapSet[T]]() + syncMapBytes(&s.items, keySizer(sizeFn))
+}
+
+// SaveTo writes a snapshot of the set items to w using codec. As with Range, the snapshot does not
+// necessarily correspond to any consistent state of the set under concurrent modification.
+func (s *SyncMapSet[T]) SaveTo(w io.Writer, codec Codec[T]) error {
+	return writeSnapshot(w, codec, s.Slice())
+}
+
+// LoadFrom replaces the set items with a snapshot written by SaveTo. On error the set is left
+// unchanged. The replacement is not atomic: concurrent readers may observe a partially loaded
+// set.
+func (s *SyncMapSet[T]) LoadFrom(r io.Reader, codec Codec[T]) error {
+	items, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	s.items.Clear()
+	for _, item := range items {
+		s.items.Store(item, struct{}{})
+	}
+	return nil
+}
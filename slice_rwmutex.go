@@ -2,19 +2,23 @@
 package threadsafe
 
 import (
+	"io"
 	"iter"
 	"sync"
 )
 
 // RWMutexSlice is a thread-safe buffer for any type T, featuring concurrent appends and atomic
 // flushes.
+//
+// The zero value is ready to use.
 type RWMutexSlice[T any] struct {
-	mu   sync.RWMutex
+	mu   observedRWMutex
 	data []T
 }
 
 // Append appends items to the slice.
 func (s *RWMutexSlice[T]) Append(item ...T) {
+	defer s.mu.observe("Append")()
 	s.mu.Lock()
 	s.data = append(s.data, item...)
 	s.mu.Unlock()
@@ -22,14 +26,33 @@ func (s *RWMutexSlice[T]) Append(item ...T) {
 
 // Len returns the current number of items in the slice.
 func (s *RWMutexSlice[T]) Len() int {
+	defer s.mu.observe("Len")()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return len(s.data)
 }
 
+// Instrument makes the slice report its operations and lock waits to ins, or stop reporting if ins
+// is nil. It must be called before the slice is shared between goroutines.
+func (s *RWMutexSlice[T]) Instrument(ins Instrumentation) {
+	s.mu.ins = ins
+}
+
+// InTx returns the view of the slice inside tx, which must be a transaction run by RunTx with the
+// slice among its participants.
+func (s *RWMutexSlice[T]) InTx(tx *Tx) SliceTx[T] {
+	tx.checkLocks(&s.mu)
+	return SliceTx[T]{tx: tx, data: &s.data}
+}
+
+func (s *RWMutexSlice[T]) txLocker() sync.Locker {
+	return &s.mu
+}
+
 // Peek returns a copy of the current slice contents without clearing.
 // The returned slice is safe to read but may be stale if new items are added concurrently.
 func (s *RWMutexSlice[T]) Peek() []T {
+	defer s.mu.observe("Peek")()
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	copied := make([]T, len(s.data))
@@ -54,9 +77,47 @@ func (s *RWMutexSlice[T]) All() iter.Seq[T] {
 	}
 }
 
+// String returns the length and the first items of the slice, for logging and debugging.
+func (s *RWMutexSlice[T]) String() string {
+	return formatItems(s, s.Len(), s.All())
+}
+
+// GoString returns the Go type, length and first items of the slice, for the %#v verb.
+func (s *RWMutexSlice[T]) GoString() string {
+	return goFormatItems(s, s.Len(), s.All())
+}
+
+// AsReadOnly returns a read-only view of the slice, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (s *RWMutexSlice[T]) AsReadOnly() ReadOnlySlice[T] {
+	return readOnlySlice[T]{s}
+}
+
+// Clone returns a new slice holding a copy of each item made by copyFn, under a single lock so
+// the clone is a consistent snapshot. copyFn must not call back into the slice; if nil, items are
+// copied shallowly.
+func (s *RWMutexSlice[T]) Clone(copyFn func(T) T) *RWMutexSlice[T] {
+	defer s.mu.observe("Clone")()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &RWMutexSlice[T]{data: cloneSlice(s.data, copyFn)}
+}
+
+// EstimateBytes returns an approximation of the memory held by the slice, for capacity planning
+// and memory accounting: the slice itself and its backing array, spare capacity included, plus
+// sizeFn(item) per item if sizeFn is not nil, e.g. to count string data. sizeFn must not call
+// back into the slice.
+func (s *RWMutexSlice[T]) EstimateBytes(sizeFn func(item T) int) int {
+	defer s.mu.observe("EstimateBytes")()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sizeOf[RWMutexSlice[T]]() + sliceBytes(s.data, cap(s.data), sizeFn)
+}
+
 // Flush atomically retrieves all items and clears the slice.
 // Returns a slice with the previous contents.
 func (s *RWMutexSlice[T]) Flush() []T {
+	defer s.mu.observe("Flush")()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	flushed := s.data
@@ -71,6 +132,32 @@ func RWMutexSliceFromSlice[T any](slice []T) *RWMutexSlice[T] {
 	return newSlice
 }
 
+// WrapSlice creates a new RWMutexSlice backed by slice itself rather than a copy, so that existing
+// state can be migrated without copying it. Ownership of slice is transferred: the caller must not
+// read or write it directly afterwards, as appends may write to its spare capacity.
+func WrapSlice[T any](slice []T) *RWMutexSlice[T] {
+	return &RWMutexSlice[T]{data: slice}
+}
+
+// SaveTo writes a snapshot of the slice items to w using codec.
+func (s *RWMutexSlice[T]) SaveTo(w io.Writer, codec Codec[T]) error {
+	return writeSnapshot(w, codec, s.Peek())
+}
+
+// LoadFrom replaces the slice items with a snapshot written by SaveTo. On error the slice is left
+// unchanged.
+func (s *RWMutexSlice[T]) LoadFrom(r io.Reader, codec Codec[T]) error {
+	defer s.mu.observe("LoadFrom")()
+	items, err := readSnapshot(r, codec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.data = items
+	s.mu.Unlock()
+	return nil
+}
+
 // NewRWMutexSlice creates a new RWMutexSlice with an optional initial capacity.
 func NewRWMutexSlice[T any](initialCap int) *RWMutexSlice[T] {
 	return &RWMutexSlice[T]{
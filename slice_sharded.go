@@ -3,6 +3,9 @@ package threadsafe
 
 import (
 	"iter"
+	"math/bits"
+	"runtime"
+	"sync"
 	"sync/atomic"
 )
 
@@ -18,34 +21,50 @@ import (
 // per-shard, which is usually acceptable for buffer/queue-like workloads where ordering
 // across goroutines is not critical.
 //
-// All methods are wait-free with bounded work and require no global locks.
+// All methods do bounded work and only share a read lock on the set of shards, which is
+// write-locked by Resharding alone.
 //
 // The zero value defaults to a single shard for compatibility, though NewShardedSlice should
 // be used for performance-sensitive use cases to configure the optimal shard count.
 type ShardedSlice[T any] struct {
-	shards  []Slice[T]
-	counter uint64 // used for round-robin shard selection in Append
+	mu         sync.RWMutex // guards the shards slice itself, not the shards' contents
+	shards     []Slice[T]
+	initialCap int    // capacity of shards created by Resharding
+	counter    uint64 // used for round-robin shard selection in Append
 }
 
 // Append adds the items to one of the shards, selected in a round-robin
 // manner using an atomic counter.  This ensures good key distribution without
 // requiring hashing the items themselves.
 func (s *ShardedSlice[T]) Append(item ...T) {
-	s.ensureInitialized()
+	s.rlockShards()
+	defer s.mu.RUnlock()
 	idx := int(atomic.AddUint64(&s.counter, 1)-1) % len(s.shards)
 	s.shards[idx].Append(item...)
 }
 
-// ensureInitialized lazily initializes the shards if needed for zero-value usage.
-func (s *ShardedSlice[T]) ensureInitialized() {
+// rlockShards read-locks the set of shards, lazily initializing it first if needed for
+// zero-value usage.
+func (s *ShardedSlice[T]) rlockShards() {
+	s.mu.RLock()
+	if s.shards != nil {
+		return
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
 	if s.shards == nil {
 		// Default to single shard for zero-value usage
 		s.shards = []Slice[T]{NewRWMutexSlice[T](0)}
 	}
+	s.mu.Unlock()
+	s.mu.RLock()
 }
 
 // Len returns the combined length of all shards.
 func (s *ShardedSlice[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	total := 0
 	for _, sh := range s.shards {
 		total += sh.Len()
@@ -55,6 +74,8 @@ func (s *ShardedSlice[T]) Len() int {
 
 // Peek returns a copy of the current contents of all shards without clearing them.
 func (s *ShardedSlice[T]) Peek() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	total := 0
 	for _, sh := range s.shards {
 		total += sh.Len()
@@ -70,10 +91,7 @@ func (s *ShardedSlice[T]) Peek() []T {
 // The iteration order is not guaranteed to be consistent.
 func (s *ShardedSlice[T]) All() iter.Seq[T] {
 	return func(yield func(T) bool) {
-		items := make([]T, 0, s.Len())
-		for _, sh := range s.shards {
-			items = append(items, sh.Peek()...)
-		}
+		items := s.Peek()
 
 		for _, item := range items {
 			if !yield(item) {
@@ -83,8 +101,27 @@ func (s *ShardedSlice[T]) All() iter.Seq[T] {
 	}
 }
 
+// String returns the length and the first items of the slice, for logging and debugging.
+func (s *ShardedSlice[T]) String() string {
+	return formatItems(s, s.Len(), s.All())
+}
+
+// GoString returns the Go type, length and first items of the slice, for the %#v verb.
+func (s *ShardedSlice[T]) GoString() string {
+	return goFormatItems(s, s.Len(), s.All())
+}
+
+// AsReadOnly returns a read-only view of the slice, reflecting its current and future
+// contents, for exposing it to code that must not modify it.
+func (s *ShardedSlice[T]) AsReadOnly() ReadOnlySlice[T] {
+	return readOnlySlice[T]{s}
+}
+
 // Flush atomically retrieves and clears all shards, concatenating the results into a single slice.
 func (s *ShardedSlice[T]) Flush() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	// First pass: determine total length
 	total := 0
 	for _, sh := range s.shards {
@@ -98,18 +135,63 @@ func (s *ShardedSlice[T]) Flush() []T {
 	return out
 }
 
+// Shards returns the current number of shards.
+func (s *ShardedSlice[T]) Shards() int {
+	s.rlockShards()
+	defer s.mu.RUnlock()
+	return len(s.shards)
+}
+
+// Resharding rebuilds the slice with shardCount shards, e.g. after the number of usable cores
+// of a long-lived process changed. shardCount follows the rules of NewShardedSlice, so 0 picks a
+// count based on the current runtime.GOMAXPROCS. The items are moved to the new shards in their
+// concatenated order, split into contiguous runs; concurrent operations wait until it is done.
+func (s *ShardedSlice[T]) Resharding(shardCount int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []T
+	for _, sh := range s.shards {
+		items = append(items, sh.Flush()...)
+	}
+	s.shards = newShards[T](shardCount, s.initialCap)
+	for i, sh := range s.shards {
+		lo, hi := i*len(items)/len(s.shards), (i+1)*len(items)/len(s.shards)
+		if lo < hi {
+			sh.Append(items[lo:hi]...)
+		}
+	}
+}
+
 // NewShardedSlice creates a ShardedSlice with the given number of shards.
-// Each shard is pre-allocated with initialCap capacity.  shardCount must be
-// >0; if <=0, it is coerced to 1.
+// Each shard is pre-allocated with initialCap capacity. If shardCount is 0, a power of two
+// based on runtime.GOMAXPROCS is used; if shardCount is negative, it is coerced to 1.
 func NewShardedSlice[T any](shardCount, initialCap int) *ShardedSlice[T] {
-	nShards := shardCount
-	if shardCount <= 0 {
-		nShards = 1
-	}
-	shards := make([]Slice[T], nShards)
-	for i := 0; i < nShards; i++ {
+	return &ShardedSlice[T]{shards: newShards[T](shardCount, initialCap), initialCap: initialCap}
+}
+
+// newShards creates the shards of a ShardedSlice, see NewShardedSlice.
+func newShards[T any](shardCount, initialCap int) []Slice[T] {
+	shards := make([]Slice[T], resolveShardCount(shardCount))
+	for i := range shards {
 		// Use a minimal internal implementation – simple mutex slice.
 		shards[i] = NewRWMutexSlice[T](initialCap)
 	}
-	return &ShardedSlice[T]{shards: shards}
+	return shards
+}
+
+// resolveShardCount returns the number of shards to create for a requested shardCount: 0 picks
+// the smallest power of two not below runtime.GOMAXPROCS, and negative counts are coerced to 1.
+func resolveShardCount(shardCount int) int {
+	switch {
+	case shardCount > 0:
+		return shardCount
+	case shardCount < 0:
+		return 1
+	}
+	procs := runtime.GOMAXPROCS(0)
+	if procs <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(procs-1))
 }
@@ -1,6 +1,7 @@
 package threadsafe
 
 import (
+	"runtime"
 	"strconv"
 	"sync"
 	"testing"
@@ -290,6 +291,64 @@ func TestSliceZeroValue(t *testing.T) {
 	})
 }
 
+func TestWrapSlice(t *testing.T) {
+	items := make([]int, 2, 4)
+	s := WrapSlice(items)
+	s.Append(3)
+	assert.Equal(t, 3, items[:3][2], "appends use the wrapped spare capacity")
+	assert.Equal(t, []int{0, 0, 3}, s.Peek())
+
+	var nilSlice []string
+	assert.Equal(t, 0, WrapSlice(nilSlice).Len())
+}
+
+func TestShardedSliceResharding(t *testing.T) {
+	t.Run("AutoShardCount", func(t *testing.T) {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(6))
+		assert.Equal(t, 8, NewShardedSlice[int](0, 0).Shards())
+		assert.Equal(t, 3, NewShardedSlice[int](3, 0).Shards())
+		assert.Equal(t, 1, NewShardedSlice[int](-2, 0).Shards())
+
+		runtime.GOMAXPROCS(1)
+		assert.Equal(t, 1, NewShardedSlice[int](0, 0).Shards())
+	})
+
+	t.Run("KeepsItems", func(t *testing.T) {
+		s := NewShardedSlice[int](4, 0)
+		for i := range 10 {
+			s.Append(i)
+		}
+		s.Resharding(3)
+		assert.Equal(t, 3, s.Shards())
+		assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, s.Peek())
+
+		var zero ShardedSlice[int]
+		zero.Resharding(2)
+		zero.Append(1, 2)
+		assert.Equal(t, 2, zero.Shards())
+		assert.Equal(t, []int{1, 2}, zero.Flush())
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		s := NewShardedSlice[int](2, 0)
+		var wg sync.WaitGroup
+		for g := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 100 {
+					s.Append(g*100 + i)
+				}
+			}()
+		}
+		for n := range 8 {
+			s.Resharding(n + 1)
+		}
+		wg.Wait()
+		assert.Equal(t, 400, s.Len())
+	})
+}
+
 //
 // BENCHMARKS
 //
@@ -0,0 +1,112 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	swcCountBits = 40 // counts up to ~10^12 per bucket
+	swcCountMask = 1<<swcCountBits - 1
+	swcTagMask   = 1<<(64-swcCountBits) - 1
+)
+
+// SlidingWindowCounter counts events over a sliding time window, such as requests in the last
+// 60s, in constant memory. Events are counted in a ring of time buckets of a fixed resolution,
+// each a single atomic word, so Incr and Count never lock.
+//
+// Counts are approximate at the granularity of a bucket: Count includes the whole oldest bucket
+// overlapping the window, so it may include events up to one resolution older than the window.
+//
+// The zero value is not ready to use; construct via NewSlidingWindowCounter.
+type SlidingWindowCounter struct {
+	resolution time.Duration
+	// buckets pack the low bits of the bucket's epoch, the number of resolutions since the Unix
+	// epoch, with its count, so a stale bucket is detected and reset in the same atomic step.
+	buckets []atomic.Uint64
+}
+
+// NewSlidingWindowCounter creates a new counter covering windows of up to span, in buckets of the
+// given resolution. A non-positive resolution defaults to one second, and span is coerced to at
+// least one resolution.
+func NewSlidingWindowCounter(span, resolution time.Duration) *SlidingWindowCounter {
+	if resolution <= 0 {
+		resolution = time.Second
+	}
+	n := max(int((span+resolution-1)/resolution), 1)
+	return &SlidingWindowCounter{
+		resolution: resolution,
+		buckets:    make([]atomic.Uint64, n),
+	}
+}
+
+// Span returns the longest window the counter covers.
+func (c *SlidingWindowCounter) Span() time.Duration {
+	return c.resolution * time.Duration(len(c.buckets))
+}
+
+// Incr counts one event now.
+func (c *SlidingWindowCounter) Incr() {
+	c.Add(1)
+}
+
+// Add counts n events now. Non-positive values of n are ignored.
+func (c *SlidingWindowCounter) Add(n int64) {
+	c.addAt(time.Now(), n)
+}
+
+// Count returns the number of events in the last window, which is capped to Span.
+func (c *SlidingWindowCounter) Count(window time.Duration) int64 {
+	return c.countAt(time.Now(), window)
+}
+
+// Reset discards all counted events.
+func (c *SlidingWindowCounter) Reset() {
+	for i := range c.buckets {
+		c.buckets[i].Store(0)
+	}
+}
+
+// Internal helpers
+
+// epoch returns the index of the bucket interval containing t.
+func (c *SlidingWindowCounter) epoch(t time.Time) int64 {
+	return t.UnixNano() / int64(c.resolution)
+}
+
+func (c *SlidingWindowCounter) addAt(now time.Time, n int64) {
+	if n <= 0 {
+		return
+	}
+	epoch := c.epoch(now)
+	b := &c.buckets[epoch%int64(len(c.buckets))]
+	tag := uint64(epoch) & swcTagMask
+	for {
+		old := b.Load()
+		var count uint64
+		if old>>swcCountBits == tag {
+			count = old & swcCountMask
+		}
+		count = min(count+uint64(n), swcCountMask)
+		if b.CompareAndSwap(old, tag<<swcCountBits|count) {
+			return
+		}
+	}
+}
+
+func (c *SlidingWindowCounter) countAt(now time.Time, window time.Duration) int64 {
+	if window <= 0 {
+		return 0
+	}
+	epoch := c.epoch(now)
+	k := min(int64((window+c.resolution-1)/c.resolution), int64(len(c.buckets)))
+	var total int64
+	for e := epoch - k + 1; e <= epoch; e++ {
+		v := c.buckets[e%int64(len(c.buckets))].Load()
+		if v>>swcCountBits == uint64(e)&swcTagMask {
+			total += int64(v & swcCountMask)
+		}
+	}
+	return total
+}
@@ -0,0 +1,54 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingWindowCounter(t *testing.T) {
+	c := NewSlidingWindowCounter(time.Minute, time.Second)
+	assert.Equal(t, time.Minute, c.Span())
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			for range 100 {
+				c.Incr()
+			}
+		})
+	}
+	wg.Wait()
+	c.Add(5)
+	c.Add(-3)
+	assert.Equal(t, int64(1005), c.Count(time.Minute))
+	assert.Equal(t, int64(1005), c.Count(time.Hour)) // capped to the span
+	assert.Equal(t, int64(0), c.Count(0))
+
+	c.Reset()
+	assert.Equal(t, int64(0), c.Count(time.Minute))
+}
+
+func TestSlidingWindowCounterExpiry(t *testing.T) {
+	c := NewSlidingWindowCounter(4*time.Second, time.Second)
+	start := time.Unix(1000, 0)
+	c.addAt(start, 3)
+	c.addAt(start.Add(2*time.Second), 1)
+	now := start.Add(2500 * time.Millisecond)
+
+	// The window only covers the recent bucket; the span covers both
+	assert.Equal(t, int64(1), c.countAt(now, time.Second))
+	assert.Equal(t, int64(4), c.countAt(now, 3*time.Second))
+
+	// Old buckets are ignored once they fall out of the window, and reused afterwards
+	now = start.Add(4 * time.Second)
+	assert.Equal(t, int64(1), c.countAt(now, 4*time.Second))
+	c.addAt(now, 2) // same ring slot as the bucket of start
+	assert.Equal(t, int64(3), c.countAt(now, 4*time.Second))
+	assert.Equal(t, int64(0), c.countAt(now.Add(time.Hour), 4*time.Second))
+
+	// Spans are coerced to at least one resolution
+	assert.Equal(t, time.Second, NewSlidingWindowCounter(0, 0).Span())
+}
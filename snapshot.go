@@ -0,0 +1,126 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrSnapshotMismatch is returned by Load when a snapshot does not hold one section per
+// collection to load.
+var ErrSnapshotMismatch = errors.New("threadsafe: snapshot does not match collections")
+
+// Snapshotter is implemented by collections whose contents can be saved to and restored from a
+// stream, with items encoded by a Codec. Maps snapshot their contents as MapEntry items.
+type Snapshotter[T any] interface {
+	// SaveTo writes a snapshot of the collection contents to w using codec.
+	SaveTo(w io.Writer, codec Codec[T]) error
+	// LoadFrom replaces the collection contents with a snapshot written by SaveTo. On error the
+	// collection is left unchanged.
+	LoadFrom(r io.Reader, codec Codec[T]) error
+}
+
+// MapEntry is a key-value pair, the item type of map snapshots.
+type MapEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Save writes a snapshot of each of the collections to w, in order, so that an application can
+// checkpoint several collections into a single stream. Each collection is snapshotted on its own,
+// so the checkpoint is not atomic across collections.
+func Save[T any](w io.Writer, codec Codec[T], collections ...Snapshotter[T]) error {
+	bw := bufio.NewWriter(w)
+	buf := binary.AppendUvarint(nil, uint64(len(collections)))
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	var section bytes.Buffer
+	for i, c := range collections {
+		section.Reset()
+		if err := c.SaveTo(&section, codec); err != nil {
+			return fmt.Errorf("threadsafe: saving collection %d: %w", i, err)
+		}
+		buf = binary.AppendUvarint(buf[:0], uint64(section.Len()))
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		if _, err := section.WriteTo(bw); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Load restores collections from a snapshot written by Save with the same collections in the same
+// order. The whole snapshot is read before any collection is modified, so a truncated or
+// mismatched snapshot leaves all collections unchanged; a decoding error in one collection leaves
+// the collections before it restored.
+func Load[T any](r io.Reader, codec Codec[T], collections ...Snapshotter[T]) error {
+	br := bufio.NewReader(r)
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return err
+	}
+	if count != uint64(len(collections)) {
+		return fmt.Errorf("%w: %d sections for %d collections",
+			ErrSnapshotMismatch, count, len(collections))
+	}
+	sections := make([][]byte, len(collections))
+	for i := range sections {
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		sections[i] = make([]byte, size)
+		if _, err := io.ReadFull(br, sections[i]); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+	for i, c := range collections {
+		if err := c.LoadFrom(bytes.NewReader(sections[i]), codec); err != nil {
+			return fmt.Errorf("threadsafe: loading collection %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Internal helpers
+
+// mapEntries converts the contents of a map to snapshot items.
+func mapEntries[K comparable, V any](values map[K]V) []MapEntry[K, V] {
+	entries := make([]MapEntry[K, V], 0, len(values))
+	for k, v := range values {
+		entries = append(entries, MapEntry[K, V]{k, v})
+	}
+	return entries
+}
+
+// entriesMap converts snapshot items back to the contents of a map.
+func entriesMap[K comparable, V any](entries []MapEntry[K, V]) map[K]V {
+	values := make(map[K]V, len(entries))
+	for _, e := range entries {
+		values[e.Key] = e.Value
+	}
+	return values
+}
+
+// Ensure the collections supporting snapshots implement Snapshotter.
+var (
+	_ Snapshotter[MapEntry[string, any]] = (*MutexMap[string, any])(nil)
+	_ Snapshotter[MapEntry[string, any]] = (*RWMutexMap[string, any])(nil)
+	_ Snapshotter[MapEntry[string, any]] = (*SyncMap[string, any])(nil)
+	_ Snapshotter[string]                = (*RWMutexSet[string])(nil)
+	_ Snapshotter[string]                = (*SyncMapSet[string])(nil)
+	_ Snapshotter[any]                   = (*MutexSlice[any])(nil)
+	_ Snapshotter[any]                   = (*RWMutexSlice[any])(nil)
+	_ Snapshotter[any]                   = (*RWMutexQueue[any])(nil)
+	_ Snapshotter[any]                   = (*CorePriorityQueue[any])(nil)
+	_ Snapshotter[any]                   = (*IndexedPriorityQueue[any])(nil)
+	_ Snapshotter[int]                   = (*OrderedPriorityQueue[int])(nil)
+	_ Snapshotter[any]                   = (*RWMutexHeap[any])(nil)
+)
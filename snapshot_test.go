@@ -0,0 +1,113 @@
+package threadsafe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	queue := NewRWMutexQueue[int]()
+	queue.Push(3, 1, 2)
+	slice := NewMutexSlice[int](0)
+	slice.Append(4, 5)
+	set := NewRWMutexSet[int]()
+	set.Add(6)
+	set.Add(7)
+	pq := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	pq.Push(9, 8)
+
+	var buf bytes.Buffer
+	require.NoError(t, Save(&buf, JSONCodec[int]{}, queue, slice, set, pq))
+
+	queue2 := NewRWMutexQueue[int]()
+	slice2 := NewRWMutexSlice[int](0)
+	set2 := NewSyncMapSet[int]()
+	pq2 := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	pq2.Push(100)
+	require.NoError(t, Load(&buf, JSONCodec[int]{}, queue2, slice2, set2, pq2))
+
+	assert.Equal(t, []int{3, 1, 2}, queue2.Slice())
+	assert.Equal(t, []int{4, 5}, slice2.Peek())
+	assert.ElementsMatch(t, []int{6, 7}, set2.Slice())
+	assert.Equal(t, []int{8, 9}, pq2.SliceSorted())
+}
+
+func TestSnapshotMaps(t *testing.T) {
+	codec := GobCodec[MapEntry[string, int]]{}
+	src := NewRWMutexMap[string, int](nil)
+	src.SetMany(map[string]int{"a": 1, "b": 2})
+	syncSrc := NewSyncMap[string, int](nil)
+	syncSrc.Set("c", 3)
+
+	var buf bytes.Buffer
+	require.NoError(t, Save(&buf, codec, src, syncSrc))
+
+	dst := NewMutexMap[string, int](nil)
+	dst.Set("stale", 0)
+	syncDst := NewSyncMap[string, int](nil)
+	require.NoError(t, Load(&buf, codec, dst, syncDst))
+	assert.Equal(t, map[string]int{"a": 1, "b": 2}, dst.GetAll())
+	assert.Equal(t, map[string]int{"c": 3}, syncDst.GetAll())
+}
+
+func TestSnapshotLoadErrors(t *testing.T) {
+	src := NewRWMutexQueue[int]()
+	src.Push(1, 2)
+	var buf bytes.Buffer
+	require.NoError(t, Save(&buf, GobCodec[int]{}, src, src))
+	data := buf.Bytes()
+
+	first, second := NewRWMutexQueue[int](), NewRWMutexQueue[int]()
+	first.Push(10)
+	second.Push(20)
+
+	// Fewer or more collections than sections
+	err := Load(bytes.NewReader(data), GobCodec[int]{}, first)
+	assert.ErrorIs(t, err, ErrSnapshotMismatch)
+
+	// A truncated snapshot leaves every collection unchanged
+	err = Load(bytes.NewReader(data[:len(data)-1]), GobCodec[int]{}, first, second)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, []int{10}, first.Slice())
+	assert.Equal(t, []int{20}, second.Slice())
+
+	assert.Error(t, Load(bytes.NewReader(nil), GobCodec[int]{}, first))
+	assert.NoError(t, Load(bytes.NewReader(data), GobCodec[int]{}, first, second))
+	assert.Equal(t, []int{1, 2}, second.Slice())
+}
+
+func TestSnapshotCorruptSize(t *testing.T) {
+	q := NewRWMutexQueue[int]()
+	q.Push(7)
+
+	// An item size beyond the available data is reported as a truncated snapshot, without
+	// allocating a buffer of that size
+	for _, size := range []uint64{1 << 35, 1 << 62, math.MaxUint64} {
+		data := binary.AppendUvarint(binary.AppendUvarint(nil, 1), size)
+		data = append(data, 1, 2, 3)
+		err := q.LoadFrom(bytes.NewReader(data), GobCodec[int]{})
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		assert.Equal(t, []int{7}, q.Slice())
+	}
+}
+
+type failingCodec struct{ GobCodec[int] }
+
+func (failingCodec) Encode(int) ([]byte, error) {
+	return nil, errors.New("encode failed")
+}
+
+func TestSnapshotSaveError(t *testing.T) {
+	q := NewRWMutexQueue[int]()
+	q.Push(1)
+	err := Save(io.Discard, failingCodec{}, q)
+	assert.ErrorContains(t, err, "collection 0")
+	assert.NoError(t, Save(io.Discard, failingCodec{}))
+}
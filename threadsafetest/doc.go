@@ -0,0 +1,31 @@
+// Package threadsafetest provides conformance test suites and stress runners for implementations
+// of the interfaces in package threadsafe, so alternative implementations can be validated with
+// the same tests as the built-in ones.
+//
+// Each suite is a struct holding a constructor and sample items, with a Run method to be called
+// from a regular test function:
+//
+//	func TestMyQueue(t *testing.T) {
+//	    suite := &threadsafetest.QueueSuite[int]{
+//	        NewQueue: func() threadsafe.Queue[int] { return NewMyQueue[int]() },
+//	        Item1:    1,
+//	        Item2:    2,
+//	        Item3:    3,
+//	    }
+//	    suite.Run(t)
+//	}
+//
+// The package also provides stress runners exercising an implementation from many goroutines at
+// once, best run with the race detector enabled. Stress runs any weighted mix of operations, and
+// StressMap, StressSet, StressQueue and StressSlice run ready-made mixes that check every result
+// against a model and the final contents once all goroutines are done:
+//
+//	func TestMyQueueStress(t *testing.T) {
+//	    threadsafetest.StressQueue(t, NewMyQueue[int](), threadsafetest.StressConfig{})
+//	}
+//
+// For bugs that only show as impossible results under concurrency, such as a compare-and-swap
+// implemented as a separate read and store, operations can be recorded with a Recorder and the
+// resulting history checked against a sequential Model with CheckLinearizable. MapModel and
+// ApplyMap cover threadsafe.Map, and lend themselves to fuzz tests.
+package threadsafetest
@@ -0,0 +1,168 @@
+package threadsafetest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+// HeapSuite is a conformance test suite for implementations of threadsafe.Heap.
+//
+// The suite expects a heap popping the minimum per Less first, accepting duplicate items, with
+// Slice, SliceSorted, AllSorted, Range and All operating on snapshots.
+type HeapSuite[T any] struct {
+	// NewHeap returns a new, empty heap. It is called once per test.
+	NewHeap func() threadsafe.Heap[T]
+	// Less is the ordering the heap is expected to follow; the minimum pops first.
+	Less func(a, b T) bool
+	// Item1, Item2 and Item3 are sample items in strictly increasing order per Less.
+	Item1, Item2, Item3 T
+}
+
+// Run runs all tests of the suite as subtests of t.
+func (s *HeapSuite[T]) Run(t *testing.T) {
+	t.Run("EmptyHeap", s.TestEmptyHeap)
+	t.Run("Ordering", s.TestOrdering)
+	t.Run("SortedViews", s.TestSortedViews)
+	t.Run("PopIf", s.TestPopIf)
+	t.Run("Clear", s.TestClear)
+	t.Run("Iteration", s.TestIteration)
+	t.Run("ConcurrentPushPop", s.TestConcurrentPushPop)
+}
+
+// TestEmptyHeap verifies the behavior of all read and remove operations on an empty heap.
+func (s *HeapSuite[T]) TestEmptyHeap(t *testing.T) {
+	h := s.NewHeap()
+	var zero T
+	assert.Equal(t, 0, h.Len())
+	item, ok := h.Pop()
+	assert.False(t, ok)
+	assert.Equal(t, zero, item)
+	_, ok = h.Peek()
+	assert.False(t, ok)
+	_, ok = h.PopIf(func(T) bool {
+		t.Error("PopIf called pred on an empty heap")
+		return true
+	})
+	assert.False(t, ok)
+	assert.Empty(t, h.Slice())
+	assert.Empty(t, h.SliceSorted())
+}
+
+// TestOrdering verifies that items come out in priority order, including duplicates.
+func (s *HeapSuite[T]) TestOrdering(t *testing.T) {
+	h := s.NewHeap()
+	h.Push(s.Item3, s.Item1)
+	h.Push(s.Item2, s.Item1)
+	h.Push()
+	assert.Equal(t, 4, h.Len())
+
+	top, ok := h.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, s.Item1, top)
+	for _, want := range []T{s.Item1, s.Item1, s.Item2, s.Item3} {
+		item, ok := h.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, want, item)
+	}
+	assert.Equal(t, 0, h.Len())
+}
+
+// TestSortedViews verifies that SliceSorted and AllSorted follow pop order without modifying the
+// heap.
+func (s *HeapSuite[T]) TestSortedViews(t *testing.T) {
+	h := s.NewHeap()
+	h.Push(s.Item2, s.Item3, s.Item1)
+	want := []T{s.Item1, s.Item2, s.Item3}
+	assert.Equal(t, want, h.SliceSorted())
+	var sorted []T
+	for item := range h.AllSorted() {
+		sorted = append(sorted, item)
+	}
+	assert.Equal(t, want, sorted)
+	assert.ElementsMatch(t, want, h.Slice())
+	assert.Equal(t, 3, h.Len())
+}
+
+// TestPopIf verifies that PopIf only pops the minimum, and only when pred accepts it.
+func (s *HeapSuite[T]) TestPopIf(t *testing.T) {
+	h := s.NewHeap()
+	h.Push(s.Item2, s.Item1)
+	_, ok := h.PopIf(func(top T) bool {
+		assert.Equal(t, s.Item1, top)
+		return false
+	})
+	assert.False(t, ok)
+	assert.Equal(t, 2, h.Len())
+	item, ok := h.PopIf(func(T) bool { return true })
+	assert.True(t, ok)
+	assert.Equal(t, s.Item1, item)
+}
+
+// TestClear verifies that Clear removes all items and leaves a usable empty heap.
+func (s *HeapSuite[T]) TestClear(t *testing.T) {
+	h := s.NewHeap()
+	h.Push(s.Item1, s.Item2)
+	h.Clear()
+	assert.Equal(t, 0, h.Len())
+	h.Push(s.Item3)
+	top, _ := h.Peek()
+	assert.Equal(t, s.Item3, top)
+}
+
+// TestIteration verifies Range and All contents, early termination and mutation during
+// iteration.
+func (s *HeapSuite[T]) TestIteration(t *testing.T) {
+	h := s.NewHeap()
+	h.Push(s.Item1, s.Item2, s.Item3)
+
+	var ranged []T
+	h.Range(func(item T) bool {
+		ranged = append(ranged, item)
+		return true
+	})
+	assert.ElementsMatch(t, []T{s.Item1, s.Item2, s.Item3}, ranged)
+
+	var all []T
+	for item := range h.All() {
+		all = append(all, item)
+		h.Push(s.Item1) // mutations must not deadlock or affect the iteration
+	}
+	assert.ElementsMatch(t, []T{s.Item1, s.Item2, s.Item3}, all)
+	assert.Equal(t, 6, h.Len())
+
+	count := 0
+	h.Range(func(T) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
+
+// TestConcurrentPushPop verifies that every pushed item is popped exactly once with concurrent
+// producers and consumers.
+func (s *HeapSuite[T]) TestConcurrentPushPop(t *testing.T) {
+	const goroutines = 8
+	const perGoroutine = 100
+	h := s.NewHeap()
+
+	var mu sync.Mutex
+	popped := 0
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for range perGoroutine {
+				h.Push(s.Item2)
+				if _, ok := h.Pop(); ok {
+					mu.Lock()
+					popped++
+					mu.Unlock()
+				}
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, goroutines*perGoroutine, popped+h.Len())
+}
@@ -0,0 +1,18 @@
+package threadsafetest
+
+import (
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+)
+
+func TestHeapSuite(t *testing.T) {
+	suite := &HeapSuite[int]{
+		NewHeap: func() threadsafe.Heap[int] { return threadsafe.NewMinHeap[int]() },
+		Less:    func(a, b int) bool { return a < b },
+		Item1:   1,
+		Item2:   2,
+		Item3:   3,
+	}
+	suite.Run(t)
+}
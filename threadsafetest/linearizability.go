@@ -0,0 +1,306 @@
+package threadsafetest
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+)
+
+// Operation is a completed operation of a concurrent history: the input a client called it with,
+// the output it returned, and the logical times of the call and of the return.
+type Operation[I, O any] struct {
+	Client int
+	Input  I
+	Output O
+	Call   int64
+	Return int64
+}
+
+// Model is a sequential specification of a collection, against which concurrent histories are
+// checked by CheckLinearizable.
+type Model[S, I, O any] struct {
+	// Init returns the initial state.
+	Init func() S
+	// Step reports whether output is a valid result of applying input to state, and returns the
+	// state after the operation. It must not modify state.
+	Step func(state S, input I, output O) (ok bool, next S)
+	// Partition, if set, splits a history into independent sub-histories checked separately, such
+	// as the operations on each key of a map. Since linearizability is compositional, this keeps
+	// the search small for long histories.
+	Partition func(history []Operation[I, O]) [][]Operation[I, O]
+	// Key, if set, returns a string identifying a state, letting the checker skip states it has
+	// already explored. Two states with the same key must behave identically.
+	Key func(state S) string
+}
+
+// Recorder records a concurrent history of operations. It is safe for concurrent use.
+type Recorder[I, O any] struct {
+	clock   atomic.Int64
+	mu      sync.Mutex
+	history []Operation[I, O]
+}
+
+// Record runs op with input on behalf of client, records it with its call and return times, and
+// returns its output.
+func (r *Recorder[I, O]) Record(client int, input I, op func(input I) O) O {
+	call := r.clock.Add(1)
+	output := op(input)
+	ret := r.clock.Add(1)
+
+	r.mu.Lock()
+	r.history = append(r.history, Operation[I, O]{client, input, output, call, ret})
+	r.mu.Unlock()
+	return output
+}
+
+// History returns a copy of the operations recorded so far.
+func (r *Recorder[I, O]) History() []Operation[I, O] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.history)
+}
+
+// CheckLinearizable reports whether history is linearizable with respect to model: whether each
+// operation can be assigned an instant between its call and its return such that applying the
+// operations in that order to the model yields the recorded outputs.
+//
+// The check is a Wing–Gong search with the memoization of Lowe's refinement when model.Key is
+// set. It is exponential in the worst case, so histories should be kept to a few hundred
+// operations per partition.
+func CheckLinearizable[S, I, O any](model Model[S, I, O], history []Operation[I, O]) bool {
+	partitions := [][]Operation[I, O]{history}
+	if model.Partition != nil {
+		partitions = model.Partition(history)
+	}
+	for _, ops := range partitions {
+		if !checkPartition(model, ops) {
+			return false
+		}
+	}
+	return true
+}
+
+// AssertLinearizable checks history with CheckLinearizable and reports a test failure, including
+// the history, if it is not linearizable.
+func AssertLinearizable[S, I, O any](
+	t testing.TB,
+	model Model[S, I, O],
+	history []Operation[I, O],
+) bool {
+	t.Helper()
+	if CheckLinearizable(model, history) {
+		return true
+	}
+	sorted := slices.Clone(history)
+	slices.SortFunc(sorted, func(a, b Operation[I, O]) int { return int(a.Call - b.Call) })
+	t.Errorf("history is not linearizable:\n%s", formatHistory(sorted))
+	return false
+}
+
+// MapOp identifies a Map method in a MapInput.
+type MapOp int
+
+// Map methods supported by MapModel.
+const (
+	MapGet MapOp = iota
+	MapSet
+	MapDelete
+	MapSwap
+	MapLoadOrStore
+	MapLoadAndDelete
+	MapCompareAndSwap
+)
+
+// String returns the name of the method.
+func (op MapOp) String() string {
+	switch op {
+	case MapGet:
+		return "Get"
+	case MapSet:
+		return "Set"
+	case MapDelete:
+		return "Delete"
+	case MapSwap:
+		return "Swap"
+	case MapLoadOrStore:
+		return "LoadOrStore"
+	case MapLoadAndDelete:
+		return "LoadAndDelete"
+	case MapCompareAndSwap:
+		return "CompareAndSwap"
+	}
+	return fmt.Sprintf("MapOp(%d)", int(op))
+}
+
+// MapInput is the input of a Map operation. Value is the value to store, if any, and Old the
+// value CompareAndSwap compares against.
+type MapInput[K comparable, V any] struct {
+	Op    MapOp
+	Key   K
+	Value V
+	Old   V
+}
+
+// MapOutput is the output of a Map operation: the value and the ok, loaded or swapped result, as
+// applicable.
+type MapOutput[V any] struct {
+	Value V
+	OK    bool
+}
+
+// MapState is the state of a single key in MapModel.
+type MapState[V any] struct {
+	Value   V
+	Present bool
+}
+
+// ApplyMap runs the operation described by input against m and returns its output, for use with
+// Recorder.Record.
+func ApplyMap[K comparable, V any](m threadsafe.Map[K, V], input MapInput[K, V]) MapOutput[V] {
+	var out MapOutput[V]
+	switch input.Op {
+	case MapGet:
+		out.Value, out.OK = m.Get(input.Key)
+	case MapSet:
+		m.Set(input.Key, input.Value)
+	case MapDelete:
+		m.Delete(input.Key)
+	case MapSwap:
+		out.Value, out.OK = m.Swap(input.Key, input.Value)
+	case MapLoadOrStore:
+		out.Value, out.OK = m.LoadOrStore(input.Key, input.Value)
+	case MapLoadAndDelete:
+		out.Value, out.OK = m.LoadAndDelete(input.Key)
+	case MapCompareAndSwap:
+		out.OK = m.CompareAndSwap(input.Key, input.Old, input.Value)
+	}
+	return out
+}
+
+// MapModel returns the sequential model of threadsafe.Map, for histories recorded with ApplyMap.
+// Histories are partitioned by key, so the state is that of a single key.
+func MapModel[K, V comparable]() Model[MapState[V], MapInput[K, V], MapOutput[V]] {
+	return Model[MapState[V], MapInput[K, V], MapOutput[V]]{
+		Init:      func() MapState[V] { return MapState[V]{} },
+		Step:      stepMap[K, V],
+		Partition: partitionByKey[K, V],
+		Key:       func(s MapState[V]) string { return fmt.Sprintf("%t:%v", s.Present, s.Value) },
+	}
+}
+
+// Internal helpers
+
+// checkPartition searches for a linearization of ops, depth first, among the operations that
+// may take effect next: those called before any remaining operation returned.
+func checkPartition[S, I, O any](model Model[S, I, O], ops []Operation[I, O]) bool {
+	ops = slices.Clone(ops)
+	slices.SortFunc(ops, func(a, b Operation[I, O]) int { return int(a.Call - b.Call) })
+	done := make([]byte, (len(ops)+7)/8)
+	var seen map[string]struct{}
+	if model.Key != nil {
+		seen = map[string]struct{}{}
+	}
+
+	var search func(state S, remaining int) bool
+	search = func(state S, remaining int) bool {
+		if remaining == 0 {
+			return true
+		}
+		if seen != nil {
+			key := string(done) + "\x00" + model.Key(state)
+			if _, ok := seen[key]; ok {
+				return false
+			}
+			seen[key] = struct{}{}
+		}
+		minReturn := int64(math.MaxInt64)
+		for i, op := range ops {
+			if done[i/8]&(1<<(i%8)) == 0 {
+				minReturn = min(minReturn, op.Return)
+			}
+		}
+		for i, op := range ops {
+			if op.Call > minReturn {
+				break
+			}
+			if done[i/8]&(1<<(i%8)) != 0 {
+				continue
+			}
+			ok, next := model.Step(state, op.Input, op.Output)
+			if !ok {
+				continue
+			}
+			done[i/8] |= 1 << (i % 8)
+			if search(next, remaining-1) {
+				return true
+			}
+			done[i/8] &^= 1 << (i % 8)
+		}
+		return false
+	}
+	return search(model.Init(), len(ops))
+}
+
+func stepMap[K, V comparable](
+	s MapState[V],
+	in MapInput[K, V],
+	out MapOutput[V],
+) (bool, MapState[V]) {
+	loaded := out.OK == s.Present && (!s.Present || out.Value == s.Value)
+	switch in.Op {
+	case MapGet:
+		return loaded, s
+	case MapSet:
+		return true, MapState[V]{in.Value, true}
+	case MapDelete:
+		return true, MapState[V]{}
+	case MapSwap:
+		return loaded, MapState[V]{in.Value, true}
+	case MapLoadOrStore:
+		if s.Present {
+			return loaded, s
+		}
+		return !out.OK && out.Value == in.Value, MapState[V]{in.Value, true}
+	case MapLoadAndDelete:
+		return loaded, MapState[V]{}
+	case MapCompareAndSwap:
+		swapped := s.Present && s.Value == in.Old
+		if !swapped {
+			return !out.OK, s
+		}
+		return out.OK, MapState[V]{in.Value, true}
+	}
+	return false, s
+}
+
+// partitionByKey splits a history of map operations by key.
+func partitionByKey[K comparable, V any](
+	history []Operation[MapInput[K, V], MapOutput[V]],
+) [][]Operation[MapInput[K, V], MapOutput[V]] {
+	var partitions [][]Operation[MapInput[K, V], MapOutput[V]]
+	index := map[K]int{}
+	for _, op := range history {
+		i, ok := index[op.Input.Key]
+		if !ok {
+			i = len(partitions)
+			index[op.Input.Key] = i
+			partitions = append(partitions, nil)
+		}
+		partitions[i] = append(partitions[i], op)
+	}
+	return partitions
+}
+
+func formatHistory[I, O any](history []Operation[I, O]) string {
+	var b []byte
+	for _, op := range history {
+		b = fmt.Appendf(b, "  [%d, %d] client %d: %+v -> %+v\n",
+			op.Call, op.Return, op.Client, op.Input, op.Output)
+	}
+	return string(b)
+}
@@ -0,0 +1,165 @@
+package threadsafetest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapOperation = Operation[MapInput[string, int], MapOutput[int]]
+
+func TestCheckLinearizable(t *testing.T) {
+	model := MapModel[string, int]()
+	set := func(call, ret int64, v int) mapOperation {
+		return mapOperation{Input: MapInput[string, int]{Op: MapSet, Key: "k", Value: v},
+			Call: call, Return: ret}
+	}
+	get := func(call, ret int64, v int, ok bool) mapOperation {
+		return mapOperation{Input: MapInput[string, int]{Op: MapGet, Key: "k"},
+			Output: MapOutput[int]{v, ok}, Call: call, Return: ret}
+	}
+	cas := func(call, ret int64, old, v int, swapped bool) mapOperation {
+		return mapOperation{
+			Input:  MapInput[string, int]{Op: MapCompareAndSwap, Key: "k", Old: old, Value: v},
+			Output: MapOutput[int]{OK: swapped}, Call: call, Return: ret}
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.True(t, CheckLinearizable(model, nil))
+	})
+	t.Run("Sequential", func(t *testing.T) {
+		assert.True(t, CheckLinearizable(model, []mapOperation{
+			get(1, 2, 0, false), set(3, 4, 1), get(5, 6, 1, true),
+		}))
+		assert.False(t, CheckLinearizable(model, []mapOperation{
+			set(1, 2, 1), get(3, 4, 0, false),
+		}))
+	})
+	t.Run("Overlapping", func(t *testing.T) {
+		// The get overlaps both sets, so it may observe either value, but not a missing key
+		// once the first set has returned.
+		assert.True(t, CheckLinearizable(model, []mapOperation{
+			set(1, 2, 1), set(3, 6, 2), get(4, 5, 1, true),
+		}))
+		assert.True(t, CheckLinearizable(model, []mapOperation{
+			set(1, 2, 1), set(3, 6, 2), get(4, 5, 2, true),
+		}))
+		assert.False(t, CheckLinearizable(model, []mapOperation{
+			set(1, 2, 1), set(3, 6, 2), get(4, 5, 0, false),
+		}))
+	})
+	t.Run("CompareAndSwapRace", func(t *testing.T) {
+		// Two overlapping swaps from the same old value cannot both succeed, as happens when
+		// CompareAndSwap reads and stores in separate steps.
+		assert.False(t, CheckLinearizable(model, []mapOperation{
+			set(1, 2, 0), cas(3, 6, 0, 1, true), cas(4, 5, 0, 2, true),
+		}))
+		assert.True(t, CheckLinearizable(model, []mapOperation{
+			set(1, 2, 0), cas(3, 6, 0, 1, true), cas(4, 5, 0, 2, false),
+		}))
+	})
+	t.Run("Partitioned", func(t *testing.T) {
+		other := set(3, 4, 1)
+		other.Input.Key = "other"
+		assert.False(t, CheckLinearizable(model, []mapOperation{
+			set(1, 2, 1), other, get(5, 6, 0, false),
+		}))
+		assert.True(t, CheckLinearizable(model, []mapOperation{other, get(5, 6, 0, false)}))
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	var r Recorder[int, int]
+	var wg sync.WaitGroup
+	for client := range 4 {
+		wg.Go(func() {
+			for i := range 10 {
+				assert.Equal(t, i*2, r.Record(client, i, func(x int) int { return x * 2 }))
+			}
+		})
+	}
+	wg.Wait()
+
+	history := r.History()
+	assert.Len(t, history, 40)
+	for _, op := range history {
+		assert.Less(t, op.Call, op.Return)
+		assert.Equal(t, op.Input*2, op.Output)
+	}
+}
+
+// FuzzMapLinearizable runs concurrent operations derived from the fuzz input against the
+// built-in maps, and checks that the recorded histories are linearizable.
+func FuzzMapLinearizable(f *testing.F) {
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13})
+	f.Add([]byte{6, 6, 6, 6, 1, 1, 1, 1, 6, 6, 6, 6, 0, 0, 0, 0})
+	f.Add([]byte{4, 5, 4, 5, 2, 3, 2, 3, 4, 5, 4, 5, 0, 0, 0, 0})
+
+	equal := func(a, b int) bool { return a == b }
+	implementations := []struct {
+		name   string
+		newMap func() threadsafe.Map[string, int]
+	}{
+		{"MutexMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewMutexMap[string](equal)
+		}},
+		{"RWMutexMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewRWMutexMap[string](equal)
+		}},
+		{"SyncMap", func() threadsafe.Map[string, int] {
+			// Without an equal function, CompareAndSwap is that of sync.Map
+			return threadsafe.NewSyncMap[string, int](nil)
+		}},
+		{"ImmutableMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewImmutableMap[string](equal)
+		}},
+		{"ShardedMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewShardedMap[string](4, equal)
+		}},
+		{"OrderedMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewOrderedMap[string](equal)
+		}},
+		{"SortedMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewSortedMap[string](equal)
+		}},
+		{"TTLMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewTTLMap[string](time.Hour, 0, equal)
+		}},
+	}
+	keys := []string{"a", "b"}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		const clients = 4
+		if len(data) > 64*clients {
+			data = data[:64*clients]
+		}
+		for _, impl := range implementations {
+			m := impl.newMap()
+			var r Recorder[MapInput[string, int], MapOutput[int]]
+			var wg sync.WaitGroup
+			for client := range clients {
+				wg.Go(func() {
+					for i := client; i < len(data); i += clients {
+						b := int(data[i])
+						input := MapInput[string, int]{
+							Op:    MapOp(b % 7),
+							Key:   keys[b/7%len(keys)],
+							Value: b % 3,
+							Old:   b / 3 % 3,
+						}
+						r.Record(client, input, func(in MapInput[string, int]) MapOutput[int] {
+							return ApplyMap(m, in)
+						})
+					}
+				})
+			}
+			wg.Wait()
+			if !AssertLinearizable(t, MapModel[string, int](), r.History()) {
+				t.Logf("implementation: %s", impl.name)
+			}
+		}
+	})
+}
@@ -0,0 +1,320 @@
+package threadsafetest
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+// MapSuite is a conformance test suite for implementations of threadsafe.Map.
+//
+// The suite expects All, Keys, Values and Range to tolerate mutations of the map during
+// iteration, and CompareAndSwap to compare values with Equal.
+type MapSuite[K comparable, V any] struct {
+	// NewMap returns a new, empty map. It is called once per test.
+	NewMap func() threadsafe.Map[K, V]
+	// Key1, Key2 and Key3 are distinct sample keys.
+	Key1, Key2, Key3 K
+	// Val1, Val2 and Val3 are distinct sample values.
+	Val1, Val2, Val3 V
+	// Equal reports whether two values are equal, as the map's CompareAndSwap and Equals do.
+	Equal func(a, b V) bool
+}
+
+// Run runs all tests of the suite as subtests of t.
+func (s *MapSuite[K, V]) Run(t *testing.T) {
+	t.Run("BasicOperations", s.TestBasicOperations)
+	t.Run("CompareAndSwap", s.TestCompareAndSwap)
+	t.Run("Swap", s.TestSwap)
+	t.Run("LoadOrStore", s.TestLoadOrStore)
+	t.Run("GetOrCompute", s.TestGetOrCompute)
+	t.Run("LoadAndDelete", s.TestLoadAndDelete)
+	t.Run("BulkOperations", s.TestBulkOperations)
+	t.Run("DeleteMany", s.TestDeleteMany)
+	t.Run("UpdateMany", s.TestUpdateMany)
+	t.Run("Equals", s.TestEquals)
+	t.Run("Range", s.TestRange)
+	t.Run("Iterators", s.TestIterators)
+	t.Run("ConcurrentAccess", s.TestConcurrentAccess)
+}
+
+// TestBasicOperations verifies Get, Set, Delete, Len and Clear.
+func (s *MapSuite[K, V]) TestBasicOperations(t *testing.T) {
+	m := s.NewMap()
+	assert.Equal(t, 0, m.Len())
+
+	m.Set(s.Key1, s.Val1)
+	m.Set(s.Key2, s.Val2)
+	assert.Equal(t, 2, m.Len())
+	value, ok := m.Get(s.Key1)
+	assert.True(t, ok)
+	assert.True(t, s.Equal(s.Val1, value))
+	_, ok = m.Get(s.Key3)
+	assert.False(t, ok)
+
+	m.Set(s.Key1, s.Val3)
+	value, _ = m.Get(s.Key1)
+	assert.True(t, s.Equal(s.Val3, value))
+	assert.Equal(t, 2, m.Len())
+
+	m.Delete(s.Key1)
+	m.Delete(s.Key3) // deleting a missing key is a no-op
+	assert.Equal(t, 1, m.Len())
+	_, ok = m.Get(s.Key1)
+	assert.False(t, ok)
+
+	m.Clear()
+	assert.Equal(t, 0, m.Len())
+	m.Set(s.Key3, s.Val3)
+	assert.Equal(t, 1, m.Len())
+}
+
+// TestCompareAndSwap verifies that CompareAndSwap only swaps a present key holding the old value.
+func (s *MapSuite[K, V]) TestCompareAndSwap(t *testing.T) {
+	m := s.NewMap()
+	assert.False(t, m.CompareAndSwap(s.Key1, s.Val1, s.Val2))
+	_, ok := m.Get(s.Key1)
+	assert.False(t, ok)
+
+	m.Set(s.Key1, s.Val1)
+	assert.True(t, m.CompareAndSwap(s.Key1, s.Val1, s.Val2))
+	assert.False(t, m.CompareAndSwap(s.Key1, s.Val1, s.Val3))
+	value, _ := m.Get(s.Key1)
+	assert.True(t, s.Equal(s.Val2, value))
+}
+
+// TestSwap verifies that Swap stores the value and returns the previous one.
+func (s *MapSuite[K, V]) TestSwap(t *testing.T) {
+	m := s.NewMap()
+	var zero V
+	previous, loaded := m.Swap(s.Key1, s.Val1)
+	assert.False(t, loaded)
+	assert.Equal(t, zero, previous)
+
+	previous, loaded = m.Swap(s.Key1, s.Val2)
+	assert.True(t, loaded)
+	assert.True(t, s.Equal(s.Val1, previous))
+	value, _ := m.Get(s.Key1)
+	assert.True(t, s.Equal(s.Val2, value))
+}
+
+// TestLoadOrStore verifies that LoadOrStore only stores into an absent key.
+func (s *MapSuite[K, V]) TestLoadOrStore(t *testing.T) {
+	m := s.NewMap()
+	value, loaded := m.LoadOrStore(s.Key1, s.Val1)
+	assert.False(t, loaded)
+	assert.True(t, s.Equal(s.Val1, value))
+
+	value, loaded = m.LoadOrStore(s.Key1, s.Val2)
+	assert.True(t, loaded)
+	assert.True(t, s.Equal(s.Val1, value))
+	assert.Equal(t, 1, m.Len())
+}
+
+// TestGetOrCompute verifies that GetOrCompute only computes absent keys, once for concurrent
+// callers.
+func (s *MapSuite[K, V]) TestGetOrCompute(t *testing.T) {
+	m := s.NewMap()
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			value, _ := m.GetOrCompute(s.Key1, func() V {
+				calls.Add(1)
+				time.Sleep(time.Millisecond)
+				return s.Val1
+			})
+			assert.True(t, s.Equal(s.Val1, value))
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	value, loaded := m.GetOrCompute(s.Key1, func() V { return s.Val2 })
+	assert.True(t, loaded)
+	assert.True(t, s.Equal(s.Val1, value))
+	value, loaded = m.GetOrCompute(s.Key2, func() V { return s.Val2 })
+	assert.False(t, loaded)
+	assert.True(t, s.Equal(s.Val2, value))
+	assert.Equal(t, 2, m.Len())
+}
+
+// TestLoadAndDelete verifies that LoadAndDelete removes and returns present keys only.
+func (s *MapSuite[K, V]) TestLoadAndDelete(t *testing.T) {
+	m := s.NewMap()
+	var zero V
+	value, loaded := m.LoadAndDelete(s.Key1)
+	assert.False(t, loaded)
+	assert.Equal(t, zero, value)
+
+	m.Set(s.Key1, s.Val1)
+	value, loaded = m.LoadAndDelete(s.Key1)
+	assert.True(t, loaded)
+	assert.True(t, s.Equal(s.Val1, value))
+	assert.Equal(t, 0, m.Len())
+}
+
+// TestBulkOperations verifies GetAll, GetMany and SetMany.
+func (s *MapSuite[K, V]) TestBulkOperations(t *testing.T) {
+	m := s.NewMap()
+	m.SetMany(map[K]V{s.Key1: s.Val1, s.Key2: s.Val2})
+	assert.Equal(t, 2, m.Len())
+
+	all := m.GetAll()
+	assert.Len(t, all, 2)
+	assert.True(t, s.Equal(s.Val1, all[s.Key1]))
+	assert.True(t, s.Equal(s.Val2, all[s.Key2]))
+
+	// The returned map is a copy
+	delete(all, s.Key1)
+	assert.Equal(t, 2, m.Len())
+
+	many := m.GetMany([]K{s.Key1, s.Key3})
+	assert.Len(t, many, 1)
+	assert.True(t, s.Equal(s.Val1, many[s.Key1]))
+}
+
+// TestDeleteMany verifies that DeleteMany removes the keys and counts the present ones only.
+func (s *MapSuite[K, V]) TestDeleteMany(t *testing.T) {
+	m := s.NewMap()
+	m.Set(s.Key1, s.Val1)
+	m.Set(s.Key2, s.Val2)
+	assert.Equal(t, 1, m.DeleteMany([]K{s.Key1, s.Key3, s.Key1}))
+	assert.Equal(t, 1, m.Len())
+	_, ok := m.Get(s.Key1)
+	assert.False(t, ok)
+	assert.Zero(t, m.DeleteMany(nil))
+}
+
+// TestUpdateMany verifies that UpdateMany stores or deletes keys per the result of f.
+func (s *MapSuite[K, V]) TestUpdateMany(t *testing.T) {
+	m := s.NewMap()
+	m.Set(s.Key1, s.Val1)
+	m.Set(s.Key2, s.Val2)
+	m.UpdateMany([]K{s.Key1, s.Key2, s.Key3}, func(key K, value V, loaded bool) (V, bool) {
+		switch key {
+		case s.Key1:
+			assert.True(t, loaded)
+			assert.True(t, s.Equal(s.Val1, value))
+			return s.Val3, true
+		case s.Key2:
+			return value, false
+		}
+		assert.False(t, loaded)
+		return s.Val2, true
+	})
+	assert.Equal(t, 2, m.Len())
+	value, _ := m.Get(s.Key1)
+	assert.True(t, s.Equal(s.Val3, value))
+	_, ok := m.Get(s.Key2)
+	assert.False(t, ok)
+	value, _ = m.Get(s.Key3)
+	assert.True(t, s.Equal(s.Val2, value))
+}
+
+// TestEquals verifies Equals against maps with the same and with different contents.
+func (s *MapSuite[K, V]) TestEquals(t *testing.T) {
+	a, b := s.NewMap(), s.NewMap()
+	assert.True(t, a.Equals(b, s.Equal))
+
+	a.Set(s.Key1, s.Val1)
+	assert.False(t, a.Equals(b, s.Equal))
+	b.Set(s.Key1, s.Val1)
+	assert.True(t, a.Equals(b, s.Equal))
+	b.Set(s.Key1, s.Val2)
+	assert.False(t, a.Equals(b, s.Equal))
+}
+
+// TestRange verifies Range contents and early termination.
+func (s *MapSuite[K, V]) TestRange(t *testing.T) {
+	m := s.NewMap()
+	m.Set(s.Key1, s.Val1)
+	m.Set(s.Key2, s.Val2)
+
+	visited := map[K]V{}
+	m.Range(func(key K, value V) bool {
+		visited[key] = value
+		return true
+	})
+	assert.Len(t, visited, 2)
+	assert.True(t, s.Equal(s.Val2, visited[s.Key2]))
+
+	count := 0
+	m.Range(func(K, V) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
+
+// TestIterators verifies All, Keys and Values contents, early termination, and that the map may
+// be modified during iteration.
+func (s *MapSuite[K, V]) TestIterators(t *testing.T) {
+	m := s.NewMap()
+	m.Set(s.Key1, s.Val1)
+	m.Set(s.Key2, s.Val2)
+
+	all := map[K]V{}
+	for key, value := range m.All() {
+		all[key] = value
+		m.Set(s.Key3, s.Val3) // mutations must not deadlock or break the iteration
+	}
+	assert.True(t, s.Equal(s.Val1, all[s.Key1]))
+	assert.True(t, s.Equal(s.Val2, all[s.Key2]))
+	assert.Equal(t, 3, m.Len())
+
+	var keys []K
+	for key := range m.Keys() {
+		keys = append(keys, key)
+	}
+	assert.ElementsMatch(t, []K{s.Key1, s.Key2, s.Key3}, keys)
+	values := 0
+	for range m.Values() {
+		values++
+	}
+	assert.Equal(t, 3, values)
+
+	count := 0
+	for range m.All() {
+		count++
+		break
+	}
+	for range m.Keys() {
+		count++
+		break
+	}
+	for range m.Values() {
+		count++
+		break
+	}
+	assert.Equal(t, 3, count)
+}
+
+// TestConcurrentAccess verifies that concurrent writers on distinct keys lose no writes.
+func (s *MapSuite[K, V]) TestConcurrentAccess(t *testing.T) {
+	m := s.NewMap()
+	keys := []K{s.Key1, s.Key2, s.Key3}
+	values := []V{s.Val1, s.Val2, s.Val3}
+
+	var wg sync.WaitGroup
+	for i := range keys {
+		wg.Go(func() {
+			for range 100 {
+				m.Set(keys[i], values[i])
+				m.Get(keys[(i+1)%len(keys)])
+				m.Range(func(K, V) bool { return true })
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, len(keys), m.Len())
+	for i, key := range keys {
+		value, ok := m.Get(key)
+		assert.True(t, ok)
+		assert.True(t, s.Equal(values[i], value))
+	}
+}
@@ -0,0 +1,57 @@
+package threadsafetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jkbrsn/threadsafe"
+)
+
+func TestMapSuite(t *testing.T) {
+	equal := func(a, b int) bool { return a == b }
+	implementations := []struct {
+		name   string
+		newMap func() threadsafe.Map[string, int]
+	}{
+		{"MutexMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewMutexMap[string](equal)
+		}},
+		{"RWMutexMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewRWMutexMap[string](equal)
+		}},
+		{"SyncMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewSyncMap[string](equal)
+		}},
+		{"ImmutableMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewImmutableMap[string](equal)
+		}},
+		{"ShardedMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewShardedMap[string](4, equal)
+		}},
+		{"OrderedMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewOrderedMap[string](equal)
+		}},
+		{"SortedMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewSortedMap[string](equal)
+		}},
+		{"TTLMap", func() threadsafe.Map[string, int] {
+			return threadsafe.NewTTLMap[string](time.Hour, 0, equal)
+		}},
+	}
+
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			suite := &MapSuite[string, int]{
+				NewMap: impl.newMap,
+				Key1:   "key1",
+				Key2:   "key2",
+				Key3:   "key3",
+				Val1:   1,
+				Val2:   2,
+				Val3:   3,
+				Equal:  equal,
+			}
+			suite.Run(t)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package threadsafetest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+// PriorityQueueSuite is a conformance test suite for implementations of threadsafe.PriorityQueue.
+//
+// The suite expects a min-priority queue per Less, accepting duplicate items, with Range, All,
+// AllSorted and SliceSorted operating on snapshots of the queue. If the queue also implements
+// threadsafe.PriorityQueueIndexed, the contract of the indexed operations is checked as well.
+type PriorityQueueSuite[T any] struct {
+	// NewPQ returns a new, empty queue. It is called once per test.
+	NewPQ func() threadsafe.PriorityQueue[T]
+	// Less is the ordering the queue is expected to follow.
+	Less func(a, b T) bool
+	// Item1, Item2 and Item3 are sample items in strictly increasing order per Less.
+	Item1, Item2, Item3 T
+}
+
+// Run runs all tests of the suite as subtests of t.
+func (s *PriorityQueueSuite[T]) Run(t *testing.T) {
+	t.Run("EmptyQueue", s.TestEmptyQueue)
+	t.Run("Ordering", s.TestOrdering)
+	t.Run("SortedViews", s.TestSortedViews)
+	t.Run("PopIf", s.TestPopIf)
+	t.Run("Clear", s.TestClear)
+	t.Run("Range", s.TestRange)
+	t.Run("AllIterator", s.TestAllIterator)
+	t.Run("IndexedOperations", s.TestIndexedOperations)
+	t.Run("ConcurrentPush", s.TestConcurrentPush)
+	t.Run("ConcurrentPushPop", s.TestConcurrentPushPop)
+}
+
+// TestEmptyQueue verifies the behavior of all read and remove operations on an empty queue.
+func (s *PriorityQueueSuite[T]) TestEmptyQueue(t *testing.T) {
+	pq := s.NewPQ()
+	var zero T
+
+	assert.Equal(t, 0, pq.Len())
+	item, ok := pq.Pop()
+	assert.False(t, ok)
+	assert.Equal(t, zero, item)
+	item, ok = pq.Peek()
+	assert.False(t, ok)
+	assert.Equal(t, zero, item)
+	_, ok = pq.PopIf(func(T) bool {
+		t.Error("PopIf called pred on an empty queue")
+		return true
+	})
+	assert.False(t, ok)
+	assert.Empty(t, pq.SliceSorted())
+	pq.Range(func(T) bool {
+		t.Error("Range called f on an empty queue")
+		return true
+	})
+	for range pq.All() {
+		t.Error("All yielded an item from an empty queue")
+	}
+	for range pq.AllSorted() {
+		t.Error("AllSorted yielded an item from an empty queue")
+	}
+	pq.Clear()
+	assert.Equal(t, 0, pq.Len())
+}
+
+// TestOrdering verifies that items come out in priority order, including duplicates.
+func (s *PriorityQueueSuite[T]) TestOrdering(t *testing.T) {
+	pq := s.NewPQ()
+	pq.Push(s.Item3, s.Item1)
+	pq.Push(s.Item2)
+	pq.Push(s.Item1)
+	assert.Equal(t, 4, pq.Len())
+
+	item, ok := pq.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, s.Item1, item)
+	assert.Equal(t, 4, pq.Len())
+
+	for i, want := range []T{s.Item1, s.Item1, s.Item2, s.Item3} {
+		item, ok := pq.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, want, item)
+		assert.Equal(t, 3-i, pq.Len())
+	}
+	_, ok = pq.Pop()
+	assert.False(t, ok)
+
+	// Pushing nothing is a no-op
+	pq.Push()
+	assert.Equal(t, 0, pq.Len())
+}
+
+// TestSortedViews verifies that AllSorted and SliceSorted follow pop order without modifying the
+// queue, and that AllSorted supports early termination.
+func (s *PriorityQueueSuite[T]) TestSortedViews(t *testing.T) {
+	pq := s.NewPQ()
+	pq.Push(s.Item2, s.Item3, s.Item1)
+
+	want := []T{s.Item1, s.Item2, s.Item3}
+	assert.Equal(t, want, pq.SliceSorted())
+	var sorted []T
+	for item := range pq.AllSorted() {
+		sorted = append(sorted, item)
+	}
+	assert.Equal(t, want, sorted)
+	assert.Equal(t, 3, pq.Len())
+
+	count := 0
+	for range pq.AllSorted() {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
+
+// TestPopIf verifies that PopIf only pops the minimum, and only when pred accepts it.
+func (s *PriorityQueueSuite[T]) TestPopIf(t *testing.T) {
+	pq := s.NewPQ()
+	pq.Push(s.Item2, s.Item1)
+
+	var seen []T
+	_, ok := pq.PopIf(func(min T) bool {
+		seen = append(seen, min)
+		return false
+	})
+	assert.False(t, ok)
+	assert.Equal(t, []T{s.Item1}, seen)
+	assert.Equal(t, 2, pq.Len())
+
+	item, ok := pq.PopIf(func(T) bool { return true })
+	assert.True(t, ok)
+	assert.Equal(t, s.Item1, item)
+	assert.Equal(t, 1, pq.Len())
+}
+
+// TestClear verifies that Clear removes all items and leaves a usable empty queue.
+func (s *PriorityQueueSuite[T]) TestClear(t *testing.T) {
+	pq := s.NewPQ()
+	pq.Push(s.Item1, s.Item2)
+	pq.Clear()
+	assert.Equal(t, 0, pq.Len())
+	_, ok := pq.Pop()
+	assert.False(t, ok)
+
+	pq.Push(s.Item3)
+	item, ok := pq.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, s.Item3, item)
+}
+
+// TestRange verifies Range contents, early termination and snapshot semantics.
+func (s *PriorityQueueSuite[T]) TestRange(t *testing.T) {
+	pq := s.NewPQ()
+	pq.Push(s.Item1, s.Item2, s.Item3)
+
+	var visited []T
+	pq.Range(func(item T) bool {
+		visited = append(visited, item)
+		if len(visited) == 1 {
+			pq.Push(s.Item1) // mutations must not affect the current iteration
+		}
+		return true
+	})
+	assert.ElementsMatch(t, []T{s.Item1, s.Item2, s.Item3}, visited)
+	assert.Equal(t, 4, pq.Len())
+
+	count := 0
+	pq.Range(func(T) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
+
+// TestAllIterator verifies All contents, early termination and snapshot semantics.
+func (s *PriorityQueueSuite[T]) TestAllIterator(t *testing.T) {
+	pq := s.NewPQ()
+	pq.Push(s.Item1, s.Item2, s.Item3)
+
+	var visited []T
+	for item := range pq.All() {
+		visited = append(visited, item)
+		if len(visited) == 1 {
+			pq.Push(s.Item1) // mutations must not affect the current iteration
+		}
+	}
+	assert.ElementsMatch(t, []T{s.Item1, s.Item2, s.Item3}, visited)
+	assert.Equal(t, 4, pq.Len())
+
+	count := 0
+	for range pq.All() {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
+
+// TestIndexedOperations verifies the contract of Find, Fix, RemoveAt and UpdateAt. It is skipped
+// for queues not implementing threadsafe.PriorityQueueIndexed.
+func (s *PriorityQueueSuite[T]) TestIndexedOperations(t *testing.T) {
+	pq, ok := s.NewPQ().(threadsafe.PriorityQueueIndexed[T])
+	if !ok {
+		t.Skip("queue does not implement PriorityQueueIndexed")
+	}
+	pq.Push(s.Item1, s.Item2, s.Item3)
+	is := func(want T) func(T) bool {
+		return func(item T) bool { return !s.Less(item, want) && !s.Less(want, item) }
+	}
+
+	// Out-of-range indices are rejected
+	_, ok = pq.RemoveAt(-1)
+	assert.False(t, ok)
+	_, ok = pq.RemoveAt(pq.Len())
+	assert.False(t, ok)
+	assert.False(t, pq.UpdateAt(pq.Len(), s.Item1))
+	pq.Fix(-1)
+	pq.Fix(pq.Len())
+	assert.Equal(t, 3, pq.Len())
+
+	// Moving the maximum to the front through UpdateAt
+	_, i, ok := pq.Find(is(s.Item3))
+	assert.True(t, ok)
+	assert.True(t, pq.UpdateAt(i, s.Item1))
+	assert.Equal(t, []T{s.Item1, s.Item1, s.Item2}, pq.SliceSorted())
+
+	// Removing by index
+	item, i, ok := pq.Find(is(s.Item2))
+	assert.True(t, ok)
+	assert.Equal(t, s.Item2, item)
+	removed, ok := pq.RemoveAt(i)
+	assert.True(t, ok)
+	assert.Equal(t, s.Item2, removed)
+	assert.Equal(t, 2, pq.Len())
+
+	_, i, ok = pq.Find(is(s.Item3))
+	assert.False(t, ok)
+	assert.Equal(t, -1, i)
+
+	// Fix on a valid index keeps the ordering
+	pq.Fix(0)
+	assert.Equal(t, []T{s.Item1, s.Item1}, pq.SliceSorted())
+}
+
+// TestConcurrentPush verifies that no items are lost under concurrent pushes, and that the queue
+// pops them in order afterwards.
+func (s *PriorityQueueSuite[T]) TestConcurrentPush(t *testing.T) {
+	const goroutines = 8
+	const perGoroutine = 100
+	pq := s.NewPQ()
+
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for range perGoroutine {
+				pq.Push(s.Item3, s.Item1, s.Item2)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, goroutines*perGoroutine*3, pq.Len())
+
+	prev, _ := pq.Pop()
+	for pq.Len() > 0 {
+		item, _ := pq.Pop()
+		if !assert.False(t, s.Less(item, prev), "items popped out of order") {
+			return
+		}
+		prev = item
+	}
+}
+
+// TestConcurrentPushPop verifies that every pushed item is popped exactly once with concurrent
+// producers and consumers.
+func (s *PriorityQueueSuite[T]) TestConcurrentPushPop(t *testing.T) {
+	const producers = 4
+	const consumers = 4
+	const perProducer = 250
+	pq := s.NewPQ()
+
+	var popped sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	done := make(chan struct{})
+	for range consumers {
+		popped.Go(func() {
+			for {
+				if _, ok := pq.Pop(); ok {
+					mu.Lock()
+					total++
+					mu.Unlock()
+					continue
+				}
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		})
+	}
+
+	var pushed sync.WaitGroup
+	for range producers {
+		pushed.Go(func() {
+			for range perProducer {
+				pq.Push(s.Item2)
+			}
+		})
+	}
+	pushed.Wait()
+	close(done)
+	popped.Wait()
+
+	// Consumers may exit before popping the remaining items.
+	for pq.Len() > 0 {
+		_, _ = pq.Pop()
+		total++
+	}
+	assert.Equal(t, producers*perProducer, total)
+}
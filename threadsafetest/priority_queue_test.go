@@ -0,0 +1,59 @@
+package threadsafetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jkbrsn/threadsafe"
+)
+
+func TestPriorityQueueSuite(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	implementations := []struct {
+		name  string
+		newPQ func() threadsafe.PriorityQueue[int]
+	}{
+		{"CorePriorityQueue", func() threadsafe.PriorityQueue[int] {
+			return threadsafe.NewCorePriorityQueue(less)
+		}},
+		{"IndexedPriorityQueue", func() threadsafe.PriorityQueue[int] {
+			return threadsafe.NewIndexedPriorityQueue(less, nil)
+		}},
+		{"OrderedPriorityQueue", func() threadsafe.PriorityQueue[int] {
+			return &threadsafe.OrderedPriorityQueue[int]{}
+		}},
+		{"LazyPriorityQueue", func() threadsafe.PriorityQueue[int] {
+			return threadsafe.NewLazyPriorityQueue(less, func(x int) int { return x })
+		}},
+		{"PairingPriorityQueue", func() threadsafe.PriorityQueue[int] {
+			return threadsafe.NewPairingPriorityQueue(less)
+		}},
+		{"BoundedPriorityQueue", func() threadsafe.PriorityQueue[int] {
+			return threadsafe.NewBoundedPriorityQueue(less, 1<<16)
+		}},
+		{"AgingPriorityQueue", func() threadsafe.PriorityQueue[int] {
+			return threadsafe.NewAgingPriorityQueue(func(x int, _ time.Duration) float64 {
+				return float64(x)
+			}, time.Hour)
+		}},
+		{"SkipListPriorityQueue", func() threadsafe.PriorityQueue[int] {
+			return threadsafe.NewSkipListPriorityQueue(less)
+		}},
+		{"RWMutexHeap", func() threadsafe.PriorityQueue[int] {
+			return threadsafe.HeapAsPriorityQueue[int](threadsafe.NewMinHeap[int]())
+		}},
+	}
+
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			suite := &PriorityQueueSuite[int]{
+				NewPQ: impl.newPQ,
+				Less:  less,
+				Item1: 1,
+				Item2: 2,
+				Item3: 3,
+			}
+			suite.Run(t)
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package threadsafetest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+// QueueSuite is a conformance test suite for implementations of threadsafe.Queue.
+//
+// The suite expects a FIFO queue accepting duplicate items, with Range, All and Slice operating
+// on snapshots of the queue.
+type QueueSuite[T any] struct {
+	// NewQueue returns a new, empty queue. It is called once per test.
+	NewQueue func() threadsafe.Queue[T]
+	// Item1, Item2 and Item3 are distinct sample items.
+	Item1, Item2, Item3 T
+}
+
+// Run runs all tests of the suite as subtests of t.
+func (s *QueueSuite[T]) Run(t *testing.T) {
+	t.Run("EmptyQueue", s.TestEmptyQueue)
+	t.Run("Ordering", s.TestOrdering)
+	t.Run("PeekNAndPeekAt", s.TestPeekNAndPeekAt)
+	t.Run("Drain", s.TestDrain)
+	t.Run("Clear", s.TestClear)
+	t.Run("Equals", s.TestEquals)
+	t.Run("Range", s.TestRange)
+	t.Run("AllIterator", s.TestAllIterator)
+	t.Run("ConcurrentPush", s.TestConcurrentPush)
+	t.Run("ConcurrentPushPop", s.TestConcurrentPushPop)
+}
+
+// TestEmptyQueue verifies the behavior of all read and remove operations on an empty queue.
+func (s *QueueSuite[T]) TestEmptyQueue(t *testing.T) {
+	q := s.NewQueue()
+	var zero T
+
+	assert.Equal(t, 0, q.Len())
+	item, ok := q.Pop()
+	assert.False(t, ok)
+	assert.Equal(t, zero, item)
+	item, ok = q.Peek()
+	assert.False(t, ok)
+	assert.Equal(t, zero, item)
+	_, ok = q.PeekAt(0)
+	assert.False(t, ok)
+	assert.Empty(t, q.PeekN(1))
+	assert.Empty(t, q.Slice())
+	assert.Empty(t, q.Drain())
+	q.Range(func(T) bool {
+		t.Error("Range called f on an empty queue")
+		return true
+	})
+	for range q.All() {
+		t.Error("All yielded an item from an empty queue")
+	}
+	q.Clear()
+	assert.Equal(t, 0, q.Len())
+}
+
+// TestOrdering verifies that items come out in FIFO order, including duplicates.
+func (s *QueueSuite[T]) TestOrdering(t *testing.T) {
+	q := s.NewQueue()
+	q.Push(s.Item1, s.Item2)
+	q.Push(s.Item3)
+	q.Push(s.Item1)
+	assert.Equal(t, 4, q.Len())
+
+	item, ok := q.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, s.Item1, item)
+	assert.Equal(t, 4, q.Len())
+
+	for i, want := range []T{s.Item1, s.Item2, s.Item3, s.Item1} {
+		item, ok := q.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, want, item)
+		assert.Equal(t, 3-i, q.Len())
+	}
+	_, ok = q.Pop()
+	assert.False(t, ok)
+
+	// Pushing nothing is a no-op
+	q.Push()
+	assert.Equal(t, 0, q.Len())
+}
+
+// TestPeekNAndPeekAt verifies the positional read accessors.
+func (s *QueueSuite[T]) TestPeekNAndPeekAt(t *testing.T) {
+	q := s.NewQueue()
+	q.Push(s.Item1, s.Item2, s.Item3)
+
+	assert.Equal(t, []T{s.Item1, s.Item2}, q.PeekN(2))
+	assert.Equal(t, []T{s.Item1, s.Item2, s.Item3}, q.PeekN(10))
+	assert.Empty(t, q.PeekN(0))
+	assert.Empty(t, q.PeekN(-1))
+
+	for i, want := range []T{s.Item1, s.Item2, s.Item3} {
+		item, ok := q.PeekAt(i)
+		assert.True(t, ok)
+		assert.Equal(t, want, item)
+	}
+	_, ok := q.PeekAt(3)
+	assert.False(t, ok)
+	_, ok = q.PeekAt(-1)
+	assert.False(t, ok)
+
+	_, _ = q.Pop()
+	item, ok := q.PeekAt(0)
+	assert.True(t, ok)
+	assert.Equal(t, s.Item2, item)
+	assert.Equal(t, 2, q.Len())
+}
+
+// TestDrain verifies that Drain returns all items in order and leaves a usable empty queue.
+func (s *QueueSuite[T]) TestDrain(t *testing.T) {
+	q := s.NewQueue()
+	q.Push(s.Item1, s.Item2, s.Item3)
+	_, _ = q.Pop()
+
+	assert.Equal(t, []T{s.Item2, s.Item3}, q.Drain())
+	assert.Equal(t, 0, q.Len())
+
+	q.Push(s.Item1)
+	item, ok := q.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, s.Item1, item)
+}
+
+// TestClear verifies that Clear removes all items and leaves a usable empty queue.
+func (s *QueueSuite[T]) TestClear(t *testing.T) {
+	q := s.NewQueue()
+	q.Push(s.Item1, s.Item2)
+	q.Clear()
+	assert.Equal(t, 0, q.Len())
+	_, ok := q.Pop()
+	assert.False(t, ok)
+
+	q.Push(s.Item3)
+	assert.Equal(t, []T{s.Item3}, q.Slice())
+}
+
+// TestEquals verifies that Equals compares contents and order, both against a queue of the same
+// implementation and against another implementation.
+func (s *QueueSuite[T]) TestEquals(t *testing.T) {
+	eq := func(a, b T) bool { return reflect.DeepEqual(a, b) }
+	q := s.NewQueue()
+	same := s.NewQueue()
+	other := threadsafe.NewRWMutexQueue[T]()
+
+	assert.True(t, q.Equals(same, eq))
+	assert.True(t, q.Equals(other, eq))
+
+	q.Push(s.Item1, s.Item2)
+	same.Push(s.Item1, s.Item2)
+	other.Push(s.Item1)
+	assert.True(t, q.Equals(same, eq))
+	assert.True(t, same.Equals(q, eq))
+	assert.False(t, q.Equals(other, eq))
+
+	other.Push(s.Item2)
+	assert.True(t, q.Equals(other, eq))
+
+	same.Clear()
+	same.Push(s.Item2, s.Item1)
+	assert.False(t, q.Equals(same, eq))
+}
+
+// TestRange verifies Range ordering, early termination and snapshot semantics.
+func (s *QueueSuite[T]) TestRange(t *testing.T) {
+	q := s.NewQueue()
+	q.Push(s.Item1, s.Item2, s.Item3)
+
+	var visited []T
+	q.Range(func(item T) bool {
+		visited = append(visited, item)
+		if len(visited) == 1 {
+			q.Push(s.Item1) // mutations must not affect the current iteration
+		}
+		return true
+	})
+	assert.Equal(t, []T{s.Item1, s.Item2, s.Item3}, visited)
+	assert.Equal(t, 4, q.Len())
+
+	count := 0
+	q.Range(func(T) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
+
+// TestAllIterator verifies All ordering, early termination and snapshot semantics.
+func (s *QueueSuite[T]) TestAllIterator(t *testing.T) {
+	q := s.NewQueue()
+	q.Push(s.Item1, s.Item2, s.Item3)
+
+	var visited []T
+	for item := range q.All() {
+		visited = append(visited, item)
+		if len(visited) == 1 {
+			q.Push(s.Item1) // mutations must not affect the current iteration
+		}
+	}
+	assert.Equal(t, []T{s.Item1, s.Item2, s.Item3}, visited)
+	assert.Equal(t, 4, q.Len())
+
+	count := 0
+	for range q.All() {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
+
+// TestConcurrentPush verifies that no items are lost under concurrent pushes, and that the
+// items pushed by each goroutine keep their relative order.
+func (s *QueueSuite[T]) TestConcurrentPush(t *testing.T) {
+	const goroutines = 8
+	const perGoroutine = 100
+	q := s.NewQueue()
+
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Go(func() {
+			for range perGoroutine {
+				q.Push(s.Item1, s.Item2)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, goroutines*perGoroutine*2, q.Len())
+
+	// Each batch was pushed atomically, so items alternate.
+	for i, item := range q.Drain() {
+		want := s.Item1
+		if i%2 == 1 {
+			want = s.Item2
+		}
+		if !assert.Equal(t, want, item) {
+			return
+		}
+	}
+}
+
+// TestConcurrentPushPop verifies that every pushed item is popped exactly once with concurrent
+// producers and consumers.
+func (s *QueueSuite[T]) TestConcurrentPushPop(t *testing.T) {
+	const producers = 4
+	const consumers = 4
+	const perProducer = 250
+	q := s.NewQueue()
+
+	var popped sync.WaitGroup
+	var mu sync.Mutex
+	total := 0
+	done := make(chan struct{})
+	for range consumers {
+		popped.Go(func() {
+			for {
+				if _, ok := q.Pop(); ok {
+					mu.Lock()
+					total++
+					mu.Unlock()
+					continue
+				}
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		})
+	}
+
+	var pushed sync.WaitGroup
+	for range producers {
+		pushed.Go(func() {
+			for range perProducer {
+				q.Push(s.Item3)
+			}
+		})
+	}
+	pushed.Wait()
+	close(done)
+	popped.Wait()
+
+	// Consumers may exit before popping the remaining items.
+	total += len(q.Drain())
+	assert.Equal(t, producers*perProducer, total)
+}
@@ -0,0 +1,64 @@
+package threadsafetest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueSuite(t *testing.T) {
+	implementations := []struct {
+		name     string
+		newQueue func(t *testing.T) threadsafe.Queue[int]
+	}{
+		{"RWMutexQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewRWMutexQueue[int]()
+		}},
+		{"BlockingQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewBlockingQueue[int](0)
+		}},
+		{"BoundedQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewBoundedQueue[int](1<<16, threadsafe.OverflowReject)
+		}},
+		{"ExpiringQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewExpiringQueue[int](time.Hour, nil)
+		}},
+		{"SegmentedQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewSegmentedQueue[int]()
+		}},
+		{"RingQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewRingQueue[int](4)
+		}},
+		{"LanedQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewLanedQueue[int](3)
+		}},
+		{"WeightedQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewWeightedQueue[int](3, 1)
+		}},
+		{"CircularQueue", func(*testing.T) threadsafe.Queue[int] {
+			return threadsafe.NewCircularQueue[int](1 << 12)
+		}},
+		{"FileQueue", func(t *testing.T) threadsafe.Queue[int] {
+			path := filepath.Join(t.TempDir(), "queue.wal")
+			q, err := threadsafe.OpenFileQueue(path, threadsafe.JSONCodec[int]{})
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = q.Close() })
+			return q
+		}},
+	}
+
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			suite := &QueueSuite[int]{
+				NewQueue: func() threadsafe.Queue[int] { return impl.newQueue(t) },
+				Item1:    1,
+				Item2:    2,
+				Item3:    3,
+			}
+			suite.Run(t)
+		})
+	}
+}
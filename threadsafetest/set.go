@@ -0,0 +1,142 @@
+package threadsafetest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+// SetSuite is a conformance test suite for implementations of threadsafe.Set.
+//
+// The suite expects All and Range to tolerate mutations of the set during iteration.
+type SetSuite[T comparable] struct {
+	// NewSet returns a new, empty set. It is called once per test.
+	NewSet func() threadsafe.Set[T]
+	// Item1, Item2 and Item3 are distinct sample items.
+	Item1, Item2, Item3 T
+}
+
+// Run runs all tests of the suite as subtests of t.
+func (s *SetSuite[T]) Run(t *testing.T) {
+	t.Run("BasicOperations", s.TestBasicOperations)
+	t.Run("Clear", s.TestClear)
+	t.Run("Slice", s.TestSlice)
+	t.Run("Range", s.TestRange)
+	t.Run("AllIterator", s.TestAllIterator)
+	t.Run("ConcurrentAdd", s.TestConcurrentAdd)
+}
+
+// TestBasicOperations verifies Add, Delete, Has and Len, including their return values.
+func (s *SetSuite[T]) TestBasicOperations(t *testing.T) {
+	set := s.NewSet()
+	assert.Equal(t, 0, set.Len())
+	assert.False(t, set.Has(s.Item1))
+
+	assert.True(t, set.Add(s.Item1))
+	assert.True(t, set.Add(s.Item2))
+	assert.False(t, set.Add(s.Item1))
+	assert.Equal(t, 2, set.Len())
+	assert.True(t, set.Has(s.Item1))
+	assert.False(t, set.Has(s.Item3))
+
+	assert.True(t, set.Delete(s.Item1))
+	assert.False(t, set.Delete(s.Item1))
+	assert.False(t, set.Delete(s.Item3))
+	assert.Equal(t, 1, set.Len())
+	assert.False(t, set.Has(s.Item1))
+}
+
+// TestClear verifies that Clear removes all items and leaves a usable empty set.
+func (s *SetSuite[T]) TestClear(t *testing.T) {
+	set := s.NewSet()
+	set.Add(s.Item1)
+	set.Add(s.Item2)
+	set.Clear()
+	assert.Equal(t, 0, set.Len())
+	assert.False(t, set.Has(s.Item1))
+	assert.True(t, set.Add(s.Item1))
+}
+
+// TestSlice verifies that Slice returns a copy of the items.
+func (s *SetSuite[T]) TestSlice(t *testing.T) {
+	set := s.NewSet()
+	assert.Empty(t, set.Slice())
+	set.Add(s.Item1)
+	set.Add(s.Item2)
+	items := set.Slice()
+	assert.ElementsMatch(t, []T{s.Item1, s.Item2}, items)
+	items[0] = s.Item3
+	assert.False(t, set.Has(s.Item3))
+}
+
+// TestRange verifies Range contents, early termination and mutation during iteration.
+func (s *SetSuite[T]) TestRange(t *testing.T) {
+	set := s.NewSet()
+	set.Add(s.Item1)
+	set.Add(s.Item2)
+
+	var visited []T
+	set.Range(func(item T) bool {
+		visited = append(visited, item)
+		return true
+	})
+	assert.ElementsMatch(t, []T{s.Item1, s.Item2}, visited)
+
+	count := 0
+	set.Range(func(T) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
+
+// TestAllIterator verifies All contents, early termination and mutation during iteration.
+func (s *SetSuite[T]) TestAllIterator(t *testing.T) {
+	set := s.NewSet()
+	set.Add(s.Item1)
+	set.Add(s.Item2)
+
+	seen := map[T]bool{}
+	for item := range set.All() {
+		seen[item] = true
+		set.Add(s.Item3) // mutations must not deadlock or break the iteration
+	}
+	assert.True(t, seen[s.Item1])
+	assert.True(t, seen[s.Item2])
+	assert.Equal(t, 3, set.Len())
+
+	count := 0
+	for range set.All() {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
+
+// TestConcurrentAdd verifies that each item is reported as added exactly once under concurrent
+// adds of the same items.
+func (s *SetSuite[T]) TestConcurrentAdd(t *testing.T) {
+	set := s.NewSet()
+	items := []T{s.Item1, s.Item2, s.Item3}
+
+	var mu sync.Mutex
+	added := 0
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for _, item := range items {
+				if set.Add(item) {
+					mu.Lock()
+					added++
+					mu.Unlock()
+				}
+				set.Has(item)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, len(items), added)
+	assert.Equal(t, len(items), set.Len())
+}
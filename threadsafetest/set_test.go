@@ -0,0 +1,33 @@
+package threadsafetest
+
+import (
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+)
+
+func TestSetSuite(t *testing.T) {
+	implementations := []struct {
+		name   string
+		newSet func() threadsafe.Set[string]
+	}{
+		{"RWMutexSet", func() threadsafe.Set[string] {
+			return threadsafe.NewRWMutexSet[string]()
+		}},
+		{"SyncMapSet", func() threadsafe.Set[string] {
+			return threadsafe.NewSyncMapSet[string]()
+		}},
+	}
+
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			suite := &SetSuite[string]{
+				NewSet: impl.newSet,
+				Item1:  "a",
+				Item2:  "b",
+				Item3:  "c",
+			}
+			suite.Run(t)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package threadsafetest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+// SliceSuite is a conformance test suite for implementations of threadsafe.Slice.
+//
+// The suite expects items to be kept in append order, with Peek and All operating on snapshots.
+type SliceSuite[T any] struct {
+	// NewSlice returns a new, empty slice. It is called once per test.
+	NewSlice func() threadsafe.Slice[T]
+	// Item1, Item2 and Item3 are distinct sample items.
+	Item1, Item2, Item3 T
+}
+
+// Run runs all tests of the suite as subtests of t.
+func (s *SliceSuite[T]) Run(t *testing.T) {
+	t.Run("AppendAndPeek", s.TestAppendAndPeek)
+	t.Run("Flush", s.TestFlush)
+	t.Run("AllIterator", s.TestAllIterator)
+	t.Run("ConcurrentAppend", s.TestConcurrentAppend)
+}
+
+// TestAppendAndPeek verifies that Append keeps items in order and that Peek returns a copy.
+func (s *SliceSuite[T]) TestAppendAndPeek(t *testing.T) {
+	slice := s.NewSlice()
+	assert.Equal(t, 0, slice.Len())
+	assert.Empty(t, slice.Peek())
+
+	slice.Append(s.Item1, s.Item2)
+	slice.Append(s.Item3)
+	slice.Append() // appending nothing is a no-op
+	assert.Equal(t, 3, slice.Len())
+	peeked := slice.Peek()
+	assert.Equal(t, []T{s.Item1, s.Item2, s.Item3}, peeked)
+
+	peeked[0] = s.Item3
+	assert.Equal(t, []T{s.Item1, s.Item2, s.Item3}, slice.Peek())
+}
+
+// TestFlush verifies that Flush returns the items and leaves a usable empty slice.
+func (s *SliceSuite[T]) TestFlush(t *testing.T) {
+	slice := s.NewSlice()
+	assert.Empty(t, slice.Flush())
+
+	slice.Append(s.Item1, s.Item2)
+	assert.Equal(t, []T{s.Item1, s.Item2}, slice.Flush())
+	assert.Equal(t, 0, slice.Len())
+
+	slice.Append(s.Item3)
+	assert.Equal(t, []T{s.Item3}, slice.Peek())
+}
+
+// TestAllIterator verifies All contents, early termination and mutation during iteration.
+func (s *SliceSuite[T]) TestAllIterator(t *testing.T) {
+	slice := s.NewSlice()
+	slice.Append(s.Item1, s.Item2)
+
+	var visited []T
+	for item := range slice.All() {
+		visited = append(visited, item)
+		slice.Append(s.Item3) // mutations must not deadlock or affect the iteration
+	}
+	assert.Equal(t, []T{s.Item1, s.Item2}, visited)
+	assert.Equal(t, 4, slice.Len())
+
+	count := 0
+	for range slice.All() {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
+
+// TestConcurrentAppend verifies that no items are lost under concurrent appends and flushes.
+func (s *SliceSuite[T]) TestConcurrentAppend(t *testing.T) {
+	const goroutines = 8
+	const perGoroutine = 100
+	slice := s.NewSlice()
+
+	var mu sync.Mutex
+	flushed := 0
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Go(func() {
+			for i := range perGoroutine {
+				slice.Append(s.Item1)
+				if g == 0 && i%10 == 0 {
+					n := len(slice.Flush())
+					mu.Lock()
+					flushed += n
+					mu.Unlock()
+				}
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, goroutines*perGoroutine, flushed+len(slice.Flush()))
+}
@@ -0,0 +1,33 @@
+package threadsafetest
+
+import (
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+)
+
+func TestSliceSuite(t *testing.T) {
+	implementations := []struct {
+		name     string
+		newSlice func() threadsafe.Slice[int]
+	}{
+		{"MutexSlice", func() threadsafe.Slice[int] {
+			return threadsafe.NewMutexSlice[int](0)
+		}},
+		{"RWMutexSlice", func() threadsafe.Slice[int] {
+			return threadsafe.NewRWMutexSlice[int](0)
+		}},
+	}
+
+	for _, impl := range implementations {
+		t.Run(impl.name, func(t *testing.T) {
+			suite := &SliceSuite[int]{
+				NewSlice: impl.newSlice,
+				Item1:    1,
+				Item2:    2,
+				Item3:    3,
+			}
+			suite.Run(t)
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package threadsafetest
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+// StressConfig configures a stress run. Non-positive values are coerced to their defaults.
+type StressConfig struct {
+	// Goroutines is the number of goroutines running operations concurrently. Defaults to 8.
+	Goroutines int
+	// Ops is the number of operations run by each goroutine. Defaults to 1000.
+	Ops int
+	// Seed seeds the random choice of operations. Each goroutine derives its own stream from it,
+	// so a run is reproducible up to scheduling.
+	Seed uint64
+}
+
+// StressOp is an operation of a stress run, picked at random in proportion to its weight.
+type StressOp[C any] struct {
+	// Name identifies the operation in the counts returned by Stress.
+	Name string
+	// Weight is the relative frequency of the operation, coerced to at least 1.
+	Weight int
+	// Run performs the operation on c from goroutine g, using r for any random choice. Failed
+	// assertions must be reported with the non-fatal methods of testing.TB, such as Errorf.
+	Run func(c C, g int, r *rand.Rand)
+}
+
+// Stress runs a random mix of ops against c from cfg.Goroutines goroutines, each running cfg.Ops
+// operations, and returns how many times each operation ran once all goroutines are done. Since
+// the counts are only returned after the run, they can be used for exact assertions on the final
+// state of c.
+func Stress[C any](t testing.TB, c C, cfg StressConfig, ops ...StressOp[C]) map[string]int {
+	t.Helper()
+	cfg = cfg.withDefaults()
+	if len(ops) == 0 {
+		return map[string]int{}
+	}
+	total := 0
+	for _, op := range ops {
+		total += max(op.Weight, 1)
+	}
+
+	counts := make([][]int, cfg.Goroutines)
+	var wg sync.WaitGroup
+	for g := range cfg.Goroutines {
+		counts[g] = make([]int, len(ops))
+		wg.Go(func() {
+			r := rand.New(rand.NewPCG(cfg.Seed, uint64(g)))
+			for range cfg.Ops {
+				i := pickOp(ops, r.IntN(total))
+				ops[i].Run(c, g, r)
+				counts[g][i]++
+			}
+		})
+	}
+	wg.Wait()
+
+	merged := make(map[string]int, len(ops))
+	for _, perG := range counts {
+		for i, n := range perG {
+			merged[ops[i].Name] += n
+		}
+	}
+	return merged
+}
+
+// StressMap runs a mix of writes, reads and iterations against m from concurrent goroutines. Each
+// goroutine writes to its own range of keys and checks every read against a local model, while
+// iterations span all keys. Once done, the contents of m are checked against the merged models.
+// Maps must compare values with ==, as CompareAndSwap is part of the mix.
+func StressMap(t testing.TB, m threadsafe.Map[int, int], cfg StressConfig) {
+	t.Helper()
+	cfg = cfg.withDefaults()
+	const keysPerGoroutine = 64
+	models := make([]map[int]int, cfg.Goroutines)
+	for g := range models {
+		models[g] = map[int]int{}
+	}
+	key := func(g int, r *rand.Rand) int { return g*keysPerGoroutine + r.IntN(keysPerGoroutine) }
+	check := func(g, k, got int, ok bool) {
+		want, exists := models[g][k]
+		if ok != exists || got != want {
+			t.Errorf("key %d: got (%d, %t), want (%d, %t)", k, got, ok, want, exists)
+		}
+	}
+
+	Stress(t, m, cfg,
+		StressOp[threadsafe.Map[int, int]]{Name: "Set", Weight: 4,
+			Run: func(m threadsafe.Map[int, int], g int, r *rand.Rand) {
+				k, v := key(g, r), r.Int()
+				m.Set(k, v)
+				models[g][k] = v
+			}},
+		StressOp[threadsafe.Map[int, int]]{Name: "Get", Weight: 8,
+			Run: func(m threadsafe.Map[int, int], g int, r *rand.Rand) {
+				k := key(g, r)
+				v, ok := m.Get(k)
+				check(g, k, v, ok)
+			}},
+		StressOp[threadsafe.Map[int, int]]{Name: "Delete", Weight: 2,
+			Run: func(m threadsafe.Map[int, int], g int, r *rand.Rand) {
+				k := key(g, r)
+				m.Delete(k)
+				delete(models[g], k)
+			}},
+		StressOp[threadsafe.Map[int, int]]{Name: "LoadOrStore", Weight: 2,
+			Run: func(m threadsafe.Map[int, int], g int, r *rand.Rand) {
+				k, v := key(g, r), r.Int()
+				want, exists := models[g][k]
+				if !exists {
+					want = v
+				}
+				got, loaded := m.LoadOrStore(k, v)
+				if loaded != exists || got != want {
+					t.Errorf("LoadOrStore %d: got (%d, %t), want (%d, %t)",
+						k, got, loaded, want, exists)
+				}
+				models[g][k] = want
+			}},
+		StressOp[threadsafe.Map[int, int]]{Name: "CompareAndSwap", Weight: 2,
+			Run: func(m threadsafe.Map[int, int], g int, r *rand.Rand) {
+				k, v := key(g, r), r.Int()
+				old, exists := models[g][k]
+				if swapped := m.CompareAndSwap(k, old, v); swapped != exists {
+					t.Errorf("CompareAndSwap %d: got %t, want %t", k, swapped, exists)
+				}
+				if exists {
+					models[g][k] = v
+				}
+			}},
+		StressOp[threadsafe.Map[int, int]]{Name: "Range", Weight: 1,
+			Run: func(m threadsafe.Map[int, int], _ int, _ *rand.Rand) {
+				m.Range(func(int, int) bool { return true })
+				m.Len()
+			}},
+	)
+
+	want := map[int]int{}
+	for _, model := range models {
+		for k, v := range model {
+			want[k] = v
+		}
+	}
+	assert.Equal(t, want, m.GetAll())
+}
+
+// StressSet runs a mix of adds, deletes, lookups and iterations against s from concurrent
+// goroutines. Each goroutine works on its own range of items and checks every result against a
+// local model. Once done, the contents of s are checked against the merged models.
+func StressSet(t testing.TB, s threadsafe.Set[int], cfg StressConfig) {
+	t.Helper()
+	cfg = cfg.withDefaults()
+	const itemsPerGoroutine = 64
+	models := make([]map[int]bool, cfg.Goroutines)
+	for g := range models {
+		models[g] = map[int]bool{}
+	}
+	item := func(g int, r *rand.Rand) int { return g*itemsPerGoroutine + r.IntN(itemsPerGoroutine) }
+
+	Stress(t, s, cfg,
+		StressOp[threadsafe.Set[int]]{Name: "Add", Weight: 4,
+			Run: func(s threadsafe.Set[int], g int, r *rand.Rand) {
+				x := item(g, r)
+				if added := s.Add(x); added == models[g][x] {
+					t.Errorf("Add %d: got %t, want %t", x, added, !models[g][x])
+				}
+				models[g][x] = true
+			}},
+		StressOp[threadsafe.Set[int]]{Name: "Delete", Weight: 2,
+			Run: func(s threadsafe.Set[int], g int, r *rand.Rand) {
+				x := item(g, r)
+				if deleted := s.Delete(x); deleted != models[g][x] {
+					t.Errorf("Delete %d: got %t, want %t", x, deleted, models[g][x])
+				}
+				delete(models[g], x)
+			}},
+		StressOp[threadsafe.Set[int]]{Name: "Has", Weight: 8,
+			Run: func(s threadsafe.Set[int], g int, r *rand.Rand) {
+				x := item(g, r)
+				if has := s.Has(x); has != models[g][x] {
+					t.Errorf("Has %d: got %t, want %t", x, has, models[g][x])
+				}
+			}},
+		StressOp[threadsafe.Set[int]]{Name: "Range", Weight: 1,
+			Run: func(s threadsafe.Set[int], _ int, _ *rand.Rand) {
+				s.Range(func(int) bool { return true })
+				s.Len()
+			}},
+	)
+
+	var want []int
+	for _, model := range models {
+		for x := range model {
+			want = append(want, x)
+		}
+	}
+	assert.ElementsMatch(t, want, s.Slice())
+}
+
+// StressQueue runs concurrent producers and consumers against q. Each goroutine both pushes items
+// unique to it and pops, checking that items from any one producer are popped in the order they
+// were pushed. Once done, the queue is drained and every item is checked to have been popped
+// exactly once. The queue must be FIFO.
+func StressQueue(t testing.TB, q threadsafe.Queue[int], cfg StressConfig) {
+	t.Helper()
+	cfg = cfg.withDefaults()
+	pushed := make([]int, cfg.Goroutines)     // next item per producer
+	lastSeen := make([][]int, cfg.Goroutines) // per consumer, last item per producer
+	popped := make([][]int, cfg.Goroutines)   // per consumer
+	for g := range lastSeen {
+		lastSeen[g] = make([]int, cfg.Goroutines)
+		for p := range lastSeen[g] {
+			lastSeen[g][p] = -1
+		}
+	}
+	record := func(g, x int) {
+		p, seq := x/cfg.Ops, x%cfg.Ops
+		if seq <= lastSeen[g][p] {
+			t.Errorf("item %d of producer %d popped after item %d", seq, p, lastSeen[g][p])
+		}
+		lastSeen[g][p] = seq
+		popped[g] = append(popped[g], x)
+	}
+
+	Stress(t, q, cfg,
+		StressOp[threadsafe.Queue[int]]{Name: "Push", Weight: 2,
+			Run: func(q threadsafe.Queue[int], g int, _ *rand.Rand) {
+				if pushed[g] < cfg.Ops {
+					q.Push(g*cfg.Ops + pushed[g])
+					pushed[g]++
+				}
+			}},
+		StressOp[threadsafe.Queue[int]]{Name: "Pop", Weight: 2,
+			Run: func(q threadsafe.Queue[int], g int, _ *rand.Rand) {
+				if x, ok := q.Pop(); ok {
+					record(g, x)
+				}
+			}},
+		StressOp[threadsafe.Queue[int]]{Name: "Peek", Weight: 1,
+			Run: func(q threadsafe.Queue[int], _ int, _ *rand.Rand) {
+				q.Peek()
+				q.Len()
+			}},
+	)
+
+	want := make([]int, 0, cfg.Goroutines*cfg.Ops)
+	for p, n := range pushed {
+		for seq := range n {
+			want = append(want, p*cfg.Ops+seq)
+		}
+	}
+	got := slices.Concat(append(popped, q.Drain())...)
+	slices.Sort(got)
+	assert.Equal(t, want, got, "items not popped exactly once")
+}
+
+// StressSlice runs concurrent appends, peeks and flushes against s. Every goroutine appends items
+// unique to it, and every flush is checked to hold the items of each goroutine in append order.
+// Once done, the slice is flushed and every item is checked to have been flushed exactly once.
+func StressSlice(t testing.TB, s threadsafe.Slice[int], cfg StressConfig) {
+	t.Helper()
+	cfg = cfg.withDefaults()
+	appended := make([]int, cfg.Goroutines)
+	flushed := make([][]int, cfg.Goroutines)
+	checkOrder := func(items []int) {
+		last := map[int]int{}
+		for _, x := range items {
+			g, seq := x/cfg.Ops, x%cfg.Ops
+			if prev, ok := last[g]; ok && seq <= prev {
+				t.Errorf("item %d of goroutine %d flushed after item %d", seq, g, prev)
+			}
+			last[g] = seq
+		}
+	}
+
+	Stress(t, s, cfg,
+		StressOp[threadsafe.Slice[int]]{Name: "Append", Weight: 8,
+			Run: func(s threadsafe.Slice[int], g int, _ *rand.Rand) {
+				s.Append(g*cfg.Ops + appended[g])
+				appended[g]++
+			}},
+		StressOp[threadsafe.Slice[int]]{Name: "Peek", Weight: 2,
+			Run: func(s threadsafe.Slice[int], _ int, _ *rand.Rand) {
+				checkOrder(s.Peek())
+				s.Len()
+			}},
+		StressOp[threadsafe.Slice[int]]{Name: "Flush", Weight: 1,
+			Run: func(s threadsafe.Slice[int], g int, _ *rand.Rand) {
+				items := s.Flush()
+				checkOrder(items)
+				flushed[g] = append(flushed[g], items...)
+			}},
+	)
+
+	want := make([]int, 0, cfg.Goroutines*cfg.Ops)
+	for g, n := range appended {
+		for seq := range n {
+			want = append(want, g*cfg.Ops+seq)
+		}
+	}
+	got := slices.Concat(append(flushed, s.Flush())...)
+	slices.Sort(got)
+	assert.Equal(t, want, got, "items not flushed exactly once")
+}
+
+// Internal helpers
+
+func (cfg StressConfig) withDefaults() StressConfig {
+	if cfg.Goroutines <= 0 {
+		cfg.Goroutines = 8
+	}
+	if cfg.Ops <= 0 {
+		cfg.Ops = 1000
+	}
+	return cfg
+}
+
+// pickOp returns the index of the op that n, in [0, total weight), falls on.
+func pickOp[C any](ops []StressOp[C], n int) int {
+	for i, op := range ops {
+		n -= max(op.Weight, 1)
+		if n < 0 {
+			return i
+		}
+	}
+	return len(ops) - 1
+}
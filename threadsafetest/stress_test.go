@@ -0,0 +1,74 @@
+package threadsafetest
+
+import (
+	"math/rand/v2"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jkbrsn/threadsafe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStress(t *testing.T) {
+	var counter atomic.Int64
+	counts := Stress(t, &counter, StressConfig{Goroutines: 4, Ops: 500},
+		StressOp[*atomic.Int64]{Name: "Inc", Weight: 3,
+			Run: func(c *atomic.Int64, _ int, _ *rand.Rand) { c.Add(1) }},
+		StressOp[*atomic.Int64]{Name: "Dec", Weight: 1,
+			Run: func(c *atomic.Int64, _ int, _ *rand.Rand) { c.Add(-1) }},
+	)
+	assert.Equal(t, 4*500, counts["Inc"]+counts["Dec"])
+	assert.Greater(t, counts["Inc"], counts["Dec"])
+	assert.Equal(t, int64(counts["Inc"]-counts["Dec"]), counter.Load())
+
+	assert.Empty(t, Stress[*atomic.Int64](t, &counter, StressConfig{}))
+}
+
+func TestStressBuiltins(t *testing.T) {
+	cfg := StressConfig{Goroutines: 8, Ops: 500, Seed: 1}
+	equal := func(a, b int) bool { return a == b }
+
+	t.Run("MutexMap", func(t *testing.T) {
+		StressMap(t, threadsafe.NewMutexMap[int](equal), cfg)
+	})
+	t.Run("RWMutexMap", func(t *testing.T) {
+		StressMap(t, threadsafe.NewRWMutexMap[int](equal), cfg)
+	})
+	t.Run("SyncMap", func(t *testing.T) {
+		StressMap(t, threadsafe.NewSyncMap[int](equal), cfg)
+	})
+	t.Run("ImmutableMap", func(t *testing.T) {
+		StressMap(t, threadsafe.NewImmutableMap[int](equal), cfg)
+	})
+	t.Run("ShardedMap", func(t *testing.T) {
+		StressMap(t, threadsafe.NewShardedMap[int](0, equal), cfg)
+	})
+	t.Run("OrderedMap", func(t *testing.T) {
+		StressMap(t, threadsafe.NewOrderedMap[int](equal), cfg)
+	})
+	t.Run("SortedMap", func(t *testing.T) {
+		StressMap(t, threadsafe.NewSortedMap[int](equal), cfg)
+	})
+	t.Run("TTLMap", func(t *testing.T) {
+		StressMap(t, threadsafe.NewTTLMap[int](time.Hour, 0, equal), cfg)
+	})
+	t.Run("RWMutexSet", func(t *testing.T) {
+		StressSet(t, threadsafe.NewRWMutexSet[int](), cfg)
+	})
+	t.Run("SyncMapSet", func(t *testing.T) {
+		StressSet(t, threadsafe.NewSyncMapSet[int](), cfg)
+	})
+	t.Run("RWMutexQueue", func(t *testing.T) {
+		StressQueue(t, threadsafe.NewRWMutexQueue[int](), cfg)
+	})
+	t.Run("SegmentedQueue", func(t *testing.T) {
+		StressQueue(t, threadsafe.NewSegmentedQueue[int](), cfg)
+	})
+	t.Run("MutexSlice", func(t *testing.T) {
+		StressSlice(t, threadsafe.NewMutexSlice[int](0), cfg)
+	})
+	t.Run("RWMutexSlice", func(t *testing.T) {
+		StressSlice(t, threadsafe.NewRWMutexSlice[int](0), cfg)
+	})
+}
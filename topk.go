@@ -0,0 +1,135 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// ItemCount is an item tracked by a TopK with its estimated weight.
+type ItemCount[T any] struct {
+	Item T
+	// Count is the estimated total weight of the item, which may overestimate it by up to Error.
+	Count int64
+	// Error bounds the overestimation of Count, inherited from the item it evicted; 0 if Count
+	// is exact.
+	Error int64
+}
+
+// topKEntry is a counter of a TopK, kept in a min-heap by count.
+type topKEntry[T any] struct {
+	ItemCount[T]
+	index int
+}
+
+func (e *topKEntry[T]) SetHeapIndex(i int) { e.index = i }
+func (e *topKEntry[T]) HeapIndex() int     { return e.index }
+
+// TopK tracks the heaviest items of a stream, such as the most frequent keys, users or errors, in
+// memory bounded by k counters. Reads are cheap: the counters are always ordered, so TopN doesn't
+// have to sort a full count map.
+//
+// It implements the Space-Saving algorithm: items are counted exactly while fewer than k are
+// tracked. Beyond that, a new item takes over the counter of the lightest tracked item, inheriting
+// its count as an error bound. Any item whose true weight exceeds 1/k of the total weight is
+// guaranteed to be tracked.
+//
+// The counters are kept in a map by item and in an IndexedPriorityQueue keyed by count.
+//
+// The zero value is not ready to use; construct via NewTopK.
+type TopK[T comparable] struct {
+	mu      sync.Mutex
+	k       int
+	entries map[T]*topKEntry[T]
+	heap    *IndexedPriorityQueue[*topKEntry[T]] // lightest counter first
+}
+
+// NewTopK creates a new TopK tracking up to k items. k must be >0; if <=0, it is coerced to 1.
+func NewTopK[T comparable](k int) *TopK[T] {
+	k = max(k, 1)
+	t := &TopK[T]{
+		k:       k,
+		entries: make(map[T]*topKEntry[T], k),
+		heap: NewIndexedPriorityQueue(func(a, b *topKEntry[T]) bool {
+			return a.Count < b.Count
+		}, nil),
+	}
+	t.heap.Reserve(k)
+	return t
+}
+
+// Add adds weight to item. Non-positive weights are ignored.
+func (t *TopK[T]) Add(item T, weight int64) {
+	if weight <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if e, ok := t.entries[item]; ok {
+		e.Count += weight
+		t.heap.FixItem(e)
+		return
+	}
+	if len(t.entries) < t.k {
+		e := &topKEntry[T]{ItemCount: ItemCount[T]{Item: item, Count: weight}}
+		t.entries[item] = e
+		t.heap.Push(e)
+		return
+	}
+	e, _ := t.heap.Peek()
+	delete(t.entries, e.Item)
+	e.Item, e.Error = item, e.Count
+	e.Count += weight
+	t.entries[item] = e
+	t.heap.FixItem(e)
+}
+
+// Count returns the estimated weight of item, if it is tracked.
+func (t *TopK[T]) Count(item T) (ic ItemCount[T], ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.entries[item]; ok {
+		return e.ItemCount, true
+	}
+	return ic, false
+}
+
+// TopN returns up to n tracked items, heaviest first.
+func (t *TopK[T]) TopN(n int) []ItemCount[T] {
+	t.mu.Lock()
+	items := make([]ItemCount[T], 0, len(t.entries))
+	for _, e := range t.entries {
+		items = append(items, e.ItemCount)
+	}
+	t.mu.Unlock()
+
+	slices.SortFunc(items, func(a, b ItemCount[T]) int { return cmp.Compare(b.Count, a.Count) })
+	return items[:min(max(n, 0), len(items))]
+}
+
+// Len returns the number of tracked items, at most k.
+func (t *TopK[T]) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.entries)
+}
+
+// String returns the number of tracked items and the heaviest ones, for logging and debugging.
+func (t *TopK[T]) String() string {
+	return formatItems(t, t.Len(), slices.Values(t.TopN(stringMaxItems+1)))
+}
+
+// GoString returns the Go type, number of tracked items and the heaviest ones, for the %#v verb.
+func (t *TopK[T]) GoString() string {
+	return goFormatItems(t, t.Len(), slices.Values(t.TopN(stringMaxItems+1)))
+}
+
+// Reset discards all tracked items.
+func (t *TopK[T]) Reset() {
+	t.mu.Lock()
+	clear(t.entries)
+	t.heap.Clear()
+	t.mu.Unlock()
+}
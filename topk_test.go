@@ -0,0 +1,71 @@
+package threadsafe
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopK(t *testing.T) {
+	top := NewTopK[string](3)
+	top.Add("a", 5)
+	top.Add("b", 2)
+	top.Add("c", 1)
+	top.Add("a", 1)
+	top.Add("x", 0) // ignored
+	assert.Equal(t, []ItemCount[string]{{Item: "a", Count: 6}, {Item: "b", Count: 2}},
+		top.TopN(2))
+
+	// A new item takes over the lightest counter, inheriting its count as error bound
+	top.Add("d", 4)
+	assert.Equal(t, 3, top.Len())
+	_, ok := top.Count("c")
+	assert.False(t, ok)
+	d, ok := top.Count("d")
+	assert.True(t, ok)
+	assert.Equal(t, ItemCount[string]{Item: "d", Count: 5, Error: 1}, d)
+	assert.Equal(t, []string{"a", "d", "b"}, topKItems(top.TopN(10)))
+
+	top.Reset()
+	assert.Equal(t, 0, top.Len())
+	assert.Empty(t, top.TopN(3))
+}
+
+func TestTopKHeavyHitters(t *testing.T) {
+	top := NewTopK[string](10)
+	r := rand.New(rand.NewSource(1))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for range 4 {
+		wg.Go(func() {
+			for range 2000 {
+				mu.Lock()
+				n := r.Intn(100)
+				mu.Unlock()
+				top.Add("noise"+strconv.Itoa(n), 1)
+				top.Add("heavy1", 1)
+				if n%2 == 0 {
+					top.Add("heavy2", 1)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	// Items above 1/k of the total weight are always tracked, heaviest first
+	assert.Equal(t, []string{"heavy1", "heavy2"}, topKItems(top.TopN(2)))
+	heavy1, _ := top.Count("heavy1")
+	assert.GreaterOrEqual(t, heavy1.Count, int64(8000))
+	assert.LessOrEqual(t, heavy1.Count-heavy1.Error, int64(8000))
+}
+
+func topKItems(items []ItemCount[string]) []string {
+	names := make([]string, len(items))
+	for i, ic := range items {
+		names[i] = ic.Item
+	}
+	return names
+}
@@ -0,0 +1,247 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"iter"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// trieNode is a node of a Trie. Edges are labeled with strings, so chains of single-child nodes
+// are compressed into one node.
+type trieNode[V any] struct {
+	prefix   string         // edge label from the parent
+	children []*trieNode[V] // sorted by the first byte of their prefix
+	value    V
+	hasValue bool
+}
+
+// child returns the index of the child whose prefix starts with b, and whether there is one.
+func (n *trieNode[V]) child(b byte) (int, bool) {
+	return slices.BinarySearchFunc(n.children, b, func(c *trieNode[V], b byte) int {
+		return int(c.prefix[0]) - int(b)
+	})
+}
+
+// TrieEntry is a key and value stored in a Trie.
+type TrieEntry[V any] struct {
+	Key   string
+	Value V
+}
+
+// Trie is a thread-safe radix tree mapping string keys to values, protected by a sync.RWMutex,
+// for prefix lookups such as routing tables and prefix-based configuration. Lookups take the
+// read lock and run in O(len(key)), regardless of the number of keys.
+//
+// Keys are compared byte-wise, and iteration yields them in lexicographic byte order.
+//
+// The zero value is ready to use.
+type Trie[V any] struct {
+	mu   sync.RWMutex
+	root trieNode[V]
+	size int
+}
+
+// Insert stores value for key, and reports whether it replaced an existing value.
+func (t *Trie[V]) Insert(key string, value V) (replaced bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, rest := &t.root, key
+	for rest != "" {
+		i, found := n.child(rest[0])
+		if !found {
+			leaf := &trieNode[V]{prefix: rest, value: value, hasValue: true}
+			n.children = slices.Insert(n.children, i, leaf)
+			t.size++
+			return false
+		}
+		c := n.children[i]
+		common := commonPrefixLen(c.prefix, rest)
+		if common < len(c.prefix) {
+			// Split the edge at the end of the common prefix
+			split := &trieNode[V]{prefix: c.prefix[:common], children: []*trieNode[V]{c}}
+			c.prefix = c.prefix[common:]
+			n.children[i] = split
+		}
+		n, rest = n.children[i], rest[common:]
+	}
+	replaced = n.hasValue
+	n.value, n.hasValue = value, true
+	if !replaced {
+		t.size++
+	}
+	return replaced
+}
+
+// Get returns the value stored for key.
+func (t *Trie[V]) Get(key string) (value V, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if n := t.findLocked(key); n != nil && n.hasValue {
+		return n.value, true
+	}
+	return value, false
+}
+
+// Delete removes key, and reports whether it was present.
+func (t *Trie[V]) Delete(key string) (deleted bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var parent *trieNode[V]
+	n, rest := &t.root, key
+	for rest != "" {
+		i, found := n.child(rest[0])
+		if !found || !strings.HasPrefix(rest, n.children[i].prefix) {
+			return false
+		}
+		parent, n, rest = n, n.children[i], rest[len(n.children[i].prefix):]
+	}
+	if !n.hasValue {
+		return false
+	}
+	var zero V
+	n.value, n.hasValue = zero, false
+	t.size--
+
+	if parent == nil {
+		return true // the root is never removed or merged
+	}
+	switch len(n.children) {
+	case 0:
+		i, _ := parent.child(n.prefix[0])
+		parent.children = slices.Delete(parent.children, i, i+1)
+		if parent != &t.root && !parent.hasValue && len(parent.children) == 1 {
+			parent.mergeChild()
+		}
+	case 1:
+		n.mergeChild()
+	}
+	return true
+}
+
+// LongestPrefixMatch returns the longest stored key that is a prefix of s, and its value.
+func (t *Trie[V]) LongestPrefixMatch(s string) (key string, value V, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n, consumed := &t.root, 0
+	if n.hasValue {
+		value, ok = n.value, true
+	}
+	for consumed < len(s) {
+		i, found := n.child(s[consumed])
+		if !found || !strings.HasPrefix(s[consumed:], n.children[i].prefix) {
+			break
+		}
+		n = n.children[i]
+		consumed += len(n.prefix)
+		if n.hasValue {
+			key, value, ok = s[:consumed], n.value, true
+		}
+	}
+	return key, value, ok
+}
+
+// WalkPrefix calls f sequentially for each key starting with prefix, and its value, in
+// lexicographic order. If f returns false, the walk stops. It operates on a snapshot, so f may
+// modify the trie.
+func (t *Trie[V]) WalkPrefix(prefix string, f func(key string, value V) bool) {
+	for _, e := range t.snapshotPrefix(prefix) {
+		if !f(e.Key, e.Value) {
+			return
+		}
+	}
+}
+
+// All returns an iterator over a snapshot of all keys and values in lexicographic order.
+func (t *Trie[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		t.WalkPrefix("", yield)
+	}
+}
+
+// String returns the length and the first entries of the trie, for logging and debugging.
+func (t *Trie[V]) String() string {
+	return formatEntries(t, t.Len(), t.All())
+}
+
+// GoString returns the Go type, length and first entries of the trie, for the %#v verb.
+func (t *Trie[V]) GoString() string {
+	return goFormatEntries(t, t.Len(), t.All())
+}
+
+// Len returns the number of keys.
+func (t *Trie[V]) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.size
+}
+
+// Internal helpers
+
+// findLocked returns the node ending exactly at key, or nil.
+func (t *Trie[V]) findLocked(key string) *trieNode[V] {
+	n, rest := &t.root, key
+	for rest != "" {
+		i, found := n.child(rest[0])
+		if !found || !strings.HasPrefix(rest, n.children[i].prefix) {
+			return nil
+		}
+		n, rest = n.children[i], rest[len(n.children[i].prefix):]
+	}
+	return n
+}
+
+// snapshotPrefix returns the entries whose key starts with prefix, in order.
+func (t *Trie[V]) snapshotPrefix(prefix string) []TrieEntry[V] {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	// Find the node at or just below the end of prefix, which may end mid-edge
+	n, path := &t.root, ""
+	for len(path) < len(prefix) {
+		rest := prefix[len(path):]
+		i, found := n.child(rest[0])
+		if !found {
+			return nil
+		}
+		c := n.children[i]
+		if !strings.HasPrefix(c.prefix, rest) && !strings.HasPrefix(rest, c.prefix) {
+			return nil
+		}
+		n, path = c, path+c.prefix
+	}
+	var entries []TrieEntry[V]
+	var walk func(n *trieNode[V], key string)
+	walk = func(n *trieNode[V], key string) {
+		if n.hasValue {
+			entries = append(entries, TrieEntry[V]{Key: key, Value: n.value})
+		}
+		for _, c := range n.children {
+			walk(c, key+c.prefix)
+		}
+	}
+	walk(n, path)
+	return entries
+}
+
+// mergeChild merges the only child of n, which holds no value, into n.
+func (n *trieNode[V]) mergeChild() {
+	c := n.children[0]
+	n.prefix += c.prefix
+	n.children = c.children
+	n.value, n.hasValue = c.value, c.hasValue
+}
+
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,114 @@
+package threadsafe
+
+import (
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrie(t *testing.T) {
+	var trie Trie[int]
+	assert.False(t, trie.Insert("/api/users", 1))
+	assert.False(t, trie.Insert("/api", 2))
+	assert.False(t, trie.Insert("/api/users/admin", 3))
+	assert.False(t, trie.Insert("/apps", 4))
+	assert.True(t, trie.Insert("/api", 5))
+	assert.Equal(t, 4, trie.Len())
+
+	v, ok := trie.Get("/api")
+	assert.True(t, ok)
+	assert.Equal(t, 5, v)
+	_, ok = trie.Get("/ap") // an edge split point, not a key
+	assert.False(t, ok)
+	_, ok = trie.Get("/api/users/adm")
+	assert.False(t, ok)
+
+	key, v, ok := trie.LongestPrefixMatch("/api/users/42")
+	assert.True(t, ok)
+	assert.Equal(t, "/api/users", key)
+	assert.Equal(t, 1, v)
+	key, _, ok = trie.LongestPrefixMatch("/api/user")
+	assert.True(t, ok)
+	assert.Equal(t, "/api", key)
+	_, _, ok = trie.LongestPrefixMatch("/a")
+	assert.False(t, ok)
+
+	var keys []string
+	trie.WalkPrefix("/api/u", func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Equal(t, []string{"/api/users", "/api/users/admin"}, keys)
+	keys = keys[:0]
+	for key := range trie.All() {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"/api", "/api/users", "/api/users/admin", "/apps"}, keys)
+
+	assert.True(t, trie.Delete("/api/users"))
+	assert.False(t, trie.Delete("/api/users"))
+	assert.False(t, trie.Delete("/ap"))
+	assert.True(t, trie.Delete("/apps"))
+	v, ok = trie.Get("/api/users/admin")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, 2, trie.Len())
+
+	// The empty key is a valid key, matching every string
+	trie.Insert("", 0)
+	key, v, ok = trie.LongestPrefixMatch("/x")
+	assert.True(t, ok)
+	assert.Equal(t, "", key)
+	assert.Equal(t, 0, v)
+}
+
+func TestTrieRandomized(t *testing.T) {
+	var trie Trie[int]
+	ref := map[string]int{}
+	r := rand.New(rand.NewSource(1))
+	for i := range 2000 {
+		key := strconv.FormatInt(int64(r.Intn(500)), 3) // short keys sharing many prefixes
+		if r.Intn(3) == 0 {
+			_, had := ref[key]
+			assert.Equal(t, had, trie.Delete(key))
+			delete(ref, key)
+		} else {
+			trie.Insert(key, i)
+			ref[key] = i
+		}
+	}
+	assert.Equal(t, len(ref), trie.Len())
+	var keys []string
+	for key, v := range trie.All() {
+		assert.Equal(t, ref[key], v)
+		keys = append(keys, key)
+	}
+	assert.True(t, slices.IsSorted(keys))
+	assert.Len(t, keys, len(ref))
+	for key, want := range ref {
+		v, ok := trie.Get(key)
+		assert.True(t, ok)
+		assert.Equal(t, want, v)
+	}
+}
+
+func TestTrieConcurrent(t *testing.T) {
+	var trie Trie[int]
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 100 {
+				key := strconv.Itoa(g) + "/" + strconv.Itoa(i)
+				trie.Insert(key, i)
+				trie.LongestPrefixMatch(key + "/x")
+				trie.WalkPrefix(strconv.Itoa(g), func(string, int) bool { return true })
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 800, trie.Len())
+}
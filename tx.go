@@ -0,0 +1,271 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"cmp"
+	"iter"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// TxParticipant is implemented by the collections that can take part in a transaction run by
+// RunTx: MutexMap, RWMutexMap, RWMutexSet, MutexSlice, RWMutexSlice and RWMutexQueue. Inside the
+// transaction, each is accessed through the view returned by its InTx method.
+type TxParticipant interface {
+	// txLocker returns the lock guarding the collection.
+	txLocker() sync.Locker
+}
+
+// Ensure the participating collections implement TxParticipant.
+var (
+	_ TxParticipant = (*MutexMap[int, any])(nil)
+	_ TxParticipant = (*RWMutexMap[int, any])(nil)
+	_ TxParticipant = (*RWMutexSet[int])(nil)
+	_ TxParticipant = (*MutexSlice[any])(nil)
+	_ TxParticipant = (*RWMutexSlice[any])(nil)
+	_ TxParticipant = (*RWMutexQueue[any])(nil)
+)
+
+// Tx is a transaction in progress, holding the locks of its participants. It is only valid
+// during the call to RunTx that created it.
+type Tx struct {
+	lockers map[sync.Locker]struct{}
+	done    bool
+}
+
+// RunTx calls f with exclusive access to all participants at once, so that invariants spanning
+// several collections, such as an item being either queued or in a map of in-flight items, hold
+// for every other goroutine:
+//
+//	threadsafe.RunTx(func(tx *threadsafe.Tx) {
+//		if job, ok := queue.InTx(tx).Pop(); ok {
+//			inFlight.InTx(tx).Set(job.ID, job)
+//		}
+//	}, queue, inFlight)
+//
+// The participants are locked in a deterministic order, by address, so concurrent transactions
+// over overlapping collections cannot deadlock whatever order they list them in. Participants
+// listed more than once are locked once. The locks are released when f returns or panics.
+//
+// Inside f, the participants must only be accessed through the views returned by their InTx
+// methods: their own methods would deadlock. f must not retain tx or the views, nor run another
+// transaction over any of the participants.
+func RunTx(f func(tx *Tx), participants ...TxParticipant) {
+	tx := &Tx{lockers: make(map[sync.Locker]struct{}, len(participants))}
+	lockers := make([]sync.Locker, 0, len(participants))
+	for _, p := range participants {
+		l := p.txLocker()
+		if _, dup := tx.lockers[l]; !dup {
+			tx.lockers[l] = struct{}{}
+			lockers = append(lockers, l)
+		}
+	}
+	slices.SortFunc(lockers, func(a, b sync.Locker) int {
+		return cmp.Compare(reflect.ValueOf(a).Pointer(), reflect.ValueOf(b).Pointer())
+	})
+
+	for _, l := range lockers {
+		l.Lock()
+	}
+	defer func() {
+		tx.done = true
+		for i := len(lockers) - 1; i >= 0; i-- {
+			lockers[i].Unlock()
+		}
+	}()
+	f(tx)
+}
+
+// MapTx is the view of a MutexMap or RWMutexMap inside a transaction, accessing the map without
+// locking.
+type MapTx[K comparable, V any] struct {
+	tx     *Tx
+	values *map[K]V
+}
+
+// Get retrieves the value for the given key.
+func (m MapTx[K, V]) Get(key K) (V, bool) {
+	m.tx.checkOpen()
+	value, ok := (*m.values)[key]
+	return value, ok
+}
+
+// Set stores a value for the given key.
+func (m MapTx[K, V]) Set(key K, value V) {
+	m.tx.checkOpen()
+	if *m.values == nil {
+		*m.values = make(map[K]V)
+	}
+	(*m.values)[key] = value
+}
+
+// Delete removes the key from the map.
+func (m MapTx[K, V]) Delete(key K) {
+	m.tx.checkOpen()
+	delete(*m.values, key)
+}
+
+// Len returns the number of items in the map.
+func (m MapTx[K, V]) Len() int {
+	m.tx.checkOpen()
+	return len(*m.values)
+}
+
+// All returns an iterator over the entries of the map, in no particular order. It iterates over
+// the map itself rather than a snapshot, so the loop may Set and Delete entries with the same
+// semantics as a range over a Go map.
+func (m MapTx[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.tx.checkOpen()
+		for key, value := range *m.values {
+			if !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// SetTx is the view of an RWMutexSet inside a transaction, accessing the set without locking.
+type SetTx[T comparable] struct {
+	tx  *Tx
+	set *RWMutexSet[T]
+}
+
+// Add stores an item in the set, and reports whether it was added.
+func (s SetTx[T]) Add(item T) (added bool) {
+	s.tx.checkOpen()
+	return s.set.addLocked(item)
+}
+
+// Delete removes an item from the set, and reports whether it was present.
+func (s SetTx[T]) Delete(item T) (removed bool) {
+	s.tx.checkOpen()
+	return s.set.deleteLocked(item)
+}
+
+// Has returns true if the item is in the set, otherwise false.
+func (s SetTx[T]) Has(item T) bool {
+	s.tx.checkOpen()
+	_, exists := s.set.items[item]
+	return exists
+}
+
+// Len returns the number of items in the set.
+func (s SetTx[T]) Len() int {
+	s.tx.checkOpen()
+	return s.set.size
+}
+
+// All returns an iterator over the items of the set, in no particular order. It iterates over a
+// snapshot, so the loop may Add and Delete items.
+func (s SetTx[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.tx.checkOpen()
+		for _, item := range slices.Collect(maps.Keys(s.set.items)) {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// SliceTx is the view of a MutexSlice or RWMutexSlice inside a transaction, accessing the slice
+// without locking.
+type SliceTx[T any] struct {
+	tx   *Tx
+	data *[]T
+}
+
+// Append appends items to the slice.
+func (s SliceTx[T]) Append(items ...T) {
+	s.tx.checkOpen()
+	*s.data = append(*s.data, items...)
+}
+
+// Len returns the current number of items in the slice.
+func (s SliceTx[T]) Len() int {
+	s.tx.checkOpen()
+	return len(*s.data)
+}
+
+// All returns an iterator over the items of the slice, in order. It iterates over a snapshot, so
+// the loop may Append and Flush.
+func (s SliceTx[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		s.tx.checkOpen()
+		for _, item := range slices.Clone(*s.data) {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Flush removes and returns all items of the slice.
+func (s SliceTx[T]) Flush() []T {
+	s.tx.checkOpen()
+	flushed := *s.data
+	*s.data = make([]T, 0, cap(flushed))
+	return flushed
+}
+
+// QueueTx is the view of an RWMutexQueue inside a transaction, accessing the queue without
+// locking.
+type QueueTx[T any] struct {
+	tx *Tx
+	q  *RWMutexQueue[T]
+}
+
+// Push adds one or more items to the back of the queue.
+func (q QueueTx[T]) Push(items ...T) {
+	q.tx.checkOpen()
+	if len(items) == 0 {
+		return
+	}
+	q.q.items = append(q.q.items, items...)
+	q.q.signalLocked()
+}
+
+// Pop removes and returns the item at the front of the queue.
+// If the queue is empty it returns ok == false and the zero value of T.
+func (q QueueTx[T]) Pop() (item T, ok bool) {
+	q.tx.checkOpen()
+	return q.q.popLocked()
+}
+
+// Peek returns the item at the front without removing it.
+func (q QueueTx[T]) Peek() (item T, ok bool) {
+	q.tx.checkOpen()
+	if q.q.head >= len(q.q.items) {
+		return item, false
+	}
+	return q.q.items[q.q.head], true
+}
+
+// Len returns the current number of items.
+func (q QueueTx[T]) Len() int {
+	q.tx.checkOpen()
+	return len(q.q.items) - q.q.head
+}
+
+// Internal helpers
+
+// checkOpen panics if the transaction has finished.
+func (tx *Tx) checkOpen() {
+	if tx.done {
+		panic("threadsafe: use of a finished transaction")
+	}
+}
+
+// checkLocks panics unless the transaction is in progress and holds l.
+func (tx *Tx) checkLocks(l sync.Locker) {
+	if tx == nil {
+		panic("threadsafe: nil transaction")
+	}
+	tx.checkOpen()
+	if _, ok := tx.lockers[l]; !ok {
+		panic("threadsafe: collection is not a participant of the transaction")
+	}
+}
@@ -0,0 +1,114 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTx(t *testing.T) {
+	queue := NewRWMutexQueue[int]()
+	var inFlight MutexMap[int, int]
+	var seen RWMutexSet[int]
+	for i := range 100 {
+		queue.Push(i)
+	}
+
+	// Move items between the queue and the map in both directions, listing the participants in
+	// different orders, while checking that no item is ever missing from both
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 200 {
+				RunTx(func(tx *Tx) {
+					if item, ok := queue.InTx(tx).Pop(); ok {
+						inFlight.InTx(tx).Set(item, item)
+						seen.InTx(tx).Add(item)
+					}
+				}, queue, &inFlight, &seen)
+			}
+		})
+		wg.Go(func() {
+			for range 200 {
+				RunTx(func(tx *Tx) {
+					m := inFlight.InTx(tx)
+					for item := range m.All() {
+						m.Delete(item)
+						queue.InTx(tx).Push(item)
+						break
+					}
+				}, &inFlight, queue, &inFlight)
+			}
+		})
+		wg.Go(func() {
+			for range 200 {
+				RunTx(func(tx *Tx) {
+					assert.Equal(t, 100, queue.InTx(tx).Len()+inFlight.InTx(tx).Len())
+				}, queue, &inFlight)
+			}
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 100, queue.Len()+inFlight.Len())
+	assert.Positive(t, seen.Len())
+}
+
+func TestRunTxViews(t *testing.T) {
+	var buffer RWMutexSlice[string]
+	var log MutexSlice[string]
+	buffer.Append("a", "b")
+
+	RunTx(func(tx *Tx) {
+		b := buffer.InTx(tx)
+		l := log.InTx(tx)
+		for item := range b.All() {
+			l.Append(item)
+		}
+		assert.Equal(t, []string{"a", "b"}, b.Flush())
+		assert.Zero(t, b.Len())
+		assert.Equal(t, 2, l.Len())
+	}, &buffer, &log)
+	assert.Equal(t, []string{"a", "b"}, log.Flush())
+
+	var set RWMutexSet[int]
+	var queue RWMutexQueue[int]
+	RunTx(func(tx *Tx) {
+		s := set.InTx(tx)
+		assert.True(t, s.Add(1))
+		assert.False(t, s.Add(1))
+		assert.True(t, s.Has(1))
+		assert.True(t, s.Delete(1))
+		assert.Zero(t, s.Len())
+
+		q := queue.InTx(tx)
+		q.Push(1, 2)
+		item, ok := q.Peek()
+		assert.True(t, ok)
+		assert.Equal(t, 1, item)
+		assert.Equal(t, 2, q.Len())
+	}, &set, &queue)
+	assert.Equal(t, 2, queue.Len())
+}
+
+func TestRunTxMisuse(t *testing.T) {
+	var a, b RWMutexMap[string, int]
+	var leaked *Tx
+	var view MapTx[string, int]
+	RunTx(func(tx *Tx) {
+		assert.PanicsWithValue(t, "threadsafe: collection is not a participant of the transaction",
+			func() { b.InTx(tx) })
+		leaked, view = tx, a.InTx(tx)
+	}, &a)
+
+	assert.Panics(t, func() { a.InTx(leaked) })
+	assert.PanicsWithValue(t, "threadsafe: use of a finished transaction", func() { view.Set("x", 1) })
+	assert.Panics(t, func() { a.InTx(nil) })
+
+	// The locks are released when f panics
+	assert.Panics(t, func() {
+		RunTx(func(_ *Tx) { panic("boom") }, &a)
+	})
+	a.Set("x", 1)
+	assert.Equal(t, 1, a.Len())
+}
@@ -0,0 +1,121 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "sync"
+
+// versionedEntry is a value of a Versioned with its version number.
+type versionedEntry[T any] struct {
+	version uint64
+	value   T
+}
+
+// Versioned holds a value together with a bounded history of its previous versions, such as a
+// hot-reloaded configuration shared across goroutines. Every Set creates a new version with an
+// increasing version number, and previous versions can be read back or restored with Rollback.
+//
+// The zero value is not ready to use; construct via NewVersioned.
+type Versioned[T any] struct {
+	mu      sync.RWMutex
+	entries []versionedEntry[T] // oldest first; the last one is current
+	keep    int                 // previous versions kept
+}
+
+// NewVersioned creates a new Versioned holding initial as version 1, and keeping up to history
+// previous versions. history is coerced to at least 0.
+func NewVersioned[T any](initial T, history int) *Versioned[T] {
+	keep := max(history, 0)
+	v := &Versioned[T]{entries: make([]versionedEntry[T], 0, keep+1), keep: keep}
+	v.entries = append(v.entries, versionedEntry[T]{version: 1, value: initial})
+	return v
+}
+
+// Current returns the current value and its version.
+func (v *Versioned[T]) Current() (value T, version uint64) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	e := v.entries[len(v.entries)-1]
+	return e.value, e.version
+}
+
+// Version returns the current version number.
+func (v *Versioned[T]) Version() uint64 {
+	_, version := v.Current()
+	return version
+}
+
+// Get returns the value of the given version, if it is current or still in the history.
+func (v *Versioned[T]) Get(version uint64) (value T, ok bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if i, found := v.indexLocked(version); found {
+		return v.entries[i].value, true
+	}
+	return value, false
+}
+
+// Set stores value as a new version, and returns its version number. The oldest version is
+// dropped if the history is full.
+func (v *Versioned[T]) Set(value T) uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.setLocked(value)
+}
+
+// CompareAndSet stores value as a new version only if the current version is expected, so that
+// concurrent read-modify-write updates don't overwrite each other. It returns the new version
+// number and true if it did.
+func (v *Versioned[T]) CompareAndSet(expected uint64, value T) (version uint64, ok bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.entries[len(v.entries)-1].version != expected {
+		return 0, false
+	}
+	return v.setLocked(value), true
+}
+
+// Rollback restores the value of the given version by storing it as a new version, so versions
+// keep increasing and the rolled-back versions stay in the history. It returns the new version
+// number, or false if the version is no longer in the history.
+func (v *Versioned[T]) Rollback(version uint64) (newVersion uint64, ok bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	i, found := v.indexLocked(version)
+	if !found {
+		return 0, false
+	}
+	return v.setLocked(v.entries[i].value), true
+}
+
+// Versions returns the version numbers available to Get, oldest first.
+func (v *Versioned[T]) Versions() []uint64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	versions := make([]uint64, len(v.entries))
+	for i, e := range v.entries {
+		versions[i] = e.version
+	}
+	return versions
+}
+
+// Internal helpers (callers must hold the lock)
+
+func (v *Versioned[T]) setLocked(value T) uint64 {
+	version := v.entries[len(v.entries)-1].version + 1
+	if len(v.entries) > v.keep {
+		n := copy(v.entries, v.entries[len(v.entries)-v.keep:])
+		clear(v.entries[n:])
+		v.entries = v.entries[:n]
+	}
+	v.entries = append(v.entries, versionedEntry[T]{version: version, value: value})
+	return version
+}
+
+// indexLocked returns the index of version in entries. Versions are consecutive, so it is a
+// simple offset from the oldest one.
+func (v *Versioned[T]) indexLocked(version uint64) (int, bool) {
+	oldest := v.entries[0].version
+	if version < oldest || version-oldest >= uint64(len(v.entries)) {
+		return 0, false
+	}
+	return int(version - oldest), true
+}
@@ -0,0 +1,238 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sort"
+	"sync"
+)
+
+// mvccEntry is the value a key of a VersionedMap holds from a version on.
+type mvccEntry[V any] struct {
+	version uint64
+	value   V
+	deleted bool
+}
+
+// VersionedMap is a thread-safe multi-version map, for consistent reporting over shared state
+// without stopping writers. Every write creates a new version with an increasing version number,
+// and any version still in the history can be read with GetAt and SnapshotAt, giving a consistent
+// view across keys even as writes continue.
+//
+// The history keeps the latest history previous versions, or every version since the oldest one
+// pinned with Pin if that is older, so that a reader needing a version for longer than the
+// history lasts pins it first. Versions older than Oldest are garbage collected: each key only
+// keeps the values visible from a readable version, and a full sweep runs once per number of
+// keys changes.
+//
+// Versions start at 0 for the empty map. The zero value is ready to use, keeping no history
+// besides pinned versions.
+type VersionedMap[K comparable, V any] struct {
+	mu      sync.RWMutex
+	chains  map[K][]mvccEntry[V] // values of each key, oldest first
+	version uint64               // current version
+	oldest  uint64               // oldest readable version
+	keep    int                  // previous versions kept besides pinned ones
+	pins    map[uint64]int       // number of pins per pinned version
+	size    int                  // number of keys present in the current version
+	changes int                  // changes since the last full sweep
+}
+
+// NewVersionedMap creates a new, empty VersionedMap keeping up to history previous versions.
+// history is coerced to at least 0.
+func NewVersionedMap[K comparable, V any](history int) *VersionedMap[K, V] {
+	return &VersionedMap[K, V]{keep: max(history, 0)}
+}
+
+// Version returns the current version number.
+func (m *VersionedMap[K, V]) Version() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.version
+}
+
+// Oldest returns the oldest version number that can still be read.
+func (m *VersionedMap[K, V]) Oldest() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.oldest
+}
+
+// Len returns the number of keys in the current version.
+func (m *VersionedMap[K, V]) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.size
+}
+
+// Get returns the value of key in the current version.
+func (m *VersionedMap[K, V]) Get(key K) (value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getLocked(m.version, key)
+}
+
+// GetAt returns the value key had in the given version. It returns ok == false if key was not
+// present in that version, or if the version is newer than the current one or no longer in the
+// history; use Pin to keep a version readable.
+func (m *VersionedMap[K, V]) GetAt(version uint64, key K) (value V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.readableLocked(version) {
+		return value, false
+	}
+	return m.getLocked(version, key)
+}
+
+// SnapshotAt returns a copy of the map as it was in the given version. It returns ok == false if
+// the version is newer than the current one or no longer in the history.
+func (m *VersionedMap[K, V]) SnapshotAt(version uint64) (snapshot map[K]V, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.readableLocked(version) {
+		return nil, false
+	}
+	snapshot = make(map[K]V)
+	for key := range m.chains {
+		if value, found := m.getLocked(version, key); found {
+			snapshot[key] = value
+		}
+	}
+	return snapshot, true
+}
+
+// Set stores value under key as a new version, and returns its version number.
+func (m *VersionedMap[K, V]) Set(key K, value V) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.version++
+	m.writeLocked(key, mvccEntry[V]{version: m.version, value: value})
+	m.collectLocked()
+	return m.version
+}
+
+// SetMany stores all entries as a single new version, so that no version shows only some of
+// them, and returns its version number. If entries is empty, no version is created.
+func (m *VersionedMap[K, V]) SetMany(entries map[K]V) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(entries) == 0 {
+		return m.version
+	}
+	m.version++
+	for key, value := range entries {
+		m.writeLocked(key, mvccEntry[V]{version: m.version, value: value})
+	}
+	m.collectLocked()
+	return m.version
+}
+
+// Delete removes key as a new version, and returns its version number. If key is not present,
+// no version is created and it returns the current version and false.
+func (m *VersionedMap[K, V]) Delete(key K) (version uint64, deleted bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, found := m.getLocked(m.version, key); !found {
+		return m.version, false
+	}
+	m.version++
+	m.writeLocked(key, mvccEntry[V]{version: m.version, deleted: true})
+	m.collectLocked()
+	return m.version, true
+}
+
+// Pin keeps the current version readable until release is called, however many versions are
+// written meanwhile, and returns its version number. release may be called more than once.
+func (m *VersionedMap[K, V]) Pin() (version uint64, release func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pins == nil {
+		m.pins = make(map[uint64]int)
+	}
+	version = m.version
+	m.pins[version]++
+
+	var once sync.Once
+	return version, func() {
+		once.Do(func() { m.unpin(version) })
+	}
+}
+
+// Internal helpers (callers must hold the lock, except for unpin)
+
+func (m *VersionedMap[K, V]) unpin(version uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pins[version]--; m.pins[version] == 0 {
+		delete(m.pins, version)
+	}
+	m.collectLocked()
+}
+
+func (m *VersionedMap[K, V]) readableLocked(version uint64) bool {
+	return version >= m.oldest && version <= m.version
+}
+
+// getLocked returns the value of key in the given version, which must be readable.
+func (m *VersionedMap[K, V]) getLocked(version uint64, key K) (value V, ok bool) {
+	chain := m.chains[key]
+	i := sort.Search(len(chain), func(i int) bool { return chain[i].version > version }) - 1
+	if i < 0 || chain[i].deleted {
+		return value, false
+	}
+	return chain[i].value, true
+}
+
+// writeLocked appends e to the values of key, keeping the number of present keys up to date.
+func (m *VersionedMap[K, V]) writeLocked(key K, e mvccEntry[V]) {
+	if m.chains == nil {
+		m.chains = make(map[K][]mvccEntry[V])
+	}
+	chain := m.chains[key]
+	present := len(chain) > 0 && !chain[len(chain)-1].deleted
+	switch {
+	case present && e.deleted:
+		m.size--
+	case !present && !e.deleted:
+		m.size++
+	}
+	m.pruneLocked(key, append(chain, e))
+}
+
+// collectLocked advances the oldest readable version past the versions that are neither kept
+// nor pinned, and sweeps the values they leave behind once per number of keys changes, so that
+// the sweeps cost O(1) amortized per change.
+func (m *VersionedMap[K, V]) collectLocked() {
+	oldest := m.version - min(uint64(m.keep), m.version)
+	for pinned := range m.pins {
+		oldest = min(oldest, pinned)
+	}
+	m.oldest = max(m.oldest, oldest)
+
+	m.changes++
+	if m.changes < len(m.chains) {
+		return
+	}
+	m.changes = 0
+	for key, chain := range m.chains {
+		m.pruneLocked(key, chain)
+	}
+}
+
+// pruneLocked stores chain as the values of key, without the values no readable version sees.
+// The key is removed once all that is left is a deletion no readable version precedes.
+func (m *VersionedMap[K, V]) pruneLocked(key K, chain []mvccEntry[V]) {
+	drop := 0
+	for drop+1 < len(chain) && chain[drop+1].version <= m.oldest {
+		drop++
+	}
+	if drop > 0 {
+		n := copy(chain, chain[drop:])
+		clear(chain[n:])
+		chain = chain[:n]
+	}
+	if len(chain) == 1 && chain[0].deleted && chain[0].version <= m.oldest {
+		delete(m.chains, key)
+		return
+	}
+	m.chains[key] = chain
+}
@@ -0,0 +1,102 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionedMap(t *testing.T) {
+	m := NewVersionedMap[string, int](2)
+	assert.Equal(t, uint64(0), m.Version())
+
+	v1 := m.Set("a", 1)
+	v2 := m.SetMany(map[string]int{"a": 2, "b": 2})
+	v3, deleted := m.Delete("a")
+	assert.True(t, deleted)
+	assert.Equal(t, []uint64{1, 2, 3}, []uint64{v1, v2, v3})
+	_, deleted = m.Delete("a")
+	assert.False(t, deleted)
+	assert.Equal(t, v3, m.Version(), "deleting a missing key creates no version")
+	assert.Equal(t, 1, m.Len())
+
+	value, ok := m.GetAt(v1, "a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	_, ok = m.GetAt(v1, "b")
+	assert.False(t, ok)
+	_, ok = m.Get("a")
+	assert.False(t, ok)
+	_, ok = m.GetAt(v3+1, "b")
+	assert.False(t, ok, "future versions are not readable")
+
+	snapshot, ok := m.SnapshotAt(v2)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"a": 2, "b": 2}, snapshot)
+
+	// Only the bounded history stays readable
+	m.Set("c", 3)
+	assert.Equal(t, v2, m.Oldest())
+	_, ok = m.GetAt(v1, "a")
+	assert.False(t, ok)
+	_, ok = m.SnapshotAt(v1)
+	assert.False(t, ok)
+}
+
+func TestVersionedMapPin(t *testing.T) {
+	var m VersionedMap[int, int]
+	m.SetMany(map[int]int{1: 1, 2: 2})
+	pinned, release := m.Pin()
+
+	for i := range 100 {
+		m.Set(1, i+10)
+		m.Delete(2)
+		m.Set(2, i+10)
+	}
+	snapshot, ok := m.SnapshotAt(pinned)
+	require.True(t, ok, "pinned versions outlive the history")
+	assert.Equal(t, map[int]int{1: 1, 2: 2}, snapshot)
+	assert.Equal(t, pinned, m.Oldest())
+
+	release()
+	release()
+	assert.Equal(t, m.Version(), m.Oldest())
+	_, ok = m.SnapshotAt(pinned)
+	assert.False(t, ok)
+
+	// Values hidden from every readable version are garbage collected
+	m.Delete(1)
+	m.Delete(2)
+	m.Set(3, 3)
+	assert.LessOrEqual(t, len(m.chains), 1)
+	for _, chain := range m.chains {
+		assert.Len(t, chain, 1)
+	}
+}
+
+func TestVersionedMapConcurrentSnapshots(t *testing.T) {
+	m := NewVersionedMap[int, int](0)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		// Every version moves value between the keys, keeping their sum constant
+		for i := range 500 {
+			m.SetMany(map[int]int{0: 100 - i%100, 1: i % 100})
+		}
+	})
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				version, release := m.Pin()
+				snapshot, ok := m.SnapshotAt(version)
+				release()
+				assert.True(t, ok)
+				if len(snapshot) > 0 {
+					assert.Equal(t, 100, snapshot[0]+snapshot[1])
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersioned(t *testing.T) {
+	v := NewVersioned("v1", 2)
+	value, version := v.Current()
+	assert.Equal(t, "v1", value)
+	assert.Equal(t, uint64(1), version)
+
+	assert.Equal(t, uint64(2), v.Set("v2"))
+	assert.Equal(t, uint64(3), v.Set("v3"))
+	assert.Equal(t, uint64(4), v.Set("v4"))
+	assert.Equal(t, []uint64{2, 3, 4}, v.Versions())
+
+	// Only the bounded history is kept
+	_, ok := v.Get(1)
+	assert.False(t, ok)
+	_, ok = v.Get(5)
+	assert.False(t, ok)
+	value, ok = v.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, "v2", value)
+
+	// Rolling back creates a new version with the old value
+	version, ok = v.Rollback(2)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(5), version)
+	value, _ = v.Current()
+	assert.Equal(t, "v2", value)
+	assert.Equal(t, []uint64{3, 4, 5}, v.Versions())
+	_, ok = v.Rollback(2)
+	assert.False(t, ok)
+
+	// Without history only the current version is available
+	single := NewVersioned(1, -1)
+	single.Set(2)
+	assert.Equal(t, []uint64{2}, single.Versions())
+}
+
+func TestVersionedCompareAndSet(t *testing.T) {
+	v := NewVersioned(0, 0)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			for {
+				value, version := v.Current()
+				if _, ok := v.CompareAndSet(version, value+1); ok {
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	value, version := v.Current()
+	assert.Equal(t, 10, value)
+	assert.Equal(t, uint64(11), version)
+	_, ok := v.CompareAndSet(1, 0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,39 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import "context"
+
+// PopWaiter is implemented by structures whose PopWait blocks until an item is available, such as
+// BlockingQueue, BoundedQueue, DelayQueue and the binary-heap priority queues.
+//
+// Blocking operations in this package follow one convention: they are named after the
+// non-blocking operation with a Wait suffix (PushWait, PopWait, LockWait), take a context as their
+// first argument, and return ctx.Err() if ctx is done before they could complete. Cancellation
+// and timeouts therefore propagate through every structure a goroutine may wait on, instead of
+// leaving it blocked on a full or empty structure at shutdown.
+type PopWaiter[T any] interface {
+	// PopWait removes and returns the next item, blocking while none is available until ctx is
+	// done, in which case ctx.Err() is returned.
+	PopWait(ctx context.Context) (item T, err error)
+}
+
+// PushWaiter is implemented by structures whose PushWait blocks until there is room for the
+// pushed items, such as BlockingQueue and BoundedQueue.
+type PushWaiter[T any] interface {
+	// PushWait adds one or more items, blocking while there is no room until ctx is done, in
+	// which case ctx.Err() is returned.
+	PushWait(ctx context.Context, items ...T) error
+}
+
+// Ensure the blocking structures implement the convention.
+var (
+	_ PopWaiter[any]  = (*BlockingQueue[any])(nil)
+	_ PopWaiter[any]  = (*BoundedQueue[any])(nil)
+	_ PopWaiter[any]  = (*DelayQueue[any])(nil)
+	_ PopWaiter[any]  = (*CorePriorityQueue[any])(nil)
+	_ PopWaiter[any]  = (*IndexedPriorityQueue[any])(nil)
+	_ PopWaiter[int]  = (*OrderedPriorityQueue[int])(nil)
+	_ PopWaiter[any]  = (*BoundedPriorityQueue[any])(nil)
+	_ PushWaiter[any] = (*BlockingQueue[any])(nil)
+	_ PushWaiter[any] = (*BoundedQueue[any])(nil)
+)
@@ -0,0 +1,116 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"sync"
+	"time"
+)
+
+// Window is the aggregated value of a fixed time window of a WindowAggregator.
+type Window[A any] struct {
+	// Start is the start of the window; it ends where the next window starts.
+	Start time.Time
+	// Value is the aggregate of the values added during the window, or the zero value of A if
+	// there were none.
+	Value A
+}
+
+// aggBucket is the accumulator of a single window, tagged with its epoch.
+type aggBucket[A any] struct {
+	epoch int64
+	acc   A
+}
+
+// WindowAggregator accumulates values into fixed, aligned time windows with a reduce function,
+// and keeps the aggregates of the most recent windows, e.g. per-minute sums, maxima or
+// histograms for metrics rollups. Memory is bounded by the number of windows kept.
+//
+// Each window's accumulator starts as the zero value of A, and reduce folds every value added
+// during the window into it. A struct accumulator can hold whatever state the aggregate needs,
+// such as a count next to a sum for averages.
+//
+// The zero value is not ready to use; construct via NewWindowAggregator.
+type WindowAggregator[T, A any] struct {
+	mu      sync.RWMutex
+	window  time.Duration
+	reduce  func(acc A, v T) A
+	buckets []aggBucket[A]
+}
+
+// NewWindowAggregator creates a new aggregator over windows of the given duration, keeping the
+// last keep windows, including the current one. A non-positive window defaults to one second,
+// and keep is coerced to at least 1.
+func NewWindowAggregator[T, A any](
+	window time.Duration,
+	keep int,
+	reduce func(acc A, v T) A,
+) *WindowAggregator[T, A] {
+	if window <= 0 {
+		window = time.Second
+	}
+	a := &WindowAggregator[T, A]{
+		window:  window,
+		reduce:  reduce,
+		buckets: make([]aggBucket[A], max(keep, 1)),
+	}
+	for i := range a.buckets {
+		a.buckets[i].epoch = -1
+	}
+	return a
+}
+
+// Add folds v into the current window.
+func (a *WindowAggregator[T, A]) Add(v T) {
+	a.addAt(time.Now(), v)
+}
+
+// Current returns the aggregate of the current, still open, window.
+func (a *WindowAggregator[T, A]) Current() A {
+	w := a.windowsAt(time.Now(), 1)
+	return w[0].Value
+}
+
+// Windows returns the last n windows, oldest first and ending with the current one. n is capped
+// to the number of windows kept. Windows without values are included with a zero Value, so the
+// result is a regular time series.
+func (a *WindowAggregator[T, A]) Windows(n int) []Window[A] {
+	return a.windowsAt(time.Now(), n)
+}
+
+// Reset discards all aggregates.
+func (a *WindowAggregator[T, A]) Reset() {
+	a.mu.Lock()
+	for i := range a.buckets {
+		a.buckets[i] = aggBucket[A]{epoch: -1}
+	}
+	a.mu.Unlock()
+}
+
+// Internal helpers
+
+func (a *WindowAggregator[T, A]) addAt(now time.Time, v T) {
+	epoch := now.UnixNano() / int64(a.window)
+	a.mu.Lock()
+	b := &a.buckets[epoch%int64(len(a.buckets))]
+	if b.epoch != epoch {
+		*b = aggBucket[A]{epoch: epoch}
+	}
+	b.acc = a.reduce(b.acc, v)
+	a.mu.Unlock()
+}
+
+func (a *WindowAggregator[T, A]) windowsAt(now time.Time, n int) []Window[A] {
+	epoch := now.UnixNano() / int64(a.window)
+	n = min(max(n, 0), len(a.buckets))
+	windows := make([]Window[A], n)
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for i := range windows {
+		e := epoch - int64(n-1-i)
+		windows[i].Start = time.Unix(0, e*int64(a.window))
+		if b := a.buckets[e%int64(len(a.buckets))]; b.epoch == e {
+			windows[i].Value = b.acc
+		}
+	}
+	return windows
+}
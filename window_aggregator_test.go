@@ -0,0 +1,60 @@
+package threadsafe
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type windowTestStats struct {
+	count int
+	max   float64
+}
+
+func TestWindowAggregator(t *testing.T) {
+	agg := NewWindowAggregator(time.Minute, 3, func(acc windowTestStats, v float64) windowTestStats {
+		acc.count++
+		acc.max = max(acc.max, v)
+		return acc
+	})
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() { agg.Add(float64(i)) })
+	}
+	wg.Wait()
+	// Adds straddling a minute boundary end up in two windows
+	total := 0
+	for _, w := range agg.Windows(2) {
+		total += w.Value.count
+	}
+	assert.Equal(t, 50, total)
+
+	agg.Reset()
+	assert.Equal(t, windowTestStats{}, agg.Current())
+}
+
+func TestWindowAggregatorWindows(t *testing.T) {
+	sum := func(acc, v int) int { return acc + v }
+	agg := NewWindowAggregator(time.Second, 3, sum)
+	start := time.Unix(1000, 0)
+	agg.addAt(start, 1)
+	agg.addAt(start.Add(100*time.Millisecond), 2)
+	agg.addAt(start.Add(2*time.Second), 5)
+
+	// Windows are aligned and regular, including empty ones
+	windows := agg.windowsAt(start.Add(2500*time.Millisecond), 5)
+	assert.Equal(t, []Window[int]{
+		{Start: time.Unix(1000, 0), Value: 3},
+		{Start: time.Unix(1001, 0), Value: 0},
+		{Start: time.Unix(1002, 0), Value: 5},
+	}, windows)
+
+	// Windows older than the ones kept are recycled
+	agg.addAt(start.Add(3*time.Second), 7)
+	windows = agg.windowsAt(start.Add(3*time.Second), 3)
+	assert.Equal(t, []int{0, 5, 7}, []int{windows[0].Value, windows[1].Value, windows[2].Value})
+	assert.Empty(t, agg.windowsAt(start, 0))
+}
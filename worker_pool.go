@@ -0,0 +1,147 @@
+// Package threadsafe implements thread-safe operations.
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultWorkerPoolPollInterval = 10 * time.Millisecond
+
+// Popper is the source of items for a WorkerPool. Every Queue and PriorityQueue in this package
+// satisfies it.
+type Popper[T any] interface {
+	// Pop removes and returns the next item. If none is available, it returns ok == false.
+	Pop() (item T, ok bool)
+}
+
+// WorkerPool processes items pulled from a Popper with a fixed number of worker goroutines, which
+// bounds the number of items handled concurrently.
+//
+// Sources implementing PopWaiter, such as BlockingQueue, are waited on directly.
+// Other sources are polled every PollInterval while empty.
+//
+// A panic in the handler is recovered, reported to OnPanic if set, and the worker moves on to the
+// next item.
+//
+// The zero value is not ready to use; construct via NewWorkerPool.
+type WorkerPool[T any] struct {
+	// PollInterval is how long a worker waits before polling an empty source again. It defaults to
+	// 10ms when not positive, and must be set before Start.
+	PollInterval time.Duration
+	// OnPanic, if set, is called with the item and the recovered value when the handler panics.
+	// It must be set before Start.
+	OnPanic func(item T, recovered any)
+
+	source  Popper[T]
+	handler func(ctx context.Context, item T)
+	workers int
+
+	mu      sync.Mutex
+	started bool
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewWorkerPool creates a new WorkerPool running handler for each item popped from source, with
+// the given number of workers. workers must be >0; if <=0, it is coerced to 1.
+func NewWorkerPool[T any](
+	source Popper[T],
+	workers int,
+	handler func(ctx context.Context, item T),
+) *WorkerPool[T] {
+	return &WorkerPool[T]{
+		PollInterval: defaultWorkerPoolPollInterval,
+		source:       source,
+		handler:      handler,
+		workers:      max(workers, 1),
+	}
+}
+
+// Start launches the workers. Handlers receive ctx, and the workers stop pulling items once ctx is
+// done. Calling Start more than once has no effect.
+func (p *WorkerPool[T]) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return
+	}
+	p.started = true
+
+	stopCtx, stop := context.WithCancel(ctx)
+	p.stop = stop
+	for range p.workers {
+		p.wg.Go(func() { p.work(ctx, stopCtx) })
+	}
+}
+
+// Shutdown gracefully stops the pool: workers stop pulling new items and Shutdown waits for the
+// items being handled to complete, or for ctx to be done, in which case ctx.Err() is returned.
+// Items still in the source are left there.
+func (p *WorkerPool[T]) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	stop := p.stop
+	p.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+	stop()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Internal helpers
+
+// work is the loop run by each worker until stopCtx is done.
+func (p *WorkerPool[T]) work(ctx, stopCtx context.Context) {
+	for stopCtx.Err() == nil {
+		item, ok := p.next(stopCtx)
+		if !ok {
+			continue
+		}
+		p.handle(ctx, item)
+	}
+}
+
+// next returns the next item from the source, waiting for up to one poll interval for an item.
+func (p *WorkerPool[T]) next(stopCtx context.Context) (item T, ok bool) {
+	if w, isWaiter := p.source.(PopWaiter[T]); isWaiter {
+		item, err := w.PopWait(stopCtx)
+		return item, err == nil
+	}
+	if item, ok = p.source.Pop(); ok {
+		return item, true
+	}
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = defaultWorkerPoolPollInterval
+	}
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-stopCtx.Done():
+	}
+	return item, false
+}
+
+// handle runs the handler for item, recovering from panics.
+func (p *WorkerPool[T]) handle(ctx context.Context, item T) {
+	defer func() {
+		if r := recover(); r != nil && p.OnPanic != nil {
+			p.OnPanic(item, r)
+		}
+	}()
+	p.handler(ctx, item)
+}
@@ -0,0 +1,124 @@
+package threadsafe
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPoolProcessesAllItems(t *testing.T) {
+	sources := map[string]func() Queue[int]{
+		"Polled":  func() Queue[int] { return NewRWMutexQueue[int]() },
+		"PopWait": func() Queue[int] { return NewBlockingQueue[int](0) },
+	}
+	for name, newSource := range sources {
+		t.Run(name, func(t *testing.T) {
+			const items = 200
+			source := newSource()
+
+			var mu sync.Mutex
+			seen := make(map[int]bool)
+			var running, maxRunning atomic.Int32
+			pool := NewWorkerPool[int](source, 4, func(_ context.Context, item int) {
+				n := running.Add(1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				mu.Lock()
+				seen[item] = true
+				mu.Unlock()
+				running.Add(-1)
+			})
+			pool.PollInterval = time.Millisecond
+			pool.Start(context.Background())
+			pool.Start(context.Background()) // no-op
+
+			for i := range items {
+				source.Push(i)
+			}
+			assert.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(seen) == items
+			}, time.Second, time.Millisecond)
+
+			assert.NoError(t, pool.Shutdown(context.Background()))
+			assert.LessOrEqual(t, maxRunning.Load(), int32(4))
+		})
+	}
+}
+
+func TestWorkerPoolPriorityQueueSource(t *testing.T) {
+	pq := NewCorePriorityQueue(func(a, b int) bool { return a < b })
+	pq.Push(3, 1, 2)
+
+	var got []int
+	pool := NewWorkerPool[int](pq, 1, func(_ context.Context, item int) { got = append(got, item) })
+	pool.Start(context.Background())
+	assert.Eventually(t, func() bool { return pq.Len() == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+	assert.Equal(t, []int{1, 2, 3}, got)
+}
+
+func TestWorkerPoolRecoversPanics(t *testing.T) {
+	source := NewBlockingQueue[int](0)
+	source.Push(1, 2, 3)
+
+	var mu sync.Mutex
+	var panicked []int
+	var handled atomic.Int32
+	pool := NewWorkerPool[int](source, 1, func(_ context.Context, item int) {
+		handled.Add(1)
+		if item == 2 {
+			panic("boom")
+		}
+	})
+	pool.OnPanic = func(item int, recovered any) {
+		mu.Lock()
+		panicked = append(panicked, item)
+		mu.Unlock()
+		assert.Equal(t, "boom", recovered)
+	}
+	pool.Start(context.Background())
+	assert.Eventually(t, func() bool { return handled.Load() == 3 }, time.Second, time.Millisecond)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []int{2}, panicked)
+}
+
+func TestWorkerPoolShutdown(t *testing.T) {
+	// Shutdown before Start is a no-op
+	idle := NewWorkerPool[int](NewRWMutexQueue[int](), 1, nil)
+	assert.NoError(t, idle.Shutdown(context.Background()))
+
+	source := NewBlockingQueue[int](0)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	pool := NewWorkerPool[int](source, 1, func(context.Context, int) {
+		close(started)
+		<-release
+	})
+	pool.Start(context.Background())
+	source.Push(1, 2)
+	<-started
+
+	// Shutdown waits for the in-flight item and gives up when ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.Shutdown(ctx), context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, pool.Shutdown(context.Background()))
+
+	// The remaining item is left in the source
+	assert.Equal(t, []int{2}, source.Slice())
+}